@@ -1,21 +1,128 @@
 package api_cache
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"sort"
+	"sync"
+	"time"
 
+	"blockbuster/api/constants"
 	"blockbuster/api/data"
 	"blockbuster/api/utils"
 )
 
+// CentroidCache holds the metrics of every centroid, the labels generated from them and the
+// labels staff have set. Reload swaps in a freshly loaded set under a lock, so reads see
+// either the old centroids or the new ones, never a mix.
 type CentroidCache struct {
-	centroids map[int]data.MovieMetrics
+	mu         sync.RWMutex
+	centroids  map[int]data.MovieMetrics
+	labels     map[int]data.CentroidLabel
+	staff      map[int]data.CentroidLabel
+	reloads    int
+	relabelled map[int]data.CentroidLabel
+	loadedAt   time.Time
+	lastErr    error
+	load       func(ctx context.Context) (map[int]data.MovieMetrics, error)
+	loadStaff  func(ctx context.Context) (map[int]data.CentroidLabel, error)
+}
+
+func NewCentroidCache(load func(ctx context.Context) (map[int]data.MovieMetrics, error)) *CentroidCache {
+	return &CentroidCache{load: load}
+}
+
+// NewLabelledCentroidCache returns a cache that also loads the labels staff have set with
+// every reload.
+func NewLabelledCentroidCache(
+	load func(ctx context.Context) (map[int]data.MovieMetrics, error),
+	loadStaff func(ctx context.Context) (map[int]data.CentroidLabel, error),
+) *CentroidCache {
+	return &CentroidCache{load: load, loadStaff: loadStaff}
+}
+
+// Reload fetches the centroids and staff labels again and swaps them in. Labels set since
+// the load started are kept. On failure the previous centroids are kept and the error is
+// reported by Status.
+func (c *CentroidCache) Reload(ctx context.Context) error {
+	c.mu.Lock()
+	c.reloads++
+	c.mu.Unlock()
+
+	centroids, err := c.load(ctx)
+	var labels, staff map[int]data.CentroidLabel
+	if err == nil {
+		labels = labelCentroids(centroids)
+		if c.loadStaff != nil {
+			staff, err = c.loadStaff(ctx)
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reloads--
+	if err != nil {
+		c.lastErr = err
+		if c.reloads == 0 {
+			c.relabelled = nil
+		}
+		return utils.LogError("failed to reload centroid cache", err)
+	}
+	if staff == nil {
+		staff = make(map[int]data.CentroidLabel)
+	}
+	c.centroids, c.labels, c.staff, c.loadedAt, c.lastErr = centroids, labels, staff, time.Now(), nil
+	for id, label := range c.relabelled {
+		c.setLabel(id, label)
+	}
+	if c.reloads == 0 {
+		c.relabelled = nil
+	}
+	return nil
+}
+
+// SetLabel caches a staff label already saved for a centroid. An empty label reverts to the
+// generated one.
+func (c *CentroidCache) SetLabel(id int, label data.CentroidLabel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reloads > 0 {
+		if c.relabelled == nil {
+			c.relabelled = make(map[int]data.CentroidLabel)
+		}
+		c.relabelled[id] = label
+	}
+	c.setLabel(id, label)
+}
+
+// setLabel caches a staff label. Callers hold the write lock.
+func (c *CentroidCache) setLabel(id int, label data.CentroidLabel) {
+	if c.staff == nil {
+		c.staff = make(map[int]data.CentroidLabel)
+	}
+	if label.Label == "" {
+		delete(c.staff, id)
+		return
+	}
+	c.staff[id] = label
+}
+
+func (c *CentroidCache) Status() data.CacheStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return data.CacheStatus{
+		Name:       constants.CENTROID_CACHE,
+		Size:       len(c.centroids),
+		LastLoaded: c.loadedAt,
+		LastError:  errorString(c.lastErr),
+	}
 }
 
 func (c *CentroidCache) GetMetricsByCentroid(centroidID int) (data.MovieMetrics, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	if c.centroids == nil {
-		return data.MovieMetrics{}, utils.LogError("centroid cache failed to initialize. GetMetricsByCentroid functionality unavailable", nil)
+		return data.MovieMetrics{}, utils.LogError("centroid cache not loaded. GetMetricsByCentroid functionality unavailable", nil)
 	}
 
 	centroid, ok := c.centroids[centroidID]
@@ -26,8 +133,15 @@ func (c *CentroidCache) GetMetricsByCentroid(centroidID int) (data.MovieMetrics,
 }
 
 func (c *CentroidCache) GetKNearestCentroidsFromMood(mood data.MovieMetrics, k int) ([]int, error) {
+	return c.GetKNearestCentroids(mood, k, utils.MetricDistance)
+}
+
+// GetKNearestCentroids returns the ids of the k centroids nearest mood under distance.
+func (c *CentroidCache) GetKNearestCentroids(mood data.MovieMetrics, k int, distance utils.DistanceFunc) ([]int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	if c.centroids == nil {
-		return nil, utils.LogError("centroid cache failed to initialize. GetKNearestCentroidsFromMood functionality unavailable", nil)
+		return nil, utils.LogError("centroid cache not loaded. GetKNearestCentroidsFromMood functionality unavailable", nil)
 	}
 	if k <= 0 {
 		return nil, utils.LogError("k must be greater than 0", nil)
@@ -39,7 +153,7 @@ func (c *CentroidCache) GetKNearestCentroidsFromMood(mood data.MovieMetrics, k i
 	}
 	var dists []centroidDist
 	for id, metrics := range c.centroids {
-		d := utils.MetricDistance(mood, metrics)
+		d := distance(mood, metrics)
 		dists = append(dists, centroidDist{id: id, dist: d})
 	}
 
@@ -55,6 +169,35 @@ func (c *CentroidCache) GetKNearestCentroidsFromMood(mood data.MovieMetrics, k i
 	return result, nil
 }
 
+// GetCentroids returns every centroid in id order with its staff label, or else the label
+// generated from its metrics on load. Only the id, label, description, custom flag and
+// metrics are set.
+func (c *CentroidCache) GetCentroids() []data.Centroid {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	centroids := make([]data.Centroid, 0, len(c.centroids))
+	for id, metrics := range c.centroids {
+		metrics.ID = id
+		label, ok := c.staff[id]
+		if !ok {
+			label = c.labels[id]
+		}
+		centroids = append(centroids, data.Centroid{
+			ID:          id,
+			Label:       label.Label,
+			Description: label.Description,
+			Custom:      ok,
+			Metrics:     metrics,
+		})
+	}
+	sort.Slice(centroids, func(i, j int) bool {
+		return centroids[i].ID < centroids[j].ID
+	})
+	return centroids
+}
+
 func (c *CentroidCache) Size() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return len(c.centroids)
 }
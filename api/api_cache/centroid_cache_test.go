@@ -2,6 +2,7 @@ package api_cache
 
 import (
 	"blockbuster/api/data"
+	"blockbuster/api/utils"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -68,3 +69,30 @@ func TestGetKNearestCentroidsFromMood_KZeroOrNegative(t *testing.T) {
 	_, err = cache.GetKNearestCentroidsFromMood(mood, -1)
 	assert.Error(t, err)
 }
+
+func TestGetKNearestCentroids_DistanceFunc(t *testing.T) {
+	cache := CentroidCache{
+		centroids: map[int]data.MovieMetrics{
+			1: {Suspense: 60, Writing: 10},
+			2: {Suspense: 90, Writing: 90},
+			3: {Suspense: 20, Writing: 60},
+		},
+	}
+	mood := data.MovieMetrics{Suspense: 60, Writing: 60}
+
+	ids, err := cache.GetKNearestCentroids(mood, 1, utils.MetricDistance)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3}, ids)
+
+	// Cosine ignores magnitude, so the equally balanced centroid wins.
+	ids, err = cache.GetKNearestCentroids(mood, 1, utils.CosineDistance)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, ids)
+
+	// Caring mostly about suspense pulls the centroid matching it closest.
+	weighted, err := utils.NewDistanceFunc(data.DistanceOptions{Weights: map[string]float64{"suspense": 10}})
+	assert.NoError(t, err)
+	ids, err = cache.GetKNearestCentroids(mood, 1, weighted)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, ids)
+}
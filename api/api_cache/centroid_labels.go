@@ -0,0 +1,82 @@
+package api_cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"blockbuster/api/data"
+)
+
+// dimensionWords describes a movie strong in each dimension, as an adjective and as the
+// kind of movie it is.
+type dimensionWord struct{ adjective, noun string }
+
+var dimensionWords = map[string]dimensionWord{
+	"acting":         {"well-acted", "performance pieces"},
+	"action":         {"action-packed", "action movies"},
+	"cinematography": {"beautifully shot", "visual feasts"},
+	"comedy":         {"funny", "comedies"},
+	"directing":      {"masterfully directed", "auteur films"},
+	"drama":          {"dramatic", "dramas"},
+	"fantasy":        {"fantastical", "fantasies"},
+	"horror":         {"scary", "horror films"},
+	"romance":        {"romantic", "romances"},
+	"story_telling":  {"gripping", "stories"},
+	"suspense":       {"suspenseful", "thrillers"},
+	"writing":        {"sharply written", "talkies"},
+}
+
+// labelCentroids labels every centroid by the dimensions it stands out in most against the
+// average centroid, so dimensions every centroid scores well in don't name them all. The
+// strongest names the kind of movie and the next describes it, e.g. "suspenseful dramas".
+func labelCentroids(centroids map[int]data.MovieMetrics) map[int]data.CentroidLabel {
+	var mean data.MetricVector
+	for _, metrics := range centroids {
+		mean = mean.Add(metrics.Vector())
+	}
+	if len(centroids) > 0 {
+		mean = mean.Scale(1 / float64(len(centroids)))
+	}
+
+	labels := make(map[int]data.CentroidLabel, len(centroids))
+	for id, metrics := range centroids {
+		dims := dominantDimensions(metrics.Vector().Sub(mean))
+		labels[id] = data.CentroidLabel{
+			Label: fmt.Sprintf("%s %s", wordsFor(dims[1]).adjective, wordsFor(dims[0]).noun),
+			Description: fmt.Sprintf("Movies that stand out for their %s, %s and %s.",
+				displayName(dims[0]), displayName(dims[1]), displayName(dims[2])),
+		}
+	}
+	return labels
+}
+
+// dominantDimensions returns every dimension name, largest value first, ties in vector
+// order.
+func dominantDimensions(v data.MetricVector) []string {
+	names := data.MetricDimensions()
+	order := make([]int, len(names))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return v[order[a]] > v[order[b]]
+	})
+	dims := make([]string, len(order))
+	for i, idx := range order {
+		dims[i] = names[idx]
+	}
+	return dims
+}
+
+// wordsFor falls back to the dimension's own name for dimensions without words yet.
+func wordsFor(dimension string) dimensionWord {
+	if words, ok := dimensionWords[dimension]; ok {
+		return words
+	}
+	return dimensionWord{displayName(dimension), displayName(dimension) + " movies"}
+}
+
+func displayName(dimension string) string {
+	return strings.ReplaceAll(dimension, "_", " ")
+}
@@ -0,0 +1,85 @@
+package api_cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/data"
+)
+
+func TestLabelCentroids_UsesDimensionsAboveAverage(t *testing.T) {
+	labels := labelCentroids(map[int]data.MovieMetrics{
+		0: {Acting: 90, Drama: 80, Suspense: 70, Comedy: 10},
+		1: {Acting: 90, Comedy: 80, Romance: 70, Drama: 10},
+	})
+	// Acting is high everywhere, so it names neither centroid.
+	assert.Equal(t, "suspenseful dramas", labels[0].Label)
+	assert.Equal(t, "Movies that stand out for their drama, suspense and acting.", labels[0].Description)
+	assert.Equal(t, "romantic comedies", labels[1].Label)
+}
+
+func TestLabelCentroids_Empty(t *testing.T) {
+	assert.Empty(t, labelCentroids(map[int]data.MovieMetrics{}))
+}
+
+func TestWordsFor_FallsBackToDimensionName(t *testing.T) {
+	assert.Equal(t, dimensionWord{"film noir", "film noir movies"}, wordsFor("film_noir"))
+}
+
+func TestGetCentroids_SortedAndLabelled(t *testing.T) {
+	cache := NewCentroidCache(func(ctx context.Context) (map[int]data.MovieMetrics, error) {
+		return map[int]data.MovieMetrics{
+			2: {Horror: 90, Suspense: 80},
+			0: {Comedy: 90, Romance: 80},
+		}, nil
+	})
+	assert.NoError(t, cache.Reload(context.Background()))
+	centroids := cache.GetCentroids()
+	assert.Len(t, centroids, 2)
+	assert.Equal(t, 0, centroids[0].ID)
+	assert.Equal(t, 0, centroids[0].Metrics.ID)
+	assert.Equal(t, "romantic comedies", centroids[0].Label)
+	assert.Equal(t, 2, centroids[1].ID)
+	assert.Equal(t, 2, centroids[1].Metrics.ID)
+	assert.Equal(t, "suspenseful horror films", centroids[1].Label)
+}
+
+func TestGetCentroids_StaffLabels(t *testing.T) {
+	staff := map[int]data.CentroidLabel{2: {Label: "Midnight movies"}}
+	var cache *CentroidCache
+	cache = NewLabelledCentroidCache(func(ctx context.Context) (map[int]data.MovieMetrics, error) {
+		return map[int]data.MovieMetrics{
+			2: {Horror: 90, Suspense: 80},
+			0: {Comedy: 90, Romance: 80},
+		}, nil
+	}, func(ctx context.Context) (map[int]data.CentroidLabel, error) {
+		return staff, nil
+	})
+	assert.NoError(t, cache.Reload(context.Background()))
+	centroids := cache.GetCentroids()
+	assert.Equal(t, "romantic comedies", centroids[0].Label)
+	assert.False(t, centroids[0].Custom)
+	assert.Equal(t, "Midnight movies", centroids[1].Label)
+	assert.True(t, centroids[1].Custom)
+
+	cache.SetLabel(0, data.CentroidLabel{Label: "Date night", Description: "Romcoms for two."})
+	cache.SetLabel(2, data.CentroidLabel{})
+	centroids = cache.GetCentroids()
+	assert.Equal(t, data.Centroid{ID: 0, Label: "Date night", Description: "Romcoms for two.", Custom: true, Metrics: centroids[0].Metrics}, centroids[0])
+	assert.Equal(t, "suspenseful horror films", centroids[1].Label)
+	assert.False(t, centroids[1].Custom)
+
+	// the labels were read before this one was saved
+	staff = map[int]data.CentroidLabel{0: {Label: "Date night", Description: "Romcoms for two."}}
+	cache.loadStaff = func(ctx context.Context) (map[int]data.CentroidLabel, error) {
+		cache.SetLabel(2, data.CentroidLabel{Label: "Creature features"})
+		return staff, nil
+	}
+	assert.NoError(t, cache.Reload(context.Background()))
+	centroids = cache.GetCentroids()
+	assert.Equal(t, "Creature features", centroids[1].Label, "labels set during a reload are kept")
+	assert.Equal(t, "Date night", centroids[0].Label)
+	assert.Nil(t, cache.relabelled)
+}
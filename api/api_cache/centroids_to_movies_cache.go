@@ -1,26 +1,303 @@
 package api_cache
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"slices"
+	"sync"
+	"time"
 
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
 	"blockbuster/api/utils"
 )
 
+// CentroidsToMoviesCache maps each centroid to the movies assigned to it. Reload swaps in a
+// freshly loaded mapping under a lock; the reverse index is rebuilt on first use after
+// each swap.
+//
+// Movies without a known centroid are assigned the nearest one on load (see loadedCentroid),
+// and the assignment is written back through setCentroid. Movies without metrics belong to no centroid and are
+// reported by IsExcluded so the rec engine can skip them.
 type CentroidsToMoviesCache struct {
 	CentroidToMovieIDs map[int][]string
+
+	mu              sync.RWMutex
+	movieToCentroid map[string]int
+	excluded        map[string]bool
+	seq             uint64
+	reloads         int
+	reassigned      map[string]reassignment
+	loadedAt        time.Time
+	lastErr         error
+	load            func(ctx context.Context) ([]data.Movie, error)
+	centroids       CentroidCacheInterface
+	setCentroid     func(ctx context.Context, movieID string, centroid int) error
+}
+
+// reassignment is an Assign made while a reload was running, replayed once the reload swaps
+// in the movies it loaded. seq orders it against the reload's start.
+type reassignment struct {
+	centroid int
+	seq      uint64
+}
+
+func NewCentroidsToMoviesCache(
+	load func(ctx context.Context) ([]data.Movie, error),
+	centroids CentroidCacheInterface,
+	setCentroid func(ctx context.Context, movieID string, centroid int) error,
+) *CentroidsToMoviesCache {
+	return &CentroidsToMoviesCache{
+		CentroidToMovieIDs: make(map[int][]string),
+		load:               load,
+		centroids:          centroids,
+		setCentroid:        setCentroid,
+	}
+}
+
+// Reload fetches the movies again and swaps in their centroid assignments. Movies assigned
+// since the load started keep their new centroid. On failure, including a movie that needs
+// a centroid while the centroids are unavailable, the previous assignments are kept and the
+// error is reported by Status.
+func (ctm *CentroidsToMoviesCache) Reload(ctx context.Context) error {
+	ctm.mu.Lock()
+	start := ctm.seq
+	ctm.reloads++
+	ctm.mu.Unlock()
+
+	movies, err := ctm.load(ctx)
+	var centroidToMovieIDs map[int][]string
+	var excluded map[string]bool
+	var assigned map[string]int
+	if err == nil {
+		centroidToMovieIDs, excluded, assigned, err = ctm.group(movies)
+	}
+
+	ctm.mu.Lock()
+	ctm.reloads--
+	if err != nil {
+		ctm.lastErr = err
+		if ctm.reloads == 0 {
+			ctm.reassigned = nil
+		}
+		ctm.mu.Unlock()
+		return utils.LogError("failed to reload centroid to movies cache", err)
+	}
+	ctm.CentroidToMovieIDs, ctm.excluded, ctm.movieToCentroid = centroidToMovieIDs, excluded, nil
+	ctm.loadedAt, ctm.lastErr = time.Now(), nil
+	for movieID, r := range ctm.reassigned {
+		if r.seq > start {
+			ctm.assign(movieID, r.centroid)
+			delete(assigned, movieID)
+		}
+	}
+	if ctm.reloads == 0 {
+		ctm.reassigned = nil
+	}
+	ctm.mu.Unlock()
+
+	ctm.persist(ctx, assigned)
+	return nil
+}
+
+func (ctm *CentroidsToMoviesCache) group(movies []data.Movie) (map[int][]string, map[string]bool, map[string]int, error) {
+	centroidToMovieIDs := make(map[int][]string)
+	excluded := make(map[string]bool)
+	assigned := make(map[string]int)
+	for _, movie := range movies {
+		if movie.Metrics == (data.MovieMetrics{}) {
+			excluded[movie.ID] = true
+			continue
+		}
+		centroid, err := ctm.loadedCentroid(movie)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if centroid != movie.Centroid {
+			assigned[movie.ID] = centroid
+		}
+		centroidToMovieIDs[centroid] = append(centroidToMovieIDs[centroid], movie.ID)
+	}
+	// Sorted so a seeded draw picks the same movie whatever order the pages loaded in.
+	for _, movieIDs := range centroidToMovieIDs {
+		slices.Sort(movieIDs)
+	}
+	return centroidToMovieIDs, excluded, assigned, nil
+}
+
+// loadedCentroid returns the centroid a loaded movie belongs to. A movie is assigned the
+// nearest centroid when it has none, when its centroid is unknown to the centroid cache, or
+// when it is 0, which older writes stored for unclustered movies, and 0 is not the nearest.
+// Stored centroids are kept as they are while the centroid cache is empty.
+func (ctm *CentroidsToMoviesCache) loadedCentroid(movie data.Movie) (int, error) {
+	centroid := movie.Centroid
+	if centroid != constants.NO_CENTROID {
+		if ctm.centroids == nil || ctm.centroids.Size() == 0 {
+			return centroid, nil
+		}
+		if _, err := ctm.centroids.GetMetricsByCentroid(centroid); err == nil && centroid != 0 {
+			return centroid, nil
+		}
+	}
+	nearest, err := ctm.NearestCentroid(movie.Metrics)
+	if err != nil {
+		return 0, utils.LogError(fmt.Sprintf("cannot assign a centroid to %s", movie.ID), err)
+	}
+	return nearest, nil
+}
+
+// persist writes back centroids assigned on load so the next load finds them. Failures are
+// logged only; the movie is simply assigned again next time.
+func (ctm *CentroidsToMoviesCache) persist(ctx context.Context, assigned map[string]int) {
+	if ctm.setCentroid == nil {
+		return
+	}
+	for movieID, centroid := range assigned {
+		if err := ctm.setCentroid(ctx, movieID, centroid); err != nil {
+			utils.LogError(fmt.Sprintf("failed to save centroid %d assigned to %s", centroid, movieID), err)
+		}
+	}
+}
+
+// Status reports the number of movies assigned to a centroid and the number excluded for
+// having no metrics.
+func (ctm *CentroidsToMoviesCache) Status() data.CacheStatus {
+	ctm.mu.RLock()
+	defer ctm.mu.RUnlock()
+	size := 0
+	for _, movieIDs := range ctm.CentroidToMovieIDs {
+		size += len(movieIDs)
+	}
+	return data.CacheStatus{
+		Name:       constants.CENTROIDS_TO_MOVIES_CACHE,
+		Size:       size,
+		Excluded:   len(ctm.excluded),
+		LastLoaded: ctm.loadedAt,
+		LastError:  errorString(ctm.lastErr),
+	}
+}
+
+// NearestCentroid returns the centroid closest to metrics.
+func (ctm *CentroidsToMoviesCache) NearestCentroid(metrics data.MovieMetrics) (int, error) {
+	if ctm.centroids == nil {
+		return 0, utils.LogError("no centroids to assign movies to", nil)
+	}
+	nearest, err := ctm.centroids.GetKNearestCentroids(metrics, 1, utils.MetricDistance)
+	if err != nil {
+		return 0, err
+	}
+	if len(nearest) == 0 {
+		return 0, utils.LogError("no centroids to assign movies to", nil)
+	}
+	return nearest[0], nil
+}
+
+// Assign moves a movie to centroid without a reload, taking it out of its previous centroid
+// and clearing any exclusion. The per-centroid slices are copied rather than modified so
+// slices already handed out by GetMovieIDsByCentroid never change underneath a caller.
+// Assignments made while a reload is loading are applied again once it swaps.
+func (ctm *CentroidsToMoviesCache) Assign(movieID string, centroid int) {
+	ctm.mu.Lock()
+	defer ctm.mu.Unlock()
+	ctm.seq++
+	if ctm.reloads > 0 {
+		if ctm.reassigned == nil {
+			ctm.reassigned = make(map[string]reassignment)
+		}
+		ctm.reassigned[movieID] = reassignment{centroid: centroid, seq: ctm.seq}
+	}
+	ctm.assign(movieID, centroid)
+}
+
+// assign moves a movie to centroid. Callers hold the write lock.
+func (ctm *CentroidsToMoviesCache) assign(movieID string, centroid int) {
+	if ctm.CentroidToMovieIDs == nil {
+		ctm.CentroidToMovieIDs = make(map[int][]string)
+	}
+	for c, movieIDs := range ctm.CentroidToMovieIDs {
+		for i, mid := range movieIDs {
+			if mid != movieID {
+				continue
+			}
+			if c == centroid {
+				return
+			}
+			remaining := append(append([]string{}, movieIDs[:i]...), movieIDs[i+1:]...)
+			if len(remaining) == 0 {
+				delete(ctm.CentroidToMovieIDs, c)
+			} else {
+				ctm.CentroidToMovieIDs[c] = remaining
+			}
+			break
+		}
+	}
+	movieIDs := ctm.CentroidToMovieIDs[centroid]
+	ctm.CentroidToMovieIDs[centroid] = append(movieIDs[:len(movieIDs):len(movieIDs)], movieID)
+	if ctm.movieToCentroid != nil {
+		ctm.movieToCentroid[movieID] = centroid
+	}
+	delete(ctm.excluded, movieID)
+}
+
+// IsExcluded reports whether a movie was left out of every centroid for having no metrics.
+func (ctm *CentroidsToMoviesCache) IsExcluded(movieID string) bool {
+	ctm.mu.RLock()
+	defer ctm.mu.RUnlock()
+	return ctm.excluded[movieID]
 }
 
 func (ctm *CentroidsToMoviesCache) GetMovieIDsByCentroid(centroid int) ([]string, error) {
+	ctm.mu.RLock()
+	defer ctm.mu.RUnlock()
 	if movieIDs, ok := ctm.CentroidToMovieIDs[centroid]; ok {
 		return movieIDs, nil
 	}
 	return nil, utils.LogError(fmt.Sprintf("cannot find movies for centroid id %v", centroid), nil)
 }
 
-func (ctm *CentroidsToMoviesCache) GetRandomMovieFromCentroid(centroid int) (string, error) {
+// GetRandomMovieFromCentroid draws one of the centroid's movies from rng, so callers decide
+// whether the draw can be replayed.
+func (ctm *CentroidsToMoviesCache) GetRandomMovieFromCentroid(centroid int, rng *rand.Rand) (string, error) {
+	ctm.mu.RLock()
+	defer ctm.mu.RUnlock()
 	if movieIDs, ok := ctm.CentroidToMovieIDs[centroid]; ok {
-		return movieIDs[rand.Intn(len(movieIDs))], nil
+		return movieIDs[rng.Intn(len(movieIDs))], nil
 	}
 	return "", utils.LogError(fmt.Sprintf("failed to retrieve random movie from centroid %d", centroid), nil)
 }
+
+// GetCentroidByMovieID returns the centroid a movie is assigned to. The reverse index is
+// built from CentroidToMovieIDs on first use.
+func (ctm *CentroidsToMoviesCache) GetCentroidByMovieID(movieID string) (int, error) {
+	ctm.mu.RLock()
+	movieToCentroid := ctm.movieToCentroid
+	var centroid int
+	var ok bool
+	if movieToCentroid != nil {
+		centroid, ok = movieToCentroid[movieID]
+	}
+	ctm.mu.RUnlock()
+	if movieToCentroid == nil {
+		centroid, ok = ctm.buildReverseIndex(movieID)
+	}
+	if ok {
+		return centroid, nil
+	}
+	return 0, utils.LogError(fmt.Sprintf("cannot find centroid for movie id %s", movieID), nil)
+}
+
+func (ctm *CentroidsToMoviesCache) buildReverseIndex(movieID string) (int, bool) {
+	ctm.mu.Lock()
+	defer ctm.mu.Unlock()
+	if ctm.movieToCentroid == nil {
+		ctm.movieToCentroid = make(map[string]int)
+		for centroid, movieIDs := range ctm.CentroidToMovieIDs {
+			for _, mid := range movieIDs {
+				ctm.movieToCentroid[mid] = centroid
+			}
+		}
+	}
+	centroid, ok := ctm.movieToCentroid[movieID]
+	return centroid, ok
+}
@@ -1,9 +1,14 @@
 package api_cache
 
 import (
+	"context"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
 )
 
 func TestGetRandomMovieFromCentroid_Found(t *testing.T) {
@@ -13,16 +18,179 @@ func TestGetRandomMovieFromCentroid_Found(t *testing.T) {
 		},
 	}
 	// Should always return a movie from the list
-	movie, err := cache.GetRandomMovieFromCentroid(1)
+	movie, err := cache.GetRandomMovieFromCentroid(1, rand.New(rand.NewSource(1)))
 	assert.NoError(t, err)
 	assert.Contains(t, []string{"movieA", "movieB", "movieC"}, movie)
 }
 
+func TestGetRandomMovieFromCentroid_SameSeedSameMovies(t *testing.T) {
+	cache := CentroidsToMoviesCache{
+		CentroidToMovieIDs: map[int][]string{
+			1: {"movieA", "movieB", "movieC", "movieD", "movieE"},
+		},
+	}
+	draw := func(seed int64) []string {
+		rng := rand.New(rand.NewSource(seed))
+		var movies []string
+		for range 10 {
+			movie, err := cache.GetRandomMovieFromCentroid(1, rng)
+			assert.NoError(t, err)
+			movies = append(movies, movie)
+		}
+		return movies
+	}
+	assert.Equal(t, draw(42), draw(42))
+}
+
 func TestGetRandomMovieFromCentroid_NotFound(t *testing.T) {
 	cache := CentroidsToMoviesCache{
 		CentroidToMovieIDs: map[int][]string{},
 	}
-	movie, err := cache.GetRandomMovieFromCentroid(99)
+	movie, err := cache.GetRandomMovieFromCentroid(99, rand.New(rand.NewSource(1)))
 	assert.Error(t, err)
 	assert.Empty(t, movie)
 }
+
+func TestGetCentroidByMovieID(t *testing.T) {
+	cache := CentroidsToMoviesCache{
+		CentroidToMovieIDs: map[int][]string{
+			1: {"movieA", "movieB"},
+			2: {"movieC"},
+		},
+	}
+	centroid, err := cache.GetCentroidByMovieID("movieC")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, centroid)
+
+	_, err = cache.GetCentroidByMovieID("missing")
+	assert.Error(t, err)
+}
+
+func TestCentroidsToMoviesCache_AssignsUnclusteredAndExcludesUnmeasured(t *testing.T) {
+	centroids := &CentroidCache{centroids: map[int]data.MovieMetrics{
+		0: {ID: 0, Comedy: 90},
+		1: {ID: 1, Horror: 90},
+	}}
+	saved := map[string]int{}
+	cache := NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		return []data.Movie{
+			{ID: "airplane", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 95}},
+			{ID: "the_thing", Centroid: constants.NO_CENTROID, Metrics: data.MovieMetrics{Horror: 85}},
+			{ID: "untitled", Centroid: constants.NO_CENTROID},
+		}, nil
+	}, centroids, func(ctx context.Context, movieID string, centroid int) error {
+		saved[movieID] = centroid
+		return nil
+	})
+
+	assert.NoError(t, cache.Reload(context.Background()))
+	assert.Equal(t, []string{"airplane"}, cache.CentroidToMovieIDs[0], "unclustered movies are not lumped into centroid 0")
+	assert.Equal(t, []string{"the_thing"}, cache.CentroidToMovieIDs[1])
+	assert.Equal(t, map[string]int{"the_thing": 1}, saved)
+	assert.True(t, cache.IsExcluded("untitled"))
+	assert.False(t, cache.IsExcluded("the_thing"))
+	_, err := cache.GetCentroidByMovieID("untitled")
+	assert.Error(t, err)
+	status := cache.Status()
+	assert.Equal(t, 2, status.Size)
+	assert.Equal(t, 1, status.Excluded)
+}
+
+func TestCentroidsToMoviesCache_ReassignsUnknownAndStrayCentroids(t *testing.T) {
+	centroids := &CentroidCache{centroids: map[int]data.MovieMetrics{
+		0: {ID: 0, Comedy: 90},
+		1: {ID: 1, Horror: 90},
+	}}
+	saved := map[string]int{}
+	cache := NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		return []data.Movie{
+			{ID: "airplane", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 95}},
+			{ID: "alien", Centroid: 0, Metrics: data.MovieMetrics{Horror: 90}},
+			{ID: "aliens", Centroid: 1, Metrics: data.MovieMetrics{Comedy: 50, Horror: 60}},
+			{ID: "the_fly", Centroid: 7, Metrics: data.MovieMetrics{Horror: 85}},
+		}, nil
+	}, centroids, func(ctx context.Context, movieID string, centroid int) error {
+		saved[movieID] = centroid
+		return nil
+	})
+
+	assert.NoError(t, cache.Reload(context.Background()))
+	assert.Equal(t, []string{"airplane"}, cache.CentroidToMovieIDs[0])
+	assert.Equal(t, []string{"alien", "aliens", "the_fly"}, cache.CentroidToMovieIDs[1])
+	assert.NotContains(t, cache.CentroidToMovieIDs, 7)
+	assert.Equal(t, map[string]int{"alien": 1, "the_fly": 1}, saved, "stored centroids other than 0 are kept when known")
+}
+
+func TestCentroidsToMoviesCache_KeepsStoredCentroidsWithoutCentroids(t *testing.T) {
+	cache := NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		return []data.Movie{{ID: "alien", Centroid: 7, Metrics: data.MovieMetrics{Horror: 90}}}, nil
+	}, &CentroidCache{}, nil)
+
+	assert.NoError(t, cache.Reload(context.Background()))
+	assert.Equal(t, []string{"alien"}, cache.CentroidToMovieIDs[7])
+}
+
+func TestCentroidsToMoviesCache_ReloadFailsWithoutCentroids(t *testing.T) {
+	cache := NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		return []data.Movie{{ID: "the_thing", Centroid: constants.NO_CENTROID, Metrics: data.MovieMetrics{Horror: 85}}}, nil
+	}, &CentroidCache{}, nil)
+
+	assert.Error(t, cache.Reload(context.Background()))
+	assert.Contains(t, cache.Status().LastError, "centroid cache not loaded")
+}
+
+func TestCentroidsToMoviesCache_Assign(t *testing.T) {
+	cache := CentroidsToMoviesCache{
+		CentroidToMovieIDs: map[int][]string{
+			1: {"alien", "aliens"},
+			2: {"airplane"},
+		},
+		excluded: map[string]bool{"untitled": true},
+	}
+	handedOut, _ := cache.GetMovieIDsByCentroid(1)
+	_, err := cache.GetCentroidByMovieID("alien")
+	assert.NoError(t, err)
+
+	cache.Assign("alien", 2)
+	cache.Assign("untitled", 2)
+	cache.Assign("airplane", 2)
+
+	assert.Equal(t, []string{"aliens"}, cache.CentroidToMovieIDs[1])
+	assert.Equal(t, []string{"airplane", "alien", "untitled"}, cache.CentroidToMovieIDs[2])
+	assert.Equal(t, []string{"alien", "aliens"}, handedOut, "slices already handed out are left alone")
+	centroid, err := cache.GetCentroidByMovieID("alien")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, centroid)
+	assert.False(t, cache.IsExcluded("untitled"))
+
+	cache.Assign("aliens", 2)
+	_, err = cache.GetMovieIDsByCentroid(1)
+	assert.Error(t, err, "emptied centroids are dropped")
+}
+
+func TestCentroidsToMoviesCache_AssignDuringReloadIsReplayed(t *testing.T) {
+	centroids := &CentroidCache{centroids: map[int]data.MovieMetrics{
+		0: {ID: 0, Comedy: 90},
+		1: {ID: 1, Horror: 90},
+	}}
+	saved := map[string]int{}
+	var cache *CentroidsToMoviesCache
+	cache = NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		// the table was read before either movie's new metrics were written
+		cache.Assign("airplane", 1)
+		cache.Assign("the_thing", 0)
+		return []data.Movie{
+			{ID: "airplane", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 95}},
+			{ID: "the_thing", Centroid: constants.NO_CENTROID, Metrics: data.MovieMetrics{Horror: 85}},
+		}, nil
+	}, centroids, func(ctx context.Context, movieID string, centroid int) error {
+		saved[movieID] = centroid
+		return nil
+	})
+
+	assert.NoError(t, cache.Reload(context.Background()))
+	assert.Equal(t, []string{"the_thing"}, cache.CentroidToMovieIDs[0])
+	assert.Equal(t, []string{"airplane"}, cache.CentroidToMovieIDs[1])
+	assert.Empty(t, saved, "centroids assigned on load are not written over newer assignments")
+	assert.Nil(t, cache.reassigned, "assignments are only kept while a reload runs")
+}
@@ -0,0 +1,127 @@
+package api_cache
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"blockbuster/api/data"
+	"blockbuster/api/utils"
+)
+
+// CoRentalCache is an item-to-item co-occurrence model built from members' rental
+// histories. It is rebuilt wholesale by Refresh and swapped in under a lock, so reads
+// never see a half built model.
+type CoRentalCache struct {
+	mu        sync.RWMutex
+	pairs     map[string]map[string]int
+	rentals   map[string]int
+	updatedAt time.Time
+	load      func(ctx context.Context) ([][]string, error)
+}
+
+func NewCoRentalCache(load func(ctx context.Context) ([][]string, error)) *CoRentalCache {
+	return &CoRentalCache{
+		pairs:   make(map[string]map[string]int),
+		rentals: make(map[string]int),
+		load:    load,
+	}
+}
+
+// Refresh reloads every member's rental history and rebuilds the co-occurrence counts.
+// On failure the previous model is kept.
+func (c *CoRentalCache) Refresh(ctx context.Context) error {
+	histories, err := c.load(ctx)
+	if err != nil {
+		return utils.LogError("failed to load rental histories for co-rental cache", err)
+	}
+
+	pairs := make(map[string]map[string]int)
+	rentals := make(map[string]int)
+	for _, history := range histories {
+		movieIDs := dedupe(history)
+		for _, a := range movieIDs {
+			rentals[a]++
+			for _, b := range movieIDs {
+				if a == b {
+					continue
+				}
+				if pairs[a] == nil {
+					pairs[a] = make(map[string]int)
+				}
+				pairs[a][b]++
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pairs, c.rentals, c.updatedAt = pairs, rentals, time.Now()
+	return nil
+}
+
+// StartRefresher refreshes the cache every interval until ctx is cancelled.
+func (c *CoRentalCache) StartRefresher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.Refresh(ctx)
+			}
+		}
+	}()
+}
+
+// GetAlsoRented returns up to k movies most often rented alongside movieID, scored by the
+// cosine similarity of their renter sets. Only the movie IDs of the results are set.
+func (c *CoRentalCache) GetAlsoRented(movieID string, k int) []data.CoRental {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	results := make([]data.CoRental, 0, len(c.pairs[movieID]))
+	for other, count := range c.pairs[movieID] {
+		score := float64(count) / math.Sqrt(float64(c.rentals[movieID]*c.rentals[other]))
+		results = append(results, data.CoRental{Movie: data.Movie{ID: other}, Count: count, Score: score})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+		return results[i].Movie.ID < results[j].Movie.ID
+	})
+	return results[:min(max(k, 0), len(results))]
+}
+
+// RentalCount returns how many members have rented movieID.
+func (c *CoRentalCache) RentalCount(movieID string) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rentals[movieID]
+}
+
+func (c *CoRentalCache) UpdatedAt() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.updatedAt
+}
+
+func dedupe(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,46 @@
+package api_cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoRentalCache_GetAlsoRented(t *testing.T) {
+	cache := NewCoRentalCache(func(ctx context.Context) ([][]string, error) {
+		return [][]string{
+			{"alien", "aliens", "the_thing"},
+			{"alien", "aliens"},
+			{"alien", "the_thing", "the_thing"},
+			{"aliens", "terminator"},
+		}, nil
+	})
+	assert.NoError(t, cache.Refresh(context.Background()))
+
+	results := cache.GetAlsoRented("alien", 5)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "the_thing", results[0].Movie.ID, "rarer co-rentals score higher for the same count")
+	assert.Equal(t, 2, results[0].Count)
+	assert.InDelta(t, 2/2.449, results[0].Score, 0.01)
+	assert.Equal(t, "aliens", results[1].Movie.ID)
+
+	assert.Len(t, cache.GetAlsoRented("alien", 1), 1)
+	assert.Empty(t, cache.GetAlsoRented("unknown", 5))
+}
+
+func TestCoRentalCache_RefreshKeepsModelOnError(t *testing.T) {
+	fail := false
+	cache := NewCoRentalCache(func(ctx context.Context) ([][]string, error) {
+		if fail {
+			return nil, errors.New("scan failed")
+		}
+		return [][]string{{"alien", "aliens"}}, nil
+	})
+	assert.NoError(t, cache.Refresh(context.Background()))
+
+	fail = true
+	assert.Error(t, cache.Refresh(context.Background()))
+	assert.Len(t, cache.GetAlsoRented("alien", 5), 1)
+}
@@ -2,10 +2,9 @@ package api_cache
 
 import (
 	"context"
+	"fmt"
 	"sync"
-
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"time"
 
 	"blockbuster/api/constants"
 	"blockbuster/api/data"
@@ -17,57 +16,132 @@ var (
 	centroidCache                  *CentroidCache
 	initCentroidsToMoviesCacheOnce sync.Once
 	centroidToMoviesCache          *CentroidsToMoviesCache
+	initVotingSessionCacheOnce     sync.Once
+	votingSessionCache             *VotingSessionCache
+	initVotingRoomCacheOnce        sync.Once
+	votingRoomCache                *VotingRoomCache
+	initQuizCacheOnce              sync.Once
+	quizCache                      *QuizCache
+	initCoRentalCacheOnce          sync.Once
+	coRentalCache                  *CoRentalCache
+	initMovieIndexOnce             sync.Once
+	movieIndex                     *MovieIndex
+	initQuizLeaderboardOnce        sync.Once
+	quizLeaderboard                *QuizLeaderboardCache
 )
 
-func GetDynamoClientCentroidCache() *CentroidCache {
+// GetCentroidCache loads the centroids and staff labels on first use, retrying with backoff,
+// and reloads them in the background every CACHE_RELOAD_MINUTES. If every attempt fails the
+// cache starts empty and the next reload tries again.
+func GetCentroidCache(
+	load func(ctx context.Context) (map[int]data.MovieMetrics, error),
+	loadStaff func(ctx context.Context) (map[int]data.CentroidLabel, error),
+) *CentroidCache {
 	initCentroidsCacheOnce.Do(func() {
-		centroidCache = &CentroidCache{
-			centroids: getCentroidsFromDynamo(),
-		}
+		centroidCache = NewLabelledCentroidCache(load, loadStaff)
+		startReloadable(centroidCache.Reload)
 	})
 	return centroidCache
 }
 
-func getCentroidsFromDynamo() map[int]data.MovieMetrics {
-	centroidsMap := make(map[int]data.MovieMetrics)
-	centroidTableName, client := "centroids", utils.GetDynamoClient()
-
-	centroidItems, err := client.Scan(context.TODO(), &dynamodb.ScanInput{
-		TableName: &centroidTableName,
+// InitCentroidsToMoviesCache maps each centroid to its movies from every page of movies,
+// loaded and reloaded like GetCentroidCache. Movies without a centroid are assigned the
+// nearest of centroids and saved through SetCentroid. A load fails if any page fails, so a
+// reload never swaps in a partial mapping.
+func InitCentroidsToMoviesCache(
+	GetMoviesByPage func(ctx context.Context, page string, purpose string) ([]data.Movie, error),
+	centroids CentroidCacheInterface,
+	SetCentroid func(ctx context.Context, movieID string, centroid int) error,
+) *CentroidsToMoviesCache {
+	initCentroidsToMoviesCacheOnce.Do(func() {
+		centroidToMoviesCache = NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+			var all []data.Movie
+			for _, page := range constants.PAGES {
+				movies, err := GetMoviesByPage(ctx, string(page), constants.FOR_CENTROID_CACHE)
+				if err != nil {
+					return nil, utils.LogError(fmt.Sprintf("failed to get page %c for centroid to movies cache", page), err)
+				}
+				all = append(all, movies...)
+			}
+			return all, nil
+		}, centroids, SetCentroid)
+		startReloadable(centroidToMoviesCache.Reload)
 	})
-	if err != nil {
-		utils.LogError("failed to scan dynamo table for centroids", err)
-		return nil
-	}
-	var centroids []data.MovieMetrics
-	err = attributevalue.UnmarshalListOfMaps(centroidItems.Items, &centroids)
-	if err != nil {
-		utils.LogError("failed to unmarshall dynamo table centroid items", err)
-		return nil
-	}
+	return centroidToMoviesCache
+}
 
-	for _, centroid := range centroids {
-		centroidsMap[centroid.ID] = centroid
-	}
-	return centroidsMap
+func startReloadable(reload func(ctx context.Context) error) {
+	ctx := context.Background()
+	loadWithRetry(ctx, constants.CACHE_LOAD_ATTEMPTS, constants.CACHE_LOAD_BACKOFF_MS*time.Millisecond, reload)
+	startReloader(ctx, constants.CACHE_RELOAD_MINUTES*time.Minute, reload)
 }
 
-func InitCentroidsToMoviesCache(GetMoviesByPage func(
+// InitMovieIndex indexes every movie with metrics from every page of movies, loaded and
+// reloaded like GetCentroidCache. A load fails if any page fails, so a reload never swaps in
+// a partial index.
+func InitMovieIndex(GetMoviesByPage func(
 	ctx context.Context, page string, purpose string) ([]data.Movie, error),
-) *CentroidsToMoviesCache {
-	initCentroidsToMoviesCacheOnce.Do(func() {
-		centroidsToMovies := make(map[int][]string)
-		for _, page := range constants.PAGES {
-			movies, err := GetMoviesByPage(context.Background(), string(page), constants.FOR_CENTROID_CACHE)
-			if err != nil {
-				utils.LogError("failed to get movies for centroid to movies cache", err)
-				continue
-			}
-			for _, movie := range movies {
-				centroidsToMovies[movie.Centroid] = append(centroidsToMovies[movie.Centroid], movie.ID)
+) *MovieIndex {
+	initMovieIndexOnce.Do(func() {
+		movieIndex = NewReloadableMovieIndex(func(ctx context.Context) ([]data.Movie, error) {
+			var indexed []data.Movie
+			for _, page := range constants.PAGES {
+				movies, err := GetMoviesByPage(ctx, string(page), constants.FOR_METRICS_INDEX)
+				if err != nil {
+					return nil, utils.LogError(fmt.Sprintf("failed to get page %c for movie metrics index", page), err)
+				}
+				for _, movie := range movies {
+					if movie.Metrics != (data.MovieMetrics{}) {
+						indexed = append(indexed, movie)
+					}
+				}
 			}
-		}
-		centroidToMoviesCache = &CentroidsToMoviesCache{CentroidToMovieIDs: centroidsToMovies}
+			return indexed, nil
+		})
+		startReloadable(movieIndex.Reload)
 	})
-	return centroidToMoviesCache
+	return movieIndex
+}
+
+func GetVotingSessionCache() *VotingSessionCache {
+	initVotingSessionCacheOnce.Do(func() {
+		votingSessionCache = NewVotingSessionCache(constants.VOTING_SESSION_TTL_MINUTES*time.Minute, time.Now)
+	})
+	return votingSessionCache
+}
+
+func GetVotingRoomCache() *VotingRoomCache {
+	initVotingRoomCacheOnce.Do(func() {
+		votingRoomCache = NewVotingRoomCache(constants.VOTING_SESSION_TTL_MINUTES*time.Minute, time.Now)
+	})
+	return votingRoomCache
+}
+
+func GetQuizCache() *QuizCache {
+	initQuizCacheOnce.Do(func() {
+		quizCache = NewQuizCache(constants.QUIZ_TTL_MINUTES*time.Minute, time.Now)
+	})
+	return quizCache
+}
+
+// GetQuizLeaderboardCache loads every player's quiz totals on first use and reloads them
+// like GetCentroidCache, so scores recorded by other instances show up. If every attempt fails
+// the leaderboard loads on its first read instead.
+func GetQuizLeaderboardCache(load func(ctx context.Context) ([]data.QuizStats, error)) *QuizLeaderboardCache {
+	initQuizLeaderboardOnce.Do(func() {
+		quizLeaderboard = NewQuizLeaderboardCache(load)
+		startReloadable(quizLeaderboard.Reload)
+	})
+	return quizLeaderboard
+}
+
+// GetCoRentalCache builds the co-rental model on first use and keeps it fresh in the
+// background every CO_RENTAL_REFRESH_MINUTES.
+func GetCoRentalCache(load func(ctx context.Context) ([][]string, error)) *CoRentalCache {
+	initCoRentalCacheOnce.Do(func() {
+		coRentalCache = NewCoRentalCache(load)
+		coRentalCache.Refresh(context.Background())
+		coRentalCache.StartRefresher(context.Background(), constants.CO_RENTAL_REFRESH_MINUTES*time.Minute)
+	})
+	return coRentalCache
 }
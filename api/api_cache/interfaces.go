@@ -1,14 +1,74 @@
 package api_cache
 
-import "blockbuster/api/data"
+import (
+	"context"
+	"math/rand"
+
+	"blockbuster/api/data"
+	"blockbuster/api/utils"
+)
 
 type CentroidCacheInterface interface {
 	GetMetricsByCentroid(centroidID int) (data.MovieMetrics, error)
 	GetKNearestCentroidsFromMood(mood data.MovieMetrics, k int) ([]int, error)
+	GetKNearestCentroids(mood data.MovieMetrics, k int, distance utils.DistanceFunc) ([]int, error)
+	GetCentroids() []data.Centroid
+	SetLabel(id int, label data.CentroidLabel)
 	Size() int
 }
 
 type CentroidsToMoviesCacheInterface interface {
 	GetMovieIDsByCentroid(centroid int) ([]string, error)
-	GetRandomMovieFromCentroid(centroid int) (string, error)
+	GetRandomMovieFromCentroid(centroid int, rng *rand.Rand) (string, error)
+	GetCentroidByMovieID(movieID string) (int, error)
+	NearestCentroid(metrics data.MovieMetrics) (int, error)
+	Assign(movieID string, centroid int)
+	IsExcluded(movieID string) bool
+}
+
+type VotingSessionCacheInterface interface {
+	Create(session data.VotingSession) (data.VotingSession, error)
+	Get(sessionID string) (data.VotingSession, error)
+	Update(sessionID string, update func(session *data.VotingSession) error) (data.VotingSession, error)
+	Take(sessionID string) (data.VotingSession, error)
+	Restore(session data.VotingSession)
+}
+
+type VotingRoomCacheInterface interface {
+	Create(room data.VotingRoom) (data.VotingRoom, error)
+	Get(code string) (data.VotingRoom, error)
+	Update(code string, update func(room *data.VotingRoom) error) (data.VotingRoom, error)
+	Take(code string) (data.VotingRoom, error)
+	Restore(room data.VotingRoom)
+}
+
+type QuizCacheInterface interface {
+	Create(quiz data.Quiz) (data.Quiz, error)
+	Get(quizID string) (data.Quiz, error)
+	Take(quizID string) (data.Quiz, error)
+}
+
+// QuizLeaderboardInterface ranks members by their quiz totals.
+type QuizLeaderboardInterface interface {
+	Top(ctx context.Context, limit int) ([]data.QuizStats, error)
+	Stats(ctx context.Context, username string) (data.QuizStats, error)
+	Record(stats data.QuizStats) data.QuizStats
+}
+
+type CoRentalCacheInterface interface {
+	GetAlsoRented(movieID string, k int) []data.CoRental
+	RentalCount(movieID string) int
+}
+
+type MovieIndexInterface interface {
+	KNearest(query data.MovieMetrics, k int, distance utils.DistanceFunc, filter func(movieID string) bool) []data.Neighbor
+	GetMetrics(movieID string) (data.MovieMetrics, bool)
+	Size() int
+	Set(movieID string, metrics data.MovieMetrics, centroid int)
+}
+
+// ReloadableCache is a cache that can be reloaded from its source while serving reads.
+type ReloadableCache interface {
+	Reload(ctx context.Context) error
+	Status() data.CacheStatus
 }
@@ -0,0 +1,361 @@
+package api_cache
+
+import (
+	"container/heap"
+	"context"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/utils"
+)
+
+// MovieIndex is an in-memory KD-tree over every movie's metric vector. Each tree is
+// immutable once built, so queries search the current tree without holding the lock.
+// Reload builds a new tree and swaps it in. Set records the write in a small overlay of
+// pending writes that queries consult alongside the tree; once enough are pending they are
+// folded into a new tree built in the background.
+type MovieIndex struct {
+	mu         sync.RWMutex
+	tree       *kdTree
+	pending    map[string]pendingWrite
+	seq        uint64
+	reloads    int
+	compacting bool
+	loadedAt   time.Time
+	lastErr    error
+	load       func(ctx context.Context) ([]data.Movie, error)
+}
+
+// kdTree is one build of the index.
+type kdTree struct {
+	root      *kdNode
+	metrics   map[string]data.MovieMetrics
+	centroids map[string]int
+}
+
+type kdNode struct {
+	movieID     string
+	point       data.MetricVector
+	axis        int
+	left, right *kdNode
+}
+
+// pendingWrite is a Set not yet folded into the tree. Zero metrics mark a removed movie.
+// seq orders writes against reloads and compactions.
+type pendingWrite struct {
+	metrics  data.MovieMetrics
+	centroid int
+	seq      uint64
+}
+
+// NewMovieIndex indexes the metrics and centroids of movies. Later duplicates of a movie ID
+// replace earlier ones. Reload indexes the same movies again.
+func NewMovieIndex(movies []data.Movie) *MovieIndex {
+	idx := NewReloadableMovieIndex(func(ctx context.Context) ([]data.Movie, error) {
+		return movies, nil
+	})
+	idx.Reload(context.Background())
+	return idx
+}
+
+// NewReloadableMovieIndex returns an empty index that is filled with the movies load returns
+// on each Reload.
+func NewReloadableMovieIndex(load func(ctx context.Context) ([]data.Movie, error)) *MovieIndex {
+	return &MovieIndex{tree: newKDTree(nil), load: load}
+}
+
+func newKDTree(movies []data.Movie) *kdTree {
+	tree := &kdTree{
+		metrics:   make(map[string]data.MovieMetrics, len(movies)),
+		centroids: make(map[string]int, len(movies)),
+	}
+	for _, movie := range movies {
+		tree.metrics[movie.ID] = movie.Metrics
+		tree.centroids[movie.ID] = movie.Centroid
+	}
+	nodes := make([]*kdNode, 0, len(tree.metrics))
+	for mid, metrics := range tree.metrics {
+		nodes = append(nodes, &kdNode{movieID: mid, point: metrics.Vector()})
+	}
+	tree.root = buildKDTree(nodes, 0)
+	return tree
+}
+
+// Reload fetches the movies again and swaps in a tree built from them. Writes Set since the
+// load started are replayed onto the new tree. On failure the previous tree is kept and the
+// error is reported by Status.
+func (idx *MovieIndex) Reload(ctx context.Context) error {
+	idx.mu.Lock()
+	start := idx.seq
+	idx.reloads++
+	idx.mu.Unlock()
+
+	movies, err := idx.load(ctx)
+	var tree *kdTree
+	if err == nil {
+		tree = newKDTree(movies)
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.reloads--
+	if err != nil {
+		idx.lastErr = err
+		return utils.LogError("failed to reload movie index", err)
+	}
+	idx.tree, idx.loadedAt, idx.lastErr = tree, time.Now(), nil
+	// Writes made before the load started are in the movies it read. A reload still in
+	// flight may have read them too early, so they are only dropped once none is.
+	if idx.reloads == 0 {
+		idx.pending = pendingSince(idx.pending, start)
+	}
+	return nil
+}
+
+// Status reports the number of movies indexed.
+func (idx *MovieIndex) Status() data.CacheStatus {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return data.CacheStatus{
+		Name:       constants.MOVIE_INDEX_CACHE,
+		Size:       size(idx.tree, idx.pending),
+		LastLoaded: idx.loadedAt,
+		LastError:  errorString(idx.lastErr),
+	}
+}
+
+// Set indexes new metrics and a new centroid for movieID without waiting for a reload. Zero
+// metrics take the movie out of the index. Once MOVIE_INDEX_PENDING_WRITES writes are
+// pending, a new tree including them is built in the background.
+func (idx *MovieIndex) Set(movieID string, metrics data.MovieMetrics, centroid int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.seq++
+	pending := make(map[string]pendingWrite, len(idx.pending)+1)
+	maps.Copy(pending, idx.pending)
+	pending[movieID] = pendingWrite{metrics: metrics, centroid: centroid, seq: idx.seq}
+	idx.pending = pending
+	if len(pending) >= constants.MOVIE_INDEX_PENDING_WRITES && !idx.compacting {
+		idx.compacting = true
+		go idx.compact(idx.tree, pending, idx.seq)
+	}
+}
+
+// compact builds a tree from tree and the writes pending up to seq and swaps it in, unless a
+// reload swapped in another tree or is still running.
+func (idx *MovieIndex) compact(tree *kdTree, pending map[string]pendingWrite, seq uint64) {
+	movies := make([]data.Movie, 0, len(tree.metrics)+len(pending))
+	for mid, metrics := range tree.metrics {
+		if _, ok := pending[mid]; !ok {
+			movies = append(movies, data.Movie{ID: mid, Metrics: metrics, Centroid: tree.centroids[mid]})
+		}
+	}
+	for mid, write := range pending {
+		if write.metrics != (data.MovieMetrics{}) {
+			movies = append(movies, data.Movie{ID: mid, Metrics: write.metrics, Centroid: write.centroid})
+		}
+	}
+	compacted := newKDTree(movies)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.compacting = false
+	if idx.tree != tree || idx.reloads > 0 {
+		return
+	}
+	idx.tree = compacted
+	idx.pending = pendingSince(idx.pending, seq)
+}
+
+// pendingSince returns the writes in pending made after seq.
+func pendingSince(pending map[string]pendingWrite, seq uint64) map[string]pendingWrite {
+	kept := make(map[string]pendingWrite)
+	for mid, write := range pending {
+		if write.seq > seq {
+			kept[mid] = write
+		}
+	}
+	return kept
+}
+
+// size counts the movies in tree, less those removed and plus those added by pending.
+func size(tree *kdTree, pending map[string]pendingWrite) int {
+	n := len(tree.metrics)
+	for mid, write := range pending {
+		_, inTree := tree.metrics[mid]
+		removed := write.metrics == (data.MovieMetrics{})
+		if inTree && removed {
+			n--
+		} else if !inTree && !removed {
+			n++
+		}
+	}
+	return n
+}
+
+// current returns the tree and the writes pending on it. Neither is modified once handed
+// out.
+func (idx *MovieIndex) current() (*kdTree, map[string]pendingWrite) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.tree, idx.pending
+}
+
+// buildKDTree splits nodes on the median of axis, cycling through the metric dimensions
+// by depth. Ties are broken by movie ID so the tree is the same on every build.
+func buildKDTree(nodes []*kdNode, depth int) *kdNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+	axis := depth % data.NumMetricDimensions
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].point[axis] != nodes[j].point[axis] {
+			return nodes[i].point[axis] < nodes[j].point[axis]
+		}
+		return nodes[i].movieID < nodes[j].movieID
+	})
+	mid := len(nodes) / 2
+	node := nodes[mid]
+	node.axis = axis
+	node.left = buildKDTree(nodes[:mid], depth+1)
+	node.right = buildKDTree(nodes[mid+1:], depth+1)
+	return node
+}
+
+// GetMetrics returns the indexed metrics of movieID.
+func (idx *MovieIndex) GetMetrics(movieID string) (data.MovieMetrics, bool) {
+	tree, pending := idx.current()
+	if write, ok := pending[movieID]; ok {
+		return write.metrics, write.metrics != (data.MovieMetrics{})
+	}
+	metrics, ok := tree.metrics[movieID]
+	return metrics, ok
+}
+
+// Size is the number of movies indexed.
+func (idx *MovieIndex) Size() int {
+	return size(idx.current())
+}
+
+// KNearest returns up to k movies nearest query under distance, closest first, skipping
+// those filter rejects. A nil distance means utils.MetricDistance and a nil filter keeps
+// every movie.
+//
+// Branches are pruned by the distance from query to the splitting plane, which bounds the
+// distance to everything beyond it for euclidean, weighted euclidean and manhattan
+// metrics. Cosine distance has no such bound, so it searches every movie.
+func (idx *MovieIndex) KNearest(query data.MovieMetrics, k int, distance utils.DistanceFunc, filter func(movieID string) bool) []data.Neighbor {
+	tree, pending := idx.current()
+	if k <= 0 || (tree.root == nil && len(pending) == 0) {
+		return nil
+	}
+	if distance == nil {
+		distance = utils.MetricDistance
+	}
+	search := &kNearestSearch{
+		query:    query.Vector(),
+		k:        k,
+		distance: distance,
+		prune:    planeBounded(distance),
+		filter:   filter,
+		tree:     tree,
+		pending:  pending,
+	}
+	for mid, write := range pending {
+		if write.metrics != (data.MovieMetrics{}) {
+			search.offer(mid, write.metrics.Vector(), write.centroid)
+		}
+	}
+	search.visit(tree.root)
+
+	neighbors := make([]data.Neighbor, len(search.best))
+	for i := len(neighbors) - 1; i >= 0; i-- {
+		neighbors[i] = heap.Pop(&search.best).(data.Neighbor)
+	}
+	return neighbors
+}
+
+// planeBounded reports whether distance never exceeds the distance to any point beyond a
+// splitting plane, so branches can be pruned by it. Euclidean and manhattan distances grow
+// with every coordinate's difference. Cosine distance ignores scale, so a point and its
+// double are no distance apart; distances that do the same are searched exhaustively.
+func planeBounded(distance utils.DistanceFunc) bool {
+	const epsilon = 1e-9
+	var point data.MetricVector
+	for i := range point {
+		point[i] = 1
+	}
+	return distance(point.Metrics(), point.Scale(2).Metrics()) > epsilon
+}
+
+type kNearestSearch struct {
+	query    data.MetricVector
+	k        int
+	distance utils.DistanceFunc
+	prune    bool
+	filter   func(movieID string) bool
+	tree     *kdTree
+	pending  map[string]pendingWrite
+	best     neighborHeap
+}
+
+func (s *kNearestSearch) visit(node *kdNode) {
+	if node == nil {
+		return
+	}
+	// a pending write supersedes the tree's copy of the movie
+	if _, ok := s.pending[node.movieID]; !ok {
+		s.offer(node.movieID, node.point, s.tree.centroids[node.movieID])
+	}
+
+	near, far := node.left, node.right
+	if s.query[node.axis] > node.point[node.axis] {
+		near, far = far, near
+	}
+	s.visit(near)
+
+	plane := s.query
+	plane[node.axis] = node.point[node.axis]
+	if !s.prune || len(s.best) < s.k || s.distance(s.query.Metrics(), plane.Metrics()) <= s.best[0].Distance {
+		s.visit(far)
+	}
+}
+
+// offer keeps the movie if filter does and it is among the k nearest found so far.
+func (s *kNearestSearch) offer(movieID string, point data.MetricVector, centroid int) {
+	if s.filter != nil && !s.filter(movieID) {
+		return
+	}
+	neighbor := data.Neighbor{MovieID: movieID, Distance: s.distance(s.query.Metrics(), point.Metrics()), Centroid: centroid}
+	if len(s.best) < s.k {
+		heap.Push(&s.best, neighbor)
+	} else if farther(s.best[0], neighbor) {
+		s.best[0] = neighbor
+		heap.Fix(&s.best, 0)
+	}
+}
+
+// farther reports whether a ranks after b: by distance, then by movie ID.
+func farther(a, b data.Neighbor) bool {
+	if a.Distance != b.Distance {
+		return a.Distance > b.Distance
+	}
+	return a.MovieID > b.MovieID
+}
+
+// neighborHeap is a max-heap on distance, so the worst of the k best is at the root.
+type neighborHeap []data.Neighbor
+
+func (h neighborHeap) Len() int            { return len(h) }
+func (h neighborHeap) Less(i, j int) bool  { return farther(h[i], h[j]) }
+func (h neighborHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *neighborHeap) Push(x interface{}) { *h = append(*h, x.(data.Neighbor)) }
+func (h *neighborHeap) Pop() interface{} {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
@@ -0,0 +1,217 @@
+package api_cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/utils"
+)
+
+func randomMovies(n int, seed int64) []data.Movie {
+	rng := rand.New(rand.NewSource(seed))
+	movies := make([]data.Movie, n)
+	for i := range movies {
+		var v data.MetricVector
+		for d := range v {
+			v[d] = float64(rng.Intn(100))
+		}
+		movies[i] = data.Movie{ID: fmt.Sprintf("m%03d", i), Centroid: i % 7, Metrics: v.Metrics()}
+	}
+	return movies
+}
+
+// bruteForce ranks every movie filter keeps by distance, then ID.
+func bruteForce(movies []data.Movie, query data.MovieMetrics, k int, distance utils.DistanceFunc, filter func(string) bool) []data.Neighbor {
+	var all []data.Neighbor
+	for _, movie := range movies {
+		if filter == nil || filter(movie.ID) {
+			all = append(all, data.Neighbor{MovieID: movie.ID, Distance: distance(query, movie.Metrics), Centroid: movie.Centroid})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return farther(all[j], all[i]) })
+	return all[:min(k, len(all))]
+}
+
+func TestMovieIndex_KNearestMatchesBruteForce(t *testing.T) {
+	movies := randomMovies(300, 1)
+	idx := NewMovieIndex(movies)
+	weighted, err := utils.NewDistanceFunc(data.DistanceOptions{Metric: constants.WEIGHTED_EUCLIDEAN, Weights: map[string]float64{"horror": 4, "comedy": 0.5}})
+	assert.NoError(t, err)
+	weightedCosine, err := utils.NewDistanceFunc(data.DistanceOptions{Metric: constants.COSINE, Weights: map[string]float64{"drama": 3}})
+	assert.NoError(t, err)
+	distances := map[string]utils.DistanceFunc{
+		"euclidean":       utils.MetricDistance,
+		"manhattan":       utils.ManhattanDistance,
+		"weighted":        weighted,
+		"cosine":          utils.CosineDistance,
+		"weighted cosine": weightedCosine,
+	}
+
+	for _, query := range randomMovies(20, 2) {
+		for name, distance := range distances {
+			assert.Equal(t, bruteForce(movies, query.Metrics, 10, distance, nil), idx.KNearest(query.Metrics, 10, distance, nil), name)
+		}
+	}
+}
+
+func TestMovieIndex_CosineSearchesEveryMovie(t *testing.T) {
+	// Movies rated on two dimensions only leave most planes close to the query by angle
+	// while the best match lies beyond them.
+	rng := rand.New(rand.NewSource(7))
+	flat := func(n int) []data.Movie {
+		movies := make([]data.Movie, n)
+		for i := range movies {
+			movies[i] = data.Movie{ID: fmt.Sprintf("m%02d", i), Metrics: data.MovieMetrics{Action: float64(rng.Intn(100)), Drama: float64(rng.Intn(100))}}
+		}
+		return movies
+	}
+	movies := flat(40)
+	idx := NewMovieIndex(movies)
+
+	for _, query := range flat(20) {
+		assert.Equal(t, bruteForce(movies, query.Metrics, 3, utils.CosineDistance, nil), idx.KNearest(query.Metrics, 3, utils.CosineDistance, nil))
+	}
+}
+
+func TestMovieIndex_KNearestFilters(t *testing.T) {
+	movies := randomMovies(100, 3)
+	idx := NewMovieIndex(movies)
+	evenCentroids := func(movieID string) bool { return idx.tree.centroids[movieID]%2 == 0 }
+	query := movies[0].Metrics
+
+	neighbors := idx.KNearest(query, 5, nil, evenCentroids)
+	assert.Equal(t, bruteForce(movies, query, 5, utils.MetricDistance, evenCentroids), neighbors)
+	for _, n := range neighbors {
+		assert.Zero(t, n.Centroid%2)
+	}
+
+	self := idx.KNearest(query, 1, nil, nil)
+	assert.Equal(t, movies[0].ID, self[0].MovieID)
+	assert.Zero(t, self[0].Distance)
+	assert.Empty(t, idx.KNearest(query, 3, nil, func(string) bool { return false }))
+}
+
+func TestMovieIndex_SmallAndEmpty(t *testing.T) {
+	empty := NewMovieIndex(nil)
+	assert.Zero(t, empty.Size())
+	assert.Empty(t, empty.KNearest(data.MovieMetrics{Drama: 5}, 3, nil, nil))
+	_, ok := empty.GetMetrics("alien")
+	assert.False(t, ok)
+
+	idx := NewMovieIndex([]data.Movie{
+		{ID: "alien", Centroid: 2, Metrics: data.MovieMetrics{Horror: 90}},
+		{ID: "aliens", Centroid: 2, Metrics: data.MovieMetrics{Horror: 70, Action: 90}},
+	})
+	assert.Equal(t, 2, idx.Size())
+	metrics, ok := idx.GetMetrics("aliens")
+	assert.True(t, ok)
+	assert.Equal(t, 90.0, metrics.Action)
+	neighbors := idx.KNearest(data.MovieMetrics{Horror: 85}, 5, nil, nil)
+	assert.Equal(t, []string{"alien", "aliens"}, []string{neighbors[0].MovieID, neighbors[1].MovieID})
+	assert.Nil(t, idx.KNearest(data.MovieMetrics{}, 0, nil, nil))
+}
+
+func TestMovieIndex_ReloadKeepsIndexOnFailure(t *testing.T) {
+	var loadErr error
+	movies := []data.Movie{{ID: "alien", Centroid: 2, Metrics: data.MovieMetrics{Horror: 90}}}
+	idx := NewReloadableMovieIndex(func(ctx context.Context) ([]data.Movie, error) {
+		return movies, loadErr
+	})
+	assert.Zero(t, idx.Size(), "nothing is indexed before the first load")
+
+	assert.NoError(t, idx.Reload(context.Background()))
+	status := idx.Status()
+	assert.Equal(t, constants.MOVIE_INDEX_CACHE, status.Name)
+	assert.Equal(t, 1, status.Size)
+	assert.False(t, status.LastLoaded.IsZero())
+
+	loadErr = errors.New("page B failed")
+	movies = nil
+	assert.Error(t, idx.Reload(context.Background()))
+	assert.Equal(t, 1, idx.Size())
+	assert.Equal(t, "page B failed", idx.Status().LastError)
+
+	loadErr = nil
+	movies = []data.Movie{{ID: "alien", Centroid: 5, Metrics: data.MovieMetrics{Horror: 95}}}
+	assert.NoError(t, idx.Reload(context.Background()))
+	assert.Equal(t, 5, idx.KNearest(data.MovieMetrics{Horror: 90}, 1, nil, nil)[0].Centroid)
+	assert.Empty(t, idx.Status().LastError)
+}
+
+func TestMovieIndex_Set(t *testing.T) {
+	idx := NewMovieIndex([]data.Movie{
+		{ID: "alien", Centroid: 2, Metrics: data.MovieMetrics{Horror: 90}},
+		{ID: "airplane", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 95}},
+	})
+
+	idx.Set("alien", data.MovieMetrics{Comedy: 80}, 0)
+	metrics, _ := idx.GetMetrics("alien")
+	assert.Equal(t, data.MovieMetrics{Comedy: 80}, metrics)
+	nearest := idx.KNearest(data.MovieMetrics{Comedy: 80}, 1, nil, nil)
+	assert.Equal(t, []data.Neighbor{{MovieID: "alien", Centroid: 0}}, nearest)
+
+	idx.Set("the_fly", data.MovieMetrics{Horror: 85}, 2)
+	assert.Equal(t, 3, idx.Size())
+	idx.Set("airplane", data.MovieMetrics{}, 0)
+	_, ok := idx.GetMetrics("airplane")
+	assert.False(t, ok)
+	assert.Equal(t, 2, idx.Size())
+}
+
+func TestMovieIndex_SetDuringReloadIsReplayed(t *testing.T) {
+	var idx *MovieIndex
+	idx = NewReloadableMovieIndex(func(ctx context.Context) ([]data.Movie, error) {
+		// the table was read before the_fly's metrics were written
+		idx.Set("the_fly", data.MovieMetrics{Horror: 85}, 2)
+		return []data.Movie{{ID: "alien", Centroid: 2, Metrics: data.MovieMetrics{Horror: 90}}}, nil
+	})
+
+	assert.NoError(t, idx.Reload(context.Background()))
+	metrics, ok := idx.GetMetrics("the_fly")
+	assert.True(t, ok)
+	assert.Equal(t, 85.0, metrics.Horror)
+	assert.Equal(t, 2, idx.Size())
+	assert.Equal(t, "the_fly", idx.KNearest(data.MovieMetrics{Horror: 85}, 1, nil, nil)[0].MovieID)
+}
+
+func TestMovieIndex_PendingWritesAreCompacted(t *testing.T) {
+	movies := randomMovies(300, 4)
+	idx := NewMovieIndex(movies)
+	updates := randomMovies(constants.MOVIE_INDEX_PENDING_WRITES+50, 5)
+	current := make(map[string]data.Movie, len(movies))
+	for _, movie := range movies {
+		current[movie.ID] = movie
+	}
+
+	for i, update := range updates {
+		if i%10 == 0 {
+			update.Metrics = data.MovieMetrics{}
+			delete(current, update.ID)
+		} else {
+			current[update.ID] = update
+		}
+		idx.Set(update.ID, update.Metrics, update.Centroid)
+	}
+	assert.Eventually(t, func() bool {
+		_, pending := idx.current()
+		return len(pending) < constants.MOVIE_INDEX_PENDING_WRITES
+	}, time.Second, time.Millisecond, "pending writes are folded into a new tree")
+
+	expected := make([]data.Movie, 0, len(current))
+	for _, movie := range current {
+		expected = append(expected, movie)
+	}
+	assert.Equal(t, len(expected), idx.Size())
+	for _, query := range randomMovies(10, 6) {
+		assert.Equal(t, bruteForce(expected, query.Metrics, 10, utils.MetricDistance, nil), idx.KNearest(query.Metrics, 10, nil, nil))
+	}
+}
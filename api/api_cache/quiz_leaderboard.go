@@ -0,0 +1,149 @@
+package api_cache
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/utils"
+)
+
+// QuizLeaderboardCache keeps every quiz player's totals ranked in memory, so the leaderboard
+// and a member's rank are read without scanning the members table. Reload replaces the totals
+// from the table and Record updates one member's as their score is submitted.
+type QuizLeaderboardCache struct {
+	mu       sync.RWMutex
+	byUser   map[string]data.QuizStats
+	ranked   []data.QuizStats
+	rankOf   map[string]int
+	loadedAt time.Time
+	lastErr  error
+	load     func(ctx context.Context) ([]data.QuizStats, error)
+}
+
+func NewQuizLeaderboardCache(load func(ctx context.Context) ([]data.QuizStats, error)) *QuizLeaderboardCache {
+	return &QuizLeaderboardCache{load: load}
+}
+
+// Reload fetches every player's totals again and re-ranks them. Totals recorded since the
+// load started are kept when they have more quizzes played than the loaded ones. On failure
+// the previous leaderboard is kept and the error is reported by Status.
+func (l *QuizLeaderboardCache) Reload(ctx context.Context) error {
+	scores, err := l.load(ctx)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		l.lastErr = err
+		return utils.LogError("failed to reload quiz leaderboard", err)
+	}
+	byUser := make(map[string]data.QuizStats, len(scores))
+	for _, stats := range scores {
+		byUser[stats.Username] = stats
+	}
+	for username, recorded := range l.byUser {
+		if recorded.Played > byUser[username].Played {
+			byUser[username] = recorded
+		}
+	}
+	l.byUser, l.loadedAt, l.lastErr = byUser, time.Now(), nil
+	l.rank()
+	return nil
+}
+
+func (l *QuizLeaderboardCache) Status() data.CacheStatus {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return data.CacheStatus{
+		Name:       constants.QUIZ_LEADERBOARD_CACHE,
+		Size:       len(l.ranked),
+		LastLoaded: l.loadedAt,
+		LastError:  errorString(l.lastErr),
+	}
+}
+
+// Top returns the top limit players, loading the leaderboard first if it never loaded.
+func (l *QuizLeaderboardCache) Top(ctx context.Context, limit int) ([]data.QuizStats, error) {
+	if err := l.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	top := make([]data.QuizStats, min(limit, len(l.ranked)))
+	copy(top, l.ranked)
+	return top, nil
+}
+
+// Stats returns a member's ranked totals, or zero totals and no rank if they never played.
+func (l *QuizLeaderboardCache) Stats(ctx context.Context, username string) (data.QuizStats, error) {
+	if err := l.ensureLoaded(ctx); err != nil {
+		return data.QuizStats{}, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if i, ok := l.rankOf[username]; ok {
+		return l.ranked[i], nil
+	}
+	return data.QuizStats{Username: username}, nil
+}
+
+// Record takes a member's new totals, as returned when their score was recorded, and returns
+// them ranked. Totals older than those already held are ignored. Before the first load the
+// totals are returned unranked; the load will include them.
+func (l *QuizLeaderboardCache) Record(stats data.QuizStats) data.QuizStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.byUser == nil {
+		return stats
+	}
+	if stats.Played > l.byUser[stats.Username].Played {
+		l.byUser[stats.Username] = stats
+		l.rank()
+	}
+	if i, ok := l.rankOf[stats.Username]; ok {
+		return l.ranked[i]
+	}
+	return stats
+}
+
+func (l *QuizLeaderboardCache) ensureLoaded(ctx context.Context) error {
+	l.mu.RLock()
+	loaded := l.byUser != nil
+	l.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+	return l.Reload(ctx)
+}
+
+// rank orders the players by points, then by the share of questions answered right, then by
+// username. Players on the same points share a rank. Callers hold the write lock.
+func (l *QuizLeaderboardCache) rank() {
+	ranked := make([]data.QuizStats, 0, len(l.byUser))
+	for _, stats := range l.byUser {
+		ranked = append(ranked, stats)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		// compare a.Points/a.Asked with b.Points/b.Asked without dividing by zero
+		if left, right := a.Points*b.Asked, b.Points*a.Asked; left != right {
+			return left > right
+		}
+		return a.Username < b.Username
+	})
+	rankOf := make(map[string]int, len(ranked))
+	for i := range ranked {
+		if i > 0 && ranked[i].Points == ranked[i-1].Points {
+			ranked[i].Rank = ranked[i-1].Rank
+		} else {
+			ranked[i].Rank = i + 1
+		}
+		rankOf[ranked[i].Username] = i
+	}
+	l.ranked, l.rankOf = ranked, rankOf
+}
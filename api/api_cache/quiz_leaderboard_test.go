@@ -0,0 +1,72 @@
+package api_cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+)
+
+func TestQuizLeaderboard_RanksAndRecords(t *testing.T) {
+	loads := 0
+	leaderboard := NewQuizLeaderboardCache(func(ctx context.Context) ([]data.QuizStats, error) {
+		loads++
+		return []data.QuizStats{
+			{Username: "alice", Points: 12, Asked: 15, Played: 3},
+			{Username: "bob", Points: 9, Asked: 20, Played: 4},
+			{Username: "carol", Points: 9, Asked: 10, Played: 2},
+		}, nil
+	})
+
+	top, err := leaderboard.Top(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "carol"}, []string{top[0].Username, top[1].Username})
+	assert.Equal(t, 2, top[1].Rank)
+
+	recorded := leaderboard.Record(data.QuizStats{Username: "bob", Points: 14, Asked: 22, Played: 5})
+	assert.Equal(t, 1, recorded.Rank)
+	stale := leaderboard.Record(data.QuizStats{Username: "bob", Points: 10, Asked: 21, Played: 4})
+	assert.Equal(t, recorded, stale, "older totals arriving late are ignored")
+	newcomer := leaderboard.Record(data.QuizStats{Username: "dave", Points: 1, Asked: 5, Played: 1})
+	assert.Equal(t, 4, newcomer.Rank)
+
+	stats, err := leaderboard.Stats(context.Background(), "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stats.Rank)
+	stats, err = leaderboard.Stats(context.Background(), "erin")
+	assert.NoError(t, err)
+	assert.Equal(t, data.QuizStats{Username: "erin"}, stats)
+	assert.Equal(t, 1, loads, "reads after the first load are served from memory")
+
+	top[0].Points = 0
+	again, _ := leaderboard.Top(context.Background(), 1)
+	assert.Equal(t, 14, again[0].Points, "returned slices are copies")
+}
+
+func TestQuizLeaderboard_ReloadKeepsNewerRecords(t *testing.T) {
+	loaded := []data.QuizStats{{Username: "alice", Points: 12, Asked: 15, Played: 3}}
+	var loadErr error
+	leaderboard := NewQuizLeaderboardCache(func(ctx context.Context) ([]data.QuizStats, error) {
+		return loaded, loadErr
+	})
+	assert.Equal(t, data.QuizStats{Username: "alice", Played: 1}, leaderboard.Record(data.QuizStats{Username: "alice", Played: 1}),
+		"totals recorded before the first load are returned unranked")
+
+	assert.NoError(t, leaderboard.Reload(context.Background()))
+	leaderboard.Record(data.QuizStats{Username: "alice", Points: 15, Asked: 20, Played: 4})
+	// a reload that read alice's item before her latest score was recorded
+	assert.NoError(t, leaderboard.Reload(context.Background()))
+	stats, _ := leaderboard.Stats(context.Background(), "alice")
+	assert.Equal(t, 4, stats.Played)
+
+	loadErr = errors.New("scan failed")
+	assert.Error(t, leaderboard.Reload(context.Background()))
+	status := leaderboard.Status()
+	assert.Equal(t, constants.QUIZ_LEADERBOARD_CACHE, status.Name)
+	assert.Equal(t, 1, status.Size)
+	assert.Equal(t, "scan failed", status.LastError)
+}
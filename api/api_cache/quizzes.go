@@ -0,0 +1,97 @@
+package api_cache
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"blockbuster/api/data"
+)
+
+var ErrQuizNotFound = errors.New("quiz not found, expired or already submitted")
+
+// QuizCache holds trivia quizzes waiting for their answers. Like VotingSessionCache, quizzes
+// expire ttl after they were created. A quiz is graded once, so submitting takes it out of
+// the cache.
+type QuizCache struct {
+	mu      sync.Mutex
+	quizzes map[string]data.Quiz
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+func NewQuizCache(ttl time.Duration, now func() time.Time) *QuizCache {
+	return &QuizCache{
+		quizzes: make(map[string]data.Quiz),
+		ttl:     ttl,
+		now:     now,
+	}
+}
+
+// Create stores quiz under a new random ID and returns it with its ID and expiry set.
+func (c *QuizCache) Create(quiz data.Quiz) (data.Quiz, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return data.Quiz{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purgeExpired()
+	quiz.ID = id
+	quiz.ExpiresAt = c.now().Add(c.ttl)
+	c.quizzes[id] = cloneQuiz(quiz)
+	return quiz, nil
+}
+
+func (c *QuizCache) Get(quizID string) (data.Quiz, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	quiz, ok := c.quizzes[quizID]
+	if !ok {
+		return data.Quiz{}, ErrQuizNotFound
+	}
+	if !c.now().Before(quiz.ExpiresAt) {
+		delete(c.quizzes, quizID)
+		return data.Quiz{}, ErrQuizNotFound
+	}
+	return cloneQuiz(quiz), nil
+}
+
+// Take removes an unexpired quiz and returns it, so only one submission can grade it.
+func (c *QuizCache) Take(quizID string) (data.Quiz, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	quiz, ok := c.quizzes[quizID]
+	delete(c.quizzes, quizID)
+	if !ok || !c.now().Before(quiz.ExpiresAt) {
+		return data.Quiz{}, ErrQuizNotFound
+	}
+	return quiz, nil
+}
+
+func (c *QuizCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.quizzes)
+}
+
+func (c *QuizCache) purgeExpired() {
+	now := c.now()
+	for id, quiz := range c.quizzes {
+		if !now.Before(quiz.ExpiresAt) {
+			delete(c.quizzes, id)
+		}
+	}
+}
+
+// cloneQuiz copies the slices of a quiz so callers can't change the cached one.
+func cloneQuiz(quiz data.Quiz) data.Quiz {
+	quiz.Answers = slices.Clone(quiz.Answers)
+	quiz.Questions = slices.Clone(quiz.Questions)
+	for i := range quiz.Questions {
+		quiz.Questions[i].Choices = slices.Clone(quiz.Questions[i].Choices)
+	}
+	return quiz
+}
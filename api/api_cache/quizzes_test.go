@@ -0,0 +1,62 @@
+package api_cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/data"
+)
+
+func TestQuizCache_CreateGetAndTake(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewQuizCache(time.Minute, func() time.Time { return now })
+
+	quiz, err := cache.Create(data.Quiz{Username: "alice", Answers: []int{2}})
+	assert.NoError(t, err)
+	assert.Len(t, quiz.ID, 32)
+	assert.Equal(t, now.Add(time.Minute), quiz.ExpiresAt)
+
+	got, err := cache.Get(quiz.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, got.Answers)
+
+	taken, err := cache.Take(quiz.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", taken.Username)
+	_, err = cache.Take(quiz.ID)
+	assert.ErrorIs(t, err, ErrQuizNotFound, "a quiz is only graded once")
+	assert.Equal(t, 0, cache.Size())
+}
+
+func TestQuizCache_Expiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewQuizCache(time.Minute, func() time.Time { return now })
+	quiz, _ := cache.Create(data.Quiz{})
+	expired, _ := cache.Create(data.Quiz{})
+
+	now = now.Add(time.Minute)
+	_, err := cache.Get(quiz.ID)
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+	_, err = cache.Take(expired.ID)
+	assert.ErrorIs(t, err, ErrQuizNotFound)
+	assert.Equal(t, 0, cache.Size())
+}
+
+func TestQuizCache_CopiesQuizzes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewQuizCache(time.Minute, func() time.Time { return now })
+	quiz, _ := cache.Create(data.Quiz{
+		Questions: []data.QuizQuestion{{Choices: []string{"a", "b"}}},
+		Answers:   []int{1},
+	})
+
+	got, _ := cache.Get(quiz.ID)
+	got.Answers[0] = 0
+	got.Questions[0].Choices[0] = "changed"
+
+	again, _ := cache.Get(quiz.ID)
+	assert.Equal(t, []int{1}, again.Answers)
+	assert.Equal(t, []string{"a", "b"}, again.Questions[0].Choices)
+}
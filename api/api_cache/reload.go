@@ -0,0 +1,53 @@
+package api_cache
+
+import (
+	"context"
+	"time"
+
+	"blockbuster/api/utils"
+)
+
+// loadWithRetry calls load until it succeeds or attempts run out, doubling the wait after
+// each failure. It gives up early if ctx is cancelled and returns the last error.
+func loadWithRetry(ctx context.Context, attempts int, backoff time.Duration, load func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = load(ctx); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return utils.LogError("giving up loading cache", err)
+}
+
+// startReloader calls reload every interval until ctx is cancelled. Failures are recorded
+// by the cache itself, which keeps serving its previous contents.
+func startReloader(ctx context.Context, interval time.Duration, reload func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				reload(ctx)
+			}
+		}
+	}()
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,132 @@
+package api_cache
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/data"
+)
+
+func TestLoadWithRetry_SucceedsAfterFailures(t *testing.T) {
+	calls := 0
+	err := loadWithRetry(context.Background(), 3, time.Millisecond, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("table not ready")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestLoadWithRetry_GivesUp(t *testing.T) {
+	calls := 0
+	err := loadWithRetry(context.Background(), 2, time.Millisecond, func(ctx context.Context) error {
+		calls++
+		return errors.New("table not ready")
+	})
+	assert.ErrorContains(t, err, "table not ready")
+	assert.Equal(t, 2, calls)
+}
+
+func TestLoadWithRetry_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := loadWithRetry(ctx, 5, time.Hour, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errors.New("table not ready")
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCentroidCache_ReloadKeepsCentroidsOnFailure(t *testing.T) {
+	var loadErr error
+	cache := NewCentroidCache(func(ctx context.Context) (map[int]data.MovieMetrics, error) {
+		return map[int]data.MovieMetrics{1: {ID: 1, Horror: 90}}, loadErr
+	})
+	_, err := cache.GetMetricsByCentroid(1)
+	assert.Error(t, err, "nothing is served before the first load")
+
+	assert.NoError(t, cache.Reload(context.Background()))
+	status := cache.Status()
+	assert.Equal(t, "centroids", status.Name)
+	assert.Equal(t, 1, status.Size)
+	assert.False(t, status.LastLoaded.IsZero())
+	assert.Empty(t, status.LastError)
+
+	loadErr = errors.New("scan failed")
+	assert.Error(t, cache.Reload(context.Background()))
+	metrics, err := cache.GetMetricsByCentroid(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 90.0, metrics.Horror)
+	assert.Equal(t, "scan failed", cache.Status().LastError)
+	assert.Equal(t, status.LastLoaded, cache.Status().LastLoaded)
+
+	loadErr = nil
+	assert.NoError(t, cache.Reload(context.Background()))
+	assert.Empty(t, cache.Status().LastError)
+}
+
+func TestCentroidsToMoviesCache_ReloadRebuildsReverseIndex(t *testing.T) {
+	movies := []data.Movie{
+		{ID: "alien", Centroid: 1, Metrics: data.MovieMetrics{Horror: 90}},
+		{ID: "aliens", Centroid: 1, Metrics: data.MovieMetrics{Action: 90}},
+	}
+	cache := NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		return movies, nil
+	}, nil, nil)
+	assert.NoError(t, cache.Reload(context.Background()))
+	centroid, err := cache.GetCentroidByMovieID("alien")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, centroid)
+
+	movies = []data.Movie{
+		{ID: "alien", Centroid: 2, Metrics: data.MovieMetrics{Horror: 90}},
+		{ID: "the_thing", Centroid: 3, Metrics: data.MovieMetrics{Horror: 95}},
+	}
+	assert.NoError(t, cache.Reload(context.Background()))
+	centroid, err = cache.GetCentroidByMovieID("alien")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, centroid)
+	_, err = cache.GetCentroidByMovieID("aliens")
+	assert.Error(t, err)
+	assert.Equal(t, 2, cache.Status().Size)
+}
+
+func TestCentroidsToMoviesCache_ConcurrentReadsDuringReload(t *testing.T) {
+	cache := NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		return []data.Movie{{ID: "alien", Centroid: 1, Metrics: data.MovieMetrics{Horror: 90}}}, nil
+	}, nil, nil)
+	assert.NoError(t, cache.Reload(context.Background()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			cache.Reload(context.Background())
+		}()
+		go func() {
+			defer wg.Done()
+			cache.Assign("the_thing", 2)
+		}()
+		go func() {
+			defer wg.Done()
+			centroid, err := cache.GetCentroidByMovieID("alien")
+			assert.NoError(t, err)
+			assert.Equal(t, 1, centroid)
+			_, err = cache.GetRandomMovieFromCentroid(1, rand.New(rand.NewSource(1)))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,170 @@
+package api_cache
+
+import (
+	"crypto/rand"
+	"errors"
+	"maps"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+)
+
+var ErrVotingRoomNotFound = errors.New("voting room not found or expired")
+
+// roomCodeAlphabet leaves out letters and digits easily mistaken for one another when a
+// code is read out across the room.
+const roomCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// VotingRoomCache holds group voting rooms in memory, keyed by their join code. Like
+// VotingSessionCache, rooms expire ttl after they were last updated. Members vote
+// concurrently, so rooms are only changed through Update, which applies each change to a
+// copy under the lock.
+type VotingRoomCache struct {
+	mu    sync.Mutex
+	rooms map[string]data.VotingRoom
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+func NewVotingRoomCache(ttl time.Duration, now func() time.Time) *VotingRoomCache {
+	return &VotingRoomCache{
+		rooms: make(map[string]data.VotingRoom),
+		ttl:   ttl,
+		now:   now,
+	}
+}
+
+// Create stores room under a new join code and returns it with its code and expiry set.
+func (c *VotingRoomCache) Create(room data.VotingRoom) (data.VotingRoom, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purgeExpired()
+	for {
+		code, err := newRoomCode()
+		if err != nil {
+			return data.VotingRoom{}, err
+		}
+		if _, taken := c.rooms[code]; taken {
+			continue
+		}
+		room.Code = code
+		room.ExpiresAt = c.now().Add(c.ttl)
+		c.rooms[code] = cloneRoom(room)
+		return room, nil
+	}
+}
+
+func (c *VotingRoomCache) Get(code string) (data.VotingRoom, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	room, err := c.get(code)
+	if err != nil {
+		return data.VotingRoom{}, err
+	}
+	return cloneRoom(room), nil
+}
+
+// Update applies update to a copy of the room and stores the copy, extending the room's
+// expiry, unless update returns an error. update runs under the cache's lock and must not
+// block.
+func (c *VotingRoomCache) Update(code string, update func(room *data.VotingRoom) error) (data.VotingRoom, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	room, err := c.get(code)
+	if err != nil {
+		return data.VotingRoom{}, err
+	}
+	room = cloneRoom(room)
+	if err := update(&room); err != nil {
+		return data.VotingRoom{}, err
+	}
+	room.ExpiresAt = c.now().Add(c.ttl)
+	c.rooms[room.Code] = room
+	return cloneRoom(room), nil
+}
+
+// Take removes an unexpired room and returns it, so only one caller can finish it.
+func (c *VotingRoomCache) Take(code string) (data.VotingRoom, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	room, err := c.get(code)
+	if err != nil {
+		return data.VotingRoom{}, err
+	}
+	delete(c.rooms, room.Code)
+	return room, nil
+}
+
+// Restore puts back a room removed by Take, e.g. when finishing it failed, extending its
+// expiry.
+func (c *VotingRoomCache) Restore(room data.VotingRoom) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	room.ExpiresAt = c.now().Add(c.ttl)
+	c.rooms[room.Code] = cloneRoom(room)
+}
+
+func (c *VotingRoomCache) Delete(code string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.rooms, strings.ToUpper(code))
+}
+
+func (c *VotingRoomCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.rooms)
+}
+
+// get returns the stored room for code, which is matched case-insensitively.
+func (c *VotingRoomCache) get(code string) (data.VotingRoom, error) {
+	code = strings.ToUpper(code)
+	room, ok := c.rooms[code]
+	if !ok {
+		return data.VotingRoom{}, ErrVotingRoomNotFound
+	}
+	if !c.now().Before(room.ExpiresAt) {
+		delete(c.rooms, code)
+		return data.VotingRoom{}, ErrVotingRoomNotFound
+	}
+	return room, nil
+}
+
+func (c *VotingRoomCache) purgeExpired() {
+	now := c.now()
+	for code, room := range c.rooms {
+		if !now.Before(room.ExpiresAt) {
+			delete(c.rooms, code)
+		}
+	}
+}
+
+// cloneRoom copies the room's members and slates so rooms handed out never share state
+// with the stored one.
+func cloneRoom(room data.VotingRoom) data.VotingRoom {
+	room.Members = maps.Clone(room.Members)
+	for username, member := range room.Members {
+		member.Selected = slices.Clone(member.Selected)
+		room.Members[username] = member
+	}
+	room.Slate = slices.Clone(room.Slate)
+	room.Shown = slices.Clone(room.Shown)
+	return room
+}
+
+func newRoomCode() (string, error) {
+	code := make([]byte, constants.ROOM_CODE_LENGTH)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(roomCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = roomCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
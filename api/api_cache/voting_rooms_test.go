@@ -0,0 +1,98 @@
+package api_cache
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/data"
+)
+
+func TestVotingRoomCache_CreateAndGet(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewVotingRoomCache(time.Minute, func() time.Time { return now })
+
+	room, err := cache.Create(data.VotingRoom{Host: "john", Members: map[string]data.RoomMember{"john": {}}})
+	assert.NoError(t, err)
+	assert.Len(t, room.Code, 6)
+	assert.Equal(t, strings.ToUpper(room.Code), room.Code)
+	assert.Equal(t, now.Add(time.Minute), room.ExpiresAt)
+
+	got, err := cache.Get(strings.ToLower(room.Code))
+	assert.NoError(t, err, "codes are matched case-insensitively")
+	assert.Equal(t, "john", got.Host)
+}
+
+func TestVotingRoomCache_Update(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewVotingRoomCache(time.Minute, func() time.Time { return now })
+	room, _ := cache.Create(data.VotingRoom{Members: map[string]data.RoomMember{"john": {}}, Slate: []string{"m1"}})
+
+	now = now.Add(30 * time.Second)
+	updated, err := cache.Update(room.Code, func(room *data.VotingRoom) error {
+		room.Members["jane"] = data.RoomMember{Selected: []string{"m1"}}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, updated.Members, 2)
+	assert.Equal(t, now.Add(time.Minute), updated.ExpiresAt)
+	assert.Len(t, room.Members, 1, "rooms handed out never change underneath the caller")
+
+	_, err = cache.Update(room.Code, func(room *data.VotingRoom) error {
+		delete(room.Members, "john")
+		return errors.New("rejected")
+	})
+	assert.EqualError(t, err, "rejected")
+	got, _ := cache.Get(room.Code)
+	assert.Len(t, got.Members, 2, "a failed update is discarded")
+
+	got.Members["jane"].Selected[0] = "m2"
+	got, _ = cache.Get(room.Code)
+	assert.Equal(t, []string{"m1"}, got.Members["jane"].Selected)
+}
+
+func TestVotingRoomCache_Expiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewVotingRoomCache(time.Minute, func() time.Time { return now })
+	room, _ := cache.Create(data.VotingRoom{})
+	_, _ = cache.Create(data.VotingRoom{})
+
+	now = now.Add(time.Minute)
+	_, err := cache.Get(room.Code)
+	assert.ErrorIs(t, err, ErrVotingRoomNotFound)
+	_, err = cache.Update(room.Code, func(*data.VotingRoom) error { return nil })
+	assert.ErrorIs(t, err, ErrVotingRoomNotFound)
+
+	_, _ = cache.Create(data.VotingRoom{})
+	assert.Equal(t, 1, cache.Size(), "create purges expired rooms")
+}
+
+func TestVotingRoomCache_TakeAndRestore(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewVotingRoomCache(time.Minute, func() time.Time { return now })
+	room, _ := cache.Create(data.VotingRoom{Host: "john", Members: map[string]data.RoomMember{"john": {}}})
+
+	taken, err := cache.Take(strings.ToLower(room.Code))
+	assert.NoError(t, err)
+	assert.Equal(t, "john", taken.Host)
+	_, err = cache.Take(room.Code)
+	assert.ErrorIs(t, err, ErrVotingRoomNotFound, "a room can only be taken once")
+
+	now = now.Add(50 * time.Second)
+	cache.Restore(taken)
+	now = now.Add(50 * time.Second)
+	restored, err := cache.Get(room.Code)
+	assert.NoError(t, err, "restoring extends the expiry")
+	assert.Equal(t, "john", restored.Host)
+}
+
+func TestVotingRoomCache_Delete(t *testing.T) {
+	cache := NewVotingRoomCache(time.Minute, time.Now)
+	room, _ := cache.Create(data.VotingRoom{})
+	cache.Delete(strings.ToLower(room.Code))
+	_, err := cache.Get(room.Code)
+	assert.ErrorIs(t, err, ErrVotingRoomNotFound)
+}
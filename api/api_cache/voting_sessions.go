@@ -0,0 +1,148 @@
+package api_cache
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"blockbuster/api/data"
+)
+
+var ErrVotingSessionNotFound = errors.New("voting session not found or expired")
+
+// VotingSessionCache holds in-progress mood voting sessions in memory. Sessions expire
+// ttl after they were last updated; expired sessions are dropped lazily as they are read
+// and whenever a new session is created.
+type VotingSessionCache struct {
+	mu       sync.Mutex
+	sessions map[string]data.VotingSession
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+func NewVotingSessionCache(ttl time.Duration, now func() time.Time) *VotingSessionCache {
+	return &VotingSessionCache{
+		sessions: make(map[string]data.VotingSession),
+		ttl:      ttl,
+		now:      now,
+	}
+}
+
+// Create stores session under a new random ID and returns it with its ID and expiry set.
+func (c *VotingSessionCache) Create(session data.VotingSession) (data.VotingSession, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return data.VotingSession{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purgeExpired()
+	session.ID = id
+	session.ExpiresAt = c.now().Add(c.ttl)
+	c.sessions[id] = cloneSession(session)
+	return session, nil
+}
+
+func (c *VotingSessionCache) Get(sessionID string) (data.VotingSession, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	session, err := c.get(sessionID)
+	if err != nil {
+		return data.VotingSession{}, err
+	}
+	return cloneSession(session), nil
+}
+
+// Update applies update to a copy of the session and stores the copy, extending the
+// session's expiry, unless update returns an error. update runs under the cache's lock and
+// must not block.
+func (c *VotingSessionCache) Update(sessionID string, update func(session *data.VotingSession) error) (data.VotingSession, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	session, err := c.get(sessionID)
+	if err != nil {
+		return data.VotingSession{}, err
+	}
+	session = cloneSession(session)
+	if err := update(&session); err != nil {
+		return data.VotingSession{}, err
+	}
+	session.ExpiresAt = c.now().Add(c.ttl)
+	c.sessions[session.ID] = session
+	return cloneSession(session), nil
+}
+
+// Take removes an unexpired session and returns it, so only one caller can finish it.
+func (c *VotingSessionCache) Take(sessionID string) (data.VotingSession, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	session, err := c.get(sessionID)
+	if err != nil {
+		return data.VotingSession{}, err
+	}
+	delete(c.sessions, sessionID)
+	return session, nil
+}
+
+// Restore puts back a session removed by Take, e.g. when finishing it failed, extending its
+// expiry.
+func (c *VotingSessionCache) Restore(session data.VotingSession) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	session.ExpiresAt = c.now().Add(c.ttl)
+	c.sessions[session.ID] = cloneSession(session)
+}
+
+func (c *VotingSessionCache) Delete(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sessions, sessionID)
+}
+
+func (c *VotingSessionCache) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.sessions)
+}
+
+func (c *VotingSessionCache) get(sessionID string) (data.VotingSession, error) {
+	session, ok := c.sessions[sessionID]
+	if !ok {
+		return data.VotingSession{}, ErrVotingSessionNotFound
+	}
+	if !c.now().Before(session.ExpiresAt) {
+		delete(c.sessions, sessionID)
+		return data.VotingSession{}, ErrVotingSessionNotFound
+	}
+	return session, nil
+}
+
+func (c *VotingSessionCache) purgeExpired() {
+	now := c.now()
+	for id, session := range c.sessions {
+		if !now.Before(session.ExpiresAt) {
+			delete(c.sessions, id)
+		}
+	}
+}
+
+// cloneSession copies the session's slates so sessions handed out never share state with
+// the stored one.
+func cloneSession(session data.VotingSession) data.VotingSession {
+	session.Slate = slices.Clone(session.Slate)
+	session.Shown = slices.Clone(session.Shown)
+	session.Selected = slices.Clone(session.Selected)
+	return session
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,139 @@
+package api_cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/data"
+)
+
+func TestVotingSessionCache_CreateAndGet(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewVotingSessionCache(time.Minute, func() time.Time { return now })
+
+	session, err := cache.Create(data.VotingSession{Slate: []string{"m1"}})
+	assert.NoError(t, err)
+	assert.Len(t, session.ID, 32)
+	assert.Equal(t, now.Add(time.Minute), session.ExpiresAt)
+
+	got, err := cache.Get(session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m1"}, got.Slate)
+}
+
+func TestVotingSessionCache_Expiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewVotingSessionCache(time.Minute, func() time.Time { return now })
+	session, _ := cache.Create(data.VotingSession{})
+
+	now = now.Add(30 * time.Second)
+	session, err := cache.Update(session.ID, func(session *data.VotingSession) error { return nil })
+	assert.NoError(t, err)
+
+	now = now.Add(45 * time.Second)
+	_, err = cache.Get(session.ID)
+	assert.NoError(t, err, "update should extend the expiry")
+
+	now = now.Add(time.Minute)
+	_, err = cache.Get(session.ID)
+	assert.ErrorIs(t, err, ErrVotingSessionNotFound)
+	_, err = cache.Update(session.ID, func(session *data.VotingSession) error { return nil })
+	assert.ErrorIs(t, err, ErrVotingSessionNotFound)
+	assert.Equal(t, 0, cache.Size())
+}
+
+func TestVotingSessionCache_CreatePurgesExpired(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := NewVotingSessionCache(time.Minute, func() time.Time { return now })
+	_, _ = cache.Create(data.VotingSession{})
+	_, _ = cache.Create(data.VotingSession{})
+
+	now = now.Add(2 * time.Minute)
+	_, _ = cache.Create(data.VotingSession{})
+	assert.Equal(t, 1, cache.Size())
+}
+
+func TestVotingSessionCache_Delete(t *testing.T) {
+	cache := NewVotingSessionCache(time.Minute, time.Now)
+	session, _ := cache.Create(data.VotingSession{})
+
+	cache.Delete(session.ID)
+	_, err := cache.Get(session.ID)
+	assert.ErrorIs(t, err, ErrVotingSessionNotFound)
+}
+
+func TestVotingSessionCache_TakeAndRestore(t *testing.T) {
+	now := time.Now()
+	cache := NewVotingSessionCache(time.Minute, func() time.Time { return now })
+	session, _ := cache.Create(data.VotingSession{Username: "john"})
+
+	taken, err := cache.Take(session.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "john", taken.Username)
+	_, err = cache.Take(session.ID)
+	assert.ErrorIs(t, err, ErrVotingSessionNotFound, "a session can only be taken once")
+
+	now = now.Add(50 * time.Second)
+	cache.Restore(taken)
+	now = now.Add(50 * time.Second)
+	restored, err := cache.Get(session.ID)
+	assert.NoError(t, err, "restoring extends the expiry")
+	assert.Equal(t, "john", restored.Username)
+
+	now = now.Add(time.Minute)
+	_, err = cache.Take(session.ID)
+	assert.ErrorIs(t, err, ErrVotingSessionNotFound)
+}
+
+func TestVotingSessionCache_Update(t *testing.T) {
+	cache := NewVotingSessionCache(time.Minute, time.Now)
+	slate := []string{"m1", "m2"}
+	session, _ := cache.Create(data.VotingSession{Slate: slate, Shown: slate})
+
+	updated, err := cache.Update(session.ID, func(session *data.VotingSession) error {
+		session.Iteration++
+		session.Shown = append(session.Shown, "m3")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, updated.Iteration)
+	assert.Equal(t, []string{"m1", "m2", "m3"}, updated.Shown)
+
+	_, err = cache.Update(session.ID, func(session *data.VotingSession) error {
+		session.Iteration++
+		return ErrVotingSessionNotFound
+	})
+	assert.Error(t, err)
+	got, _ := cache.Get(session.ID)
+	assert.Equal(t, 1, got.Iteration, "a failed update leaves the session alone")
+
+	got.Slate[0] = "changed"
+	again, _ := cache.Get(session.ID)
+	assert.Equal(t, []string{"m1", "m2"}, again.Slate, "callers get copies")
+}
+
+func TestVotingSessionCache_ConcurrentUpdates(t *testing.T) {
+	cache := NewVotingSessionCache(time.Minute, time.Now)
+	session, _ := cache.Create(data.VotingSession{})
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = cache.Update(session.ID, func(session *data.VotingSession) error {
+				session.NumSelected++
+				session.Selected = append(session.Selected, "m1")
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	got, _ := cache.Get(session.ID)
+	assert.Equal(t, 50, got.NumSelected)
+	assert.Len(t, got.Selected, 50)
+}
@@ -0,0 +1,171 @@
+// Package clustering groups movies by their metrics with k-means so the rec engine's
+// centroids can be rebuilt without the offline Python pipeline.
+package clustering
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+)
+
+// Options configures a k-means run. Zero MaxIterations and Tolerance use the defaults; the
+// same Seed always produces the same clustering.
+type Options struct {
+	K             int
+	MaxIterations int
+	Tolerance     float64
+	Seed          int64
+}
+
+// Result is a fitted clustering. Centroid IDs are their index in Centroids and Assignments
+// maps each movie ID to its centroid.
+type Result struct {
+	Centroids   []data.MovieMetrics
+	Assignments map[string]int
+	Inertia     float64
+	Silhouette  float64
+	Iterations  int
+	Converged   bool
+}
+
+type point struct {
+	movieID string
+	vector  data.MetricVector
+}
+
+// KMeans clusters movies into opts.K groups, seeding the centroids with k-means++ and
+// refining them with Lloyd's algorithm until no centroid moves more than opts.Tolerance.
+// A centroid left without movies is moved to the movie farthest from its own centroid.
+func KMeans(movies []data.Movie, opts Options) (Result, error) {
+	if opts.MaxIterations <= 0 {
+		opts.MaxIterations = constants.KMEANS_MAX_ITERATIONS
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = constants.KMEANS_TOLERANCE
+	}
+	if opts.K < 2 {
+		return Result{}, errors.New("k must be at least 2")
+	}
+	if len(movies) < opts.K {
+		return Result{}, fmt.Errorf("cannot make %d clusters from %d movies", opts.K, len(movies))
+	}
+
+	points := make([]point, len(movies))
+	for i, movie := range movies {
+		points[i] = point{movieID: movie.ID, vector: movie.Metrics.Vector()}
+	}
+	// map iteration upstream shouldn't change the result for a seed
+	sort.Slice(points, func(i, j int) bool { return points[i].movieID < points[j].movieID })
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	centroids := kMeansPlusPlus(points, opts.K, rng)
+	labels := make([]int, len(points))
+	result := Result{}
+	for result.Iterations < opts.MaxIterations {
+		result.Iterations++
+		for i, p := range points {
+			labels[i], _ = nearest(p.vector, centroids)
+		}
+		next := recompute(points, labels, centroids)
+		shift := 0.0
+		for c := range centroids {
+			shift = math.Max(shift, next[c].Sub(centroids[c]).Norm())
+		}
+		centroids = next
+		if shift <= opts.Tolerance {
+			result.Converged = true
+			break
+		}
+	}
+
+	result.Centroids = make([]data.MovieMetrics, len(centroids))
+	for c, centroid := range centroids {
+		result.Centroids[c] = centroid.Metrics()
+		result.Centroids[c].ID = c
+	}
+	result.Assignments = make(map[string]int, len(points))
+	for i, p := range points {
+		var d float64
+		labels[i], d = nearest(p.vector, centroids)
+		result.Assignments[p.movieID] = labels[i]
+		result.Inertia += d
+	}
+	result.Silhouette = silhouette(points, labels, len(centroids))
+	return result, nil
+}
+
+// kMeansPlusPlus picks the first centroid uniformly and each further one with probability
+// proportional to its squared distance from the nearest centroid chosen so far.
+func kMeansPlusPlus(points []point, k int, rng *rand.Rand) []data.MetricVector {
+	centroids := []data.MetricVector{points[rng.Intn(len(points))].vector}
+	dists := make([]float64, len(points))
+	for len(centroids) < k {
+		total := 0.0
+		for i, p := range points {
+			_, dists[i] = nearest(p.vector, centroids)
+			total += dists[i]
+		}
+		if total == 0 {
+			// fewer distinct points than k; duplicates are all that's left
+			centroids = append(centroids, points[rng.Intn(len(points))].vector)
+			continue
+		}
+		target := rng.Float64() * total
+		chosen := len(points) - 1
+		for i, d := range dists {
+			if target -= d; target < 0 {
+				chosen = i
+				break
+			}
+		}
+		centroids = append(centroids, points[chosen].vector)
+	}
+	return centroids
+}
+
+// recompute moves each centroid to the mean of its points, reseeding empty clusters.
+func recompute(points []point, labels []int, old []data.MetricVector) []data.MetricVector {
+	sums := make([]data.MetricVector, len(old))
+	counts := make([]int, len(old))
+	for i, p := range points {
+		sums[labels[i]] = sums[labels[i]].Add(p.vector)
+		counts[labels[i]]++
+	}
+	next := make([]data.MetricVector, len(old))
+	for c := range old {
+		if counts[c] > 0 {
+			next[c] = sums[c].Scale(1 / float64(counts[c]))
+			continue
+		}
+		farthest, farthestDist := 0, -1.0
+		for i, p := range points {
+			if d := sqDist(p.vector, old[labels[i]]); d > farthestDist {
+				farthest, farthestDist = i, d
+			}
+		}
+		next[c] = points[farthest].vector
+		labels[farthest] = c
+	}
+	return next
+}
+
+// nearest returns the index of the centroid closest to v and its squared distance.
+func nearest(v data.MetricVector, centroids []data.MetricVector) (int, float64) {
+	best, bestDist := 0, math.Inf(1)
+	for c, centroid := range centroids {
+		if d := sqDist(v, centroid); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best, bestDist
+}
+
+func sqDist(a, b data.MetricVector) float64 {
+	d := a.Sub(b)
+	return d.Dot(d)
+}
@@ -0,0 +1,98 @@
+package clustering
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/data"
+)
+
+// blobs makes perCenter movies scattered tightly around each center.
+func blobs(centers []data.MovieMetrics, perCenter int, seed int64) []data.Movie {
+	rng := rand.New(rand.NewSource(seed))
+	var movies []data.Movie
+	for c, center := range centers {
+		for i := 0; i < perCenter; i++ {
+			v := center.Vector()
+			for d := range v {
+				v[d] += rng.Float64()*2 - 1
+			}
+			movies = append(movies, data.Movie{ID: fmt.Sprintf("c%d_%02d", c, i), Metrics: v.Metrics()})
+		}
+	}
+	return movies
+}
+
+var blobCenters = []data.MovieMetrics{
+	{Horror: 90, Suspense: 80},
+	{Comedy: 85, Romance: 70},
+	{Action: 90, Cinematography: 75},
+}
+
+func TestKMeans_RecoversSeparatedClusters(t *testing.T) {
+	movies := blobs(blobCenters, 20, 1)
+
+	result, err := KMeans(movies, Options{K: 3, Seed: 7})
+	assert.NoError(t, err)
+	assert.True(t, result.Converged)
+	assert.Len(t, result.Centroids, 3)
+	assert.Len(t, result.Assignments, len(movies))
+	for c := range blobCenters {
+		first := result.Assignments[fmt.Sprintf("c%d_00", c)]
+		for i := 1; i < 20; i++ {
+			assert.Equal(t, first, result.Assignments[fmt.Sprintf("c%d_%02d", c, i)])
+		}
+		assert.InDelta(t, blobCenters[c].Horror, result.Centroids[first].Horror, 1)
+		assert.Equal(t, first, result.Centroids[first].ID)
+	}
+	assert.Greater(t, result.Silhouette, 0.9)
+	assert.Less(t, result.Inertia, float64(len(movies)*12))
+}
+
+func TestKMeans_QualityTracksK(t *testing.T) {
+	movies := blobs(blobCenters, 20, 2)
+
+	two, err := KMeans(movies, Options{K: 2, Seed: 1})
+	assert.NoError(t, err)
+	three, err := KMeans(movies, Options{K: 3, Seed: 1})
+	assert.NoError(t, err)
+	assert.Greater(t, two.Inertia, three.Inertia)
+	assert.Greater(t, three.Silhouette, two.Silhouette)
+}
+
+func TestKMeans_SeedIsDeterministic(t *testing.T) {
+	movies := blobs(blobCenters, 15, 3)
+	shuffled := append([]data.Movie{}, movies...)
+	rand.New(rand.NewSource(4)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	a, _ := KMeans(movies, Options{K: 4, Seed: 9})
+	b, _ := KMeans(shuffled, Options{K: 4, Seed: 9})
+	assert.Equal(t, a, b)
+}
+
+func TestKMeans_DuplicatePointsFillEveryCluster(t *testing.T) {
+	movies := []data.Movie{
+		{ID: "a", Metrics: data.MovieMetrics{Drama: 5}},
+		{ID: "b", Metrics: data.MovieMetrics{Drama: 5}},
+		{ID: "c", Metrics: data.MovieMetrics{Drama: 5}},
+		{ID: "d", Metrics: data.MovieMetrics{Horror: 50}},
+	}
+
+	result, err := KMeans(movies, Options{K: 3, Seed: 1})
+	assert.NoError(t, err)
+	assert.Len(t, result.Centroids, 3)
+	assert.NotEqual(t, result.Assignments["a"], result.Assignments["d"])
+	assert.Zero(t, result.Inertia)
+}
+
+func TestKMeans_InvalidK(t *testing.T) {
+	movies := blobs(blobCenters, 1, 5)
+
+	_, err := KMeans(movies, Options{K: 1})
+	assert.ErrorContains(t, err, "at least 2")
+	_, err = KMeans(movies, Options{K: 4})
+	assert.ErrorContains(t, err, "from 3 movies")
+}
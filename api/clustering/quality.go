@@ -0,0 +1,46 @@
+package clustering
+
+import "math"
+
+// silhouette is the mean silhouette coefficient over every point: how much closer, by
+// euclidean distance, a point is to its own cluster than to the nearest other one. It runs
+// from -1 to 1; higher means tighter, better separated clusters. Points alone in their
+// cluster score 0. This is quadratic in the number of points.
+func silhouette(points []point, labels []int, k int) float64 {
+	if len(points) < 2 {
+		return 0
+	}
+	sizes := make([]int, k)
+	for _, label := range labels {
+		sizes[label]++
+	}
+
+	total := 0.0
+	sums := make([]float64, k)
+	for i, p := range points {
+		for c := range sums {
+			sums[c] = 0
+		}
+		for j, q := range points {
+			if i != j {
+				sums[labels[j]] += math.Sqrt(sqDist(p.vector, q.vector))
+			}
+		}
+		own := labels[i]
+		if sizes[own] < 2 {
+			continue
+		}
+		a := sums[own] / float64(sizes[own]-1)
+		b := math.Inf(1)
+		for c, sum := range sums {
+			if c != own && sizes[c] > 0 {
+				b = math.Min(b, sum/float64(sizes[c]))
+			}
+		}
+		if math.IsInf(b, 1) || math.Max(a, b) == 0 {
+			continue
+		}
+		total += (b - a) / math.Max(a, b)
+	}
+	return total / float64(len(points))
+}
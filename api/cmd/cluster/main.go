@@ -0,0 +1,109 @@
+// Command cluster runs k-means over every movie's metrics and reports cluster quality, so k
+// can be tuned before the centroids are rebuilt.
+//
+// Usage:
+//
+//	go run ./cmd/cluster -k 40,50,60,70
+//	go run ./cmd/cluster -k 60 -write
+//
+// Several values of k are compared side by side. With -write, a single k's centroids replace
+// the centroids table and every movie whose cluster changed is reassigned. Running servers
+// pick up the new centroids on their next periodic cache reload, every CACHE_RELOAD_MINUTES,
+// or at once with POST /api/v1/admin/caches/reload.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"blockbuster/api/clustering"
+	"blockbuster/api/constants"
+	"blockbuster/api/repos"
+	"blockbuster/api/utils"
+)
+
+func main() {
+	ks := flag.String(constants.K, strconv.Itoa(constants.DEFAULT_CLUSTERS), "comma separated numbers of clusters to compare")
+	seed := flag.Int64("seed", 0, "seed for k-means++ initialisation")
+	iterations := flag.Int("iterations", constants.KMEANS_MAX_ITERATIONS, "maximum k-means iterations")
+	write := flag.Bool("write", false, "write the centroids and movie assignments for a single k")
+	flag.Parse()
+
+	clusterCounts, err := parseKs(*ks)
+	if err != nil {
+		log.Fatalln("invalid -k:", err)
+	}
+	if *write && len(clusterCounts) != 1 {
+		log.Fatalln("-write needs exactly one value of -k")
+	}
+
+	ctx := context.Background()
+	movieRepo := repos.NewDynamoMovieRepo(utils.GetDynamoClient())
+	movies, err := movieRepo.GetMoviesWithMetrics(ctx)
+	if err != nil {
+		log.Fatalln("failed to load movie metrics:", err)
+	}
+	log.Printf("clustering %d movies with metrics", len(movies))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "k\titerations\tconverged\tinertia\tsilhouette\tsmallest\tlargest")
+	var last clustering.Result
+	for _, k := range clusterCounts {
+		result, err := clustering.KMeans(movies, clustering.Options{K: k, MaxIterations: *iterations, Seed: *seed})
+		if err != nil {
+			log.Fatalf("k-means with k=%d failed: %v", k, err)
+		}
+		smallest, largest := clusterSizes(result)
+		fmt.Fprintf(w, "%d\t%d\t%t\t%.1f\t%.4f\t%d\t%d\n",
+			k, result.Iterations, result.Converged, result.Inertia, result.Silhouette, smallest, largest)
+		last = result
+	}
+	w.Flush()
+
+	if !*write {
+		return
+	}
+	if err := repos.NewCentroidsRepoWithDynamo().ReplaceCentroids(ctx, last.Centroids); err != nil {
+		log.Fatalln("failed to write centroids:", err)
+	}
+	moved := 0
+	for _, movie := range movies {
+		if centroid := last.Assignments[movie.ID]; centroid != movie.Centroid {
+			if err := movieRepo.SetCentroid(ctx, movie.ID, centroid); err != nil {
+				log.Fatalln("failed to reassign movie:", err)
+			}
+			moved++
+		}
+	}
+	log.Printf("wrote %d centroids and reassigned %d movies", len(last.Centroids), moved)
+}
+
+func parseKs(arg string) ([]int, error) {
+	var ks []int
+	for _, field := range strings.Split(arg, ",") {
+		k, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		ks = append(ks, k)
+	}
+	return ks, nil
+}
+
+func clusterSizes(result clustering.Result) (smallest, largest int) {
+	sizes := make([]int, len(result.Centroids))
+	for _, centroid := range result.Assignments {
+		sizes[centroid]++
+	}
+	smallest = len(result.Assignments)
+	for _, size := range sizes {
+		smallest, largest = min(smallest, size), max(largest, size)
+	}
+	return smallest, largest
+}
@@ -0,0 +1,115 @@
+// Command evaluate scores the rec engine offline. It loads the movies, centroids and member
+// rental histories once, then replays them against in-memory caches, so nothing is written
+// and runs can be compared before a change to voting or final picks ships.
+//
+// Usage:
+//
+//	go run ./cmd/evaluate
+//	go run ./cmd/evaluate -k 5 -holdout 3 -users 10 -noise 15 -seed 7
+//
+// Two scenarios are reported. holdout hides each member's last rentals and checks whether
+// the final picks for the rest find them. Rentals are stored as sets without dates, so
+// checked out movies are taken as the most recent. The co-rental model only sees the
+// rentals left in, so hidden rentals can't leak into the picks. synthetic runs full voting
+// sessions for users drawn around every centroid, who always vote for the slate movies
+// nearest their taste, and checks the picks against the movies nearest it.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/evaluation"
+	"blockbuster/api/repos"
+	"blockbuster/api/utils"
+)
+
+func main() {
+	opts := evaluation.Options{}
+	flag.IntVar(&opts.K, constants.K, constants.EVAL_K, "number of final picks scored per run")
+	flag.IntVar(&opts.HoldOut, "holdout", constants.EVAL_HOLD_OUT, "most recent rentals hidden from each member")
+	flag.IntVar(&opts.UsersPerCentroid, "users", constants.EVAL_USERS_PER_CENTROID, "synthetic users drawn around each centroid")
+	flag.Float64Var(&opts.Noise, "noise", constants.EVAL_NOISE, "standard deviation of synthetic tastes around their centroid")
+	flag.IntVar(&opts.Relevant, "relevant", constants.EVAL_RELEVANT, "movies nearest a synthetic user that count as relevant")
+	flag.IntVar(&opts.VotesPerRound, "votes", constants.EVAL_VOTES_PER_ROUND, "movies a synthetic user votes for each round")
+	flag.Float64Var(&opts.Diversity, "diversity", constants.DEFAULT_PICK_DIVERSITY, "final picks diversity")
+	flag.Int64Var(&opts.Seed, "seed", 0, "seed for drawing synthetic users")
+	flag.Parse()
+
+	ctx := context.Background()
+	movies, err := repos.NewDynamoMovieRepo(utils.GetDynamoClient()).GetMoviesWithMetrics(ctx)
+	if err != nil {
+		log.Fatalln("failed to load movies:", err)
+	}
+	centroids, err := repos.NewCentroidsRepoWithDynamo().GetCentroids(ctx)
+	if err != nil {
+		log.Fatalln("failed to load centroids:", err)
+	}
+	catalog := evaluation.NewCatalog(movies)
+	histories, err := repos.NewMembersRepo(utils.GetDynamoClient(), catalog, nil, nil).GetRentalHistories(ctx)
+	if err != nil {
+		log.Fatalln("failed to load rental histories:", err)
+	}
+	log.Printf("evaluating %d movies, %d centroids and %d rental histories", catalog.Size(), len(centroids), len(histories))
+
+	engine, err := newEngine(ctx, catalog, movies, centroids, withoutHoldOut(histories, opts.HoldOut))
+	if err != nil {
+		log.Fatalln("failed to build the rec engine:", err)
+	}
+	evaluator := evaluation.NewEvaluator(engine, catalog, histories, opts)
+	reports := []evaluation.Report{evaluator.HoldOut(ctx), evaluator.Synthetic(ctx, centroids)}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "scenario\truns\tskipped\tprecision@k\trecall@k\tcoverage\tnovelty\tdiversity")
+	for _, r := range reports {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.4f\t%.4f\t%.4f\t%.2f\t%.2f\n",
+			r.Scenario, r.Runs, r.Skipped, r.Precision, r.Recall, r.Coverage, r.Novelty, r.Diversity)
+	}
+	w.Flush()
+}
+
+// newEngine builds a MemberRepo whose caches are all loaded from memory. Movies without a
+// centroid are assigned one in the cache only, since the catalog is read only.
+func newEngine(ctx context.Context, catalog *evaluation.Catalog, movies []data.Movie, centroids map[int]data.MovieMetrics, histories [][]string) (*repos.MemberRepo, error) {
+	centroidCache := api_cache.NewCentroidCache(func(context.Context) (map[int]data.MovieMetrics, error) {
+		return centroids, nil
+	})
+	if err := centroidCache.Reload(ctx); err != nil {
+		return nil, err
+	}
+	centroidsToMovies := api_cache.NewCentroidsToMoviesCache(func(context.Context) ([]data.Movie, error) {
+		return movies, nil
+	}, centroidCache, nil)
+	if err := centroidsToMovies.Reload(ctx); err != nil {
+		return nil, err
+	}
+	coRentals := api_cache.NewCoRentalCache(func(context.Context) ([][]string, error) {
+		return histories, nil
+	})
+	if err := coRentals.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	engine := repos.NewMembersRepo(utils.GetDynamoClient(), catalog, centroidCache, centroidsToMovies).(*repos.MemberRepo)
+	engine.SetMovieIndex(api_cache.NewMovieIndex(movies))
+	engine.SetCoRentalCache(coRentals)
+	return engine, nil
+}
+
+// withoutHoldOut returns the histories with the rentals the holdout scenario hides removed.
+func withoutHoldOut(histories [][]string, holdOut int) [][]string {
+	trimmed := make([][]string, 0, len(histories))
+	for _, history := range histories {
+		if len(history) > holdOut {
+			trimmed = append(trimmed, history[:len(history)-holdOut])
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,56 @@
+// Command graph_export writes the co-star MovieGraph as GraphViz DOT, GEXF or GraphML.
+//
+// Usage:
+//
+//	go run ./cmd/graph_export -format gexf -star "Kevin Bacon" -depth 2 -out bacon.gexf
+//
+// With no -star, -title or -director the whole graph is exported.
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"blockbuster/api/constants"
+	graphsearch "blockbuster/api/graph_search"
+)
+
+func main() {
+	format := flag.String(constants.FORMAT, constants.DOT, "export format: dot, gexf or graphml")
+	star := flag.String(constants.STAR, "", "seed the export with a star")
+	title := flag.String(constants.TITLE, "", "seed the export with the cast of a movie title")
+	year := flag.String(constants.YEAR, "", "narrow -title to the movie released that year")
+	director := flag.String(constants.DIRECTOR, "", "seed the export with the actors a director has worked with")
+	depth := flag.Int(constants.DEPTH, 1, "search depth around the seeds (max 10)")
+	out := flag.String("out", "", "output file; defaults to stdout")
+	flag.Parse()
+
+	graph, err := graphsearch.GetMovieGraph()
+	if err != nil {
+		log.Fatalln("failed to build movie graph:", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalln("failed to create output file:", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	err = graph.Export(w, graphsearch.ExportRequest{
+		Format:   *format,
+		Star:     *star,
+		Title:    *title,
+		Year:     *year,
+		Director: *director,
+		Depth:    min(*depth, constants.MAX_KEVIN_BACON_DEPTH),
+	})
+	if err != nil {
+		log.Fatalln("failed to export movie graph:", err)
+	}
+}
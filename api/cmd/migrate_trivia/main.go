@@ -0,0 +1,75 @@
+// Command migrate_trivia converts trivia stored as one delimited string into the list of
+// question and answer pairs the API now writes.
+//
+// Usage:
+//
+//	go run ./cmd/migrate_trivia
+//	go run ./cmd/migrate_trivia -write
+//
+// Without -write it only reports what would change. Movies whose trivia has malformed
+// entries or fails validation are listed and left as they are; reads still parse what they
+// can of the legacy string, so they can be fixed by hand through PUT /movies/:movieID/trivia.
+// Running the command again only picks up movies not yet migrated.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+	"blockbuster/api/utils"
+)
+
+func main() {
+	write := flag.Bool("write", false, "write the converted trivia")
+	flag.Parse()
+
+	ctx := context.Background()
+	movieRepo := repos.NewDynamoMovieRepo(utils.GetDynamoClient())
+	legacy, err := movieRepo.GetLegacyTrivia(ctx)
+	if err != nil {
+		log.Fatalln("failed to scan legacy trivia:", err)
+	}
+	log.Printf("found %d movies with legacy trivia", len(legacy))
+
+	ids := make([]string, 0, len(legacy))
+	for id := range legacy {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "movie\tquestions\tproblem")
+	converted, skipped := 0, 0
+	for _, id := range ids {
+		trivia, err := data.ParseTrivia(legacy[id])
+		if err == nil {
+			trivia = trivia.Normalize()
+			err = trivia.Validate()
+		}
+		if err != nil {
+			fmt.Fprintf(w, "%s\t%d\t%v\n", id, len(trivia), err)
+			skipped++
+			continue
+		}
+		if *write {
+			if err := movieRepo.SetTrivia(ctx, id, trivia); err != nil {
+				log.Fatalf("failed to write trivia of %s: %v", id, err)
+			}
+		}
+		converted++
+	}
+	w.Flush()
+
+	if *write {
+		log.Printf("converted %d movies and skipped %d", converted, skipped)
+	} else {
+		log.Printf("would convert %d movies and skip %d; rerun with -write to save", converted, skipped)
+	}
+}
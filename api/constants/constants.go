@@ -45,57 +45,271 @@ const (
 	NOT_CHECKOUT = false
 
 	// GraphQL
-	ROOT_QUERY          = "RootQuery"
-	ROOT_MUTATION       = "RootMutation"
-	CHECKOUT_STRING     = "Checkout"
-	MOVIE_ID            = "movieID"
-	MOVIE_IDS           = "movieIDs"
-	GET_MOVIES          = "GetMovies"
-	GET_MOVIE           = "GetMovie"
-	GET_CHECKEDOUT      = "GetCheckedout"
-	GET_CART            = "GetCart"
-	RETURN_RENTALS      = "ReturnRentals"
-	GET_MEMBER          = "GetMember"
-	DIRECTED_MOVIES     = "DirectedMovies"
-	DIRECTED_PERFORMERS = "DirectedPerformers"
-	DIRECTORS           = "directors"
-	STAR                = "star"
-	STARS               = "stars"
-	STARREDIN           = "StarredIn"
-	STARREDWITH         = "StarredWith"
-	KEVING_BACON        = "KevinBacon"
-	KEVING_BACON_TYPE   = "KevinBaconType"
-	DEPTH               = "depth"
-	TOTAL_DIRECTORS     = "total_directors"
-	TOTAL_MOVIES        = "total_movies"
-	TOTAL_STARS         = "total_stars"
-	REMOVE_FROM_CART    = "removeFromCart"
-	UPDATE_CART         = "UpdateCart"
-	SET_API_CHOICE      = "SetAPIChoice"
-	SUCCESS             = "success"
-	CODE                = "code"
+	ROOT_QUERY            = "RootQuery"
+	ROOT_MUTATION         = "RootMutation"
+	CHECKOUT_STRING       = "Checkout"
+	MOVIE_ID              = "movieID"
+	MOVIE_IDS             = "movieIDs"
+	GET_MOVIES            = "GetMovies"
+	GET_MOVIE             = "GetMovie"
+	GET_CHECKEDOUT        = "GetCheckedout"
+	GET_CART              = "GetCart"
+	RETURN_RENTALS        = "ReturnRentals"
+	GET_MEMBER            = "GetMember"
+	DIRECTED_MOVIES       = "DirectedMovies"
+	DIRECTED_PERFORMERS   = "DirectedPerformers"
+	DIRECTORS             = "directors"
+	STAR                  = "star"
+	STARS                 = "stars"
+	STARREDIN             = "StarredIn"
+	STARREDWITH           = "StarredWith"
+	KEVING_BACON          = "KevinBacon"
+	KEVING_BACON_TYPE     = "KevinBaconType"
+	KEVIN_BACON_NODE_TYPE = "KevinBaconNode"
+	DEPTH                 = "depth"
+	TOTAL_DIRECTORS       = "total_directors"
+	TOTAL_MOVIES          = "total_movies"
+	TOTAL_STARS           = "total_stars"
+	NODES                 = "nodes"
+	TOTAL_NODES           = "total_nodes"
+	NAME                  = "name"
+	NODE_TYPE             = "type"
+	VIA_MOVIE             = "via_movie"
+	VIA_STAR              = "via_star"
+	LIMIT                 = "limit"
+	OFFSET                = "offset"
+	HAS_MORE              = "has_more"
+	REMOVE_FROM_CART      = "removeFromCart"
+	UPDATE_CART           = "UpdateCart"
+	SET_API_CHOICE        = "SetAPIChoice"
+	SUCCESS               = "success"
+	CODE                  = "code"
+	SUGGESTIONS           = "suggestions"
+	PERSON_TYPE           = "Person"
+	CREDIT_TYPE           = "Credit"
+	COLLABORATOR_TYPE     = "Collaborator"
+	METRICS_TYPE          = "MovieMetrics"
+	GET_PERSON            = "Person"
 
 	// AWS
-	PAGE               = "page"
-	DEFAULT_PAGE       = "A"
-	PAGINATE_KEY       = "paginate_key"
-	PAGINATE_KEY_INDEX = "paginate_key-index"
-	PAGES              = "#ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	FOR_GRAPH          = "FOR_GRAPH"
-	FOR_REST_CALL      = "FOR_REST_CALL"
-	FOR_CENTROID_CACHE = "FOR_CENTROID_CACHE"
+	PAGE                = "page"
+	DEFAULT_PAGE        = "A"
+	PAGINATE_KEY        = "paginate_key"
+	PAGINATE_KEY_INDEX  = "paginate_key-index"
+	PAGES               = "#ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	FOR_GRAPH           = "FOR_GRAPH"
+	FOR_REST_CALL       = "FOR_REST_CALL"
+	FOR_CENTROID_CACHE  = "FOR_CENTROID_CACHE"
+	FOR_METRICS_INDEX   = "FOR_METRICS_INDEX"
+	BATCH_GET_LIMIT     = 10
+	BATCH_WRITE_LIMIT   = 25
+	BATCH_WRITE_RETRIES = 3
 
 	// API Choice
 	API_CHOICE  = "api_choice"
 	REST_API    = "REST"
 	GRAPHQL_API = "GraphQL"
 
+	// Graph Export
+	FORMAT  = "format"
+	DOT     = "dot"
+	GEXF    = "gexf"
+	GRAPHML = "graphml"
+
 	// Router group
 	REST_ROUTER_GROUP = "/api/v1"
 	GRAPHQL_ENDPOINT  = "/graphql/v1"
 
 	// Rec Engine
-	MAX_MOVIE_SUGGESTIONS = 15
-	MAX_CENTROIDS_COUNT   = 7
-	NUMBER_FINAL_PICKS    = 3
+	MAX_MOVIE_SUGGESTIONS  = 15
+	MAX_CENTROIDS_COUNT    = 7
+	NUMBER_FINAL_PICKS     = 3
+	MAX_VOTING_ITERATIONS  = MAX_CENTROIDS_COUNT - 1
+	SLATE_STOCK_OVERSAMPLE = 2
+
+	// Movie Index
+	ANN_CANDIDATES             = 100
+	MOVIE_INDEX_PENDING_WRITES = 256
+
+	// Clustering
+	DEFAULT_CLUSTERS      = 60
+	KMEANS_MAX_ITERATIONS = 300
+	KMEANS_TOLERANCE      = 1e-4
+	NO_CENTROID           = -1
+
+	// Final Picks
+	MAX_FINAL_PICKS        = 10
+	DEFAULT_PICK_DIVERSITY = 0.3
+	MMR_CANDIDATE_POOL     = 30
+	EXPLANATION_DIMENSIONS = 3
+	EXPLANATION_INFLUENCES = 2
+
+	// Explained Picks
+	FINISH_VOTING_SESSION       = "finishVotingSession"
+	FINAL_PICK_TYPE             = "FinalPick"
+	PICK_EXPLANATION_TYPE       = "PickExplanation"
+	DIMENSION_CONTRIBUTION_TYPE = "DimensionContribution"
+	DIMENSION_WEIGHT_INPUT      = "DimensionWeight"
+	EXPLANATION                 = "explanation"
+	TOP_DIMENSIONS              = "topDimensions"
+	INFLUENCED_BY               = "influencedBy"
+	ALSO_RENTED_WITH            = "alsoRentedWith"
+	DIMENSION                   = "dimension"
+	MOOD                        = "mood"
+	SHARE                       = "share"
+	NUM_PICKS                   = "numPicks"
+	DIVERSITY                   = "diversity"
+	BLEND_CO_RENTALS            = "blendCoRentals"
+	METRIC                      = "metric"
+	WEIGHTS                     = "weights"
+	WEIGHT                      = "weight"
+
+	// Recommendations
+	RECOMMENDATIONS         = "Recommendations"
+	RECOMMENDATION_TYPE     = "Recommendation"
+	SCORE                   = "score"
+	CENTROID                = "centroid"
+	DEFAULT_RECOMMENDATIONS = 10
+	MAX_RECOMMENDATIONS     = 50
+
+	// Distance Metrics
+	DIMENSIONS         = "dimensions"
+	EUCLIDEAN          = "euclidean"
+	WEIGHTED_EUCLIDEAN = "weighted_euclidean"
+	MANHATTAN          = "manhattan"
+	COSINE             = "cosine"
+
+	// Similar Movies
+	SIMILAR            = "similar"
+	SIMILAR_MOVIE_TYPE = "SimilarMovie"
+	DISTANCE           = "distance"
+	DEFAULT_SIMILAR    = 5
+	MAX_SIMILAR        = 10
+
+	// Co-Rentals
+	ALSO_RENTED               = "also_rented"
+	CO_RENTAL_TYPE            = "CoRental"
+	K                         = "k"
+	DEFAULT_ALSO_RENTED       = 5
+	MAX_ALSO_RENTED           = 10
+	CO_RENTAL_REFRESH_MINUTES = 60
+	CO_RENTAL_CANDIDATES      = 5
+
+	// Taste Profile
+	TASTE                 = "taste"
+	TASTE_HALF_LIFE_DAYS  = 90
+	TASTE_CHECKOUT_WEIGHT = 1.0
+	TASTE_RETURN_WEIGHT   = 0.5
+	TASTE_VOTE_WEIGHT     = 2.0
+	TASTE_UPDATE_ATTEMPTS = 3
+
+	// Centroids
+	CENTROIDS            = "Centroids"
+	GET_CENTROID         = "Centroid"
+	CENTROID_TYPE        = "Centroid"
+	CENTROID_ID          = "centroidID"
+	LABEL                = "label"
+	DESCRIPTION          = "description"
+	CUSTOM               = "custom"
+	SIZE                 = "size"
+	CENTROID_METRICS     = "metrics"
+	DEFAULT_SHELF_MOVIES = 5
+	MAX_SHELF_MOVIES     = 20
+
+	// Cache Reload
+	CENTROID_CACHE            = "centroids"
+	CENTROIDS_TO_MOVIES_CACHE = "centroids_to_movies"
+	MOVIE_INDEX_CACHE         = "movie_index"
+	QUIZ_LEADERBOARD_CACHE    = "quiz_leaderboard"
+	CACHE_LOAD_ATTEMPTS       = 5
+	CACHE_LOAD_BACKOFF_MS     = 500
+	CACHE_RELOAD_MINUTES      = 15
+	CACHE                     = "cache"
+	CACHES                    = "caches"
+
+	// Voting Sessions
+	SESSION_ID                 = "sessionID"
+	SEED                       = "seed"
+	VOTING_SESSION_TTL_MINUTES = 30
+
+	// Voting Rooms
+	ROOM_CODE             = "code"
+	ROOM_CODE_LENGTH      = 6
+	MAX_ROOM_MEMBERS      = 8
+	AVERAGE_STRATEGY      = "average"
+	LEAST_MISERY_STRATEGY = "least_misery"
+	APPROVAL_STRATEGY     = "approval"
+
+	// Evaluation
+	EVAL_K                  = 10
+	EVAL_HOLD_OUT           = 2
+	EVAL_USERS_PER_CENTROID = 5
+	EVAL_NOISE              = 10.0
+	EVAL_RELEVANT           = 20
+	EVAL_VOTES_PER_ROUND    = 2
+
+	// Trivia
+	MAX_TRIVIA_ITEMS           = 20
+	MAX_TRIVIA_QUESTION_LENGTH = 300
+	MAX_TRIVIA_ANSWER_LENGTH   = 500
+	TRIVIA_TYPE                = "Trivia"
+	QUESTION                   = "question"
+	ANSWER                     = "answer"
+
+	// Trivia Quiz
+	QUIZ_ID                  = "quizID"
+	QUIZ_SOURCE_RENTED       = "rented"
+	QUIZ_SOURCE_CENTROID     = "centroid"
+	DEFAULT_QUIZ_QUESTIONS   = 5
+	MAX_QUIZ_QUESTIONS       = 10
+	QUIZ_CHOICES             = 4
+	QUIZ_TRIVIA_MOVIES       = 30
+	QUIZ_TTL_MINUTES         = 15
+	DEFAULT_QUIZ_LEADERBOARD = 10
+	MAX_QUIZ_LEADERBOARD     = 100
+	START_QUIZ               = "startQuiz"
+	SUBMIT_QUIZ              = "submitQuiz"
+	QUIZ_LEADERBOARD         = "QuizLeaderboard"
+	QUIZ_STATS               = "QuizStats"
+	QUIZ_TYPE                = "Quiz"
+	QUIZ_QUESTION_TYPE       = "QuizQuestion"
+	QUIZ_RESULT_TYPE         = "QuizResult"
+	GRADED_ANSWER_TYPE       = "GradedAnswer"
+	QUIZ_STATS_TYPE          = "QuizStats"
+	SOURCE                   = "source"
+	NUM_QUESTIONS            = "numQuestions"
+	QUESTIONS                = "questions"
+	CHOICES                  = "choices"
+	ANSWERS                  = "answers"
+	CHOSEN                   = "chosen"
+	CORRECT                  = "correct"
+	TOTAL                    = "total"
+	GRADED                   = "graded"
+	STATS                    = "stats"
+	POINTS                   = "points"
+	ASKED                    = "asked"
+	PLAYED                   = "played"
+	RANK                     = "rank"
+	EXPIRES_AT               = "expiresAt"
+
+	// Kevin Bacon
+	MAX_KEVIN_BACON_DEPTH     = 10
+	KEVIN_BACON_PAGE_SIZE     = 50
+	MAX_KEVIN_BACON_PAGE_SIZE = 200
+
+	// People
+	PERSON            = "person"
+	ROLES             = "roles"
+	FILMOGRAPHY       = "filmography"
+	COLLABORATORS     = "collaborators"
+	COUNT             = "count"
+	ROLE              = "role"
+	CAREER_START      = "career_start"
+	CAREER_END        = "career_end"
+	AVERAGE_METRICS   = "average_metrics"
+	AVAILABLE_TITLES  = "available_titles"
+	MAX_COLLABORATORS = 10
+
+	// Name Resolution
+	MAX_NAME_SUGGESTIONS = 3
 )
@@ -0,0 +1,203 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// metricDimensions is the single list of MovieMetrics dimensions. Vectors, JSON and Dynamo
+// serialization all iterate it, so a new dimension only needs a struct field and a row here.
+var metricDimensions = []struct {
+	name  string
+	field func(m *MovieMetrics) *float64
+}{
+	{"acting", func(m *MovieMetrics) *float64 { return &m.Acting }},
+	{"action", func(m *MovieMetrics) *float64 { return &m.Action }},
+	{"cinematography", func(m *MovieMetrics) *float64 { return &m.Cinematography }},
+	{"comedy", func(m *MovieMetrics) *float64 { return &m.Comedy }},
+	{"directing", func(m *MovieMetrics) *float64 { return &m.Directing }},
+	{"drama", func(m *MovieMetrics) *float64 { return &m.Drama }},
+	{"fantasy", func(m *MovieMetrics) *float64 { return &m.Fantasy }},
+	{"horror", func(m *MovieMetrics) *float64 { return &m.Horror }},
+	{"romance", func(m *MovieMetrics) *float64 { return &m.Romance }},
+	{"story_telling", func(m *MovieMetrics) *float64 { return &m.StoryTelling }},
+	{"suspense", func(m *MovieMetrics) *float64 { return &m.Suspense }},
+	{"writing", func(m *MovieMetrics) *float64 { return &m.Writing }},
+}
+
+// NumMetricDimensions is the length of a MetricVector.
+const NumMetricDimensions = 12
+
+func init() {
+	if len(metricDimensions) != NumMetricDimensions {
+		panic(fmt.Sprintf("metricDimensions lists %d dimensions; NumMetricDimensions is %d", len(metricDimensions), NumMetricDimensions))
+	}
+}
+
+// MetricVector is MovieMetrics as a fixed-length vector, indexed in MetricDimensions order.
+type MetricVector [NumMetricDimensions]float64
+
+// MetricDimensions returns the dimension names, as used in JSON and Dynamo, in vector order.
+func MetricDimensions() []string {
+	names := make([]string, len(metricDimensions))
+	for i, dim := range metricDimensions {
+		names[i] = dim.name
+	}
+	return names
+}
+
+// MetricDimensionIndex returns the vector index of a named dimension, or -1.
+func MetricDimensionIndex(name string) int {
+	for i, dim := range metricDimensions {
+		if dim.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Vector returns the metrics as a MetricVector. The ID is dropped.
+func (m MovieMetrics) Vector() MetricVector {
+	var v MetricVector
+	for i, dim := range metricDimensions {
+		v[i] = *dim.field(&m)
+	}
+	return v
+}
+
+// Metrics converts the vector back to MovieMetrics with no ID.
+func (v MetricVector) Metrics() MovieMetrics {
+	var m MovieMetrics
+	for i, dim := range metricDimensions {
+		*dim.field(&m) = v[i]
+	}
+	return m
+}
+
+func (v MetricVector) Add(o MetricVector) MetricVector {
+	for i := range v {
+		v[i] += o[i]
+	}
+	return v
+}
+
+func (v MetricVector) Sub(o MetricVector) MetricVector {
+	for i := range v {
+		v[i] -= o[i]
+	}
+	return v
+}
+
+func (v MetricVector) Scale(s float64) MetricVector {
+	for i := range v {
+		v[i] *= s
+	}
+	return v
+}
+
+func (v MetricVector) Dot(o MetricVector) float64 {
+	sum := 0.0
+	for i := range v {
+		sum += v[i] * o[i]
+	}
+	return sum
+}
+
+func (v MetricVector) Norm() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// MarshalJSON writes the id, when set, followed by every dimension in vector order.
+func (m MovieMetrics) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if m.ID != 0 {
+		fmt.Fprintf(&buf, `"id":%d,`, m.ID)
+	}
+	for i, dim := range metricDimensions {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		value, err := json.Marshal(*dim.field(&m))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "%q:%s", dim.name, value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON reads the id and any known dimensions; missing dimensions are zero and
+// unknown keys are ignored.
+func (m *MovieMetrics) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	var out MovieMetrics
+	if id, ok := raw["id"]; ok {
+		if err := json.Unmarshal(id, &out.ID); err != nil {
+			return fmt.Errorf("metrics id: %w", err)
+		}
+	}
+	for _, dim := range metricDimensions {
+		if value, ok := raw[dim.name]; ok {
+			if err := json.Unmarshal(value, dim.field(&out)); err != nil {
+				return fmt.Errorf("metric %s: %w", dim.name, err)
+			}
+		}
+	}
+	*m = out
+	return nil
+}
+
+// MarshalDynamoDBAttributeValue stores the metrics as a map of numbers keyed by dimension.
+func (m MovieMetrics) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	item := make(map[string]types.AttributeValue, len(metricDimensions)+1)
+	if m.ID != 0 {
+		item["id"] = &types.AttributeValueMemberN{Value: strconv.Itoa(m.ID)}
+	}
+	for _, dim := range metricDimensions {
+		item[dim.name] = &types.AttributeValueMemberN{Value: strconv.FormatFloat(*dim.field(&m), 'f', -1, 64)}
+	}
+	return &types.AttributeValueMemberM{Value: item}, nil
+}
+
+// UnmarshalDynamoDBAttributeValue reads a map written by MarshalDynamoDBAttributeValue, or a
+// whole centroid item. Missing dimensions are zero.
+func (m *MovieMetrics) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	if _, ok := av.(*types.AttributeValueMemberNULL); ok {
+		return nil
+	}
+	item, ok := av.(*types.AttributeValueMemberM)
+	if !ok {
+		return fmt.Errorf("metrics must be a map, got %T", av)
+	}
+	var out MovieMetrics
+	if id, ok := item.Value["id"].(*types.AttributeValueMemberN); ok {
+		parsed, err := strconv.Atoi(id.Value)
+		if err != nil {
+			return fmt.Errorf("metrics id: %w", err)
+		}
+		out.ID = parsed
+	}
+	for _, dim := range metricDimensions {
+		value, ok := item.Value[dim.name].(*types.AttributeValueMemberN)
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value.Value, 64)
+		if err != nil {
+			return fmt.Errorf("metric %s: %w", dim.name, err)
+		}
+		*dim.field(&out) = parsed
+	}
+	*m = out
+	return nil
+}
@@ -1,18 +1,29 @@
 package data
 
+import "time"
+
 type Cart struct {
 	Cart []string `json:"cart,omitempty"`
 }
 
 type Member struct {
-	Username   string   `json:"username" dynamodbav:"username"`
-	FirstName  string   `json:"first_name" dynamodbav:"first_name"`
-	LastName   string   `json:"last_name" dynamodbav:"last_name"`
-	Cart       []string `json:"cart,omitempty" dynamodbav:"cart,omitempty"`
-	Checkedout []string `json:"checked_out,omitempty" dynamodbav:"checked_out,omitempty"`
-	Rented     []string `json:"rented,omitempty" dynamodbav:"rented,omitempty"`
-	Type       string   `json:"member_type" dynamodbav:"member_type"`
-	APIChoice  string   `json:"api_choice,omitempty" dynamodbav:"api_choice,omitempty"`
+	Username   string        `json:"username" dynamodbav:"username"`
+	FirstName  string        `json:"first_name" dynamodbav:"first_name"`
+	LastName   string        `json:"last_name" dynamodbav:"last_name"`
+	Cart       []string      `json:"cart,omitempty" dynamodbav:"cart,omitempty"`
+	Checkedout []string      `json:"checked_out,omitempty" dynamodbav:"checked_out,omitempty"`
+	Rented     []string      `json:"rented,omitempty" dynamodbav:"rented,omitempty"`
+	Type       string        `json:"member_type" dynamodbav:"member_type"`
+	APIChoice  string        `json:"api_choice,omitempty" dynamodbav:"api_choice,omitempty"`
+	Taste      *TasteProfile `json:"taste,omitempty" dynamodbav:"taste,omitempty"`
+}
+
+// TasteProfile is a member's long-running taste: a decaying weighted average of the
+// metrics of movies they rented and the moods of voting sessions they finished.
+type TasteProfile struct {
+	Metrics   MovieMetrics `json:"metrics" dynamodbav:"metrics"`
+	Weight    float64      `json:"weight" dynamodbav:"weight"`
+	UpdatedAt time.Time    `json:"updated_at" dynamodbav:"updated_at"`
 }
 
 type Movie struct {
@@ -26,27 +37,265 @@ type Movie struct {
 	Review    string       `json:"review,omitempty" dynamodbav:"review,omitempty"`
 	Synopsis  string       `json:"synopsis,omitempty" dynamodbav:"synopsis,omitempty"`
 	Metrics   MovieMetrics `json:"metrics,omitempty" dynamodbav:"metrics,omitempty"`
-	Trivia    string       `json:"trivia,omitempty" dynamodbav:"trivia,omitempty"`
+	Trivia    Trivia       `json:"trivia,omitempty" dynamodbav:"trivia,omitempty"`
 	Year      string       `json:"year,omitempty" dynamodbav:"year,omitempty"`
 	Centroid  int          `json:"centroid,omitempty" dynamodbav:"centroid,omitempty"`
 }
 
 type MovieTrivia struct {
-	Trivia string `json:"trivia" dynamodbav:"trivia"`
+	Trivia Trivia `json:"trivia" dynamodbav:"trivia"`
 }
 
+// MovieMetrics scores a movie on each metric dimension. JSON, Dynamo and GraphQL all go
+// through metricDimensions, so the dimension fields need no tags.
 type MovieMetrics struct {
-	ID             int     `json:"id,omitempty" dynamodbav:"id,omitempty"`
-	Acting         float64 `json:"acting" dynamodbav:"acting"`
-	Action         float64 `json:"action" dynamodbav:"action"`
-	Cinematography float64 `json:"cinematography" dynamodbav:"cinematography"`
-	Comedy         float64 `json:"comedy" dynamodbav:"comedy"`
-	Directing      float64 `json:"directing" dynamodbav:"directing"`
-	Drama          float64 `json:"drama" dynamodbav:"drama"`
-	Fantasy        float64 `json:"fantasy" dynamodbav:"fantasy"`
-	Horror         float64 `json:"horror" dynamodbav:"horror"`
-	Romance        float64 `json:"romance" dynamodbav:"romance"`
-	StoryTelling   float64 `json:"story_telling" dynamodbav:"story_telling"`
-	Suspense       float64 `json:"suspense" dynamodbav:"suspense"`
-	Writing        float64 `json:"writing" dynamodbav:"writing"`
+	ID             int `json:"id,omitempty" dynamodbav:"id,omitempty"`
+	Acting         float64
+	Action         float64
+	Cinematography float64
+	Comedy         float64
+	Directing      float64
+	Drama          float64
+	Fantasy        float64
+	Horror         float64
+	Romance        float64
+	StoryTelling   float64
+	Suspense       float64
+	Writing        float64
+}
+
+// DistanceOptions picks how the rec engine compares metrics. Metric is one of euclidean
+// (the default), weighted_euclidean, manhattan or cosine. Weights scale individual
+// dimensions by their JSON key, e.g. {"suspense": 3, "writing": 3}; unlisted ones weigh 1.
+type DistanceOptions struct {
+	Metric  string             `json:"metric,omitempty"`
+	Weights map[string]float64 `json:"weights,omitempty"`
+}
+
+// FinalPicksOptions tunes the final picks of a voting session. NumPicks defaults to 3 and
+// Diversity, from 0 to 1, to 0.3. Username, when set, keeps the member's past rentals out.
+// Voted lists the movies voted for, which the picks' explanations point back to.
+// BlendCoRentals, off by default, mixes in movies often rented with the best pick.
+type FinalPicksOptions struct {
+	NumPicks       int             `json:"numPicks,omitempty"`
+	Diversity      *float64        `json:"diversity,omitempty"`
+	Username       string          `json:"username,omitempty"`
+	Voted          []string        `json:"voted,omitempty"`
+	Distance       DistanceOptions `json:"distance"`
+	BlendCoRentals bool            `json:"blendCoRentals,omitempty"`
+}
+
+// FinalPick is a movie picked at the end of mood voting along with why it was picked.
+type FinalPick struct {
+	MovieID     string          `json:"movieID"`
+	Explanation PickExplanation `json:"explanation"`
+}
+
+// PickExplanation describes a final pick against the mood. TopDimensions are the metrics that
+// contribute most to their agreement, InfluencedBy the voted movies closest to the pick, and
+// AlsoRentedWith is set when the pick was blended in from another pick's co-rentals.
+type PickExplanation struct {
+	Centroid       int                     `json:"centroid"`
+	Distance       float64                 `json:"distance"`
+	TopDimensions  []DimensionContribution `json:"topDimensions"`
+	InfluencedBy   []string                `json:"influencedBy"`
+	AlsoRentedWith string                  `json:"alsoRentedWith,omitempty"`
+}
+
+// DimensionContribution is one metric dimension's share of the dot product between a mood
+// and a movie's metrics.
+type DimensionContribution struct {
+	Dimension string  `json:"dimension"`
+	Mood      float64 `json:"mood"`
+	Movie     float64 `json:"movie"`
+	Share     float64 `json:"share"`
+}
+
+// Person aggregates what the catalog knows about a star or director.
+type Person struct {
+	Name            string         `json:"name"`
+	Roles           []string       `json:"roles"`
+	Filmography     []Credit       `json:"filmography"`
+	Collaborators   []Collaborator `json:"collaborators"`
+	CareerStart     int            `json:"career_start,omitempty"`
+	CareerEnd       int            `json:"career_end,omitempty"`
+	AverageMetrics  MovieMetrics   `json:"average_metrics"`
+	AvailableTitles []Movie        `json:"available_titles"`
+}
+
+type Credit struct {
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Year  string   `json:"year,omitempty"`
+	Roles []string `json:"roles"`
+}
+
+type Collaborator struct {
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+	Count int    `json:"count"`
+}
+
+// VotingSession is the server-held state of a mood voting flow. Clients only ever send
+// the session ID and the movies they picked from the current slate. Seed draws every slate
+// of the session, so starting a session with the same seed and votes replays it exactly.
+type VotingSession struct {
+	ID          string       `json:"sessionID"`
+	Mood        MovieMetrics `json:"mood"`
+	Iteration   int          `json:"iteration"`
+	NumSelected int          `json:"numSelected"`
+	Slate       []string     `json:"movies"`
+	Shown       []string     `json:"shown"`
+	Selected    []string     `json:"selected"`
+	Username    string       `json:"username,omitempty"`
+	Seed        int64        `json:"seed"`
+	ExpiresAt   time.Time    `json:"expiresAt"`
+}
+
+// VotingRoom is a mood voting session shared by several members, who join by its code and
+// vote on the same slate. Each round ends once every member has voted; the members' moods
+// are then aggregated by Strategy into the group Mood that draws the next slate.
+type VotingRoom struct {
+	Code      string                `json:"code"`
+	Host      string                `json:"host"`
+	Strategy  string                `json:"strategy"`
+	Members   map[string]RoomMember `json:"members"`
+	Mood      MovieMetrics          `json:"mood"`
+	Iteration int                   `json:"iteration"`
+	Slate     []string              `json:"movies"`
+	Shown     []string              `json:"shown"`
+	Seed      int64                 `json:"seed"`
+	ExpiresAt time.Time             `json:"expiresAt"`
+}
+
+// RoomMember is one member's votes in a VotingRoom. Voted is reset at the start of every
+// round.
+type RoomMember struct {
+	Mood        MovieMetrics `json:"mood"`
+	NumSelected int          `json:"numSelected"`
+	Selected    []string     `json:"selected"`
+	Voted       bool         `json:"voted"`
+}
+
+// Recommendation is a movie ranked for a member. Score is in (0, 1]; higher is closer to
+// the member's taste.
+type Recommendation struct {
+	Movie    Movie   `json:"movie"`
+	Score    float64 `json:"score"`
+	Centroid int     `json:"centroid"`
+}
+
+// SimilarMovie is a movie close to another in metric space. Distance is the squared
+// euclidean distance between their metrics; smaller is more alike.
+type SimilarMovie struct {
+	Movie    Movie   `json:"movie"`
+	Distance float64 `json:"distance"`
+	Centroid int     `json:"centroid"`
+}
+
+// Neighbor is a movie returned by a nearest-neighbour query on the movie metrics index.
+type Neighbor struct {
+	MovieID  string  `json:"movieID"`
+	Distance float64 `json:"distance"`
+	Centroid int     `json:"centroid"`
+}
+
+// CoRental is a movie frequently rented by members who also rented another movie.
+// Count is the number of members who rented both; Score normalises it by how often
+// each movie is rented so blockbusters don't dominate.
+type CoRental struct {
+	Movie Movie   `json:"movie"`
+	Count int     `json:"count"`
+	Score float64 `json:"score"`
+}
+
+// CacheStatus reports the state of a reloadable cache. LastError is the error from the
+// most recent load and is cleared by the next successful one. Excluded counts entries the
+// cache loaded but refuses to serve, such as movies without metrics.
+type CacheStatus struct {
+	Name       string    `json:"name"`
+	Size       int       `json:"size"`
+	Excluded   int       `json:"excluded,omitempty"`
+	LastLoaded time.Time `json:"lastLoaded"`
+	LastError  string    `json:"lastError,omitempty"`
+}
+
+// CentroidLabel names a centroid for browsing. Custom is set when staff wrote the label
+// rather than it being generated from the centroid's dominant dimensions.
+type CentroidLabel struct {
+	Label       string `json:"label" dynamodbav:"label"`
+	Description string `json:"description" dynamodbav:"description"`
+	Custom      bool   `json:"custom" dynamodbav:"-"`
+}
+
+// Centroid is a cluster of movies sharing a mood, browsed as a shelf in the storefront.
+// Size counts every movie assigned to it; Movies holds only the most representative ones.
+type Centroid struct {
+	ID          int          `json:"id"`
+	Label       string       `json:"label"`
+	Description string       `json:"description"`
+	Custom      bool         `json:"custom"`
+	Metrics     MovieMetrics `json:"metrics"`
+	Size        int          `json:"size"`
+	Movies      []Movie      `json:"movies"`
+}
+
+// QuizOptions picks where a trivia quiz's questions come from: the movies the member has
+// rented, or with Source centroid, the movies of Centroid. NumQuestions defaults to 5.
+type QuizOptions struct {
+	Username     string `json:"username"`
+	Source       string `json:"source,omitempty"`
+	Centroid     *int   `json:"centroid,omitempty"`
+	NumQuestions int    `json:"numQuestions,omitempty"`
+}
+
+// Quiz is a multiple choice trivia quiz drawn for a member. Answers holds the index of the
+// right choice for each question and never leaves the server.
+type Quiz struct {
+	ID        string         `json:"quizID"`
+	Username  string         `json:"username"`
+	Source    string         `json:"source"`
+	Centroid  *int           `json:"centroid,omitempty"`
+	Questions []QuizQuestion `json:"questions"`
+	Answers   []int          `json:"-"`
+	ExpiresAt time.Time      `json:"expiresAt"`
+}
+
+// QuizQuestion is one trivia question about a movie. Its right answer is mixed in among
+// answers to other movies' trivia.
+type QuizQuestion struct {
+	MovieID  string   `json:"movieID"`
+	Title    string   `json:"title"`
+	Question string   `json:"question"`
+	Choices  []string `json:"choices"`
+}
+
+// QuizResult grades a submitted quiz. Stats are the member's running totals including it.
+type QuizResult struct {
+	QuizID   string         `json:"quizID"`
+	Username string         `json:"username"`
+	Correct  int            `json:"correct"`
+	Total    int            `json:"total"`
+	Graded   []GradedAnswer `json:"graded"`
+	Stats    QuizStats      `json:"stats"`
+}
+
+// GradedAnswer is the choice picked for a question, -1 when it was skipped, against the
+// right one.
+type GradedAnswer struct {
+	Question string `json:"question"`
+	Chosen   int    `json:"chosen"`
+	Answer   int    `json:"answer"`
+	Correct  bool   `json:"correct"`
+}
+
+// QuizStats are a member's running quiz totals, kept on their member item. Points count
+// right answers out of the Asked questions of the Played quizzes; Rank is their place on the
+// leaderboard, shared by members on the same points.
+type QuizStats struct {
+	Username string `json:"username" dynamodbav:"username"`
+	Points   int    `json:"points" dynamodbav:"quiz_points"`
+	Asked    int    `json:"asked" dynamodbav:"quiz_asked"`
+	Played   int    `json:"played" dynamodbav:"quizzes_played"`
+	Rank     int    `json:"rank,omitempty" dynamodbav:"-"`
 }
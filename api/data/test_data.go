@@ -15,8 +15,12 @@ var TestMovies = []Movie{
 		Review:    "foo",
 		Synopsis:  "bar",
 		Title:     "L.A. Confidential",
-		Trivia:    ":What iconic L.A. landmark is featured in the film as the setting for the bloody \"Bloody Christmas\" incident?: The Christmas-themed Central Booking lobby of the L.A. Police Department&:&:What is the name of the call girl who bears a striking resemblance to Veronica Lake and is crucial to the central plot?: Lynn Bracken&:&:Which actor won the Academy Award for Best Supporting Actress for their portrayal of Lynn Bracken in L.A. Confidential?: Kim Basinger",
-		Year:      "1997",
+		Trivia: Trivia{
+			{Question: "What iconic L.A. landmark is featured in the film as the setting for the bloody \"Bloody Christmas\" incident?", Answer: "The Christmas-themed Central Booking lobby of the L.A. Police Department"},
+			{Question: "What is the name of the call girl who bears a striking resemblance to Veronica Lake and is crucial to the central plot?", Answer: "Lynn Bracken"},
+			{Question: "Which actor won the Academy Award for Best Supporting Actress for their portrayal of Lynn Bracken in L.A. Confidential?", Answer: "Kim Basinger"},
+		},
+		Year: "1997",
 	},
 	{Cast: []string{
 		"Humphrey Bogart",
@@ -32,8 +36,12 @@ var TestMovies = []Movie{
 		Review:    " An undisputed masterpiece and perhaps Hollywood's quintessential statement on love and romance, ",
 		Synopsis:  "Rick Blaine (Humphrey Bogart), who owns a nightclub in Casablanca, discovers his old flame Ilsa (Ingrid Bergman) is in town...",
 		Title:     "Casablanca",
-		Trivia:    ":What song, frequently requested by Ilsa Lund, becomes a symbol of her past relationship with Rick and a source of conflict?:\"As Time Goes By\"&:&:What is the name of the gambling establishment owned by Rick Blaine in Casablanca?:Rick's Café Américain&:&:What are the \"letters of transit\" that everyone in Casablanca is so desperate to obtain?:They are documents that allow the bearer to travel freely to neutral Portugal and then on to the United States, effectively escaping Nazi-occupied Europe.",
-		Year:      "1942",
+		Trivia: Trivia{
+			{Question: "What song, frequently requested by Ilsa Lund, becomes a symbol of her past relationship with Rick and a source of conflict?", Answer: "\"As Time Goes By\""},
+			{Question: "What is the name of the gambling establishment owned by Rick Blaine in Casablanca?", Answer: "Rick's Café Américain"},
+			{Question: "What are the \"letters of transit\" that everyone in Casablanca is so desperate to obtain?", Answer: "They are documents that allow the bearer to travel freely to neutral Portugal and then on to the United States, effectively escaping Nazi-occupied Europe."},
+		},
+		Year: "1942",
 	},
 }
 
@@ -0,0 +1,121 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"blockbuster/api/constants"
+)
+
+// Legacy trivia was stored as one string of entries separated by triviaSeparator, each a
+// question and its answer joined by triviaAnswerPrefix, with the first entry led by a colon:
+// ":Q1?:A1&:&:Q2?:A2".
+const (
+	triviaSeparator    = "&:&:"
+	triviaAnswerPrefix = "?:"
+)
+
+// TriviaItem is one question about a movie and its answer.
+type TriviaItem struct {
+	Question string `json:"question" dynamodbav:"question"`
+	Answer   string `json:"answer" dynamodbav:"answer"`
+}
+
+// Trivia is a movie's trivia, stored as a list of question and answer maps. Movies not yet
+// migrated still hold the legacy delimited string, which is parsed on read.
+type Trivia []TriviaItem
+
+// ParseTrivia splits legacy delimited trivia into its questions and answers. Entries without
+// an answer are left out and reported in the error, so callers can keep what was parsed.
+func ParseTrivia(raw string) (Trivia, error) {
+	var trivia Trivia
+	var malformed []string
+	for _, entry := range strings.Split(raw, triviaSeparator) {
+		entry = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(entry), ":"))
+		if entry == "" {
+			continue
+		}
+		question, answer, ok := strings.Cut(entry, triviaAnswerPrefix)
+		if !ok || strings.TrimSpace(question) == "" || strings.TrimSpace(answer) == "" {
+			malformed = append(malformed, entry)
+			continue
+		}
+		trivia = append(trivia, TriviaItem{
+			Question: strings.TrimSpace(question) + "?",
+			Answer:   strings.TrimSpace(answer),
+		})
+	}
+	if len(malformed) > 0 {
+		return trivia, fmt.Errorf("%d malformed trivia entries: %q", len(malformed), malformed)
+	}
+	return trivia, nil
+}
+
+// Normalize trims the whitespace around every question and answer.
+func (t Trivia) Normalize() Trivia {
+	out := make(Trivia, len(t))
+	for i, item := range t {
+		out[i] = TriviaItem{Question: strings.TrimSpace(item.Question), Answer: strings.TrimSpace(item.Answer)}
+	}
+	return out
+}
+
+// Validate checks trivia before it is written: between one and MAX_TRIVIA_ITEMS items, each a
+// distinct question ending in a question mark with a non-empty answer, neither over its
+// length limit.
+func (t Trivia) Validate() error {
+	if len(t) == 0 {
+		return errors.New("trivia needs at least one question")
+	}
+	if len(t) > constants.MAX_TRIVIA_ITEMS {
+		return fmt.Errorf("trivia has %d questions; at most %d are allowed", len(t), constants.MAX_TRIVIA_ITEMS)
+	}
+	seen := make(map[string]bool, len(t))
+	for i, item := range t {
+		switch {
+		case item.Question == "":
+			return fmt.Errorf("question %d is empty", i+1)
+		case !strings.HasSuffix(item.Question, "?"):
+			return fmt.Errorf("question %d must end with a question mark", i+1)
+		case utf8.RuneCountInString(item.Question) > constants.MAX_TRIVIA_QUESTION_LENGTH:
+			return fmt.Errorf("question %d is longer than %d characters", i+1, constants.MAX_TRIVIA_QUESTION_LENGTH)
+		case item.Answer == "":
+			return fmt.Errorf("answer %d is empty", i+1)
+		case utf8.RuneCountInString(item.Answer) > constants.MAX_TRIVIA_ANSWER_LENGTH:
+			return fmt.Errorf("answer %d is longer than %d characters", i+1, constants.MAX_TRIVIA_ANSWER_LENGTH)
+		}
+		key := strings.ToLower(item.Question)
+		if seen[key] {
+			return fmt.Errorf("question %d is a duplicate", i+1)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// UnmarshalDynamoDBAttributeValue reads the list written for Trivia, or parses legacy
+// delimited trivia. Malformed legacy entries are dropped rather than failing the whole movie.
+func (t *Trivia) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberNULL:
+		return nil
+	case *types.AttributeValueMemberS:
+		parsed, _ := ParseTrivia(v.Value)
+		*t = parsed
+		return nil
+	case *types.AttributeValueMemberL:
+		var items []TriviaItem
+		if err := attributevalue.Unmarshal(v, &items); err != nil {
+			return fmt.Errorf("trivia: %w", err)
+		}
+		*t = items
+		return nil
+	default:
+		return fmt.Errorf("trivia must be a list or a string, got %T", av)
+	}
+}
@@ -0,0 +1,104 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"blockbuster/api/data"
+)
+
+var errReadOnly = errors.New("the evaluation catalog is read only")
+
+// Catalog serves a fixed set of movies from memory in place of the movies table, so the rec
+// engine can be replayed without touching Dynamo. Every movie is in stock, so evaluations
+// measure the ranking rather than the shelves on the day.
+type Catalog struct {
+	movies map[string]data.Movie
+}
+
+func NewCatalog(movies []data.Movie) *Catalog {
+	c := &Catalog{movies: make(map[string]data.Movie, len(movies))}
+	for _, movie := range movies {
+		movie.Inventory = max(movie.Inventory, 1)
+		c.movies[movie.ID] = movie
+	}
+	return c
+}
+
+// Metrics returns every movie's metrics keyed by movie ID.
+func (c *Catalog) Metrics() map[string]data.MovieMetrics {
+	metrics := make(map[string]data.MovieMetrics, len(c.movies))
+	for id, movie := range c.movies {
+		metrics[id] = movie.Metrics
+	}
+	return metrics
+}
+
+func (c *Catalog) Size() int {
+	return len(c.movies)
+}
+
+// GetMoviesByPage ignores the page and returns the whole catalog.
+func (c *Catalog) GetMoviesByPage(ctx context.Context, page string, purpose string) ([]data.Movie, error) {
+	movies := make([]data.Movie, 0, len(c.movies))
+	for _, movie := range c.movies {
+		movies = append(movies, movie)
+	}
+	return movies, nil
+}
+
+func (c *Catalog) GetMovieByID(ctx context.Context, movieID string, forCart bool) (data.Movie, error) {
+	movie, ok := c.movies[movieID]
+	if !ok {
+		return data.Movie{}, fmt.Errorf("movie %s is not in the catalog", movieID)
+	}
+	return movie, nil
+}
+
+// GetMoviesByID returns the movies found, skipping unknown IDs like BatchGetItem does.
+func (c *Catalog) GetMoviesByID(ctx context.Context, movieIDs []string, forCart bool) ([]data.Movie, error) {
+	movies := make([]data.Movie, 0, len(movieIDs))
+	for _, mid := range movieIDs {
+		if movie, ok := c.movies[mid]; ok {
+			movies = append(movies, movie)
+		}
+	}
+	return movies, nil
+}
+
+func (c *Catalog) GetTrivia(ctx context.Context, movieID string) (data.MovieTrivia, error) {
+	return data.MovieTrivia{}, errors.New("the evaluation catalog has no trivia")
+}
+
+func (c *Catalog) GetMoviesTrivia(ctx context.Context, movieIDs []string) ([]data.Movie, error) {
+	return nil, errors.New("the evaluation catalog has no trivia")
+}
+
+func (c *Catalog) GetMovieMetrics(ctx context.Context, movieID string) (data.MovieMetrics, error) {
+	movie, ok := c.movies[movieID]
+	if !ok || movie.Metrics == (data.MovieMetrics{}) {
+		return data.MovieMetrics{}, fmt.Errorf("no metrics for %s in the catalog", movieID)
+	}
+	return movie.Metrics, nil
+}
+
+func (c *Catalog) Rent(ctx context.Context, movie data.Movie) (bool, error) {
+	return false, errReadOnly
+}
+
+func (c *Catalog) Return(ctx context.Context, movie data.Movie) (bool, error) {
+	return false, errReadOnly
+}
+
+func (c *Catalog) SetCentroid(ctx context.Context, movieID string, centroid int) error {
+	return errReadOnly
+}
+
+func (c *Catalog) SetMetrics(ctx context.Context, movieID string, metrics data.MovieMetrics, centroid int) error {
+	return errReadOnly
+}
+
+func (c *Catalog) SetTrivia(ctx context.Context, movieID string, trivia data.Trivia) error {
+	return errReadOnly
+}
@@ -0,0 +1,285 @@
+package evaluation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+	"blockbuster/api/utils"
+)
+
+// Engine is the part of the rec engine an evaluation replays. *repos.MemberRepo satisfies it.
+type Engine interface {
+	GetIniitialVotingSlate(ctx context.Context, username string, seed int64) ([]string, error)
+	IterateRecommendationVoting(ctx context.Context, currentMood data.MovieMetrics, iteration, numPrevSelected int, movieIDs []string, seed int64) (data.MovieMetrics, []string, error)
+	GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics, query repos.FinalPicksQuery) ([]data.FinalPick, error)
+}
+
+// Options configures an evaluation. K picks are scored per run. HoldOut is how many of each
+// member's most recent rentals are hidden from the replay. Synthetic users are drawn
+// UsersPerCentroid at a time around every centroid with gaussian Noise per dimension; their
+// Relevant nearest movies are the ones they would like, and they vote for the
+// VotesPerRound slate movies nearest their taste. The same Seed draws the same users and the
+// same voting sessions.
+type Options struct {
+	K                int
+	HoldOut          int
+	UsersPerCentroid int
+	Noise            float64
+	Relevant         int
+	VotesPerRound    int
+	Diversity        float64
+	Seed             int64
+}
+
+// Report averages the scores of every run of a scenario. Coverage is the share of the
+// catalog recommended at least once; Skipped counts runs that produced no picks to score.
+type Report struct {
+	Scenario  string
+	Runs      int
+	Skipped   int
+	K         int
+	Precision float64
+	Recall    float64
+	Coverage  float64
+	Novelty   float64
+	Diversity float64
+}
+
+type Evaluator struct {
+	engine    Engine
+	catalog   *Catalog
+	metrics   map[string]data.MovieMetrics
+	histories [][]string
+	rentals   map[string]int
+	opts      Options
+}
+
+// NewEvaluator replays engine over the catalog. histories are the members' rentals, oldest
+// first, and also set how popular each movie is for Novelty.
+func NewEvaluator(engine Engine, catalog *Catalog, histories [][]string, opts Options) *Evaluator {
+	rentals := make(map[string]int)
+	for _, history := range histories {
+		for _, mid := range history {
+			rentals[mid]++
+		}
+	}
+	return &Evaluator{
+		engine:    engine,
+		catalog:   catalog,
+		metrics:   catalog.Metrics(),
+		histories: histories,
+		rentals:   rentals,
+		opts:      opts,
+	}
+}
+
+// HoldOut hides each member's last opts.HoldOut rentals, asks for final picks for the mood
+// of the rest and scores the picks against the hidden rentals. Members with too short a
+// history are skipped.
+func (e *Evaluator) HoldOut(ctx context.Context) Report {
+	c := e.newCollector("holdout")
+	for _, history := range e.histories {
+		if len(history) <= e.opts.HoldOut {
+			c.report.Skipped++
+			continue
+		}
+		split := len(history) - e.opts.HoldOut
+		train, test := history[:split], history[split:]
+		mood, ok := e.profile(train)
+		if !ok {
+			c.report.Skipped++
+			continue
+		}
+
+		// Rented movies can't be excluded by username offline, so ask for enough picks to
+		// drop them and still have K left.
+		query := repos.FinalPicksQuery{NumPicks: e.opts.K + len(train), Diversity: e.opts.Diversity, Voted: train}
+		picks, err := e.engine.GetVotingFinalPicks(ctx, mood, query)
+		if len(picks) == 0 {
+			utils.LogError("no final picks for held out history", err)
+			c.report.Skipped++
+			continue
+		}
+		var recommended []string
+		for _, pick := range picks {
+			if !slices.Contains(train, pick.MovieID) {
+				recommended = append(recommended, pick.MovieID)
+			}
+		}
+		c.add(recommended[:min(e.opts.K, len(recommended))], toSet(test))
+	}
+	return c.finish()
+}
+
+// Synthetic runs a full voting session for synthetic users drawn around every centroid and
+// scores the final picks against the movies nearest each user's taste.
+func (e *Evaluator) Synthetic(ctx context.Context, centroids map[int]data.MovieMetrics) Report {
+	c := e.newCollector("synthetic")
+	rng := rand.New(rand.NewSource(e.opts.Seed))
+	ids := make([]int, 0, len(centroids))
+	for id := range centroids {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		for range e.opts.UsersPerCentroid {
+			taste := perturb(centroids[id], e.opts.Noise, rng)
+			picks, err := e.simulateSession(ctx, taste, rng.Int63())
+			if err != nil {
+				utils.LogError(fmt.Sprintf("simulated session around centroid %d failed", id), err)
+				c.report.Skipped++
+				continue
+			}
+			c.add(picks, toSet(e.nearest(taste, e.opts.Relevant)))
+		}
+	}
+	return c.finish()
+}
+
+// simulateSession votes through every iteration as a user with taste would, always picking
+// the slate movies nearest it, and returns the final picks. seed draws the session's slates.
+func (e *Evaluator) simulateSession(ctx context.Context, taste data.MovieMetrics, seed int64) ([]string, error) {
+	slate, err := e.engine.GetIniitialVotingSlate(ctx, "", seed)
+	if len(slate) == 0 {
+		return nil, fmt.Errorf("no initial slate: %w", err)
+	}
+	var mood data.MovieMetrics
+	var voted []string
+	for iteration := 0; iteration < constants.MAX_VOTING_ITERATIONS; iteration++ {
+		votes := e.favourites(slate, taste, e.opts.VotesPerRound)
+		if len(votes) == 0 {
+			break
+		}
+		newMood, next, err := e.engine.IterateRecommendationVoting(ctx, mood, iteration, len(voted), votes, seed)
+		if len(next) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+		mood, slate = newMood, next
+		voted = append(voted, votes...)
+	}
+	if len(voted) == 0 {
+		return nil, errors.New("the simulated user found nothing to vote for")
+	}
+
+	picks, err := e.engine.GetVotingFinalPicks(ctx, mood, repos.FinalPicksQuery{NumPicks: e.opts.K, Diversity: e.opts.Diversity, Voted: voted})
+	if len(picks) == 0 {
+		return nil, fmt.Errorf("no final picks: %w", err)
+	}
+	recommended := make([]string, len(picks))
+	for i, pick := range picks {
+		recommended[i] = pick.MovieID
+	}
+	return recommended, nil
+}
+
+// profile averages the metrics of the movies in history.
+func (e *Evaluator) profile(history []string) (data.MovieMetrics, bool) {
+	var sum data.MovieMetrics
+	count := 0
+	for _, mid := range history {
+		if metrics, ok := e.metrics[mid]; ok && metrics != (data.MovieMetrics{}) {
+			sum = utils.AccumulateMovieMetricsWithWeight(sum, metrics, 1)
+			count++
+		}
+	}
+	return utils.AverageMetrics(sum, count), count > 0
+}
+
+// favourites returns the n movies in slate nearest taste.
+func (e *Evaluator) favourites(slate []string, taste data.MovieMetrics, n int) []string {
+	var known []string
+	for _, mid := range slate {
+		if _, ok := e.metrics[mid]; ok {
+			known = append(known, mid)
+		}
+	}
+	return e.closest(known, taste, n)
+}
+
+// nearest returns the n catalog movies nearest taste.
+func (e *Evaluator) nearest(taste data.MovieMetrics, n int) []string {
+	ids := make([]string, 0, len(e.metrics))
+	for mid, metrics := range e.metrics {
+		if metrics != (data.MovieMetrics{}) {
+			ids = append(ids, mid)
+		}
+	}
+	return e.closest(ids, taste, n)
+}
+
+func (e *Evaluator) closest(ids []string, taste data.MovieMetrics, n int) []string {
+	sort.Slice(ids, func(i, j int) bool {
+		di, dj := utils.MetricDistance(taste, e.metrics[ids[i]]), utils.MetricDistance(taste, e.metrics[ids[j]])
+		if di != dj {
+			return di < dj
+		}
+		return ids[i] < ids[j]
+	})
+	return ids[:min(n, len(ids))]
+}
+
+// perturb draws a taste around centroid with gaussian noise, keeping every dimension
+// non-negative like real metrics.
+func perturb(centroid data.MovieMetrics, noise float64, rng *rand.Rand) data.MovieMetrics {
+	v := centroid.Vector()
+	for i := range v {
+		v[i] = max(0, v[i]+rng.NormFloat64()*noise)
+	}
+	return v.Metrics()
+}
+
+func toSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, mid := range ids {
+		set[mid] = true
+	}
+	return set
+}
+
+// collector sums the scores of every run and remembers which movies were recommended.
+type collector struct {
+	e           *Evaluator
+	report      Report
+	recommended map[string]bool
+}
+
+func (e *Evaluator) newCollector(scenario string) *collector {
+	return &collector{e: e, report: Report{Scenario: scenario, K: e.opts.K}, recommended: make(map[string]bool)}
+}
+
+func (c *collector) add(recommended []string, relevant map[string]bool) {
+	c.report.Runs++
+	c.report.Precision += PrecisionAtK(recommended, relevant, c.e.opts.K)
+	c.report.Recall += RecallAtK(recommended, relevant, c.e.opts.K)
+	c.report.Novelty += Novelty(recommended, c.e.rentals, len(c.e.histories))
+	c.report.Diversity += IntraListDiversity(recommended, c.e.metrics)
+	for _, mid := range recommended {
+		c.recommended[mid] = true
+	}
+}
+
+func (c *collector) finish() Report {
+	report := c.report
+	if report.Runs > 0 {
+		runs := float64(report.Runs)
+		report.Precision /= runs
+		report.Recall /= runs
+		report.Novelty /= runs
+		report.Diversity /= runs
+	}
+	if c.e.catalog.Size() > 0 {
+		report.Coverage = float64(len(c.recommended)) / float64(c.e.catalog.Size())
+	}
+	return report
+}
@@ -0,0 +1,116 @@
+package evaluation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+	"blockbuster/api/utils"
+)
+
+// nearestEngine is an ideal engine: the mood is the last movie voted for and the final
+// picks are the catalog movies nearest the mood.
+type nearestEngine struct {
+	catalog *Catalog
+	queries []repos.FinalPicksQuery
+}
+
+func (e *nearestEngine) GetIniitialVotingSlate(ctx context.Context, username string, seed int64) ([]string, error) {
+	return e.ids(), nil
+}
+
+func (e *nearestEngine) IterateRecommendationVoting(ctx context.Context, currentMood data.MovieMetrics, iteration, numPrevSelected int, movieIDs []string, seed int64) (data.MovieMetrics, []string, error) {
+	mood, err := e.catalog.GetMovieMetrics(ctx, movieIDs[len(movieIDs)-1])
+	return mood, e.ids(), err
+}
+
+func (e *nearestEngine) GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics, query repos.FinalPicksQuery) ([]data.FinalPick, error) {
+	e.queries = append(e.queries, query)
+	ids := e.ids()
+	metrics := e.catalog.Metrics()
+	sort.SliceStable(ids, func(i, j int) bool {
+		return utils.MetricDistance(mood, metrics[ids[i]]) < utils.MetricDistance(mood, metrics[ids[j]])
+	})
+	var picks []data.FinalPick
+	for _, mid := range ids[:min(query.NumPicks, len(ids))] {
+		picks = append(picks, data.FinalPick{MovieID: mid})
+	}
+	return picks, nil
+}
+
+func (e *nearestEngine) ids() []string {
+	var ids []string
+	for mid := range e.catalog.Metrics() {
+		ids = append(ids, mid)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+var clusterCenters = map[int]data.MovieMetrics{
+	0: {Horror: 90, Suspense: 80},
+	1: {Comedy: 85, Romance: 70},
+}
+
+// clusteredCatalog puts perCenter movies at increasing distances from each center.
+func clusteredCatalog(perCenter int) *Catalog {
+	var movies []data.Movie
+	for c, center := range clusterCenters {
+		for i := 0; i < perCenter; i++ {
+			v := center.Vector()
+			v[data.NumMetricDimensions-1] += float64(i)
+			movies = append(movies, data.Movie{ID: fmt.Sprintf("c%d_%d", c, i), Metrics: v.Metrics()})
+		}
+	}
+	return NewCatalog(movies)
+}
+
+func TestHoldOut(t *testing.T) {
+	catalog := clusteredCatalog(5)
+	engine := &nearestEngine{catalog: catalog}
+	histories := [][]string{
+		{"c0_0", "c0_1", "c0_2"},
+		{"c1_0"},
+	}
+	evaluator := NewEvaluator(engine, catalog, histories, Options{K: 2, HoldOut: 1})
+
+	report := evaluator.HoldOut(context.Background())
+
+	assert.Equal(t, "holdout", report.Scenario)
+	assert.Equal(t, 1, report.Runs)
+	assert.Equal(t, 1, report.Skipped, "a history no longer than the holdout can't be replayed")
+	// The rented movies are dropped from the picks, leaving c0_2 and c0_3.
+	assert.Equal(t, []string{"c0_0", "c0_1"}, engine.queries[0].Voted)
+	assert.Equal(t, 4, engine.queries[0].NumPicks)
+	assert.Equal(t, 0.5, report.Precision)
+	assert.Equal(t, 1.0, report.Recall)
+	assert.Equal(t, 0.2, report.Coverage)
+	assert.Greater(t, report.Diversity, 0.0)
+}
+
+func TestSynthetic(t *testing.T) {
+	catalog := clusteredCatalog(10)
+	engine := &nearestEngine{catalog: catalog}
+	opts := Options{K: 5, UsersPerCentroid: 3, Relevant: 5, VotesPerRound: 1, Seed: 1}
+	evaluator := NewEvaluator(engine, catalog, nil, opts)
+
+	report := evaluator.Synthetic(context.Background(), clusterCenters)
+
+	assert.Equal(t, "synthetic", report.Scenario)
+	assert.Equal(t, 6, report.Runs)
+	assert.Zero(t, report.Skipped)
+	// Noiseless users vote for the movie on their centroid, whose neighbours are theirs.
+	assert.Equal(t, 1.0, report.Precision)
+	assert.Equal(t, 1.0, report.Recall)
+	assert.Equal(t, 0.5, report.Coverage)
+
+	opts.Noise = 20
+	noisy := NewEvaluator(engine, catalog, nil, opts)
+	first := noisy.Synthetic(context.Background(), clusterCenters)
+	assert.Equal(t, first, noisy.Synthetic(context.Background(), clusterCenters), "the same seed draws the same users")
+}
@@ -0,0 +1,75 @@
+// Package evaluation measures the rec engine offline. It replays members' rental histories
+// and simulated voting sessions against in-memory caches and scores the final picks, so
+// changes to voting or final picks can be compared before they ship.
+package evaluation
+
+import (
+	"math"
+
+	"blockbuster/api/data"
+	"blockbuster/api/utils"
+)
+
+// PrecisionAtK is the share of the first k recommendations that are relevant. Fewer than k
+// recommendations count the missing ones as misses.
+func PrecisionAtK(recommended []string, relevant map[string]bool, k int) float64 {
+	if k <= 0 {
+		return 0
+	}
+	return float64(hits(recommended, relevant, k)) / float64(k)
+}
+
+// RecallAtK is the share of the relevant movies found in the first k recommendations.
+func RecallAtK(recommended []string, relevant map[string]bool, k int) float64 {
+	if len(relevant) == 0 {
+		return 0
+	}
+	return float64(hits(recommended, relevant, k)) / float64(len(relevant))
+}
+
+func hits(recommended []string, relevant map[string]bool, k int) int {
+	n := 0
+	for _, mid := range recommended[:min(k, len(recommended))] {
+		if relevant[mid] {
+			n++
+		}
+	}
+	return n
+}
+
+// Novelty is the mean self-information, in bits, of the recommended movies: -log2 of the
+// share of members who rented each one. Counts are smoothed so movies nobody rented score
+// highest rather than infinitely high.
+func Novelty(recommended []string, rentals map[string]int, members int) float64 {
+	if len(recommended) == 0 {
+		return 0
+	}
+	var bits float64
+	for _, mid := range recommended {
+		bits -= math.Log2(float64(rentals[mid]+1) / float64(members+1))
+	}
+	return bits / float64(len(recommended))
+}
+
+// IntraListDiversity is the mean euclidean distance between every pair of recommended
+// movies. Movies without metrics are left out.
+func IntraListDiversity(recommended []string, metrics map[string]data.MovieMetrics) float64 {
+	var known []data.MovieMetrics
+	for _, mid := range recommended {
+		if m, ok := metrics[mid]; ok {
+			known = append(known, m)
+		}
+	}
+	var total float64
+	pairs := 0
+	for i := range known {
+		for j := i + 1; j < len(known); j++ {
+			total += math.Sqrt(utils.MetricDistance(known[i], known[j]))
+			pairs++
+		}
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return total / float64(pairs)
+}
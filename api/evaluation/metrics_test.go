@@ -0,0 +1,47 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/data"
+)
+
+func TestPrecisionAndRecallAtK(t *testing.T) {
+	relevant := map[string]bool{"a": true, "c": true, "x": true, "y": true}
+
+	assert.Equal(t, 0.5, PrecisionAtK([]string{"a", "b", "c", "d"}, relevant, 4))
+	assert.Equal(t, 0.5, RecallAtK([]string{"a", "b", "c", "d"}, relevant, 4))
+	assert.Equal(t, 1.0, PrecisionAtK([]string{"a", "b", "c", "d"}, relevant, 1))
+	assert.Equal(t, 0.25, RecallAtK([]string{"a", "b", "c", "d"}, relevant, 1))
+	// Missing recommendations count as misses.
+	assert.Equal(t, 0.25, PrecisionAtK([]string{"a"}, relevant, 4))
+	assert.Zero(t, PrecisionAtK([]string{"a"}, relevant, 0))
+	assert.Zero(t, RecallAtK([]string{"a"}, nil, 4))
+}
+
+func TestNovelty(t *testing.T) {
+	rentals := map[string]int{"popular": 3, "niche": 0}
+
+	assert.Zero(t, Novelty([]string{"popular"}, rentals, 3))
+	assert.Equal(t, 2.0, Novelty([]string{"niche"}, rentals, 3))
+	assert.Equal(t, 1.0, Novelty([]string{"popular", "niche"}, rentals, 3))
+	assert.Zero(t, Novelty(nil, rentals, 3))
+}
+
+func TestIntraListDiversity(t *testing.T) {
+	metrics := map[string]data.MovieMetrics{
+		"a": {Action: 0},
+		"b": {Action: 3, Comedy: 4},
+		"c": {Action: 6, Comedy: 8},
+	}
+
+	assert.Equal(t, 5.0, IntraListDiversity([]string{"a", "b"}, metrics))
+	assert.InDelta(t, 20.0/3, IntraListDiversity([]string{"a", "b", "c"}, metrics), 1e-9)
+	// Unknown movies are left out rather than counted as distance zero.
+	assert.Equal(t, 5.0, IntraListDiversity([]string{"a", "missing", "b"}, metrics))
+	assert.Zero(t, IntraListDiversity([]string{"a"}, metrics))
+	assert.False(t, math.IsNaN(IntraListDiversity(nil, metrics)))
+}
@@ -9,4 +9,6 @@ var (
 	movieTitleArg = &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""}
 	movieIDsArg   = &graphql.ArgumentConfig{Type: graphql.NewList(graphql.ID), DefaultValue: []string{}}
 	directorArg   = &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""}
+	yearArg       = &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""}
+	nameArg       = &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}
 )
@@ -1,12 +1,14 @@
 package gql
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/graphql-go/graphql"
 
 	"blockbuster/api/constants"
 	"blockbuster/api/data"
+	graphsearch "blockbuster/api/graph_search"
 )
 
 var GetKevinBaconField = &graphql.Field{
@@ -14,86 +16,132 @@ var GetKevinBaconField = &graphql.Field{
 	Args: graphql.FieldConfigArgument{
 		constants.STAR:     starArg,
 		constants.TITLE:    movieTitleArg,
+		constants.YEAR:     yearArg,
+		constants.MOVIE_ID: movieIDArg,
 		constants.DIRECTOR: directorArg,
 		constants.DEPTH:    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+		constants.LIMIT:    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: constants.KEVIN_BACON_PAGE_SIZE},
+		constants.OFFSET:   &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
 	},
 	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 		star := p.Args[constants.STAR].(string)
 		movieTitle := p.Args[constants.TITLE].(string)
+		year, _ := p.Args[constants.YEAR].(string)
+		movieID, _ := p.Args[constants.MOVIE_ID].(string)
 		director := p.Args[constants.DIRECTOR].(string)
-		toSearch := buildToSearch(star, movieTitle, director)
-		if len(toSearch) == 0 && movieTitle == "" {
-			msg := "the KevinBacon search requires at least one star, title, or director"
+		if star == "" && movieTitle == "" && movieID == "" && director == "" {
+			msg := "the KevinBacon search requires at least one star, title, movieID, or director"
 			return nil, getFormattedError(msg, http.StatusBadRequest)
 		}
 
-		depth := min(p.Args[constants.DEPTH].(int), 10)
-		stars := make(map[string]bool)
+		var err error
 		if star != "" {
-			stars[star] = true
-		}
-		movieTitles := make(map[string]bool)
-		if movieTitle != "" {
-			movieTitles[movieTitle] = true
+			if star, err = resolveName(star, movieGraph.ResolveStar); err != nil {
+				return nil, err
+			}
 		}
-		directors := make(map[string]bool)
 		if director != "" {
-			directors[director] = true
-		}
-		for _, s := range toSearch {
-			if _, found := stars[s]; found {
-				continue
+			if director, err = resolveName(director, movieGraph.ResolveDirector); err != nil {
+				return nil, err
 			}
-			KevinBaconInOut(s, stars, movieTitles, directors, depth)
 		}
 
-		var movies []data.Movie
-		for title := range movieTitles {
-			movie, err := movieGraph.GetMovieFromTitle(title)
-			if err != nil {
-				continue
+		seedMovies, err := findSeedMovies(movieTitle, year, movieID)
+		if err != nil && star == "" && director == "" {
+			return nil, getFormattedError(err.Error(), http.StatusNotFound)
+		}
+
+		depth := min(p.Args[constants.DEPTH].(int), constants.MAX_KEVIN_BACON_DEPTH)
+		limit, ok := p.Args[constants.LIMIT].(int)
+		if !ok {
+			limit = constants.KEVIN_BACON_PAGE_SIZE
+		}
+		offset, _ := p.Args[constants.OFFSET].(int)
+		if limit <= 0 || offset < 0 {
+			return nil, getFormattedError("limit must be positive and offset must not be negative", http.StatusBadRequest)
+		}
+		limit = min(limit, constants.MAX_KEVIN_BACON_PAGE_SIZE)
+
+		seeds := graphsearch.KevinBaconSeeds{}
+		if star != "" {
+			seeds.Stars = append(seeds.Stars, star)
+		}
+		for _, movie := range seedMovies {
+			seeds.MovieIDs = append(seeds.MovieIDs, movie.ID)
+		}
+		if director != "" {
+			seeds.Directors = append(seeds.Directors, director)
+		}
+		nodes := movieGraph.KevinBaconLayers(seeds, depth)
+		page := nodes[min(offset, len(nodes)):min(offset+limit, len(nodes))]
+
+		stars, movies, directors := []string{}, []data.Movie{}, []string{}
+		for _, node := range page {
+			switch node.Type {
+			case constants.STAR:
+				stars = append(stars, node.Name)
+			case constants.DIRECTOR:
+				directors = append(directors, node.Name)
+			case constants.MOVIE:
+				movie, err := movieGraph.GetMovieByID(node.ID)
+				if err != nil {
+					continue
+				}
+				movies = append(movies, movie)
 			}
-			movies = append(movies, movie)
 		}
 
 		return map[string]interface{}{
 			constants.STAR:            star,
-			constants.STARS:           SetToList(stars),
+			constants.STARS:           stars,
 			constants.TOTAL_STARS:     movieGraph.TotalStars(),
 			constants.MOVIES:          movies,
 			constants.TOTAL_MOVIES:    movieGraph.TotalMovies(),
-			constants.DIRECTORS:       SetToList(directors),
+			constants.DIRECTORS:       directors,
 			constants.TOTAL_DIRECTORS: movieGraph.TotalDirectors(),
+			constants.NODES:           page,
+			constants.TOTAL_NODES:     len(nodes),
+			constants.OFFSET:          offset,
+			constants.LIMIT:           limit,
+			constants.HAS_MORE:        offset+len(page) < len(nodes),
 		}, nil
 	},
 }
 
 func KevinBacon(start string, depth int) ([]string, []string, []string) {
 	stars := make(map[string]bool)
-	movieTitles := make(map[string]bool)
+	movieIDs := make(map[string]bool)
 	directors := make(map[string]bool)
-	KevinBaconInOut(start, stars, movieTitles, directors, depth)
-	return SetToList(stars), SetToList(movieTitles), SetToList(directors)
+	KevinBaconInOut(start, stars, movieIDs, directors, depth)
+	return SetToList(stars), SetToList(movieIDs), SetToList(directors)
 }
 
-func KevinBaconInOut(star string, stars, movieTitles, directors map[string]bool, depth int) {
-	movieGraph.BFS(star, stars, movieTitles, directors, depth)
+func KevinBaconInOut(star string, stars, movieIDs, directors map[string]bool, depth int) {
+	movieGraph.BFS(star, stars, movieIDs, directors, depth)
 }
 
-func buildToSearch(star string, movieTitle string, director string) []string {
-	var toSearch []string
-	if star != "" {
-		toSearch = append(toSearch, star)
-	}
-	if director != "" {
-		toSearch = append(toSearch, movieGraph.GetDirectedActors(director)...)
+// findSeedMovies resolves the movie arguments of a KevinBacon search. A movieID is exact,
+// while a title may match several movies (remakes) unless narrowed by year.
+func findSeedMovies(movieTitle, year, movieID string) ([]data.Movie, error) {
+	var movies []data.Movie
+	var errs []error
+	if movieID != "" {
+		movie, err := movieGraph.GetMovieByID(movieID)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			movies = append(movies, movie)
+		}
 	}
 	if movieTitle != "" {
-		movie, err := movieGraph.GetMovieFromTitle(movieTitle)
+		matches, err := movieGraph.GetMoviesFromTitle(movieTitle, year)
 		if err != nil {
-			return toSearch
+			errs = append(errs, err)
 		}
-		toSearch = append(toSearch, movie.Cast...)
+		movies = append(movies, matches...)
+	}
+	if len(movies) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
 	}
-	return toSearch
+	return movies, nil
 }
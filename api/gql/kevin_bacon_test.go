@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
@@ -18,9 +19,16 @@ func TestGetKevinBaconField_Resolve_Valid(t *testing.T) {
 	mockGraph := new(graphsearch.MockMovieGraph)
 	gql.SetMovieGraph(mockGraph)
 
-	mockGraph.On("GetDirectedActors", "Herbert Ross").Return([]string{"Kevin Bacon"})
-	mockGraph.On("GetMovieFromTitle", "Footloose").Return(data.Movie{ID: "1", Title: "Footloose"}, nil)
-	mockGraph.On("BFS", "Kevin Bacon", mock.Anything, mock.Anything, mock.Anything, 2).Return()
+	mockGraph.On("ResolveStar", "Kevin Bacon").Return("Kevin Bacon", nil)
+	mockGraph.On("ResolveDirector", "Herbert Ross").Return("Herbert Ross", nil)
+	mockGraph.On("GetMoviesFromTitle", "Footloose", "").Return([]data.Movie{{ID: "1", Title: "Footloose"}}, nil)
+	mockGraph.On("GetMovieByID", "1").Return(data.Movie{ID: "1", Title: "Footloose"}, nil)
+	seeds := graphsearch.KevinBaconSeeds{Stars: []string{"Kevin Bacon"}, MovieIDs: []string{"1"}, Directors: []string{"Herbert Ross"}}
+	mockGraph.On("KevinBaconLayers", seeds, 2).Return([]graphsearch.KevinBaconNode{
+		{ID: "Kevin Bacon", Name: "Kevin Bacon", Type: constants.STAR},
+		{ID: "1", Name: "Footloose", Type: constants.MOVIE},
+		{ID: "Herbert Ross", Name: "Herbert Ross", Type: constants.DIRECTOR},
+	})
 	mockGraph.On("TotalStars").Return(1)
 	mockGraph.On("TotalMovies").Return(1)
 	mockGraph.On("TotalDirectors").Return(1)
@@ -43,6 +51,68 @@ func TestGetKevinBaconField_Resolve_Valid(t *testing.T) {
 	assert.ElementsMatch(t, []string{"Kevin Bacon"}, resp[constants.STARS])
 	assert.ElementsMatch(t, []string{"Footloose"}, extractTitles(resp[constants.MOVIES].([]data.Movie)))
 	assert.ElementsMatch(t, []string{"Herbert Ross"}, resp[constants.DIRECTORS])
+	assert.Equal(t, 3, resp[constants.TOTAL_NODES])
+	assert.Equal(t, false, resp[constants.HAS_MORE])
+}
+
+func TestGetKevinBaconField_Resolve_Paginated(t *testing.T) {
+	mockGraph := new(graphsearch.MockMovieGraph)
+	gql.SetMovieGraph(mockGraph)
+
+	mockGraph.On("ResolveStar", "Kevin Bacon").Return("Kevin Bacon", nil)
+	mockGraph.On("GetMovieByID", "1").Return(data.Movie{ID: "1", Title: "Footloose"}, nil)
+	mockGraph.On("KevinBaconLayers", mock.Anything, 1).Return([]graphsearch.KevinBaconNode{
+		{ID: "Kevin Bacon", Name: "Kevin Bacon", Type: constants.STAR},
+		{ID: "Lori Singer", Name: "Lori Singer", Type: constants.STAR, Depth: 1, ViaMovie: "1"},
+		{ID: "1", Name: "Footloose", Type: constants.MOVIE, Depth: 1, ViaStar: "Kevin Bacon"},
+		{ID: "Herbert Ross", Name: "Herbert Ross", Type: constants.DIRECTOR, Depth: 1, ViaMovie: "1"},
+	})
+	mockGraph.On("TotalStars").Return(2)
+	mockGraph.On("TotalMovies").Return(1)
+	mockGraph.On("TotalDirectors").Return(1)
+
+	params := graphql.ResolveParams{
+		Args: map[string]interface{}{
+			constants.STAR:     "Kevin Bacon",
+			constants.TITLE:    "",
+			constants.DIRECTOR: "",
+			constants.DEPTH:    1,
+			constants.LIMIT:    2,
+			constants.OFFSET:   1,
+		},
+	}
+
+	result, err := gql.GetKevinBaconField.Resolve(params)
+	assert.NoError(t, err)
+	resp := result.(map[string]interface{})
+	nodes := resp[constants.NODES].([]graphsearch.KevinBaconNode)
+	assert.Len(t, nodes, 2)
+	assert.Equal(t, "Lori Singer", nodes[0].Name)
+	assert.Equal(t, []string{"Lori Singer"}, resp[constants.STARS])
+	assert.Equal(t, []string{"Footloose"}, extractTitles(resp[constants.MOVIES].([]data.Movie)))
+	assert.Empty(t, resp[constants.DIRECTORS])
+	assert.Equal(t, 4, resp[constants.TOTAL_NODES])
+	assert.Equal(t, true, resp[constants.HAS_MORE])
+}
+
+func TestGetKevinBaconField_Resolve_InvalidLimit(t *testing.T) {
+	mockGraph := new(graphsearch.MockMovieGraph)
+	gql.SetMovieGraph(mockGraph)
+	mockGraph.On("ResolveStar", "Kevin Bacon").Return("Kevin Bacon", nil)
+
+	params := graphql.ResolveParams{
+		Args: map[string]interface{}{
+			constants.STAR:     "Kevin Bacon",
+			constants.TITLE:    "",
+			constants.DIRECTOR: "",
+			constants.DEPTH:    1,
+			constants.LIMIT:    0,
+		},
+	}
+
+	result, err := gql.GetKevinBaconField.Resolve(params)
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "limit must be positive")
 }
 
 func TestGetKevinBaconField_Resolve_EmptyArgs(t *testing.T) {
@@ -64,9 +134,9 @@ func TestGetKevinBaconField_Resolve_MovieLookupFails(t *testing.T) {
 	mockGraph := new(graphsearch.MockMovieGraph)
 	gql.SetMovieGraph(mockGraph)
 
-	mockGraph.On("GetDirectedActors", "Spielberg").Return([]string{"Actor A"})
-	mockGraph.On("GetMovieFromTitle", "Nonexistent Movie").Return(data.Movie{}, errors.New("not found"))
-	mockGraph.On("BFS", "Actor A", mock.Anything, mock.Anything, mock.Anything, 1).Return()
+	mockGraph.On("ResolveDirector", "Spielberg").Return("Spielberg", nil)
+	mockGraph.On("GetMoviesFromTitle", "Nonexistent Movie", "").Return(nil, errors.New("not found"))
+	mockGraph.On("KevinBaconLayers", graphsearch.KevinBaconSeeds{Directors: []string{"Spielberg"}}, 1).Return([]graphsearch.KevinBaconNode{})
 	mockGraph.On("TotalStars").Return(1)
 	mockGraph.On("TotalMovies").Return(1)
 	mockGraph.On("TotalDirectors").Return(1)
@@ -87,6 +157,83 @@ func TestGetKevinBaconField_Resolve_MovieLookupFails(t *testing.T) {
 	assert.Equal(t, "", resp[constants.STAR])
 }
 
+func TestGetKevinBaconField_Resolve_TitleWithYear(t *testing.T) {
+	mockGraph := new(graphsearch.MockMovieGraph)
+	gql.SetMovieGraph(mockGraph)
+
+	remake := data.Movie{ID: "scarface_1983", Title: "Scarface", Year: "1983", Cast: []string{"Al Pacino"}}
+	mockGraph.On("GetMoviesFromTitle", "Scarface", "1983").Return([]data.Movie{remake}, nil)
+	mockGraph.On("GetMovieByID", "scarface_1983").Return(remake, nil)
+	mockGraph.On("KevinBaconLayers", graphsearch.KevinBaconSeeds{MovieIDs: []string{"scarface_1983"}}, 1).Return([]graphsearch.KevinBaconNode{
+		{ID: "scarface_1983", Name: "Scarface", Type: constants.MOVIE},
+		{ID: "Al Pacino", Name: "Al Pacino", Type: constants.STAR},
+	})
+	mockGraph.On("TotalStars").Return(1)
+	mockGraph.On("TotalMovies").Return(2)
+	mockGraph.On("TotalDirectors").Return(1)
+
+	params := graphql.ResolveParams{
+		Args: map[string]interface{}{
+			constants.STAR:     "",
+			constants.TITLE:    "Scarface",
+			constants.YEAR:     "1983",
+			constants.MOVIE_ID: "",
+			constants.DIRECTOR: "",
+			constants.DEPTH:    1,
+		},
+	}
+
+	result, err := gql.GetKevinBaconField.Resolve(params)
+	assert.NoError(t, err)
+	resp := result.(map[string]interface{})
+	movies := resp[constants.MOVIES].([]data.Movie)
+	assert.Len(t, movies, 1)
+	assert.Equal(t, "scarface_1983", movies[0].ID)
+}
+
+func TestGetKevinBaconField_Resolve_TitleNotFound(t *testing.T) {
+	mockGraph := new(graphsearch.MockMovieGraph)
+	gql.SetMovieGraph(mockGraph)
+
+	mockGraph.On("GetMoviesFromTitle", "Nonexistent Movie", "").Return(nil, errors.New("movie with title Nonexistent Movie not found"))
+
+	params := graphql.ResolveParams{
+		Args: map[string]interface{}{
+			constants.STAR:     "",
+			constants.TITLE:    "Nonexistent Movie",
+			constants.DIRECTOR: "",
+			constants.DEPTH:    1,
+		},
+	}
+
+	result, err := gql.GetKevinBaconField.Resolve(params)
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, "not found")
+}
+
+func TestGetKevinBaconField_Resolve_StarSuggestions(t *testing.T) {
+	mockGraph := new(graphsearch.MockMovieGraph)
+	gql.SetMovieGraph(mockGraph)
+
+	notFound := &graphsearch.NameNotFoundError{Kind: "star", Name: "Kevin Bakon", Suggestions: []string{"Kevin Bacon"}}
+	mockGraph.On("ResolveStar", "Kevin Bakon").Return("", notFound)
+
+	params := graphql.ResolveParams{
+		Args: map[string]interface{}{
+			constants.STAR:     "Kevin Bakon",
+			constants.TITLE:    "",
+			constants.DIRECTOR: "",
+			constants.DEPTH:    1,
+		},
+	}
+
+	result, err := gql.GetKevinBaconField.Resolve(params)
+	assert.Nil(t, result)
+	assert.ErrorContains(t, err, `did you mean "Kevin Bacon"?`)
+	formatted := err.(gqlerrors.FormattedError)
+	assert.Equal(t, []string{"Kevin Bacon"}, formatted.Extensions[constants.SUGGESTIONS])
+}
+
 func extractTitles(movies []data.Movie) []string {
 	titles := make([]string, len(movies))
 	for i, m := range movies {
@@ -2,12 +2,16 @@
 package gql
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/graphql-go/graphql"
 
+	"blockbuster/api/api_cache"
 	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/services"
 )
 
 var ReturnRentalsField = &graphql.Field{
@@ -134,6 +138,134 @@ var SetAPIChoiceField = &graphql.Field{
 	},
 }
 
+// FinishVotingSessionField closes a voting session and returns its explained final picks.
+var FinishVotingSessionField = &graphql.Field{
+	Type: graphql.NewList(FinalPickType),
+	Args: graphql.FieldConfigArgument{
+		constants.SESSION_ID:       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		constants.NUM_PICKS:        &graphql.ArgumentConfig{Type: graphql.Int},
+		constants.DIVERSITY:        &graphql.ArgumentConfig{Type: graphql.Float},
+		constants.METRIC:           &graphql.ArgumentConfig{Type: graphql.String},
+		constants.WEIGHTS:          &graphql.ArgumentConfig{Type: graphql.NewList(DimensionWeightInput)},
+		constants.BLEND_CO_RENTALS: &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		sessionID, err := getStringArg(p, constants.SESSION_ID, constants.FINISH_VOTING_SESSION)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		opts := data.FinalPicksOptions{}
+		opts.NumPicks, _ = p.Args[constants.NUM_PICKS].(int)
+		if diversity, ok := p.Args[constants.DIVERSITY].(float64); ok {
+			opts.Diversity = &diversity
+		}
+		opts.Distance.Metric, _ = p.Args[constants.METRIC].(string)
+		opts.BlendCoRentals, _ = p.Args[constants.BLEND_CO_RENTALS].(bool)
+		if weights, ok := p.Args[constants.WEIGHTS].([]interface{}); ok && len(weights) > 0 {
+			opts.Distance.Weights = make(map[string]float64, len(weights))
+			for _, w := range weights {
+				weight, _ := w.(map[string]interface{})
+				dimension, _ := weight[constants.DIMENSION].(string)
+				opts.Distance.Weights[dimension], _ = weight[constants.WEIGHT].(float64)
+			}
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		picks, err := memberService.FinishVotingSession(ctx, sessionID, opts)
+		switch {
+		case errors.Is(err, services.ErrInvalidDistance), errors.Is(err, services.ErrInvalidPicks):
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		case errors.Is(err, api_cache.ErrVotingSessionNotFound):
+			return nil, getFormattedError(err.Error(), http.StatusNotFound)
+		case errors.Is(err, services.ErrNoVotesRecorded):
+			return nil, getFormattedError(err.Error(), http.StatusConflict)
+		case len(picks) == 0:
+			if err == nil {
+				err = errors.New("no final picks found")
+			}
+			return nil, getFormattedError(err.Error(), http.StatusInternalServerError)
+		}
+		return picks, nil
+	},
+}
+
+// StartQuizField deals a member a trivia quiz from their rentals or from one centroid.
+var StartQuizField = &graphql.Field{
+	Type: QuizType,
+	Args: graphql.FieldConfigArgument{
+		constants.USERNAME:      usernameArg,
+		constants.SOURCE:        &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: constants.QUIZ_SOURCE_RENTED},
+		constants.CENTROID:      &graphql.ArgumentConfig{Type: graphql.Int},
+		constants.NUM_QUESTIONS: &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: constants.DEFAULT_QUIZ_QUESTIONS},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		username, err := getStringArg(p, constants.USERNAME, constants.START_QUIZ)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		opts := data.QuizOptions{Username: username}
+		opts.Source, _ = p.Args[constants.SOURCE].(string)
+		opts.NumQuestions, _ = p.Args[constants.NUM_QUESTIONS].(int)
+		if centroid, ok := p.Args[constants.CENTROID].(int); ok {
+			opts.Centroid = &centroid
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		quiz, err := quizService.StartQuiz(ctx, opts)
+		if err != nil {
+			return nil, quizError(err)
+		}
+		return quiz, nil
+	},
+}
+
+// SubmitQuizField grades a quiz. answers holds the index of the chosen choice for each
+// question in order; -1 or a missing answer skips the question.
+var SubmitQuizField = &graphql.Field{
+	Type: QuizResultType,
+	Args: graphql.FieldConfigArgument{
+		constants.QUIZ_ID: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+		constants.ANSWERS: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.Int)))},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		quizID, err := getStringArg(p, constants.QUIZ_ID, constants.SUBMIT_QUIZ)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		rawAnswers, _ := p.Args[constants.ANSWERS].([]interface{})
+		answers := make([]int, len(rawAnswers))
+		for i, answer := range rawAnswers {
+			answers[i], _ = answer.(int)
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		result, err := quizService.SubmitQuiz(ctx, quizID, answers)
+		if err != nil {
+			return nil, quizError(err)
+		}
+		return result, nil
+	},
+}
+
+func quizError(err error) error {
+	switch {
+	case errors.Is(err, api_cache.ErrQuizNotFound), errors.Is(err, services.ErrUnknownMember):
+		return getFormattedError(err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrInvalidQuiz), errors.Is(err, services.ErrInvalidAnswers):
+		return getFormattedError(err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrNotEnoughTrivia):
+		return getFormattedError(err.Error(), http.StatusUnprocessableEntity)
+	default:
+		return getFormattedError(err.Error(), http.StatusInternalServerError)
+	}
+}
+
 // Helper to convert []interface{} to []string
 func extractIDList(arg interface{}) []string {
 	idsRaw, ok := arg.([]interface{})
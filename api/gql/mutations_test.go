@@ -8,7 +8,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"blockbuster/api/api_cache"
 	"blockbuster/api/constants"
+	"blockbuster/api/data"
 	"blockbuster/api/gql"
 	"blockbuster/api/services"
 )
@@ -109,3 +111,140 @@ func TestSetAPIChoiceField(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, res.(string), "successfully set dave api choice")
 }
+
+func TestFinishVotingSessionField(t *testing.T) {
+	mockSvc := setupTestMemberService()
+	diversity := 0.5
+	opts := data.FinalPicksOptions{
+		NumPicks:       2,
+		Diversity:      &diversity,
+		Distance:       data.DistanceOptions{Metric: constants.WEIGHTED_EUCLIDEAN, Weights: map[string]float64{"horror": 3}},
+		BlendCoRentals: true,
+	}
+	picks := []data.FinalPick{{MovieID: "alien", Explanation: data.PickExplanation{Centroid: 2, InfluencedBy: []string{"aliens"}}}}
+	mockSvc.On("FinishVotingSession", mock.Anything, "abc", opts).Return(picks, nil)
+
+	ctx := context.WithValue(context.Background(), gql.GinContextKey, context.Background())
+	params := graphql.ResolveParams{
+		Args: map[string]interface{}{
+			constants.SESSION_ID:       "abc",
+			constants.NUM_PICKS:        2,
+			constants.DIVERSITY:        0.5,
+			constants.METRIC:           constants.WEIGHTED_EUCLIDEAN,
+			constants.BLEND_CO_RENTALS: true,
+			constants.WEIGHTS: []interface{}{
+				map[string]interface{}{constants.DIMENSION: "horror", constants.WEIGHT: 3.0},
+			},
+		},
+		Context: ctx,
+	}
+
+	res, err := gql.FinishVotingSessionField.Resolve(params)
+	assert.NoError(t, err)
+	assert.Equal(t, picks, res)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestFinishVotingSessionField_Errors(t *testing.T) {
+	mockSvc := setupTestMemberService()
+	mockSvc.On("FinishVotingSession", mock.Anything, "early", data.FinalPicksOptions{}).Return(nil, services.ErrNoVotesRecorded)
+
+	ctx := context.WithValue(context.Background(), gql.GinContextKey, context.Background())
+	res, err := gql.FinishVotingSessionField.Resolve(graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.SESSION_ID: "early"},
+		Context: ctx,
+	})
+	assert.Nil(t, res)
+	assert.ErrorContains(t, err, services.ErrNoVotesRecorded.Error())
+
+	res, err = gql.FinishVotingSessionField.Resolve(graphql.ResolveParams{Args: map[string]interface{}{}, Context: ctx})
+	assert.Nil(t, res)
+	assert.ErrorContains(t, err, "sessionID argument is required")
+}
+
+func TestStartQuizField(t *testing.T) {
+	mockSvc := new(services.MockQuizService)
+	gql.SetQuizService(mockSvc)
+	centroid := 4
+	quiz := data.Quiz{ID: "q1", Username: "alice", Source: constants.QUIZ_SOURCE_CENTROID, Centroid: &centroid}
+	mockSvc.On("StartQuiz", mock.Anything, data.QuizOptions{Username: "alice", Source: constants.QUIZ_SOURCE_CENTROID, Centroid: &centroid, NumQuestions: 3}).Return(quiz, nil)
+	mockSvc.On("StartQuiz", mock.Anything, data.QuizOptions{Username: "bob", Source: constants.QUIZ_SOURCE_RENTED}).Return(data.Quiz{}, services.ErrNotEnoughTrivia)
+
+	ctx := context.WithValue(context.Background(), gql.GinContextKey, context.Background())
+	res, err := gql.StartQuizField.Resolve(graphql.ResolveParams{
+		Args: map[string]interface{}{
+			constants.USERNAME:      "alice",
+			constants.SOURCE:        constants.QUIZ_SOURCE_CENTROID,
+			constants.CENTROID:      4,
+			constants.NUM_QUESTIONS: 3,
+		},
+		Context: ctx,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, quiz, res)
+
+	res, err = gql.StartQuizField.Resolve(graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.USERNAME: "bob", constants.SOURCE: constants.QUIZ_SOURCE_RENTED},
+		Context: ctx,
+	})
+	assert.Nil(t, res)
+	assert.ErrorContains(t, err, services.ErrNotEnoughTrivia.Error())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestSubmitQuizField(t *testing.T) {
+	mockSvc := new(services.MockQuizService)
+	gql.SetQuizService(mockSvc)
+	result := data.QuizResult{QuizID: "q1", Username: "alice", Correct: 1, Total: 2}
+	mockSvc.On("SubmitQuiz", mock.Anything, "q1", []int{2, -1}).Return(result, nil)
+	mockSvc.On("SubmitQuiz", mock.Anything, "gone", []int{}).Return(data.QuizResult{}, api_cache.ErrQuizNotFound)
+
+	ctx := context.WithValue(context.Background(), gql.GinContextKey, context.Background())
+	res, err := gql.SubmitQuizField.Resolve(graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.QUIZ_ID: "q1", constants.ANSWERS: []interface{}{2, -1}},
+		Context: ctx,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, result, res)
+
+	res, err = gql.SubmitQuizField.Resolve(graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.QUIZ_ID: "gone", constants.ANSWERS: []interface{}{}},
+		Context: ctx,
+	})
+	assert.Nil(t, res)
+	assert.ErrorContains(t, err, api_cache.ErrQuizNotFound.Error())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestQuizType_HidesAnswers(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "QuizQuery",
+			Fields: graphql.Fields{
+				constants.QUIZ_TYPE: &graphql.Field{
+					Type: gql.QuizType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return data.Quiz{
+							ID:        "q1",
+							Questions: []data.QuizQuestion{{MovieID: "heat", Question: "Who plays Neil?", Choices: []string{"Al Pacino", "Robert De Niro"}}},
+							Answers:   []int{1},
+						}, nil
+					},
+				},
+			},
+		}),
+	})
+	assert.NoError(t, err)
+
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ Quiz { quizID questions { movieID choices } } }`})
+	assert.Empty(t, result.Errors)
+	quiz := result.Data.(map[string]interface{})[constants.QUIZ_TYPE].(map[string]interface{})
+	assert.Equal(t, "q1", quiz[constants.QUIZ_ID])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		constants.MOVIE_ID: "heat",
+		constants.CHOICES:  []interface{}{"Al Pacino", "Robert De Niro"},
+	}}, quiz[constants.QUESTIONS])
+
+	result = graphql.Do(graphql.Params{Schema: schema, RequestString: `{ Quiz { answers } }`})
+	assert.NotEmpty(t, result.Errors, "the right choices are not part of the schema")
+}
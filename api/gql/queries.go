@@ -1,6 +1,7 @@
 package gql
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -9,6 +10,8 @@ import (
 
 	"blockbuster/api/constants"
 	"blockbuster/api/data"
+	graphsearch "blockbuster/api/graph_search"
+	"blockbuster/api/services"
 )
 
 var GetMoviesField = &graphql.Field{
@@ -37,9 +40,10 @@ var GetMoviesField = &graphql.Field{
 		if !ok || director == "" {
 			return movies, nil
 		}
+		directorKey := graphsearch.NormalizeName(director.(string))
 		moviesDirected := make([]data.Movie, 0)
 		for _, movie := range movies {
-			if movie.Director == director {
+			if graphsearch.NormalizeName(movie.Director) == directorKey {
 				moviesDirected = append(moviesDirected, movie)
 			}
 		}
@@ -142,7 +146,10 @@ var GetDirectedMoviesField = &graphql.Field{
 		constants.DIRECTOR: directorArg,
 	},
 	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-		director := p.Args[constants.DIRECTOR].(string)
+		director, err := resolveName(p.Args[constants.DIRECTOR].(string), movieGraph.ResolveDirector)
+		if err != nil {
+			return nil, err
+		}
 		return movieGraph.GetDirectedMovies(director), nil
 	},
 }
@@ -153,7 +160,10 @@ var GetDirectedActorsField = &graphql.Field{
 		constants.DIRECTOR: directorArg,
 	},
 	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-		director := p.Args[constants.DIRECTOR].(string)
+		director, err := resolveName(p.Args[constants.DIRECTOR].(string), movieGraph.ResolveDirector)
+		if err != nil {
+			return nil, err
+		}
 		return movieGraph.GetDirectedActors(director), nil
 	},
 }
@@ -168,6 +178,10 @@ var GetStarredInField = &graphql.Field{
 		if star == "" {
 			return nil, getFormattedError("'star' argument is required for starredIn query", http.StatusBadRequest)
 		}
+		star, err := resolveName(star, movieGraph.ResolveStar)
+		if err != nil {
+			return nil, err
+		}
 		return movieGraph.GetStarredIn(star), nil
 	},
 }
@@ -182,6 +196,245 @@ var GetStarredWithField = &graphql.Field{
 		if star == "" {
 			return nil, getFormattedError("'star' argument is required for starredWith query", http.StatusBadRequest)
 		}
+		star, err := resolveName(star, movieGraph.ResolveStar)
+		if err != nil {
+			return nil, err
+		}
 		return movieGraph.GetStarredWith(star), nil
 	},
 }
+
+var GetPersonField = &graphql.Field{
+	Type: PersonType,
+	Args: graphql.FieldConfigArgument{
+		constants.NAME: nameArg,
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		name, err := getStringArg(p, constants.NAME, constants.GET_PERSON)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		person, err := peopleService.GetPerson(ctx, name)
+		if err != nil {
+			var notFound *graphsearch.NameNotFoundError
+			if errors.As(err, &notFound) {
+				return nil, nameNotFoundError(err)
+			}
+			return nil, getFormattedError(err.Error(), http.StatusInternalServerError)
+		}
+		return person, nil
+	},
+}
+
+var GetRecommendationsField = &graphql.Field{
+	Type: graphql.NewList(RecommendationType),
+	Args: graphql.FieldConfigArgument{
+		constants.USERNAME: usernameArg,
+		constants.LIMIT:    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: constants.DEFAULT_RECOMMENDATIONS},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		username, err := getStringArg(p, constants.USERNAME, constants.RECOMMENDATIONS)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		limit, ok := p.Args[constants.LIMIT].(int)
+		if !ok {
+			limit = constants.DEFAULT_RECOMMENDATIONS
+		}
+		if limit < 1 {
+			return nil, getFormattedError("limit must be positive", http.StatusBadRequest)
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		recs, err := memberService.GetRecommendations(ctx, username, min(limit, constants.MAX_RECOMMENDATIONS))
+		if errors.Is(err, services.ErrUnknownMember) {
+			return nil, getFormattedError(err.Error(), http.StatusNotFound)
+		}
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusInternalServerError)
+		}
+		return recs, nil
+	},
+}
+
+// AlsoRentedField resolves the movies most often rented alongside the parent Movie.
+var AlsoRentedField = &graphql.Field{
+	Type: graphql.NewList(CoRentalType),
+	Args: graphql.FieldConfigArgument{
+		constants.K: &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: constants.DEFAULT_ALSO_RENTED},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		movie, ok := p.Source.(data.Movie)
+		if !ok || movie.ID == "" {
+			return nil, getFormattedError("also_rented requires a movie with an id", http.StatusBadRequest)
+		}
+		k, ok := p.Args[constants.K].(int)
+		if !ok {
+			k = constants.DEFAULT_ALSO_RENTED
+		}
+		if k < 1 {
+			return nil, getFormattedError("k must be positive", http.StatusBadRequest)
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		coRentals, err := movieService.GetAlsoRented(ctx, movie.ID, min(k, constants.MAX_ALSO_RENTED))
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusInternalServerError)
+		}
+		return coRentals, nil
+	},
+}
+
+// SimilarField resolves the movies nearest the parent Movie in metric space.
+var SimilarField = &graphql.Field{
+	Type: graphql.NewList(SimilarMovieType),
+	Args: graphql.FieldConfigArgument{
+		constants.K: &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: constants.DEFAULT_SIMILAR},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		movie, ok := p.Source.(data.Movie)
+		if !ok || movie.ID == "" {
+			return nil, getFormattedError("similar requires a movie with an id", http.StatusBadRequest)
+		}
+		k, ok := p.Args[constants.K].(int)
+		if !ok {
+			k = constants.DEFAULT_SIMILAR
+		}
+		if k < 1 {
+			return nil, getFormattedError("k must be positive", http.StatusBadRequest)
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		similar, err := movieService.GetSimilarMovies(ctx, movie.ID, min(k, constants.MAX_SIMILAR))
+		if errors.Is(err, services.ErrMovieNotFound) || errors.Is(err, services.ErrNoMetrics) {
+			return nil, getFormattedError(err.Error(), http.StatusNotFound)
+		}
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusInternalServerError)
+		}
+		return similar, nil
+	},
+}
+
+var shelfSizeArg = &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: constants.DEFAULT_SHELF_MOVIES}
+
+// GetCentroidsField lists every centroid as a mood shelf with up to k representative movies.
+var GetCentroidsField = &graphql.Field{
+	Type: graphql.NewList(CentroidType),
+	Args: graphql.FieldConfigArgument{
+		constants.K: shelfSizeArg,
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		k, err := getShelfSize(p)
+		if err != nil {
+			return nil, err
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		centroids, err := centroidsService.GetCentroids(ctx, k)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusInternalServerError)
+		}
+		return centroids, nil
+	},
+}
+
+var GetCentroidField = &graphql.Field{
+	Type: CentroidType,
+	Args: graphql.FieldConfigArgument{
+		constants.CENTROID_ID: &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+		constants.K:           shelfSizeArg,
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		id, ok := p.Args[constants.CENTROID_ID].(int)
+		if !ok {
+			return nil, getFormattedError("centroidID is required", http.StatusBadRequest)
+		}
+		k, err := getShelfSize(p)
+		if err != nil {
+			return nil, err
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		centroid, err := centroidsService.GetCentroid(ctx, id, k)
+		if errors.Is(err, services.ErrCentroidNotFound) {
+			return nil, getFormattedError(err.Error(), http.StatusNotFound)
+		}
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusInternalServerError)
+		}
+		return centroid, nil
+	},
+}
+
+func getShelfSize(p graphql.ResolveParams) (int, error) {
+	k, ok := p.Args[constants.K].(int)
+	if !ok {
+		k = constants.DEFAULT_SHELF_MOVIES
+	}
+	if k < 0 {
+		return 0, getFormattedError("k must not be negative", http.StatusBadRequest)
+	}
+	return min(k, constants.MAX_SHELF_MOVIES), nil
+}
+
+var GetQuizLeaderboardField = &graphql.Field{
+	Type: graphql.NewList(QuizStatsType),
+	Args: graphql.FieldConfigArgument{
+		constants.LIMIT: &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: constants.DEFAULT_QUIZ_LEADERBOARD},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		limit, ok := p.Args[constants.LIMIT].(int)
+		if !ok {
+			limit = constants.DEFAULT_QUIZ_LEADERBOARD
+		}
+		if limit < 1 {
+			return nil, getFormattedError("limit must be positive", http.StatusBadRequest)
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		leaderboard, err := quizService.GetQuizLeaderboard(ctx, min(limit, constants.MAX_QUIZ_LEADERBOARD))
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusInternalServerError)
+		}
+		return leaderboard, nil
+	},
+}
+
+var GetQuizStatsField = &graphql.Field{
+	Type: QuizStatsType,
+	Args: graphql.FieldConfigArgument{
+		constants.USERNAME: usernameArg,
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		username, err := getStringArg(p, constants.USERNAME, constants.QUIZ_STATS)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		ctx, err := getContext(p)
+		if err != nil {
+			return nil, getFormattedError(err.Error(), http.StatusBadRequest)
+		}
+		stats, err := quizService.GetQuizStats(ctx, username)
+		if err != nil {
+			return nil, quizError(err)
+		}
+		return stats, nil
+	},
+}
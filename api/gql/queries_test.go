@@ -2,9 +2,12 @@ package gql_test
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
@@ -102,6 +105,7 @@ func TestGetDirectedMoviesField(t *testing.T) {
 	gql.SetMemberService(mockMemberService)
 	gql.SetMovieService(mockMovieService)
 	gql.SetMovieGraph(mockMovieGraph)
+	mockMovieGraph.On("ResolveDirector", "Spielberg").Return("Spielberg", nil)
 	mockMovieGraph.On("GetDirectedMovies", mock.Anything).Return([]data.Movie{{ID: "1", Title: "Jaws", Director: "Spielberg"}}, nil)
 
 	params := graphql.ResolveParams{
@@ -130,6 +134,7 @@ func TestGetStarredInField(t *testing.T) {
 	gql.SetMemberService(mockMemberService)
 	gql.SetMovieService(mockMovieService)
 	gql.SetMovieGraph(mockMovieGraph)
+	mockMovieGraph.On("ResolveStar", "Tom Hanks").Return("Tom Hanks", nil)
 	mockMovieGraph.On("GetStarredIn", mock.Anything).Return([]data.Movie{{ID: "1", Title: "Jaws", Director: "Spielberg", Cast: []string{"Tom Hanks"}}}, nil)
 
 	params := graphql.ResolveParams{
@@ -153,3 +158,201 @@ func TestGetStarredWithField(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Contains(t, resp.([]string), "Actor B")
 }
+
+func TestGetPersonField(t *testing.T) {
+	mockPeopleService := new(services.MockPeopleService)
+	gql.SetPeopleService(mockPeopleService)
+	mockPeopleService.On("GetPerson", mock.Anything, "Kevin Bacon").Return(data.Person{Name: "Kevin Bacon"}, nil)
+
+	params := graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.NAME: "Kevin Bacon"},
+		Context: setupTestContext(),
+	}
+	resp, err := gql.GetPersonField.Resolve(params)
+	assert.NoError(t, err)
+	assert.Equal(t, "Kevin Bacon", resp.(data.Person).Name)
+}
+
+func TestGetPersonField_NotFound(t *testing.T) {
+	mockPeopleService := new(services.MockPeopleService)
+	gql.SetPeopleService(mockPeopleService)
+	notFound := &graphsearch.NameNotFoundError{Kind: "person", Name: "Kevin Bakon", Suggestions: []string{"Kevin Bacon"}}
+	mockPeopleService.On("GetPerson", mock.Anything, "Kevin Bakon").Return(data.Person{}, notFound)
+
+	params := graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.NAME: "Kevin Bakon"},
+		Context: setupTestContext(),
+	}
+	resp, err := gql.GetPersonField.Resolve(params)
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, `did you mean "Kevin Bacon"?`)
+}
+
+func TestGetRecommendationsField(t *testing.T) {
+	memberService := new(services.MockMembersService)
+	gql.SetMemberService(memberService)
+	recs := []data.Recommendation{{Movie: data.Movie{ID: "m1"}, Score: 0.75, Centroid: 2}}
+	memberService.On("GetRecommendations", mock.Anything, "john", constants.MAX_RECOMMENDATIONS).Return(recs, nil)
+
+	params := graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.USERNAME: "john", constants.LIMIT: 1000},
+		Context: setupTestContext(),
+	}
+	resp, err := gql.GetRecommendationsField.Resolve(params)
+	assert.NoError(t, err)
+	assert.Equal(t, recs, resp)
+}
+
+func TestAlsoRentedField(t *testing.T) {
+	movieService := new(services.MockMoviesService)
+	gql.SetMovieService(movieService)
+	coRentals := []data.CoRental{{Movie: data.Movie{ID: "aliens"}, Count: 3, Score: 0.75}}
+	movieService.On("GetAlsoRented", mock.Anything, "alien", 2).Return(coRentals, nil)
+
+	params := graphql.ResolveParams{
+		Source:  data.Movie{ID: "alien"},
+		Args:    map[string]interface{}{constants.K: 2},
+		Context: setupTestContext(),
+	}
+	resp, err := gql.AlsoRentedField.Resolve(params)
+	assert.NoError(t, err)
+	assert.Equal(t, coRentals, resp)
+}
+
+func TestSimilarField(t *testing.T) {
+	movieService := new(services.MockMoviesService)
+	gql.SetMovieService(movieService)
+	similar := []data.SimilarMovie{{Movie: data.Movie{ID: "aliens"}, Distance: 12, Centroid: 3}}
+	movieService.On("GetSimilarMovies", mock.Anything, "alien", constants.MAX_SIMILAR).Return(similar, nil)
+
+	params := graphql.ResolveParams{
+		Source:  data.Movie{ID: "alien"},
+		Args:    map[string]interface{}{constants.K: 100},
+		Context: setupTestContext(),
+	}
+	resp, err := gql.SimilarField.Resolve(params)
+	assert.NoError(t, err)
+	assert.Equal(t, similar, resp)
+}
+
+func TestSimilarField_UnknownMovie(t *testing.T) {
+	movieService := new(services.MockMoviesService)
+	gql.SetMovieService(movieService)
+	movieService.On("GetSimilarMovies", mock.Anything, "missing", constants.DEFAULT_SIMILAR).
+		Return([]data.SimilarMovie{}, fmt.Errorf("%w: missing", services.ErrMovieNotFound))
+
+	params := graphql.ResolveParams{
+		Source:  data.Movie{ID: "missing"},
+		Args:    map[string]interface{}{},
+		Context: setupTestContext(),
+	}
+	_, err := gql.SimilarField.Resolve(params)
+	assert.Equal(t, http.StatusNotFound, err.(gqlerrors.FormattedError).Extensions[constants.CODE])
+}
+
+func TestGetCentroidsField(t *testing.T) {
+	centroidsService := new(services.MockCentroidsService)
+	gql.SetCentroidsService(centroidsService)
+	shelves := []data.Centroid{{ID: 0, Label: "romantic comedies", Movies: []data.Movie{{ID: "notting_hill"}}}}
+	centroidsService.On("GetCentroids", mock.Anything, constants.MAX_SHELF_MOVIES).Return(shelves, nil)
+
+	params := graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.K: 100},
+		Context: setupTestContext(),
+	}
+	resp, err := gql.GetCentroidsField.Resolve(params)
+	assert.NoError(t, err)
+	assert.Equal(t, shelves, resp)
+
+	params.Args[constants.K] = -1
+	_, err = gql.GetCentroidsField.Resolve(params)
+	assert.Error(t, err)
+}
+
+func TestGetCentroidField_NotFound(t *testing.T) {
+	centroidsService := new(services.MockCentroidsService)
+	gql.SetCentroidsService(centroidsService)
+	centroidsService.On("GetCentroid", mock.Anything, 9, constants.DEFAULT_SHELF_MOVIES).
+		Return(data.Centroid{}, services.ErrCentroidNotFound)
+
+	params := graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.CENTROID_ID: 9},
+		Context: setupTestContext(),
+	}
+	resp, err := gql.GetCentroidField.Resolve(params)
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, "centroid not found")
+}
+
+func TestMovieType_TriviaPairs(t *testing.T) {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "TriviaQuery",
+			Fields: graphql.Fields{
+				constants.MOVIE: &graphql.Field{
+					Type: gql.MovieType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return data.TestMovies[1], nil
+					},
+				},
+			},
+		}),
+	})
+	assert.NoError(t, err)
+
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: `{ movie { trivia { question answer } } }`})
+	assert.Empty(t, result.Errors)
+	trivia := result.Data.(map[string]interface{})[constants.MOVIE].(map[string]interface{})[constants.TRIVIA].([]interface{})
+	assert.Len(t, trivia, len(data.TestMovies[1].Trivia))
+	assert.Equal(t, map[string]interface{}{
+		constants.QUESTION: data.TestMovies[1].Trivia[0].Question,
+		constants.ANSWER:   data.TestMovies[1].Trivia[0].Answer,
+	}, trivia[0])
+}
+
+func TestGetQuizLeaderboardField(t *testing.T) {
+	quizService := new(services.MockQuizService)
+	gql.SetQuizService(quizService)
+	leaderboard := []data.QuizStats{{Username: "john", Points: 12, Asked: 15, Played: 3, Rank: 1}}
+	quizService.On("GetQuizLeaderboard", mock.Anything, constants.MAX_QUIZ_LEADERBOARD).Return(leaderboard, nil)
+
+	params := graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.LIMIT: 1000},
+		Context: setupTestContext(),
+	}
+	resp, err := gql.GetQuizLeaderboardField.Resolve(params)
+	assert.NoError(t, err)
+	assert.Equal(t, leaderboard, resp)
+
+	params.Args[constants.LIMIT] = 0
+	_, err = gql.GetQuizLeaderboardField.Resolve(params)
+	assert.ErrorContains(t, err, "limit must be positive")
+}
+
+func TestGetQuizStatsField_UnknownMember(t *testing.T) {
+	quizService := new(services.MockQuizService)
+	gql.SetQuizService(quizService)
+	quizService.On("GetQuizStats", mock.Anything, "ghost").Return(data.QuizStats{}, services.ErrUnknownMember)
+
+	params := graphql.ResolveParams{
+		Args:    map[string]interface{}{constants.USERNAME: "ghost"},
+		Context: setupTestContext(),
+	}
+	resp, err := gql.GetQuizStatsField.Resolve(params)
+	assert.Nil(t, resp)
+	assert.ErrorContains(t, err, services.ErrUnknownMember.Error())
+}
+
+func TestMovieMetricsType_FieldsFollowDimensions(t *testing.T) {
+	fields := gql.MovieMetricsType.Fields()
+	assert.Len(t, fields, len(data.MetricDimensions()))
+
+	metrics := data.MovieMetrics{ID: 4, StoryTelling: 72, Writing: 64}
+	for _, source := range []interface{}{metrics, &metrics} {
+		for name, want := range map[string]float64{"story_telling": 72, "writing": 64, "acting": 0} {
+			got, err := fields[name].Resolve(graphql.ResolveParams{Source: source})
+			assert.NoError(t, err)
+			assert.Equal(t, want, got, name)
+		}
+	}
+}
@@ -21,15 +21,24 @@ func getQueries() graphql.Fields {
 		constants.STARREDIN:           GetStarredInField,
 		constants.STARREDWITH:         GetStarredWithField,
 		constants.KEVING_BACON:        GetKevinBaconField,
+		constants.GET_PERSON:          GetPersonField,
+		constants.RECOMMENDATIONS:     GetRecommendationsField,
+		constants.CENTROIDS:           GetCentroidsField,
+		constants.GET_CENTROID:        GetCentroidField,
+		constants.QUIZ_LEADERBOARD:    GetQuizLeaderboardField,
+		constants.QUIZ_STATS:          GetQuizStatsField,
 	}
 }
 
 func getMutations() graphql.Fields {
 	return graphql.Fields{
-		constants.RETURN_RENTALS:  ReturnRentalsField,
-		constants.UPDATE_CART:     UpdateCartField,
-		constants.CHECKOUT_STRING: CheckoutField,
-		constants.SET_API_CHOICE:  SetAPIChoiceField,
+		constants.RETURN_RENTALS:        ReturnRentalsField,
+		constants.UPDATE_CART:           UpdateCartField,
+		constants.CHECKOUT_STRING:       CheckoutField,
+		constants.SET_API_CHOICE:        SetAPIChoiceField,
+		constants.FINISH_VOTING_SESSION: FinishVotingSessionField,
+		constants.START_QUIZ:            StartQuizField,
+		constants.SUBMIT_QUIZ:           SubmitQuizField,
 	}
 }
 
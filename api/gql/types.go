@@ -4,8 +4,17 @@ import (
 	"github.com/graphql-go/graphql"
 
 	"blockbuster/api/constants"
+	"blockbuster/api/data"
 )
 
+var TriviaType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.TRIVIA_TYPE,
+	Fields: graphql.Fields{
+		constants.QUESTION: &graphql.Field{Type: graphql.String},
+		constants.ANSWER:   &graphql.Field{Type: graphql.String},
+	},
+})
+
 var MovieType = graphql.NewObject(graphql.ObjectConfig{
 	Name: constants.MOVIE_TYPE,
 	Fields: graphql.Fields{
@@ -15,7 +24,7 @@ var MovieType = graphql.NewObject(graphql.ObjectConfig{
 		constants.REVIEW:    &graphql.Field{Type: graphql.String},
 		constants.RENTED:    &graphql.Field{Type: graphql.Int},
 		constants.SYNOPSIS:  &graphql.Field{Type: graphql.String},
-		constants.TRIVIA:    &graphql.Field{Type: graphql.String},
+		constants.TRIVIA:    &graphql.Field{Type: graphql.NewList(TriviaType)},
 		constants.YEAR:      &graphql.Field{Type: graphql.String},
 		constants.CAST:      &graphql.Field{Type: &graphql.List{OfType: graphql.String}},
 		constants.DIRECTOR:  &graphql.Field{Type: graphql.String},
@@ -36,6 +45,18 @@ var MemberType = graphql.NewObject(graphql.ObjectConfig{
 	},
 })
 
+var KevinBaconNodeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.KEVIN_BACON_NODE_TYPE,
+	Fields: graphql.Fields{
+		constants.ID:        &graphql.Field{Type: graphql.String},
+		constants.NAME:      &graphql.Field{Type: graphql.String},
+		constants.NODE_TYPE: &graphql.Field{Type: graphql.String},
+		constants.DEPTH:     &graphql.Field{Type: graphql.Int},
+		constants.VIA_MOVIE: &graphql.Field{Type: graphql.String},
+		constants.VIA_STAR:  &graphql.Field{Type: graphql.String},
+	},
+})
+
 var KevingBaconType = graphql.NewObject(graphql.ObjectConfig{
 	Name: constants.KEVING_BACON_TYPE,
 	Fields: graphql.Fields{
@@ -46,5 +67,212 @@ var KevingBaconType = graphql.NewObject(graphql.ObjectConfig{
 		constants.TOTAL_MOVIES:    &graphql.Field{Type: graphql.Int},
 		constants.DIRECTORS:       &graphql.Field{Type: graphql.NewList(graphql.String)},
 		constants.TOTAL_DIRECTORS: &graphql.Field{Type: graphql.Int},
+		constants.NODES:           &graphql.Field{Type: graphql.NewList(KevinBaconNodeType)},
+		constants.TOTAL_NODES:     &graphql.Field{Type: graphql.Int},
+		constants.OFFSET:          &graphql.Field{Type: graphql.Int},
+		constants.LIMIT:           &graphql.Field{Type: graphql.Int},
+		constants.HAS_MORE:        &graphql.Field{Type: graphql.Boolean},
+	},
+})
+
+var MovieMetricsType = graphql.NewObject(graphql.ObjectConfig{
+	Name:   constants.METRICS_TYPE,
+	Fields: movieMetricsFields(),
+})
+
+// movieMetricsFields has a Float field per metric dimension, resolved by its index in the
+// metric vector, so new dimensions show up without touching the schema.
+func movieMetricsFields() graphql.Fields {
+	fields := graphql.Fields{}
+	for i, name := range data.MetricDimensions() {
+		fields[name] = &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				switch metrics := p.Source.(type) {
+				case data.MovieMetrics:
+					return metrics.Vector()[i], nil
+				case *data.MovieMetrics:
+					if metrics != nil {
+						return metrics.Vector()[i], nil
+					}
+				}
+				return nil, nil
+			},
+		}
+	}
+	return fields
+}
+
+var CreditType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.CREDIT_TYPE,
+	Fields: graphql.Fields{
+		constants.ID:    &graphql.Field{Type: graphql.String},
+		constants.TITLE: &graphql.Field{Type: graphql.String},
+		constants.YEAR:  &graphql.Field{Type: graphql.String},
+		constants.ROLES: &graphql.Field{Type: graphql.NewList(graphql.String)},
+	},
+})
+
+var CollaboratorType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.COLLABORATOR_TYPE,
+	Fields: graphql.Fields{
+		constants.NAME:  &graphql.Field{Type: graphql.String},
+		constants.ROLE:  &graphql.Field{Type: graphql.String},
+		constants.COUNT: &graphql.Field{Type: graphql.Int},
+	},
+})
+
+var PersonType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.PERSON_TYPE,
+	Fields: graphql.Fields{
+		constants.NAME:             &graphql.Field{Type: graphql.String},
+		constants.ROLES:            &graphql.Field{Type: graphql.NewList(graphql.String)},
+		constants.FILMOGRAPHY:      &graphql.Field{Type: graphql.NewList(CreditType)},
+		constants.COLLABORATORS:    &graphql.Field{Type: graphql.NewList(CollaboratorType)},
+		constants.CAREER_START:     &graphql.Field{Type: graphql.Int},
+		constants.CAREER_END:       &graphql.Field{Type: graphql.Int},
+		constants.AVERAGE_METRICS:  &graphql.Field{Type: MovieMetricsType},
+		constants.AVAILABLE_TITLES: &graphql.Field{Type: graphql.NewList(MovieType)},
+	},
+})
+
+var CoRentalType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.CO_RENTAL_TYPE,
+	Fields: graphql.Fields{
+		constants.MOVIE: &graphql.Field{Type: MovieType},
+		constants.COUNT: &graphql.Field{Type: graphql.Int},
+		constants.SCORE: &graphql.Field{Type: graphql.Float},
+	},
+})
+
+var SimilarMovieType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.SIMILAR_MOVIE_TYPE,
+	Fields: graphql.Fields{
+		constants.MOVIE:    &graphql.Field{Type: MovieType},
+		constants.DISTANCE: &graphql.Field{Type: graphql.Float},
+		constants.CENTROID: &graphql.Field{Type: graphql.Int},
+	},
+})
+
+// CoRentalType and SimilarMovieType refer back to MovieType, so the fields returning them
+// have to be added once all three exist.
+func init() {
+	MovieType.AddFieldConfig(constants.ALSO_RENTED, AlsoRentedField)
+	MovieType.AddFieldConfig(constants.SIMILAR, SimilarField)
+}
+
+// CentroidType is a mood shelf: a centroid, its label and its most representative movies.
+var CentroidType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.CENTROID_TYPE,
+	Fields: graphql.Fields{
+		constants.ID:               &graphql.Field{Type: graphql.Int},
+		constants.LABEL:            &graphql.Field{Type: graphql.String},
+		constants.DESCRIPTION:      &graphql.Field{Type: graphql.String},
+		constants.CUSTOM:           &graphql.Field{Type: graphql.Boolean},
+		constants.CENTROID_METRICS: &graphql.Field{Type: MovieMetricsType},
+		constants.SIZE:             &graphql.Field{Type: graphql.Int},
+		constants.MOVIES:           &graphql.Field{Type: graphql.NewList(MovieType)},
+	},
+})
+
+var RecommendationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.RECOMMENDATION_TYPE,
+	Fields: graphql.Fields{
+		constants.MOVIE:    &graphql.Field{Type: MovieType},
+		constants.SCORE:    &graphql.Field{Type: graphql.Float},
+		constants.CENTROID: &graphql.Field{Type: graphql.Int},
+	},
+})
+
+var DimensionContributionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.DIMENSION_CONTRIBUTION_TYPE,
+	Fields: graphql.Fields{
+		constants.DIMENSION: &graphql.Field{Type: graphql.String},
+		constants.MOOD:      &graphql.Field{Type: graphql.Float},
+		constants.MOVIE:     &graphql.Field{Type: graphql.Float},
+		constants.SHARE:     &graphql.Field{Type: graphql.Float},
+	},
+})
+
+var PickExplanationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.PICK_EXPLANATION_TYPE,
+	Fields: graphql.Fields{
+		constants.CENTROID:         &graphql.Field{Type: graphql.Int},
+		constants.DISTANCE:         &graphql.Field{Type: graphql.Float},
+		constants.TOP_DIMENSIONS:   &graphql.Field{Type: graphql.NewList(DimensionContributionType)},
+		constants.INFLUENCED_BY:    &graphql.Field{Type: graphql.NewList(graphql.String)},
+		constants.ALSO_RENTED_WITH: &graphql.Field{Type: graphql.String},
+	},
+})
+
+var FinalPickType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.FINAL_PICK_TYPE,
+	Fields: graphql.Fields{
+		constants.MOVIE_ID:    &graphql.Field{Type: graphql.String},
+		constants.EXPLANATION: &graphql.Field{Type: PickExplanationType},
+	},
+})
+
+var DimensionWeightInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: constants.DIMENSION_WEIGHT_INPUT,
+	Fields: graphql.InputObjectConfigFieldMap{
+		constants.DIMENSION: &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		constants.WEIGHT:    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Float)},
+	},
+})
+
+var QuizQuestionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.QUIZ_QUESTION_TYPE,
+	Fields: graphql.Fields{
+		constants.MOVIE_ID: &graphql.Field{Type: graphql.String},
+		constants.TITLE:    &graphql.Field{Type: graphql.String},
+		constants.QUESTION: &graphql.Field{Type: graphql.String},
+		constants.CHOICES:  &graphql.Field{Type: graphql.NewList(graphql.String)},
+	},
+})
+
+// QuizType is a quiz waiting for its answers; the right choices are never exposed.
+var QuizType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.QUIZ_TYPE,
+	Fields: graphql.Fields{
+		constants.QUIZ_ID:    &graphql.Field{Type: graphql.ID},
+		constants.USERNAME:   &graphql.Field{Type: graphql.String},
+		constants.SOURCE:     &graphql.Field{Type: graphql.String},
+		constants.CENTROID:   &graphql.Field{Type: graphql.Int},
+		constants.QUESTIONS:  &graphql.Field{Type: graphql.NewList(QuizQuestionType)},
+		constants.EXPIRES_AT: &graphql.Field{Type: graphql.DateTime},
+	},
+})
+
+var GradedAnswerType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.GRADED_ANSWER_TYPE,
+	Fields: graphql.Fields{
+		constants.QUESTION: &graphql.Field{Type: graphql.String},
+		constants.CHOSEN:   &graphql.Field{Type: graphql.Int},
+		constants.ANSWER:   &graphql.Field{Type: graphql.Int},
+		constants.CORRECT:  &graphql.Field{Type: graphql.Boolean},
+	},
+})
+
+var QuizStatsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.QUIZ_STATS_TYPE,
+	Fields: graphql.Fields{
+		constants.USERNAME: &graphql.Field{Type: graphql.String},
+		constants.POINTS:   &graphql.Field{Type: graphql.Int},
+		constants.ASKED:    &graphql.Field{Type: graphql.Int},
+		constants.PLAYED:   &graphql.Field{Type: graphql.Int},
+		constants.RANK:     &graphql.Field{Type: graphql.Int},
+	},
+})
+
+var QuizResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: constants.QUIZ_RESULT_TYPE,
+	Fields: graphql.Fields{
+		constants.QUIZ_ID:  &graphql.Field{Type: graphql.ID},
+		constants.USERNAME: &graphql.Field{Type: graphql.String},
+		constants.CORRECT:  &graphql.Field{Type: graphql.Int},
+		constants.TOTAL:    &graphql.Field{Type: graphql.Int},
+		constants.GRADED:   &graphql.Field{Type: graphql.NewList(GradedAnswerType)},
+		constants.STATS:    &graphql.Field{Type: QuizStatsType},
 	},
 })
@@ -2,25 +2,33 @@ package gql
 
 import (
 	"blockbuster/api/constants"
+	graphsearch "blockbuster/api/graph_search"
 	"blockbuster/api/services"
 	"blockbuster/api/utils"
 	"context"
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 
 	"github.com/graphql-go/graphql"
 	"github.com/graphql-go/graphql/gqlerrors"
 )
 
 var (
-	movieService  services.MoviesServiceInterface
-	memberService services.MembersServiceInterface
+	movieService     services.MoviesServiceInterface
+	memberService    services.MembersServiceInterface
+	peopleService    services.PeopleServiceInterface
+	centroidsService services.CentroidsServiceInterface
+	quizService      services.QuizServiceInterface
 )
 
 func initServices() {
 	movieService = services.GetMovieService()
 	memberService = services.GetMemberService()
+	peopleService = services.GetPeopleService()
+	centroidsService = services.GetCentroidsService()
+	quizService = services.GetQuizService()
 }
 
 func SetMemberService(svc services.MembersServiceInterface) {
@@ -31,6 +39,18 @@ func SetMovieService(svc services.MoviesServiceInterface) {
 	movieService = svc
 }
 
+func SetPeopleService(svc services.PeopleServiceInterface) {
+	peopleService = svc
+}
+
+func SetCentroidsService(svc services.CentroidsServiceInterface) {
+	centroidsService = svc
+}
+
+func SetQuizService(svc services.QuizServiceInterface) {
+	quizService = svc
+}
+
 // getStringArg safely extracts a required string arg from the resolver params.
 func getStringArg(p graphql.ResolveParams, argName string, field string) (string, error) {
 	val, ok := p.Args[argName].(string)
@@ -60,6 +80,25 @@ func SetToList[T comparable](set map[T]bool) []T {
 	return list
 }
 
+// resolveName maps a star or director argument to its catalog spelling. Misses become a
+// 404 whose extensions carry the graph's "did you mean" suggestions.
+func resolveName(name string, resolve func(string) (string, error)) (string, error) {
+	resolved, err := resolve(name)
+	if err == nil {
+		return resolved, nil
+	}
+	return "", nameNotFoundError(err)
+}
+
+func nameNotFoundError(err error) gqlerrors.FormattedError {
+	formatted := getFormattedError(err.Error(), http.StatusNotFound)
+	var notFound *graphsearch.NameNotFoundError
+	if errors.As(err, &notFound) && len(notFound.Suggestions) > 0 {
+		formatted.Extensions[constants.SUGGESTIONS] = notFound.Suggestions
+	}
+	return formatted
+}
+
 func getFormattedError(msg string, status int) gqlerrors.FormattedError {
 	return gqlerrors.FormattedError{
 		Message: msg,
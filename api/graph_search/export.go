@@ -0,0 +1,357 @@
+package graphsearch
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/utils"
+)
+
+const (
+	nodeKindStar     = "star"
+	nodeKindMovie    = "movie"
+	nodeKindDirector = "director"
+
+	edgeKindStarredIn = "starred_in"
+	edgeKindDirected  = "directed"
+)
+
+// ExportRequest describes which part of the MovieGraph to export and in what format.
+// When Star, Title and Director are all empty the whole graph is exported, otherwise
+// the KevinBacon-style neighbourhood of the given seeds is exported up to Depth.
+// Year narrows Title when several movies share it.
+type ExportRequest struct {
+	Format   string
+	Star     string
+	Title    string
+	Year     string
+	Director string
+	Depth    int
+}
+
+type exportNode struct {
+	ID    string
+	Label string
+	Kind  string
+}
+
+type exportEdge struct {
+	Source string
+	Target string
+	Kind   string
+}
+
+type exportGraph struct {
+	nodes []exportNode
+	edges []exportEdge
+}
+
+// Export writes the requested (sub)graph to w as GraphViz DOT, GEXF or GraphML. A star or
+// director that cannot be resolved yields a *NameNotFoundError, and a title matching no movie
+// yields ErrMovieNotFound.
+func (g *MovieGraph) Export(w io.Writer, req ExportRequest) error {
+	var eg exportGraph
+	if req.Star == "" && req.Title == "" && req.Director == "" {
+		eg = g.wholeGraph()
+	} else {
+		var err error
+		if eg, err = g.subgraph(req.Star, req.Title, req.Year, req.Director, req.Depth); err != nil {
+			return err
+		}
+	}
+
+	switch strings.ToLower(req.Format) {
+	case constants.DOT:
+		return writeDOT(w, eg)
+	case constants.GEXF:
+		return writeGEXF(w, eg)
+	case constants.GRAPHML:
+		return writeGraphML(w, eg)
+	default:
+		return utils.LogError(fmt.Sprintf("unsupported export format %q; must be one of %s, %s or %s",
+			req.Format, constants.DOT, constants.GEXF, constants.GRAPHML), nil)
+	}
+}
+
+func (g *MovieGraph) wholeGraph() exportGraph {
+	stars := make(map[string]bool, len(g.starredIn))
+	for star := range g.starredIn {
+		stars[star] = true
+	}
+	directors := make(map[string]bool, len(g.directedMovies))
+	for director := range g.directedMovies {
+		directors[director] = true
+	}
+	movies := make([]data.Movie, 0, len(g.movies))
+	for _, movie := range g.movies {
+		movies = append(movies, movie)
+	}
+	return buildExportGraph(stars, movies, directors)
+}
+
+// subgraph seeds a BFS the same way the KevinBacon query does: the star itself,
+// every actor the director has worked with and the cast of every movie with the title.
+func (g *MovieGraph) subgraph(star, title, year, director string, depth int) (exportGraph, error) {
+	stars := make(map[string]bool)
+	movieIDs := make(map[string]bool)
+	directors := make(map[string]bool)
+
+	var toSearch []string
+	if star != "" {
+		canonical, err := g.starNames.resolve(star)
+		if err != nil {
+			return exportGraph{}, err
+		}
+		toSearch = append(toSearch, canonical)
+	}
+	if director != "" {
+		canonical, err := g.directorNames.resolve(director)
+		if err != nil {
+			return exportGraph{}, err
+		}
+		directors[canonical] = true
+		toSearch = append(toSearch, g.GetDirectedActors(canonical)...)
+	}
+	if title != "" {
+		matches, err := g.GetMoviesFromTitle(title, year)
+		if err != nil {
+			return exportGraph{}, err
+		}
+		for _, movie := range matches {
+			movieIDs[movie.ID] = true
+			toSearch = append(toSearch, movie.Cast...)
+		}
+	}
+	for _, s := range toSearch {
+		if stars[s] {
+			continue
+		}
+		g.BFS(s, stars, movieIDs, directors, depth)
+	}
+
+	movies := make([]data.Movie, 0, len(movieIDs))
+	for mid := range movieIDs {
+		if movie, ok := g.movies[mid]; ok {
+			movies = append(movies, movie)
+		}
+	}
+	return buildExportGraph(stars, movies, directors), nil
+}
+
+// buildExportGraph links each movie to the stars and director that made it into the export.
+func buildExportGraph(stars map[string]bool, movies []data.Movie, directors map[string]bool) exportGraph {
+	var eg exportGraph
+	for star := range stars {
+		eg.nodes = append(eg.nodes, exportNode{ID: nodeID(nodeKindStar, star), Label: star, Kind: nodeKindStar})
+	}
+	for director := range directors {
+		eg.nodes = append(eg.nodes, exportNode{ID: nodeID(nodeKindDirector, director), Label: director, Kind: nodeKindDirector})
+	}
+	for _, movie := range movies {
+		movieNode := nodeID(nodeKindMovie, movie.ID)
+		eg.nodes = append(eg.nodes, exportNode{ID: movieNode, Label: movie.Title, Kind: nodeKindMovie})
+		for _, star := range movie.Cast {
+			if stars[star] {
+				eg.edges = append(eg.edges, exportEdge{Source: nodeID(nodeKindStar, star), Target: movieNode, Kind: edgeKindStarredIn})
+			}
+		}
+		if directors[movie.Director] {
+			eg.edges = append(eg.edges, exportEdge{Source: nodeID(nodeKindDirector, movie.Director), Target: movieNode, Kind: edgeKindDirected})
+		}
+	}
+
+	// keep output stable so exports can be diffed and checked into docs
+	sort.Slice(eg.nodes, func(i, j int) bool { return eg.nodes[i].ID < eg.nodes[j].ID })
+	sort.Slice(eg.edges, func(i, j int) bool {
+		if eg.edges[i].Source != eg.edges[j].Source {
+			return eg.edges[i].Source < eg.edges[j].Source
+		}
+		return eg.edges[i].Target < eg.edges[j].Target
+	})
+	return eg
+}
+
+func nodeID(kind, name string) string {
+	return kind + ":" + name
+}
+
+func writeDOT(w io.Writer, eg exportGraph) error {
+	var b strings.Builder
+	b.WriteString("graph movies {\n")
+	for _, n := range eg.nodes {
+		fmt.Fprintf(&b, "  %s [label=%s, type=%s, shape=%s];\n", dotQuote(n.ID), dotQuote(n.Label), dotQuote(n.Kind), dotShape(n.Kind))
+	}
+	for _, e := range eg.edges {
+		fmt.Fprintf(&b, "  %s -- %s [type=%s];\n", dotQuote(e.Source), dotQuote(e.Target), dotQuote(e.Kind))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func dotShape(kind string) string {
+	switch kind {
+	case nodeKindMovie:
+		return "box"
+	case nodeKindDirector:
+		return "diamond"
+	default:
+		return "ellipse"
+	}
+}
+
+type gexfDoc struct {
+	XMLName xml.Name  `xml:"gexf"`
+	Xmlns   string    `xml:"xmlns,attr"`
+	Version string    `xml:"version,attr"`
+	Graph   gexfGraph `xml:"graph"`
+}
+
+type gexfGraph struct {
+	Mode            string         `xml:"mode,attr"`
+	DefaultEdgeType string         `xml:"defaultedgetype,attr"`
+	Attributes      gexfAttributes `xml:"attributes"`
+	Nodes           []gexfNode     `xml:"nodes>node"`
+	Edges           []gexfEdge     `xml:"edges>edge"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfNode struct {
+	ID        string         `xml:"id,attr"`
+	Label     string         `xml:"label,attr"`
+	AttValues []gexfAttValue `xml:"attvalues>attvalue"`
+}
+
+type gexfAttValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type gexfEdge struct {
+	ID     string `xml:"id,attr"`
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+	Label  string `xml:"label,attr"`
+}
+
+func writeGEXF(w io.Writer, eg exportGraph) error {
+	doc := gexfDoc{
+		Xmlns:   "http://gexf.net/1.3",
+		Version: "1.3",
+		Graph: gexfGraph{
+			Mode:            "static",
+			DefaultEdgeType: "undirected",
+			Attributes: gexfAttributes{
+				Class:      "node",
+				Attributes: []gexfAttribute{{ID: "type", Title: "type", Type: "string"}},
+			},
+		},
+	}
+	for _, n := range eg.nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, gexfNode{
+			ID:        n.ID,
+			Label:     n.Label,
+			AttValues: []gexfAttValue{{For: "type", Value: n.Kind}},
+		})
+	}
+	for i, e := range eg.edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, gexfEdge{ID: fmt.Sprint(i), Source: e.Source, Target: e.Target, Label: e.Kind})
+	}
+	return writeXML(w, doc)
+}
+
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func writeGraphML(w io.Writer, eg exportGraph) error {
+	doc := graphMLDoc{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+			{ID: "type", For: "node", AttrName: "type", AttrType: "string"},
+			{ID: "relation", For: "edge", AttrName: "relation", AttrType: "string"},
+		},
+		Graph: graphMLGraph{ID: "movies", EdgeDefault: "undirected"},
+	}
+	for _, n := range eg.nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID:   n.ID,
+			Data: []graphMLData{{Key: "label", Value: n.Label}, {Key: "type", Value: n.Kind}},
+		})
+	}
+	for _, e := range eg.edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: e.Source,
+			Target: e.Target,
+			Data:   []graphMLData{{Key: "relation", Value: e.Kind}},
+		})
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return utils.LogError("encoding graph export", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
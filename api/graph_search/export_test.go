@@ -0,0 +1,97 @@
+package graphsearch
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport_DOTWholeGraph(t *testing.T) {
+	graph := createSampleGraph()
+	var buf bytes.Buffer
+
+	err := graph.Export(&buf, ExportRequest{Format: "dot"})
+	assert.NoError(t, err)
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "graph movies {"))
+	assert.Contains(t, out, `"star:Alice" [label="Alice", type="star", shape=ellipse];`)
+	assert.Contains(t, out, `"movie:3" [label="C", type="movie", shape=box];`)
+	assert.Contains(t, out, `"director:Nolan" -- "movie:3" [type="directed"];`)
+	assert.Contains(t, out, `"star:David" -- "movie:3" [type="starred_in"];`)
+}
+
+func TestExport_SubgraphAroundStar(t *testing.T) {
+	graph := createSampleGraph()
+	var buf bytes.Buffer
+
+	err := graph.Export(&buf, ExportRequest{Format: "dot", Star: "Charlie", Depth: 1})
+	assert.NoError(t, err)
+	out := buf.String()
+	assert.Contains(t, out, `"movie:2"`)
+	assert.Contains(t, out, `"star:Bob"`)
+	assert.NotContains(t, out, `"movie:3"`)
+	assert.NotContains(t, out, `"director:Nolan"`)
+}
+
+func TestExport_GEXF(t *testing.T) {
+	graph := createSampleGraph()
+	var buf bytes.Buffer
+
+	err := graph.Export(&buf, ExportRequest{Format: "gexf", Director: "Nolan", Depth: 1})
+	assert.NoError(t, err)
+
+	var doc gexfDoc
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "1.3", doc.Version)
+	ids := make([]string, 0, len(doc.Graph.Nodes))
+	for _, n := range doc.Graph.Nodes {
+		ids = append(ids, n.ID)
+	}
+	assert.Contains(t, ids, "director:Nolan")
+	assert.Contains(t, ids, "movie:3")
+	assert.NotEmpty(t, doc.Graph.Edges)
+}
+
+func TestExport_GraphML(t *testing.T) {
+	graph := createSampleGraph()
+	var buf bytes.Buffer
+
+	err := graph.Export(&buf, ExportRequest{Format: "GraphML", Title: "A", Depth: 1})
+	assert.NoError(t, err)
+
+	var doc graphMLDoc
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "undirected", doc.Graph.EdgeDefault)
+	assert.Len(t, doc.Keys, 3)
+	assert.NotEmpty(t, doc.Graph.Nodes)
+	assert.NotEmpty(t, doc.Graph.Edges)
+}
+
+func TestExport_InvalidFormat(t *testing.T) {
+	graph := createSampleGraph()
+	var buf bytes.Buffer
+
+	err := graph.Export(&buf, ExportRequest{Format: "png"})
+	assert.Error(t, err)
+	assert.Empty(t, buf.String())
+}
+
+func TestExport_UnknownSeeds(t *testing.T) {
+	graph := createSampleGraph()
+	var buf bytes.Buffer
+
+	err := graph.Export(&buf, ExportRequest{Format: "dot", Star: "Charly", Depth: 1})
+	var notFound *NameNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+	assert.Equal(t, []string{"Charlie"}, notFound.Suggestions)
+
+	err = graph.Export(&buf, ExportRequest{Format: "dot", Director: "Kubrick", Depth: 1})
+	assert.ErrorAs(t, err, &notFound)
+
+	err = graph.Export(&buf, ExportRequest{Format: "dot", Title: "Z", Depth: 1})
+	assert.ErrorIs(t, err, ErrMovieNotFound)
+	assert.Empty(t, buf.String())
+}
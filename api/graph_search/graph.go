@@ -1,31 +1,82 @@
 package graphsearch
 
 import (
+	"errors"
 	"fmt"
 
 	"blockbuster/api/data"
 	"blockbuster/api/utils"
 )
 
+// ErrMovieNotFound is returned when no movie in the graph has the requested ID or title.
+var ErrMovieNotFound = errors.New("movie not found")
+
 type MovieGraph struct {
-	directedMovies    map[string][]data.Movie
-	starredWith       map[string]map[string]bool
-	starredIn         map[string][]data.Movie
-	NumDirectors      int
-	NumStars          int
-	NumMovies         int
-	movieTitleToMovie map[string]data.Movie
+	directedMovies  map[string][]data.Movie
+	starredWith     map[string]map[string]bool
+	starredIn       map[string][]data.Movie
+	NumDirectors    int
+	NumStars        int
+	NumMovies       int
+	movies          map[string]data.Movie
+	titleToMovieIDs map[string][]string
+	starNames       *nameIndex
+	directorNames   *nameIndex
 }
 
-// BFS traverses the graph starting from an actor and collects related stars, movies, and directors.
+// addMovie indexes a movie, storing its cast and director under their canonical spellings
+// so differently formatted credits for the same person share one node.
+func (g *MovieGraph) addMovie(movie data.Movie) {
+	cast := make([]string, len(movie.Cast))
+	for i, star := range movie.Cast {
+		cast[i] = g.starNames.add(star)
+	}
+	movie.Cast = cast
+	movie.Director = g.directorNames.add(movie.Director)
+
+	g.NumMovies++
+	g.movies[movie.ID] = movie
+	g.titleToMovieIDs[movie.Title] = append(g.titleToMovieIDs[movie.Title], movie.ID)
+
+	// Index by director
+	g.directedMovies[movie.Director] = append(g.directedMovies[movie.Director], movie)
+
+	for _, star := range movie.Cast {
+		g.starredIn[star] = append(g.starredIn[star], movie)
+
+		if _, ok := g.starredWith[star]; !ok {
+			g.starredWith[star] = make(map[string]bool)
+		}
+		for _, coStar := range movie.Cast {
+			if star != coStar {
+				g.starredWith[star][coStar] = true
+			}
+		}
+	}
+	g.NumStars = len(g.starredIn)
+	g.NumDirectors = len(g.directedMovies)
+}
+
+// ResolveStar returns the catalog spelling of a star's name. Lookups ignore case, spacing,
+// diacritics and suffixes, and honour known aliases; misses carry "did you mean" suggestions.
+func (g *MovieGraph) ResolveStar(star string) (string, error) {
+	return g.starNames.resolve(star)
+}
+
+// ResolveDirector returns the catalog spelling of a director's name; see ResolveStar.
+func (g *MovieGraph) ResolveDirector(director string) (string, error) {
+	return g.directorNames.resolve(director)
+}
+
+// BFS traverses the graph starting from an actor and collects related stars, movie IDs, and directors.
 func (g *MovieGraph) BFS(
 	startStar string,
 	stars map[string]bool,
-	movieTitles map[string]bool,
+	movieIDs map[string]bool,
 	directors map[string]bool,
 	maxDepth int,
 ) {
-	toSearch := []string{startStar}
+	toSearch := []string{g.starNames.lookup(startStar)}
 	depth := 0
 
 	for len(toSearch) > 0 && depth < maxDepth {
@@ -41,10 +92,10 @@ func (g *MovieGraph) BFS(
 			for _, movie := range g.starredIn[star] {
 				directors[movie.Director] = true
 
-				if movieTitles[movie.Title] {
+				if movieIDs[movie.ID] {
 					continue
 				}
-				movieTitles[movie.Title] = true
+				movieIDs[movie.ID] = true
 
 				for _, coStar := range movie.Cast {
 					if !stars[coStar] {
@@ -63,14 +114,14 @@ func (g *MovieGraph) BFS(
 }
 
 func (g *MovieGraph) GetDirectedMovies(director string) []data.Movie {
-	return g.directedMovies[director]
+	return g.directedMovies[g.directorNames.lookup(director)]
 }
 
 func (g *MovieGraph) GetDirectedActors(director string) []string {
 	seen := make(map[string]bool)
 	var actors []string
 
-	for _, movie := range g.directedMovies[director] {
+	for _, movie := range g.directedMovies[g.directorNames.lookup(director)] {
 		for _, actor := range movie.Cast {
 			if !seen[actor] {
 				seen[actor] = true
@@ -82,29 +133,42 @@ func (g *MovieGraph) GetDirectedActors(director string) []string {
 }
 
 func (g *MovieGraph) GetStarredIn(star string) []data.Movie {
-	return g.starredIn[star]
+	return g.starredIn[g.starNames.lookup(star)]
 }
 
 func (g *MovieGraph) GetStarredWith(star string) []string {
 	var coStars []string
-	for coStar := range g.starredWith[star] {
+	for coStar := range g.starredWith[g.starNames.lookup(star)] {
 		coStars = append(coStars, coStar)
 	}
 	return coStars
 }
 
-func (g *MovieGraph) GetIDFromTitle(title string) (string, error) {
-	if movie, ok := g.movieTitleToMovie[title]; ok {
-		return movie.ID, nil
+func (g *MovieGraph) GetMovieByID(movieID string) (data.Movie, error) {
+	if movie, ok := g.movies[movieID]; ok {
+		return movie, nil
 	}
-	return "", utils.LogError(fmt.Sprintf("failed to get movieID for title %s", title), nil)
+	return data.Movie{}, utils.LogError(fmt.Sprintf("movie with id %s not found", movieID), ErrMovieNotFound)
 }
 
-func (g *MovieGraph) GetMovieFromTitle(title string) (data.Movie, error) {
-	if movie, ok := g.movieTitleToMovie[title]; ok {
-		return movie, nil
+// GetMoviesFromTitle returns every movie sharing the title, e.g. a film and its remakes.
+// A non-empty year narrows the matches to movies released that year.
+func (g *MovieGraph) GetMoviesFromTitle(title, year string) ([]data.Movie, error) {
+	var movies []data.Movie
+	for _, mid := range g.titleToMovieIDs[title] {
+		movie := g.movies[mid]
+		if year != "" && movie.Year != year {
+			continue
+		}
+		movies = append(movies, movie)
+	}
+	if len(movies) == 0 {
+		if year != "" {
+			return nil, utils.LogError(fmt.Sprintf("movie with title %s (%s) not found", title, year), ErrMovieNotFound)
+		}
+		return nil, utils.LogError(fmt.Sprintf("movie with title %s not found", title), ErrMovieNotFound)
 	}
-	return data.TestMovies[0], utils.LogError(fmt.Sprintf("failed to retrieve movie with  %s", title), nil)
+	return movies, nil
 }
 
 func (g *MovieGraph) TotalStars() int {
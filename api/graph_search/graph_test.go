@@ -13,32 +13,13 @@ func createSampleGraph() *MovieGraph {
 	movie2 := data.Movie{ID: "2", Title: "B", Director: "Spielberg", Cast: []string{"Bob", "Charlie"}}
 	movie3 := data.Movie{ID: "3", Title: "C", Director: "Nolan", Cast: []string{"Alice", "David"}}
 
-	return &MovieGraph{
-		directedMovies: map[string][]data.Movie{
-			"Spielberg": {movie1, movie2},
-			"Nolan":     {movie3},
-		},
-		starredIn: map[string][]data.Movie{
-			"Alice":   {movie1, movie3},
-			"Bob":     {movie1, movie2},
-			"Charlie": {movie2},
-			"David":   {movie3},
-		},
-		starredWith: map[string]map[string]bool{
-			"Alice":   {"Bob": true, "David": true},
-			"Bob":     {"Alice": true, "Charlie": true},
-			"Charlie": {"Bob": true},
-			"David":   {"Alice": true},
-		},
-		movieTitleToMovie: map[string]data.Movie{
-			"A": movie1,
-			"B": movie2,
-			"C": movie3,
-		},
-		NumDirectors: 2,
-		NumMovies:    3,
-		NumStars:     4,
-	}
+	graph, _ := newMovieGraph(func(g *MovieGraph) error {
+		for _, movie := range []data.Movie{movie1, movie2, movie3} {
+			g.addMovie(movie)
+		}
+		return nil
+	})
+	return graph
 }
 
 func TestBFS(t *testing.T) {
@@ -53,9 +34,9 @@ func TestBFS(t *testing.T) {
 	assert.True(t, stars["Bob"])
 	assert.True(t, stars["Charlie"])
 	assert.True(t, stars["David"])
-	assert.True(t, movies["A"])
-	assert.True(t, movies["B"])
-	assert.True(t, movies["C"])
+	assert.True(t, movies["1"])
+	assert.True(t, movies["2"])
+	assert.True(t, movies["3"])
 	assert.True(t, directors["Spielberg"])
 	assert.True(t, directors["Nolan"])
 }
@@ -84,23 +65,50 @@ func TestGetStarredWith(t *testing.T) {
 	assert.ElementsMatch(t, []string{"Bob", "David"}, coStars)
 }
 
-func TestGetMovieFromTitle(t *testing.T) {
+func TestGetMoviesFromTitle(t *testing.T) {
 	graph := createSampleGraph()
-	movie, err := graph.GetMovieFromTitle("A")
+	movies, err := graph.GetMoviesFromTitle("A", "")
 	assert.NoError(t, err)
-	assert.Equal(t, "1", movie.ID)
+	assert.Len(t, movies, 1)
+	assert.Equal(t, "1", movies[0].ID)
 }
 
-func TestGetIDFromTitle(t *testing.T) {
+func TestGetMoviesFromTitle_Remakes(t *testing.T) {
 	graph := createSampleGraph()
-	id, err := graph.GetIDFromTitle("C")
+	original := data.Movie{ID: "scarface_1932", Title: "Scarface", Year: "1932", Director: "Howard Hawks", Cast: []string{"Paul Muni"}}
+	remake := data.Movie{ID: "scarface_1983", Title: "Scarface", Year: "1983", Director: "Brian De Palma", Cast: []string{"Al Pacino"}}
+	graph.addMovie(original)
+	graph.addMovie(remake)
+
+	movies, err := graph.GetMoviesFromTitle("Scarface", "")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []data.Movie{original, remake}, movies)
+
+	movies, err = graph.GetMoviesFromTitle("Scarface", "1983")
+	assert.NoError(t, err)
+	assert.Equal(t, []data.Movie{remake}, movies)
+
+	_, err = graph.GetMoviesFromTitle("Scarface", "2001")
+	assert.Error(t, err)
+}
+
+func TestGetMoviesFromTitle_NotFound(t *testing.T) {
+	graph := createSampleGraph()
+	movies, err := graph.GetMoviesFromTitle("Z", "")
+	assert.Error(t, err)
+	assert.Empty(t, movies)
+}
+
+func TestGetMovieByID(t *testing.T) {
+	graph := createSampleGraph()
+	movie, err := graph.GetMovieByID("3")
 	assert.NoError(t, err)
-	assert.Equal(t, "3", id)
+	assert.Equal(t, "C", movie.Title)
 }
 
-func TestGetIDFromTitle_Invalid(t *testing.T) {
+func TestGetMovieByID_Invalid(t *testing.T) {
 	graph := createSampleGraph()
-	_, err := graph.GetIDFromTitle("Z")
+	_, err := graph.GetMovieByID("Z")
 	assert.Error(t, err)
 }
 
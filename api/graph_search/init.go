@@ -31,10 +31,13 @@ func GetMovieGraph() (*MovieGraph, error) {
 // This is useful for tests or alternate data loaders.
 func newMovieGraph(populate func(*MovieGraph) error) (*MovieGraph, error) {
 	graph := &MovieGraph{
-		directedMovies:    make(map[string][]data.Movie),
-		starredWith:       make(map[string]map[string]bool),
-		starredIn:         make(map[string][]data.Movie),
-		movieTitleToMovie: make(map[string]data.Movie),
+		directedMovies:  make(map[string][]data.Movie),
+		starredWith:     make(map[string]map[string]bool),
+		starredIn:       make(map[string][]data.Movie),
+		movies:          make(map[string]data.Movie),
+		titleToMovieIDs: make(map[string][]string),
+		starNames:       newNameIndex(constants.STAR, defaultAliases),
+		directorNames:   newNameIndex(constants.DIRECTOR, defaultAliases),
 	}
 	if err := populate(graph); err != nil {
 		return nil, err
@@ -59,29 +62,10 @@ func populateCaches(g *MovieGraph) error {
 		}
 
 		for _, movie := range movies {
-			g.NumMovies++
-			g.movieTitleToMovie[movie.Title] = movie
-
-			// Index by director
-			g.directedMovies[movie.Director] = append(g.directedMovies[movie.Director], movie)
-
-			for _, star := range movie.Cast {
-				g.starredIn[star] = append(g.starredIn[star], movie)
-				g.NumStars++
-
-				if _, ok := g.starredWith[star]; !ok {
-					g.starredWith[star] = make(map[string]bool)
-				}
-				for _, coStar := range movie.Cast {
-					if star != coStar {
-						g.starredWith[star][coStar] = true
-					}
-				}
-			}
+			g.addMovie(movie)
 		}
 	}
 
-	g.NumDirectors = len(g.directedMovies)
 	if len(errs) > 0 {
 		return utils.LogError("graphsearch", errors.Join(errs...))
 	}
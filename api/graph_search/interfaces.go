@@ -1,15 +1,24 @@
 package graphsearch
 
-import "blockbuster/api/data"
+import (
+	"io"
+
+	"blockbuster/api/data"
+)
 
 type MovieGraphInterface interface {
 	BFS(start string, stars, movieIDs, directors map[string]bool, depth int)
+	KevinBaconLayers(seeds KevinBaconSeeds, maxDepth int) []KevinBaconNode
 	GetDirectedActors(director string) []string
 	GetDirectedMovies(director string) []data.Movie
 	GetStarredIn(star string) []data.Movie
 	GetStarredWith(star string) []string
-	GetMovieFromTitle(title string) (data.Movie, error)
+	ResolveStar(star string) (string, error)
+	ResolveDirector(director string) (string, error)
+	GetMovieByID(movieID string) (data.Movie, error)
+	GetMoviesFromTitle(title, year string) ([]data.Movie, error)
 	TotalStars() int
 	TotalMovies() int
 	TotalDirectors() int
+	Export(w io.Writer, req ExportRequest) error
 }
@@ -0,0 +1,114 @@
+package graphsearch
+
+import (
+	"sort"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+)
+
+// KevinBaconSeeds are the starting points of a layered KevinBacon search.
+type KevinBaconSeeds struct {
+	Stars     []string
+	MovieIDs  []string
+	Directors []string
+}
+
+// KevinBaconNode is a star, movie or director discovered by a layered KevinBacon search.
+// Depth is the number of movies separating it from the seeds. Stars and directors record
+// the movie that first reached them in ViaMovie; movies record the star in ViaStar.
+type KevinBaconNode struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Depth    int    `json:"depth"`
+	ViaMovie string `json:"via_movie,omitempty"`
+	ViaStar  string `json:"via_star,omitempty"`
+}
+
+// KevinBaconLayers runs a breadth first search out from the seeds and returns every node
+// reached within maxDepth, ordered by depth, then type, then name, so results can be paged.
+//
+// Seed stars, seed movies, the movies of seed directors and everyone credited on those
+// movies sit at depth 0. Each further layer adds the other movies of the previous layer's
+// stars along with the co-stars and directors credited on them.
+func (g *MovieGraph) KevinBaconLayers(seeds KevinBaconSeeds, maxDepth int) []KevinBaconNode {
+	var nodes []KevinBaconNode
+	seenStars := make(map[string]bool)
+	seenMovies := make(map[string]bool)
+	seenDirectors := make(map[string]bool)
+
+	var frontier []string
+	addStar := func(star string, depth int, via string) {
+		if star == "" || seenStars[star] {
+			return
+		}
+		seenStars[star] = true
+		nodes = append(nodes, KevinBaconNode{ID: star, Name: star, Type: constants.STAR, Depth: depth, ViaMovie: via})
+		frontier = append(frontier, star)
+	}
+	addDirector := func(director string, depth int, via string) {
+		if director == "" || seenDirectors[director] {
+			return
+		}
+		seenDirectors[director] = true
+		nodes = append(nodes, KevinBaconNode{ID: director, Name: director, Type: constants.DIRECTOR, Depth: depth, ViaMovie: via})
+	}
+	addMovie := func(movie data.Movie, depth int, via string) {
+		if seenMovies[movie.ID] {
+			return
+		}
+		seenMovies[movie.ID] = true
+		nodes = append(nodes, KevinBaconNode{ID: movie.ID, Name: movie.Title, Type: constants.MOVIE, Depth: depth, ViaStar: via})
+		addDirector(movie.Director, depth, movie.ID)
+		for _, coStar := range movie.Cast {
+			addStar(coStar, depth, movie.ID)
+		}
+	}
+
+	for _, star := range seeds.Stars {
+		if star = g.starNames.lookup(star); len(g.starredIn[star]) > 0 {
+			addStar(star, 0, "")
+		}
+	}
+	for _, mid := range seeds.MovieIDs {
+		if movie, ok := g.movies[mid]; ok {
+			addMovie(movie, 0, "")
+		}
+	}
+	for _, director := range seeds.Directors {
+		director = g.directorNames.lookup(director)
+		if len(g.directedMovies[director]) > 0 {
+			addDirector(director, 0, "")
+		}
+		for _, movie := range g.directedMovies[director] {
+			addMovie(movie, 0, "")
+		}
+	}
+
+	for depth := 1; depth <= maxDepth && len(frontier) > 0; depth++ {
+		layer := frontier
+		frontier = nil
+		sort.Strings(layer)
+		for _, star := range layer {
+			for _, movie := range g.starredIn[star] {
+				addMovie(movie, depth, star)
+			}
+		}
+	}
+
+	typeOrder := map[string]int{constants.STAR: 0, constants.MOVIE: 1, constants.DIRECTOR: 2}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Depth != nodes[j].Depth {
+			return nodes[i].Depth < nodes[j].Depth
+		}
+		if nodes[i].Type != nodes[j].Type {
+			return typeOrder[nodes[i].Type] < typeOrder[nodes[j].Type]
+		}
+		if nodes[i].Name != nodes[j].Name {
+			return nodes[i].Name < nodes[j].Name
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes
+}
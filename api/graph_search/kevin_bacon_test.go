@@ -0,0 +1,62 @@
+package graphsearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/constants"
+)
+
+func TestKevinBaconLayers_Depths(t *testing.T) {
+	graph := createSampleGraph()
+
+	nodes := graph.KevinBaconLayers(KevinBaconSeeds{Stars: []string{"alice"}}, 2)
+
+	depths := make(map[string]int)
+	for _, node := range nodes {
+		depths[node.Type+":"+node.ID] = node.Depth
+	}
+	assert.Equal(t, 0, depths[constants.STAR+":Alice"])
+	assert.Equal(t, 1, depths[constants.MOVIE+":1"])
+	assert.Equal(t, 1, depths[constants.STAR+":Bob"])
+	assert.Equal(t, 1, depths[constants.DIRECTOR+":Spielberg"])
+	assert.Equal(t, 2, depths[constants.MOVIE+":2"])
+	assert.Equal(t, 2, depths[constants.STAR+":Charlie"])
+	for i := 1; i < len(nodes); i++ {
+		assert.LessOrEqual(t, nodes[i-1].Depth, nodes[i].Depth)
+	}
+}
+
+func TestKevinBaconLayers_Via(t *testing.T) {
+	graph := createSampleGraph()
+
+	nodes := graph.KevinBaconLayers(KevinBaconSeeds{Stars: []string{"Alice"}}, 1)
+
+	for _, node := range nodes {
+		switch {
+		case node.Type == constants.STAR && node.Name == "Bob":
+			assert.NotEmpty(t, node.ViaMovie)
+		case node.Type == constants.MOVIE:
+			assert.Equal(t, "Alice", node.ViaStar)
+		}
+	}
+}
+
+func TestKevinBaconLayers_MovieAndDirectorSeeds(t *testing.T) {
+	graph := createSampleGraph()
+
+	nodes := graph.KevinBaconLayers(KevinBaconSeeds{MovieIDs: []string{"3"}, Directors: []string{"Nolan"}}, 0)
+
+	for _, node := range nodes {
+		assert.Equal(t, 0, node.Depth)
+	}
+	assert.Len(t, nodes, 4)
+}
+
+func TestKevinBaconLayers_UnknownSeeds(t *testing.T) {
+	graph := createSampleGraph()
+
+	nodes := graph.KevinBaconLayers(KevinBaconSeeds{Stars: []string{"Nobody"}, Directors: []string{"Nobody"}}, 3)
+	assert.Empty(t, nodes)
+}
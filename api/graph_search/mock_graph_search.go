@@ -1,6 +1,8 @@
 package graphsearch
 
 import (
+	"io"
+
 	"github.com/stretchr/testify/mock"
 	
 	"blockbuster/api/data"
@@ -13,11 +15,16 @@ type MockMovieGraph struct {
 func (m *MockMovieGraph) BFS(
 	startStar string,
 	stars map[string]bool,
-	movieTitles map[string]bool,
+	movieIDs map[string]bool,
 	directors map[string]bool,
 	maxDepth int,
 ) {
-	m.Called(startStar, stars, movieTitles, directors, maxDepth)
+	m.Called(startStar, stars, movieIDs, directors, maxDepth)
+}
+
+func (m *MockMovieGraph) KevinBaconLayers(seeds KevinBaconSeeds, maxDepth int) []KevinBaconNode {
+	args := m.Called(seeds, maxDepth)
+	return args.Get(0).([]KevinBaconNode)
 }
 
 func (m *MockMovieGraph) GetNumDirectors() int {
@@ -36,25 +43,41 @@ func (m *MockMovieGraph) GetNumMovies() int {
 }
 
 func (m *MockMovieGraph) GetDirectedMovies(director string) []data.Movie {
-	args := m.Called()
+	args := m.Called(director)
 	return args.Get(0).([]data.Movie)
 }
 
 func (m *MockMovieGraph) GetStarredWith(star string) []string {
-	args := m.Called()
+	args := m.Called(star)
 	return args.Get(0).([]string)
 }
 
 func (m *MockMovieGraph) GetStarredIn(star string) []data.Movie {
-	args := m.Called()
+	args := m.Called(star)
 	return args.Get(0).([]data.Movie)
 }
 
-func (m *MockMovieGraph) GetMovieFromTitle(title string) (data.Movie, error) {
-	args := m.Called(title)
+func (m *MockMovieGraph) ResolveStar(star string) (string, error) {
+	args := m.Called(star)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMovieGraph) ResolveDirector(director string) (string, error) {
+	args := m.Called(director)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockMovieGraph) GetMovieByID(movieID string) (data.Movie, error) {
+	args := m.Called(movieID)
 	return args.Get(0).(data.Movie), args.Error(1)
 }
 
+func (m *MockMovieGraph) GetMoviesFromTitle(title, year string) ([]data.Movie, error) {
+	args := m.Called(title, year)
+	movies, _ := args.Get(0).([]data.Movie)
+	return movies, args.Error(1)
+}
+
 func (m *MockMovieGraph) GetStarredInMovies(star string) ([]data.Movie, error) {
 	args := m.Called(star)
 	return args.Get(0).([]data.Movie), args.Error(1)
@@ -78,4 +101,8 @@ func (m *MockMovieGraph) TotalMovies() int {
 func (m *MockMovieGraph) TotalDirectors() int {
 	args := m.Called()
 	return args.Int(0)
-}
\ No newline at end of file
+}
+func (m *MockMovieGraph) Export(w io.Writer, req ExportRequest) error {
+	args := m.Called(w, req)
+	return args.Error(0)
+}
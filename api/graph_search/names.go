@@ -0,0 +1,218 @@
+package graphsearch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"blockbuster/api/constants"
+)
+
+// generational suffixes are kept in index keys so "Lon Chaney" and "Lon Chaney Jr." stay
+// apart; an unsuffixed lookup still finds the one suffixed name that shares it
+var nameSuffixes = map[string]bool{
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true,
+}
+
+// honorifics dropped from the front of names so "Sir Alec Guinness" matches "Alec Guinness"
+var namePrefixes = map[string]bool{
+	"sir": true, "dame": true,
+}
+
+// defaultAliases maps well known nicknames and birth names to the name used in the catalog.
+var defaultAliases = map[string]string{
+	"Bogie":                 "Humphrey Bogart",
+	"The Duke":              "John Wayne",
+	"Marion Morrison":       "John Wayne",
+	"Archibald Leach":       "Cary Grant",
+	"Norma Jeane Mortenson": "Marilyn Monroe",
+	"Maurice Micklewhite":   "Michael Caine",
+	"Charlie Chaplin":       "Charles Chaplin",
+	"Hitch":                 "Alfred Hitchcock",
+}
+
+// NameNotFoundError is returned when a star or director cannot be resolved.
+// Suggestions holds the closest catalog names, if any.
+type NameNotFoundError struct {
+	Kind        string
+	Name        string
+	Suggestions []string
+}
+
+func (e *NameNotFoundError) Error() string {
+	msg := fmt.Sprintf("%s %q not found", e.Kind, e.Name)
+	if len(e.Suggestions) == 0 {
+		return msg
+	}
+	quoted := make([]string, len(e.Suggestions))
+	for i, s := range e.Suggestions {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%s; did you mean %s?", msg, strings.Join(quoted, ", "))
+}
+
+var stripDiacritics = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// NormalizeName folds a person's name into a lookup key: diacritics are stripped, case is
+// folded, punctuation and repeated whitespace collapse to single spaces, and honorifics
+// such as "Sir" are dropped, so "Humphrey  BOGART" and "humphrey bogart" share a key.
+// Generational suffixes are kept ("Lon Chaney Jr." becomes "lon chaney jr"); see
+// stripNameSuffix.
+func NormalizeName(name string) string {
+	folded, _, err := transform.String(stripDiacritics, name)
+	if err != nil {
+		folded = name
+	}
+	folded = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '-':
+			return unicode.ToLower(r)
+		default:
+			return ' '
+		}
+	}, folded)
+
+	fields := strings.Fields(folded)
+	for len(fields) > 1 && namePrefixes[fields[0]] {
+		fields = fields[1:]
+	}
+	return strings.Join(fields, " ")
+}
+
+// stripNameSuffix drops generational suffixes such as "jr" from a normalized name.
+func stripNameSuffix(key string) string {
+	fields := strings.Fields(key)
+	for len(fields) > 1 && nameSuffixes[fields[len(fields)-1]] {
+		fields = fields[:len(fields)-1]
+	}
+	return strings.Join(fields, " ")
+}
+
+// nameIndex maps normalized names to the spelling first seen when building the graph.
+// suffixed maps a name without its generational suffix to the suffixed names that share it.
+type nameIndex struct {
+	kind      string
+	canonical map[string]string
+	suffixed  map[string][]string
+	aliases   map[string]string
+}
+
+func newNameIndex(kind string, aliases map[string]string) *nameIndex {
+	idx := &nameIndex{
+		kind:      kind,
+		canonical: make(map[string]string),
+		suffixed:  make(map[string][]string),
+		aliases:   make(map[string]string, len(aliases)),
+	}
+	for alias, name := range aliases {
+		idx.aliases[NormalizeName(alias)] = NormalizeName(name)
+	}
+	return idx
+}
+
+func (idx *nameIndex) key(name string) string {
+	key := NormalizeName(name)
+	if aliased, ok := idx.aliases[key]; ok {
+		return aliased
+	}
+	return key
+}
+
+// add registers name, returning the canonical spelling it should be stored under.
+func (idx *nameIndex) add(name string) string {
+	key := idx.key(name)
+	if key == "" {
+		return name
+	}
+	if canonical, ok := idx.canonical[key]; ok {
+		return canonical
+	}
+	idx.canonical[key] = name
+	if base := stripNameSuffix(key); base != key {
+		idx.suffixed[base] = append(idx.suffixed[base], name)
+	}
+	return name
+}
+
+func (idx *nameIndex) resolve(name string) (string, error) {
+	key := idx.key(name)
+	if canonical, ok := idx.canonical[key]; ok {
+		return canonical, nil
+	}
+	// A suffixed name is a different person, so "Lon Chaney Sr." never falls back to
+	// "Lon Chaney". Only an unsuffixed query falls back, and only when it is unambiguous:
+	// "Sammy Davis" finds "Sammy Davis Jr." unless another suffixed Sammy Davis exists.
+	if names := idx.suffixed[key]; len(names) == 1 {
+		return names[0], nil
+	}
+	return "", &NameNotFoundError{Kind: idx.kind, Name: name, Suggestions: idx.suggest(key)}
+}
+
+// lookup resolves name, falling back to the raw input so map lookups simply miss.
+func (idx *nameIndex) lookup(name string) string {
+	if canonical, err := idx.resolve(name); err == nil {
+		return canonical
+	}
+	return name
+}
+
+// suggest returns the closest canonical names by edit distance, favouring names
+// that contain the query outright (e.g. a surname on its own).
+func (idx *nameIndex) suggest(key string) []string {
+	if key == "" {
+		return nil
+	}
+	type candidate struct {
+		name string
+		dist int
+	}
+	maxDist := max(2, len(key)/4)
+	var candidates []candidate
+	for k, name := range idx.canonical {
+		d := levenshtein(key, k)
+		if len(key) >= 3 && strings.Contains(k, key) {
+			d = min(d, 1)
+		}
+		if d <= maxDist {
+			candidates = append(candidates, candidate{name: name, dist: d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].dist != candidates[j].dist {
+			return candidates[i].dist < candidates[j].dist
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	suggestions := make([]string, 0, constants.MAX_NAME_SUGGESTIONS)
+	for i := 0; i < len(candidates) && i < constants.MAX_NAME_SUGGESTIONS; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+	return suggestions
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,105 @@
+package graphsearch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/data"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := map[string]string{
+		"Humphrey Bogart":     "humphrey bogart",
+		"  Humphrey  BOGART ": "humphrey bogart",
+		"Penélope Cruz":       "penelope cruz",
+		"Robert Downey Jr.":   "robert downey jr",
+		"Sammy Davis, Jr.":    "sammy davis jr",
+		"Sir Alec Guinness":   "alec guinness",
+		"Lupita Nyong'o":      "lupita nyong'o",
+		"Jr.":                 "jr",
+	}
+	for in, expected := range tests {
+		assert.Equal(t, expected, NormalizeName(in), in)
+	}
+}
+
+func TestResolveStar_Normalized(t *testing.T) {
+	graph := createSampleGraph()
+	graph.addMovie(data.Movie{ID: "4", Title: "Casablanca", Director: "Michael Curtiz", Cast: []string{"Humphrey Bogart"}})
+
+	for _, name := range []string{"humphrey bogart", "Humphrey  Bogart", "HUMPHREY BOGART", "Bogie"} {
+		star, err := graph.ResolveStar(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, "Humphrey Bogart", star)
+	}
+	assert.Len(t, graph.GetStarredIn("humphrey  bogart"), 1)
+}
+
+func TestResolveStar_Suggestions(t *testing.T) {
+	graph := createSampleGraph()
+
+	_, err := graph.ResolveStar("Alise")
+	var notFound *NameNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, []string{"Alice"}, notFound.Suggestions)
+	assert.Contains(t, err.Error(), `did you mean "Alice"?`)
+
+	_, err = graph.ResolveStar("Zebediah")
+	assert.True(t, errors.As(err, &notFound))
+	assert.Empty(t, notFound.Suggestions)
+}
+
+func TestResolveDirector(t *testing.T) {
+	graph := createSampleGraph()
+
+	director, err := graph.ResolveDirector(" spielberg ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Spielberg", director)
+	assert.Len(t, graph.GetDirectedMovies("SPIELBERG"), 2)
+	assert.ElementsMatch(t, []string{"Alice", "Bob", "Charlie"}, graph.GetDirectedActors("spielberg"))
+}
+
+func TestAddMovie_MergesSpellings(t *testing.T) {
+	graph := createSampleGraph()
+	graph.addMovie(data.Movie{ID: "4", Title: "D", Director: "nolan", Cast: []string{"alice", "Bob "}})
+
+	assert.Equal(t, 4, graph.TotalStars())
+	assert.Equal(t, 2, graph.TotalDirectors())
+	assert.Len(t, graph.GetStarredIn("Alice"), 3)
+	assert.Len(t, graph.GetDirectedMovies("Nolan"), 2)
+	assert.ElementsMatch(t, []string{"Bob", "David"}, graph.GetStarredWith("ALICE"))
+}
+
+func TestResolveStar_KeepsGenerationalSuffixes(t *testing.T) {
+	graph := createSampleGraph()
+	graph.addMovie(data.Movie{ID: "4", Title: "The Phantom of the Opera", Director: "Rupert Julian", Cast: []string{"Lon Chaney"}})
+	graph.addMovie(data.Movie{ID: "5", Title: "The Wolf Man", Director: "George Waggner", Cast: []string{"Lon Chaney Jr."}})
+	graph.addMovie(data.Movie{ID: "6", Title: "Iron Man", Director: "Jon Favreau", Cast: []string{"Robert Downey Jr."}})
+	graph.addMovie(data.Movie{ID: "7", Title: "Greetings", Director: "Brian De Palma", Cast: []string{"Robert Downey"}})
+	graph.addMovie(data.Movie{ID: "8", Title: "Ocean's 11", Director: "Lewis Milestone", Cast: []string{"Sammy Davis Jr."}})
+	graph.addMovie(data.Movie{ID: "9", Title: "Dancers", Director: "Herbert Ross", Cast: []string{"Alec Guinness"}})
+
+	assert.Len(t, graph.GetStarredIn("Lon Chaney"), 1)
+	assert.Len(t, graph.GetStarredIn("lon chaney jr"), 1)
+	assert.NotEqual(t, graph.GetStarredIn("Lon Chaney")[0].ID, graph.GetStarredIn("Lon Chaney Jr.")[0].ID)
+
+	tests := map[string]string{
+		"Lon Chaney Jr":      "Lon Chaney Jr.",
+		"Robert Downey":      "Robert Downey",
+		"Sammy Davis":        "Sammy Davis Jr.",
+		"Sir Alec Guinness":  "Alec Guinness",
+		"robert downey, jr.": "Robert Downey Jr.",
+	}
+	for in, expected := range tests {
+		star, err := graph.ResolveStar(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, expected, star, in)
+	}
+
+	_, err := graph.ResolveStar("Lon Chaney Sr.")
+	var notFound *NameNotFoundError
+	assert.ErrorAs(t, err, &notFound, "a suffixed name never resolves to a different generation")
+	assert.Contains(t, notFound.Suggestions, "Lon Chaney")
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/services"
+)
+
+type AdminHandler struct {
+	service services.AdminServiceInterface
+}
+
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{
+		service: services.GetAdminService(),
+	}
+}
+
+func NewAdminHandlerWithService(service services.AdminServiceInterface) *AdminHandler {
+	return &AdminHandler{
+		service: service,
+	}
+}
+
+func (h *AdminHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/admin/caches/status", h.GetCacheStatuses)
+	rg.POST("/admin/caches/reload", h.ReloadCaches)
+}
+
+func (h *AdminHandler) GetCacheStatuses(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{constants.CACHES: h.service.GetCacheStatuses(c.Request.Context())})
+}
+
+// ReloadCaches reloads the cache named by the optional cache query parameter, or every
+// cache. A failed reload still reports the statuses, with the previous contents kept.
+func (h *AdminHandler) ReloadCaches(c *gin.Context) {
+	statuses, err := h.service.ReloadCaches(c.Request.Context(), c.Query(constants.CACHE))
+	if errors.Is(err, services.ErrUnknownCache) {
+		c.JSON(http.StatusNotFound, gin.H{"msg": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error(), constants.CACHES: statuses})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{constants.CACHES: statuses})
+}
@@ -0,0 +1,84 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/data"
+	"blockbuster/api/handlers"
+	"blockbuster/api/services"
+)
+
+func setupAdminRouter(service services.AdminServiceInterface) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	h := handlers.NewAdminHandlerWithService(service)
+	r.GET("/admin/caches/status", h.GetCacheStatuses)
+	r.POST("/admin/caches/reload", h.ReloadCaches)
+	return r
+}
+
+func TestGetCacheStatuses(t *testing.T) {
+	loaded := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mockService := new(services.MockAdminService)
+	mockService.On("GetCacheStatuses", mock.Anything).Return([]data.CacheStatus{
+		{Name: "centroids", Size: 60, LastLoaded: loaded},
+		{Name: "centroids_to_movies", LastError: "scan failed"},
+	})
+	r := setupAdminRouter(mockService)
+
+	req, _ := http.NewRequest(http.MethodGet, "/admin/caches/status", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var body struct {
+		Caches []data.CacheStatus `json:"caches"`
+	}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Len(t, body.Caches, 2)
+	assert.Equal(t, 60, body.Caches[0].Size)
+	assert.True(t, loaded.Equal(body.Caches[0].LastLoaded))
+	assert.Equal(t, "scan failed", body.Caches[1].LastError)
+}
+
+func TestReloadCaches(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		cache    string
+		statuses []data.CacheStatus
+		err      error
+		code     int
+		contains string
+	}{
+		{"all caches", "", "", []data.CacheStatus{{Name: "centroids"}, {Name: "centroids_to_movies"}}, nil, http.StatusOK, "centroids_to_movies"},
+		{"one cache", "?cache=centroids", "centroids", []data.CacheStatus{{Name: "centroids"}}, nil, http.StatusOK, "centroids"},
+		{"unknown cache", "?cache=trivia", "trivia", nil, fmt.Errorf("%w: trivia", services.ErrUnknownCache), http.StatusNotFound, "unknown cache"},
+		{"reload failed", "", "", []data.CacheStatus{{Name: "centroids", LastError: "scan failed"}}, errors.New("scan failed"), http.StatusInternalServerError, "scan failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(services.MockAdminService)
+			mockService.On("ReloadCaches", mock.Anything, tt.cache).Return(tt.statuses, tt.err)
+			r := setupAdminRouter(mockService)
+
+			req, _ := http.NewRequest(http.MethodPost, "/admin/caches/reload"+tt.query, nil)
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.code, resp.Code)
+			assert.Contains(t, resp.Body.String(), tt.contains)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/services"
+)
+
+type CentroidsHandler struct {
+	service services.CentroidsServiceInterface
+}
+
+func NewCentroidsHandler() *CentroidsHandler {
+	return &CentroidsHandler{
+		service: services.GetCentroidsService(),
+	}
+}
+
+func NewCentroidsHandlerWithService(service services.CentroidsServiceInterface) *CentroidsHandler {
+	return &CentroidsHandler{
+		service: service,
+	}
+}
+
+func (h *CentroidsHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/centroids", h.GetCentroids)
+	rg.GET("/centroids/:centroidID", h.GetCentroid)
+	rg.PUT("/admin/centroids/:centroidID/label", h.SetCentroidLabel)
+}
+
+// GetCentroids lists every centroid as a mood shelf with up to k representative movies.
+func (h *CentroidsHandler) GetCentroids(c *gin.Context) {
+	k, ok := shelfSize(c)
+	if !ok {
+		return
+	}
+	centroids, err := h.service.GetCentroids(c.Request.Context(), k)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, centroids)
+}
+
+func (h *CentroidsHandler) GetCentroid(c *gin.Context) {
+	id, ok := centroidID(c)
+	if !ok {
+		return
+	}
+	k, ok := shelfSize(c)
+	if !ok {
+		return
+	}
+	centroid, err := h.service.GetCentroid(c.Request.Context(), id, k)
+	h.respond(c, centroid, err)
+}
+
+// SetCentroidLabel overrides a centroid's generated label; an empty label reverts to it.
+func (h *CentroidsHandler) SetCentroidLabel(c *gin.Context) {
+	id, ok := centroidID(c)
+	if !ok {
+		return
+	}
+	var label data.CentroidLabel
+	if err := c.ShouldBindJSON(&label); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body"})
+		return
+	}
+	centroid, err := h.service.SetCentroidLabel(c.Request.Context(), id, label)
+	h.respond(c, centroid, err)
+}
+
+func (h *CentroidsHandler) respond(c *gin.Context, centroid data.Centroid, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidLabel):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+	case errors.Is(err, services.ErrCentroidNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+	default:
+		c.JSON(http.StatusOK, centroid)
+	}
+}
+
+func centroidID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param(constants.CENTROID_ID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid centroidID; must be an integer"})
+		return 0, false
+	}
+	return id, true
+}
+
+func shelfSize(c *gin.Context) (int, bool) {
+	k, err := strconv.Atoi(c.DefaultQuery(constants.K, strconv.Itoa(constants.DEFAULT_SHELF_MOVIES)))
+	if err != nil || k < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid k; must be a non-negative integer"})
+		return 0, false
+	}
+	return min(k, constants.MAX_SHELF_MOVIES), true
+}
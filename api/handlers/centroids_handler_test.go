@@ -0,0 +1,131 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/data"
+	"blockbuster/api/handlers"
+	"blockbuster/api/services"
+)
+
+func setupCentroidsRouter(service services.CentroidsServiceInterface) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	handlers.NewCentroidsHandlerWithService(service).RegisterRoutes(r.Group(""))
+	return r
+}
+
+func TestGetCentroidsHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantK      int
+		wantStatus int
+	}{
+		{"default k", "", 5, http.StatusOK},
+		{"capped k", "?k=100", 20, http.StatusOK},
+		{"no movies", "?k=0", 0, http.StatusOK},
+		{"negative k", "?k=-1", 0, http.StatusBadRequest},
+		{"non-numeric k", "?k=lots", 0, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(services.MockCentroidsService)
+			mockService.On("GetCentroids", mock.Anything, tt.wantK).
+				Return([]data.Centroid{{ID: 0, Label: "romantic comedies", Size: 2, Movies: []data.Movie{{ID: "notting_hill"}}}}, nil)
+			r := setupCentroidsRouter(mockService)
+
+			req, _ := http.NewRequest(http.MethodGet, "/centroids"+tt.query, nil)
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantStatus, resp.Code)
+			if tt.wantStatus != http.StatusOK {
+				mockService.AssertNotCalled(t, "GetCentroids", mock.Anything, mock.Anything)
+				return
+			}
+			var body []data.Centroid
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+			assert.Equal(t, "romantic comedies", body[0].Label)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetCentroidsHandler_Error(t *testing.T) {
+	mockService := new(services.MockCentroidsService)
+	mockService.On("GetCentroids", mock.Anything, 5).Return(nil, errors.New("failed to fetch centroid labels"))
+	r := setupCentroidsRouter(mockService)
+
+	req, _ := http.NewRequest(http.MethodGet, "/centroids", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
+
+func TestGetCentroidHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		serviceErr error
+		wantStatus int
+	}{
+		{"found", "/centroids/3", nil, http.StatusOK},
+		{"not found", "/centroids/3", fmt.Errorf("%w: 3", services.ErrCentroidNotFound), http.StatusNotFound},
+		{"bad id", "/centroids/horror", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(services.MockCentroidsService)
+			mockService.On("GetCentroid", mock.Anything, 3, 5).Return(data.Centroid{ID: 3}, tt.serviceErr)
+			r := setupCentroidsRouter(mockService)
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantStatus, resp.Code)
+		})
+	}
+}
+
+func TestSetCentroidLabelHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		serviceErr error
+		wantStatus int
+	}{
+		{"labelled", `{"label":"Date night","description":"Romcoms for two."}`, nil, http.StatusOK},
+		{"invalid label", `{"description":"Romcoms for two."}`, services.ErrInvalidLabel, http.StatusBadRequest},
+		{"not found", `{"label":"Date night"}`, services.ErrCentroidNotFound, http.StatusNotFound},
+		{"repo error", `{"label":"Date night"}`, errors.New("failed to label centroid 3"), http.StatusInternalServerError},
+		{"invalid json", `{"label":`, nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(services.MockCentroidsService)
+			mockService.On("SetCentroidLabel", mock.Anything, 3, mock.Anything).
+				Return(data.Centroid{ID: 3, Label: "Date night", Custom: true}, tt.serviceErr)
+			r := setupCentroidsRouter(mockService)
+
+			req, _ := http.NewRequest(http.MethodPut, "/admin/centroids/3/label", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.wantStatus, resp.Code)
+		})
+	}
+}
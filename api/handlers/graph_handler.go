@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"blockbuster/api/constants"
+	graphsearch "blockbuster/api/graph_search"
+)
+
+var exportContentTypes = map[string]string{
+	constants.DOT:     "text/vnd.graphviz; charset=utf-8",
+	constants.GEXF:    "application/gexf+xml; charset=utf-8",
+	constants.GRAPHML: "application/graphml+xml; charset=utf-8",
+}
+
+type GraphHandler struct {
+	graph graphsearch.MovieGraphInterface
+}
+
+func NewGraphHandler() *GraphHandler {
+	graph, err := graphsearch.GetMovieGraph()
+	if err != nil {
+		log.Printf("graph export unavailable; failed to initialize MovieGraph: %v", err)
+		return &GraphHandler{}
+	}
+	return &GraphHandler{graph: graph}
+}
+
+func NewGraphHandlerWithGraph(graph graphsearch.MovieGraphInterface) *GraphHandler {
+	return &GraphHandler{graph: graph}
+}
+
+func (h *GraphHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/graph/export", h.ExportGraph)
+}
+
+func (h *GraphHandler) ExportGraph(c *gin.Context) {
+	format := strings.ToLower(c.Query(constants.FORMAT))
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": fmt.Sprintf("Invalid format '%s'; must be one of %s, %s or %s",
+			format, constants.DOT, constants.GEXF, constants.GRAPHML)})
+		return
+	}
+	depth, err := strconv.Atoi(c.DefaultQuery(constants.DEPTH, "1"))
+	if err != nil || depth < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid depth; must be a positive integer"})
+		return
+	}
+	if h.graph == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"msg": "movie graph is unavailable"})
+		return
+	}
+
+	var buf bytes.Buffer
+	err = h.graph.Export(&buf, graphsearch.ExportRequest{
+		Format:   format,
+		Star:     c.Query(constants.STAR),
+		Title:    c.Query(constants.TITLE),
+		Year:     c.Query(constants.YEAR),
+		Director: c.Query(constants.DIRECTOR),
+		Depth:    min(depth, constants.MAX_KEVIN_BACON_DEPTH),
+	})
+	if err != nil {
+		var notFound *graphsearch.NameNotFoundError
+		switch {
+		case errors.As(err, &notFound):
+			c.JSON(http.StatusNotFound, gin.H{"msg": err.Error(), constants.SUGGESTIONS: notFound.Suggestions})
+		case errors.Is(err, graphsearch.ErrMovieNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"msg": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"msg": fmt.Sprintf("err exporting graph as %s", format)})
+		}
+		return
+	}
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
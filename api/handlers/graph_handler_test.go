@@ -0,0 +1,97 @@
+package handlers_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	graphsearch "blockbuster/api/graph_search"
+	"blockbuster/api/handlers"
+)
+
+func TestExportGraph_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	mockGraph := new(graphsearch.MockMovieGraph)
+	h := handlers.NewGraphHandlerWithGraph(mockGraph)
+	r.GET("/graph/export", h.ExportGraph)
+
+	expectedReq := graphsearch.ExportRequest{Format: "gexf", Star: "Kevin Bacon", Depth: 2}
+	mockGraph.On("Export", mock.Anything, expectedReq).Run(func(args mock.Arguments) {
+		io.WriteString(args.Get(0).(io.Writer), "<gexf/>")
+	}).Return(nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/graph/export?format=GEXF&star=Kevin%20Bacon&depth=2", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Header().Get("Content-Type"), "application/gexf+xml")
+	assert.Equal(t, "<gexf/>", resp.Body.String())
+	mockGraph.AssertExpectations(t)
+}
+
+func TestExportGraph_InvalidFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	h := handlers.NewGraphHandlerWithGraph(new(graphsearch.MockMovieGraph))
+	r.GET("/graph/export", h.ExportGraph)
+
+	req, _ := http.NewRequest(http.MethodGet, "/graph/export?format=png", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Invalid format")
+}
+
+func TestExportGraph_ExportError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	mockGraph := new(graphsearch.MockMovieGraph)
+	h := handlers.NewGraphHandlerWithGraph(mockGraph)
+	r.GET("/graph/export", h.ExportGraph)
+
+	mockGraph.On("Export", mock.Anything, mock.Anything).Return(errors.New("boom"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/graph/export?format=dot", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
+
+func TestExportGraph_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	mockGraph := new(graphsearch.MockMovieGraph)
+	h := handlers.NewGraphHandlerWithGraph(mockGraph)
+	r.GET("/graph/export", h.ExportGraph)
+
+	notFound := &graphsearch.NameNotFoundError{Kind: "star", Name: "Kevin Bakon", Suggestions: []string{"Kevin Bacon"}}
+	mockGraph.On("Export", mock.Anything, mock.MatchedBy(func(req graphsearch.ExportRequest) bool {
+		return req.Star != ""
+	})).Return(notFound)
+	mockGraph.On("Export", mock.Anything, mock.MatchedBy(func(req graphsearch.ExportRequest) bool {
+		return req.Title != ""
+	})).Return(fmt.Errorf("movie with title Tremors not found: %w", graphsearch.ErrMovieNotFound))
+
+	req, _ := http.NewRequest(http.MethodGet, "/graph/export?format=dot&star=Kevin%20Bakon", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"suggestions":["Kevin Bacon"]`)
+
+	req, _ = http.NewRequest(http.MethodGet, "/graph/export?format=dot&title=Tremors", nil)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Contains(t, resp.Body.String(), "Tremors")
+}
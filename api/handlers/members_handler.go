@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
+	"blockbuster/api/api_cache"
 	"blockbuster/api/constants"
 	"blockbuster/api/data"
 	"blockbuster/api/services"
@@ -40,11 +43,20 @@ func (h *MembersHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.POST("/members/checkout", h.Checkout)
 	rg.POST("/members/return", h.Return)
 	rg.GET("/members/:username/checkedout", h.GetCheckedOutMovies)
+	rg.GET("/members/:username/recommendations", h.GetRecommendations)
 	rg.PUT("/members/:username", h.SetAPIChoice)
 	rg.GET("/members/mood/initial_voting", h.GetIniitialVotingSlate)
 	rg.POST("/members/mood/vote", h.IterateRecommendationVoting)
 	rg.POST("/members/mood", h.UpdateMood)
 	rg.POST("/members/mood/picks", h.GetVotingFinalPicks)
+	rg.POST("/members/mood/sessions", h.StartVotingSession)
+	rg.POST("/members/mood/sessions/:sessionID/vote", h.VoteInSession)
+	rg.POST("/members/mood/sessions/:sessionID/picks", h.FinishVotingSession)
+	rg.POST("/members/mood/rooms", h.CreateVotingRoom)
+	rg.GET("/members/mood/rooms/:code", h.GetVotingRoom)
+	rg.POST("/members/mood/rooms/:code/join", h.JoinVotingRoom)
+	rg.POST("/members/mood/rooms/:code/vote", h.VoteInRoom)
+	rg.POST("/members/mood/rooms/:code/picks", h.FinishVotingRoom)
 }
 
 func (h *MembersHandler) GetMember(c *gin.Context) {
@@ -218,17 +230,28 @@ func (h *MembersHandler) SetAPIChoice(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"msg": fmt.Sprintf("API choice set to %s", apiChoice)})
 }
 
+// GetIniitialVotingSlate draws the first slate. Passing the seed from an earlier response
+// as ?seed= draws the same slate again.
 func (h *MembersHandler) GetIniitialVotingSlate(c *gin.Context) {
-	movieIDs, err := h.service.GetIniitialVotingSlate(c)
+	var seed *int64
+	if arg, ok := c.GetQuery(constants.SEED); ok {
+		parsed, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": "seed must be an integer"})
+			return
+		}
+		seed = &parsed
+	}
+	movieIDs, used, err := h.service.GetIniitialVotingSlate(c, c.Query(constants.USERNAME), seed)
 	if len(movieIDs) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"msg": "unable to get initial voting slate movies", "err": err.Error()})
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{"msg": "initial voting slate of movies gathered with errors", "err": err.Error(), "movies": movieIDs})
+		c.JSON(http.StatusOK, gin.H{"msg": "initial voting slate of movies gathered with errors", "err": err.Error(), "movies": movieIDs, "seed": used})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"movies": movieIDs})
+	c.JSON(http.StatusOK, gin.H{"movies": movieIDs, "seed": used})
 }
 
 func (h *MembersHandler) IterateRecommendationVoting(c *gin.Context) {
@@ -237,41 +260,45 @@ func (h *MembersHandler) IterateRecommendationVoting(c *gin.Context) {
 		Iteration       int               `json:"iteration"`
 		NumPrevSelected int               `json:"numPrevSelected"`
 		MovieIDs        []string          `json:"movieIDs"`
+		Seed            *int64            `json:"seed"`
 	}
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body"})
 		return
 	}
-	newMood, newMovieIDs, err := h.service.IterateRecommendationVoting(
-		c.Request.Context(), req.CurrentMood, req.Iteration, req.NumPrevSelected, req.MovieIDs,
+	newMood, newMovieIDs, seed, err := h.service.IterateRecommendationVoting(
+		c.Request.Context(), req.CurrentMood, req.Iteration, req.NumPrevSelected, req.MovieIDs, req.Seed,
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error(), "newMood": newMood, "movies": newMovieIDs})
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error(), "newMood": newMood, "movies": newMovieIDs, "seed": seed})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"newMood": newMood, "movies": newMovieIDs})
+	c.JSON(http.StatusOK, gin.H{"newMood": newMood, "movies": newMovieIDs, "seed": seed})
 }
 
 func (h *MembersHandler) GetVotingFinalPicks(c *gin.Context) {
 	var req struct {
 		CurrentMood data.MovieMetrics `json:"finalMood"`
+		data.FinalPicksOptions
 	}
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body"})
 		return
 	}
-	movieSelections, err := h.service.GetVotingFinalPicks(c.Request.Context(), req.CurrentMood)
-
-	var response struct {
-		BestPick  string   `json:"bestPick"`
-		GoodPicks []string `json:"goodPicks"`
+	picks, err := h.service.GetVotingFinalPicks(c.Request.Context(), req.CurrentMood, req.FinalPicksOptions)
+	if errors.Is(err, services.ErrInvalidDistance) || errors.Is(err, services.ErrInvalidPicks) {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
 	}
-	response.BestPick = movieSelections[0]
-	if len(movieSelections) > 1 {
-		response.GoodPicks = movieSelections[1:]
-	} else {
-		response.GoodPicks = []string{}
+	if len(picks) == 0 {
+		if err == nil {
+			err = errors.New("no final picks found")
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+		return
 	}
+
+	response := newFinalPicksResponse(picks)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, response)
 		return
@@ -279,6 +306,22 @@ func (h *MembersHandler) GetVotingFinalPicks(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// finalPicksResponse keeps bestPick and goodPicks as movie IDs for existing clients and adds
+// the explained picks alongside them.
+type finalPicksResponse struct {
+	BestPick  string           `json:"bestPick"`
+	GoodPicks []string         `json:"goodPicks"`
+	Picks     []data.FinalPick `json:"picks"`
+}
+
+func newFinalPicksResponse(picks []data.FinalPick) finalPicksResponse {
+	response := finalPicksResponse{BestPick: picks[0].MovieID, GoodPicks: []string{}, Picks: picks}
+	for _, pick := range picks[1:] {
+		response.GoodPicks = append(response.GoodPicks, pick.MovieID)
+	}
+	return response
+}
+
 func (h *MembersHandler) UpdateMood(c *gin.Context) {
 	var req struct {
 		CurrentMood data.MovieMetrics `json:"currentMood"`
@@ -296,3 +339,233 @@ func (h *MembersHandler) UpdateMood(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, newMood)
 }
+
+func (h *MembersHandler) StartVotingSession(c *gin.Context) {
+	var req struct {
+		Username string `json:"username"`
+		Seed     *int64 `json:"seed"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body"})
+			return
+		}
+	}
+	session, err := h.service.StartVotingSession(c.Request.Context(), req.Username, req.Seed)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": "unable to start voting session", "err": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, session)
+}
+
+func (h *MembersHandler) VoteInSession(c *gin.Context) {
+	sessionID, err := utils.GetStringArg(c.Params, constants.SESSION_ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	var req struct {
+		MovieIDs []string `json:"movieIDs"`
+	}
+	if err := c.BindJSON(&req); err != nil || len(req.MovieIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body. Requires 'movieIDs'"})
+		return
+	}
+	session, err := h.service.VoteInSession(c.Request.Context(), sessionID, req.MovieIDs)
+	if err != nil {
+		c.JSON(votingSessionErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+func (h *MembersHandler) FinishVotingSession(c *gin.Context) {
+	sessionID, err := utils.GetStringArg(c.Params, constants.SESSION_ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	var req data.FinalPicksOptions
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body"})
+			return
+		}
+	}
+	picks, err := h.service.FinishVotingSession(c.Request.Context(), sessionID, req)
+	if len(picks) == 0 {
+		if err == nil {
+			err = errors.New("no final picks found")
+		}
+		c.JSON(votingSessionErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+
+	response := newFinalPicksResponse(picks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func votingSessionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, api_cache.ErrVotingSessionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidVote), errors.Is(err, services.ErrInvalidDistance), errors.Is(err, services.ErrInvalidPicks):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrVotingComplete), errors.Is(err, services.ErrNoVotesRecorded), errors.Is(err, services.ErrSlateChanged):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *MembersHandler) CreateVotingRoom(c *gin.Context) {
+	var req struct {
+		Username string `json:"username"`
+		Strategy string `json:"strategy"`
+		Seed     *int64 `json:"seed"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body. Requires 'username'"})
+		return
+	}
+	room, err := h.service.CreateVotingRoom(c.Request.Context(), req.Username, req.Strategy, req.Seed)
+	if err != nil {
+		c.JSON(votingRoomErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, room)
+}
+
+func (h *MembersHandler) GetVotingRoom(c *gin.Context) {
+	code, err := utils.GetStringArg(c.Params, constants.ROOM_CODE)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	room, err := h.service.GetVotingRoom(c.Request.Context(), code)
+	if err != nil {
+		c.JSON(votingRoomErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+func (h *MembersHandler) JoinVotingRoom(c *gin.Context) {
+	code, err := utils.GetStringArg(c.Params, constants.ROOM_CODE)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body. Requires 'username'"})
+		return
+	}
+	room, err := h.service.JoinVotingRoom(c.Request.Context(), code, req.Username)
+	if err != nil {
+		c.JSON(votingRoomErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+// VoteInRoom records one member's picks from the room's slate. An empty movieIDs list
+// passes on the whole slate.
+func (h *MembersHandler) VoteInRoom(c *gin.Context) {
+	code, err := utils.GetStringArg(c.Params, constants.ROOM_CODE)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	var req struct {
+		Username string   `json:"username"`
+		MovieIDs []string `json:"movieIDs"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body. Requires 'username' and 'movieIDs'"})
+		return
+	}
+	room, err := h.service.VoteInRoom(c.Request.Context(), code, req.Username, req.MovieIDs)
+	if err != nil {
+		c.JSON(votingRoomErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, room)
+}
+
+func (h *MembersHandler) FinishVotingRoom(c *gin.Context) {
+	code, err := utils.GetStringArg(c.Params, constants.ROOM_CODE)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	// username is the host finishing the room; the rest are the final picks options
+	var req struct {
+		Username string `json:"username"`
+		data.FinalPicksOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body. Requires 'username'"})
+		return
+	}
+	picks, err := h.service.FinishVotingRoom(c.Request.Context(), code, req.Username, req.FinalPicksOptions)
+	if len(picks) == 0 {
+		if err == nil {
+			err = errors.New("no final picks found")
+		}
+		c.JSON(votingRoomErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+
+	response := newFinalPicksResponse(picks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func votingRoomErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, api_cache.ErrVotingRoomNotFound), errors.Is(err, services.ErrUnknownMember):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrNotRoomMember), errors.Is(err, services.ErrNotRoomHost):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidStrategy):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrRoomFull), errors.Is(err, services.ErrAlreadyVoted):
+		return http.StatusConflict
+	default:
+		return votingSessionErrorStatus(err)
+	}
+}
+
+func (h *MembersHandler) GetRecommendations(c *gin.Context) {
+	username, err := utils.GetStringArg(c.Params, constants.USERNAME)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery(constants.LIMIT, strconv.Itoa(constants.DEFAULT_RECOMMENDATIONS)))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid limit; must be a positive integer"})
+		return
+	}
+	recs, err := h.service.GetRecommendations(c.Request.Context(), username, min(limit, constants.MAX_RECOMMENDATIONS))
+	if errors.Is(err, services.ErrUnknownMember) {
+		c.JSON(http.StatusNotFound, gin.H{"msg": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, recs)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"blockbuster/api/api_cache"
 	"blockbuster/api/constants"
 	"blockbuster/api/data"
 	"blockbuster/api/handlers"
@@ -196,7 +197,7 @@ func TestGetVotingFinalPicksHandler(t *testing.T) {
 	tests := []struct {
 		name           string
 		requestBody    interface{}
-		mockReturn     []string
+		mockReturn     []data.FinalPick
 		mockError      error
 		expectedStatus int
 		expectedBody   string
@@ -204,23 +205,23 @@ func TestGetVotingFinalPicksHandler(t *testing.T) {
 		{
 			name: "success",
 			requestBody: gin.H{
-				"current_mood": gin.H{"acting": 50, "action": 40, "cinematography": 30},
+				"finalMood": gin.H{"acting": 50, "action": 40, "cinematography": 30},
 			},
-			mockReturn:     []string{"m1", "m2", "m3"},
+			mockReturn:     []data.FinalPick{{MovieID: "m1"}, {MovieID: "m2"}, {MovieID: "m3"}},
 			mockError:      nil,
 			expectedStatus: http.StatusOK,
-			expectedBody:   `"movies":["m1","m2","m3"]`,
+			expectedBody:   `"bestPick":"m1","goodPicks":["m2","m3"]`,
 		},
 		{
 			name:           "invalid JSON",
-			requestBody:    `{"current_mood": "oops"}`, // wrong type
+			requestBody:    `{"finalMood": "oops"}`, // wrong type
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `"msg":"Invalid request body"`,
 		},
 		{
 			name: "service error",
 			requestBody: gin.H{
-				"current_mood": gin.H{"acting": 50, "action": 40, "cinematography": 30},
+				"finalMood": gin.H{"acting": 50, "action": 40, "cinematography": 30},
 			},
 			mockReturn:     nil,
 			mockError:      errors.New("db error"),
@@ -236,7 +237,7 @@ func TestGetVotingFinalPicksHandler(t *testing.T) {
 
 			// Only set expectation if valid JSON and expecting service call
 			if tt.mockReturn != nil || tt.mockError != nil {
-				mockSvc.On("GetVotingFinalPicks", mock.Anything, mock.Anything).
+				mockSvc.On("GetVotingFinalPicks", mock.Anything, mock.Anything, mock.Anything).
 					Return(tt.mockReturn, tt.mockError)
 			}
 
@@ -341,3 +342,337 @@ func TestUpdateMoodHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestVotingSessionHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	session := data.VotingSession{ID: "abc", Slate: []string{"m1", "m2"}}
+	picks := []data.FinalPick{
+		{MovieID: "m1", Explanation: data.PickExplanation{
+			Centroid:      3,
+			Distance:      1.5,
+			TopDimensions: []data.DimensionContribution{{Dimension: "drama", Mood: 7, Movie: 8, Share: 1}},
+			InfluencedBy:  []string{"m9"},
+		}},
+		{MovieID: "m2", Explanation: data.PickExplanation{InfluencedBy: []string{}, AlsoRentedWith: "m1"}},
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setup          func(*services.MockMembersService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "start session",
+			method: http.MethodPost, path: "/members/mood/sessions",
+			setup: func(m *services.MockMembersService) {
+				m.On("StartVotingSession", mock.Anything, "", (*int64)(nil)).Return(session, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"sessionID":"abc"`,
+		},
+		{
+			name:   "start session with seed",
+			method: http.MethodPost, path: "/members/mood/sessions", body: `{"seed":42}`,
+			setup: func(m *services.MockMembersService) {
+				seeded := session
+				seeded.Seed = 42
+				m.On("StartVotingSession", mock.Anything, "", mock.MatchedBy(func(seed *int64) bool { return seed != nil && *seed == 42 })).Return(seeded, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"seed":42`,
+		},
+		{
+			name:   "vote",
+			method: http.MethodPost, path: "/members/mood/sessions/abc/vote", body: `{"movieIDs":["m1"]}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("VoteInSession", mock.Anything, "abc", []string{"m1"}).Return(session, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"movies":["m1","m2"]`,
+		},
+		{
+			name:   "vote missing movies",
+			method: http.MethodPost, path: "/members/mood/sessions/abc/vote", body: `{}`,
+			setup:          func(m *services.MockMembersService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "vote for movie not on slate",
+			method: http.MethodPost, path: "/members/mood/sessions/abc/vote", body: `{"movieIDs":["m9"]}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("VoteInSession", mock.Anything, "abc", []string{"m9"}).Return(data.VotingSession{}, services.ErrInvalidVote)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "vote in expired session",
+			method: http.MethodPost, path: "/members/mood/sessions/old/vote", body: `{"movieIDs":["m1"]}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("VoteInSession", mock.Anything, "old", []string{"m1"}).Return(data.VotingSession{}, api_cache.ErrVotingSessionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "final picks",
+			method: http.MethodPost, path: "/members/mood/sessions/abc/picks",
+			setup: func(m *services.MockMembersService) {
+				m.On("FinishVotingSession", mock.Anything, "abc", data.FinalPicksOptions{}).Return(picks, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody: `{"bestPick":"m1","goodPicks":["m2"],"picks":[{"movieID":"m1","explanation":{"centroid":3,"distance":1.5,` +
+				`"topDimensions":[{"dimension":"drama","mood":7,"movie":8,"share":1}],"influencedBy":["m9"]}},`,
+		},
+		{
+			name:   "final picks with options",
+			method: http.MethodPost, path: "/members/mood/sessions/abc/picks",
+			body: `{"numPicks":5,"diversity":0.6,"distance":{"metric":"cosine","weights":{"suspense":3}}}`,
+			setup: func(m *services.MockMembersService) {
+				diversity := 0.6
+				opts := data.FinalPicksOptions{
+					NumPicks:  5,
+					Diversity: &diversity,
+					Distance:  data.DistanceOptions{Metric: "cosine", Weights: map[string]float64{"suspense": 3}},
+				}
+				m.On("FinishVotingSession", mock.Anything, "abc", opts).Return([]data.FinalPick{{MovieID: "m1"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"bestPick":"m1","goodPicks":[],"picks":`,
+		},
+		{
+			name:   "final picks with unknown metric",
+			method: http.MethodPost, path: "/members/mood/sessions/abc/picks",
+			body: `{"distance":{"metric":"chebyshev"}}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("FinishVotingSession", mock.Anything, "abc", data.FinalPicksOptions{Distance: data.DistanceOptions{Metric: "chebyshev"}}).
+					Return(nil, services.ErrInvalidDistance)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "final picks before voting",
+			method: http.MethodPost, path: "/members/mood/sessions/abc/picks",
+			setup: func(m *services.MockMembersService) {
+				m.On("FinishVotingSession", mock.Anything, "abc", data.FinalPicksOptions{}).Return(nil, services.ErrNoVotesRecorded)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(services.MockMembersService)
+			tt.setup(mockSvc)
+			r := gin.New()
+			handlers.NewMembersHandlerWithService(mockSvc).RegisterRoutes(r.Group(""))
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestVotingRoomHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	room := data.VotingRoom{Code: "ABC234", Host: "john", Strategy: "average", Members: map[string]data.RoomMember{"john": {}}, Slate: []string{"m1", "m2"}}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setup          func(*services.MockMembersService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "create room",
+			method: http.MethodPost, path: "/members/mood/rooms", body: `{"username":"john","strategy":"average"}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("CreateVotingRoom", mock.Anything, "john", "average", (*int64)(nil)).Return(room, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"code":"ABC234"`,
+		},
+		{
+			name:   "create room without username",
+			method: http.MethodPost, path: "/members/mood/rooms", body: `{}`,
+			setup:          func(m *services.MockMembersService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "create room with unknown strategy",
+			method: http.MethodPost, path: "/members/mood/rooms", body: `{"username":"john","strategy":"dictator"}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("CreateVotingRoom", mock.Anything, "john", "dictator", (*int64)(nil)).Return(data.VotingRoom{}, services.ErrInvalidStrategy)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "get room",
+			method: http.MethodGet, path: "/members/mood/rooms/ABC234",
+			setup: func(m *services.MockMembersService) {
+				m.On("GetVotingRoom", mock.Anything, "ABC234").Return(room, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"movies":["m1","m2"]`,
+		},
+		{
+			name:   "join room",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/join", body: `{"username":"jane"}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("JoinVotingRoom", mock.Anything, "ABC234", "jane").Return(room, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "join unknown member",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/join", body: `{"username":"ghost"}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("JoinVotingRoom", mock.Anything, "ABC234", "ghost").Return(data.VotingRoom{}, services.ErrUnknownMember)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "join full room",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/join", body: `{"username":"jane"}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("JoinVotingRoom", mock.Anything, "ABC234", "jane").Return(data.VotingRoom{}, services.ErrRoomFull)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "vote",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/vote", body: `{"username":"john","movieIDs":["m1"]}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("VoteInRoom", mock.Anything, "ABC234", "john", []string{"m1"}).Return(room, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "pass",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/vote", body: `{"username":"john","movieIDs":[]}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("VoteInRoom", mock.Anything, "ABC234", "john", []string{}).Return(room, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "vote twice",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/vote", body: `{"username":"john","movieIDs":["m1"]}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("VoteInRoom", mock.Anything, "ABC234", "john", []string{"m1"}).Return(data.VotingRoom{}, services.ErrAlreadyVoted)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "vote without joining",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/vote", body: `{"username":"bob","movieIDs":["m1"]}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("VoteInRoom", mock.Anything, "ABC234", "bob", []string{"m1"}).Return(data.VotingRoom{}, services.ErrNotRoomMember)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "vote in expired room",
+			method: http.MethodPost, path: "/members/mood/rooms/OLD234/vote", body: `{"username":"john","movieIDs":["m1"]}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("VoteInRoom", mock.Anything, "OLD234", "john", []string{"m1"}).Return(data.VotingRoom{}, api_cache.ErrVotingRoomNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "final picks",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/picks", body: `{"username":"john"}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("FinishVotingRoom", mock.Anything, "ABC234", "john", data.FinalPicksOptions{}).Return([]data.FinalPick{{MovieID: "m3"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"bestPick":"m3","goodPicks":[],"picks":`,
+		},
+		{
+			name:   "final picks before voting",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/picks", body: `{"username":"john","numPicks":2}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("FinishVotingRoom", mock.Anything, "ABC234", "john", data.FinalPicksOptions{NumPicks: 2}).Return(nil, services.ErrNoVotesRecorded)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "final picks by a guest",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/picks", body: `{"username":"jane"}`,
+			setup: func(m *services.MockMembersService) {
+				m.On("FinishVotingRoom", mock.Anything, "ABC234", "jane", data.FinalPicksOptions{}).Return(nil, services.ErrNotRoomHost)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "final picks without a username",
+			method: http.MethodPost, path: "/members/mood/rooms/ABC234/picks",
+			setup:          func(m *services.MockMembersService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(services.MockMembersService)
+			tt.setup(mockSvc)
+			r := gin.New()
+			handlers.NewMembersHandlerWithService(mockSvc).RegisterRoutes(r.Group(""))
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestGetRecommendationsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		limit          int
+		mockErr        error
+		expectedStatus int
+	}{
+		{name: "default limit", query: "", limit: constants.DEFAULT_RECOMMENDATIONS, expectedStatus: http.StatusOK},
+		{name: "capped limit", query: "?limit=500", limit: constants.MAX_RECOMMENDATIONS, expectedStatus: http.StatusOK},
+		{name: "invalid limit", query: "?limit=zero", expectedStatus: http.StatusBadRequest},
+		{name: "service error", query: "", limit: constants.DEFAULT_RECOMMENDATIONS, mockErr: errors.New("boom"), expectedStatus: http.StatusInternalServerError},
+		{name: "unknown member", query: "", limit: constants.DEFAULT_RECOMMENDATIONS, mockErr: services.ErrUnknownMember, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(services.MockMembersService)
+			recs := []data.Recommendation{{Movie: data.Movie{ID: "m1"}, Score: 0.9}}
+			mockSvc.On("GetRecommendations", mock.Anything, "john", tt.limit).Return(recs, tt.mockErr)
+			r := gin.New()
+			r.GET("/members/:username/recommendations", handlers.NewMembersHandlerWithService(mockSvc).GetRecommendations)
+
+			req, _ := http.NewRequest(http.MethodGet, "/members/john/recommendations"+tt.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"score":0.9`)
+			}
+		})
+	}
+}
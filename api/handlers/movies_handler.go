@@ -1,14 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"blockbuster/api/constants"
+	"blockbuster/api/data"
 	"blockbuster/api/services"
 	"blockbuster/api/utils"
 )
@@ -33,7 +36,12 @@ func (h *MoviesHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("/movies", h.GetMoviesByPage)
 	rg.GET("/movies/:movieID", h.GetMovie)
 	rg.GET("/movies/:movieID/metrics", h.GetMovieMetrics)
+	rg.PUT("/movies/:movieID/metrics", h.SetMovieMetrics)
 	rg.GET("/movies/:movieID/trivia", h.GetTrivia)
+	rg.PUT("/movies/:movieID/trivia", h.SetTrivia)
+	rg.GET("/movies/:movieID/also-rented", h.GetAlsoRented)
+	rg.GET("/movies/:movieID/similar", h.GetSimilarMovies)
+	rg.GET("/metrics/dimensions", h.GetMetricDimensions)
 }
 
 func (h *MoviesHandler) GetMoviesByPage(c *gin.Context) {
@@ -88,6 +96,31 @@ func (h *MoviesHandler) GetMovieMetrics(c *gin.Context) {
 	c.JSON(http.StatusOK, metrics)
 }
 
+// SetMovieMetrics writes a movie's metrics and responds with the centroid it was assigned.
+func (h *MoviesHandler) SetMovieMetrics(c *gin.Context) {
+	id := c.Param(constants.MOVIE_ID)
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Missing movieID parameter"})
+		return
+	}
+	var metrics data.MovieMetrics
+	if err := c.ShouldBindJSON(&metrics); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body"})
+		return
+	}
+	movie, err := h.service.SetMovieMetrics(c.Request.Context(), id, metrics)
+	switch {
+	case errors.Is(err, services.ErrInvalidMetrics):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+	case errors.Is(err, services.ErrMovieNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+	default:
+		c.JSON(http.StatusOK, movie)
+	}
+}
+
 func (h *MoviesHandler) GetTrivia(c *gin.Context) {
 	id := c.Param(constants.MOVIE_ID)
 	if id == "" {
@@ -98,10 +131,83 @@ func (h *MoviesHandler) GetTrivia(c *gin.Context) {
 	trivia, err := h.service.GetTrivia(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"msg": fmt.Sprintf("err occurred fetching trivia for %s", id)})
+		return
 	}
-	if trivia.Trivia == "" {
+	if len(trivia.Trivia) == 0 {
 		c.JSON(http.StatusNotFound, gin.H{"msg": fmt.Sprintf("Trivia for %s not found", id)})
 		return
 	}
 	c.JSON(http.StatusOK, trivia)
 }
+
+// SetTrivia replaces a movie's trivia with the question and answer pairs in the body.
+func (h *MoviesHandler) SetTrivia(c *gin.Context) {
+	id := c.Param(constants.MOVIE_ID)
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Missing movieID parameter"})
+		return
+	}
+	var body data.MovieTrivia
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body"})
+		return
+	}
+	trivia, err := h.service.SetTrivia(c.Request.Context(), id, body.Trivia)
+	switch {
+	case errors.Is(err, services.ErrInvalidTrivia):
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+	case errors.Is(err, services.ErrMovieNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"msg": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+	default:
+		c.JSON(http.StatusOK, trivia)
+	}
+}
+
+func (h *MoviesHandler) GetAlsoRented(c *gin.Context) {
+	id := c.Param(constants.MOVIE_ID)
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Missing movieID parameter"})
+		return
+	}
+	k, err := strconv.Atoi(c.DefaultQuery(constants.K, strconv.Itoa(constants.DEFAULT_ALSO_RENTED)))
+	if err != nil || k < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid k; must be a positive integer"})
+		return
+	}
+	coRentals, err := h.service.GetAlsoRented(c.Request.Context(), id, min(k, constants.MAX_ALSO_RENTED))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": fmt.Sprintf("err fetching movies also rented with %s", id)})
+		return
+	}
+	c.JSON(http.StatusOK, coRentals)
+}
+
+func (h *MoviesHandler) GetSimilarMovies(c *gin.Context) {
+	id := c.Param(constants.MOVIE_ID)
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Missing movieID parameter"})
+		return
+	}
+	k, err := strconv.Atoi(c.DefaultQuery(constants.K, strconv.Itoa(constants.DEFAULT_SIMILAR)))
+	if err != nil || k < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid k; must be a positive integer"})
+		return
+	}
+	similar, err := h.service.GetSimilarMovies(c.Request.Context(), id, min(k, constants.MAX_SIMILAR))
+	switch {
+	case errors.Is(err, services.ErrMovieNotFound), errors.Is(err, services.ErrNoMetrics):
+		c.JSON(http.StatusNotFound, gin.H{"msg": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": fmt.Sprintf("err fetching movies similar to %s", id)})
+	default:
+		c.JSON(http.StatusOK, similar)
+	}
+}
+
+// GetMetricDimensions lists the movie metric dimensions in the order the rec engine uses them,
+// which is also the set of keys accepted as distance weights.
+func (h *MoviesHandler) GetMetricDimensions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{constants.DIMENSIONS: data.MetricDimensions()})
+}
@@ -3,14 +3,17 @@ package handlers_test
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"blockbuster/api/constants"
 	"blockbuster/api/data"
 	"blockbuster/api/handlers"
 	"blockbuster/api/services"
@@ -122,7 +125,7 @@ func TestGetTrivia_Success(t *testing.T) {
 	h := handlers.NewMoviesHandlerWithService(mockService)
 	r.GET("/movies/:movieID/trivia", h.GetTrivia)
 
-	expected := data.MovieTrivia{Trivia: "Some trivia"}
+	expected := data.MovieTrivia{Trivia: data.Trivia{{Question: "Who plays Rick?", Answer: "Humphrey Bogart"}}}
 	mockService.On("GetTrivia", mock.Anything, "m123").Return(expected, nil)
 
 	req, _ := http.NewRequest(http.MethodGet, "/movies/m123/trivia", nil)
@@ -130,7 +133,7 @@ func TestGetTrivia_Success(t *testing.T) {
 	r.ServeHTTP(resp, req)
 
 	assert.Equal(t, http.StatusOK, resp.Code)
-	assert.Contains(t, resp.Body.String(), "Some trivia")
+	assert.JSONEq(t, `{"trivia":[{"question":"Who plays Rick?","answer":"Humphrey Bogart"}]}`, resp.Body.String())
 }
 
 func TestGetTrivia_NotFound(t *testing.T) {
@@ -140,7 +143,7 @@ func TestGetTrivia_NotFound(t *testing.T) {
 	h := handlers.NewMoviesHandlerWithService(mockService)
 	r.GET("/movies/:movieID/trivia", h.GetTrivia)
 
-	expected := data.MovieTrivia{Trivia: ""}
+	expected := data.MovieTrivia{}
 	mockService.On("GetTrivia", mock.Anything, "m123").Return(expected, nil)
 
 	req, _ := http.NewRequest(http.MethodGet, "/movies/m123/trivia", nil)
@@ -151,11 +154,169 @@ func TestGetTrivia_NotFound(t *testing.T) {
 	assert.Contains(t, resp.Body.String(), "Trivia for m123 not found")
 }
 
+func TestGetTrivia_Error(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	mockService := new(services.MockMoviesService)
+	h := handlers.NewMoviesHandlerWithService(mockService)
+	r.GET("/movies/:movieID/trivia", h.GetTrivia)
+
+	mockService.On("GetTrivia", mock.Anything, "m123").Return(data.MovieTrivia{}, errors.New("fail"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/movies/m123/trivia", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.NotContains(t, resp.Body.String(), "not found")
+}
+
+func TestSetTriviaHandler(t *testing.T) {
+	written := data.MovieTrivia{Trivia: data.Trivia{{Question: "Who plays Rick?", Answer: "Humphrey Bogart"}}}
+	tests := []struct {
+		name     string
+		body     string
+		trivia   data.MovieTrivia
+		err      error
+		code     int
+		contains string
+	}{
+		{"written", `{"trivia": [{"question": "Who plays Rick?", "answer": "Humphrey Bogart"}]}`, written, nil, http.StatusOK, `"question":"Who plays Rick?"`},
+		{"invalid json", `{"trivia": "Who?:Him"`, data.MovieTrivia{}, nil, http.StatusBadRequest, "Invalid request body"},
+		{"invalid trivia", `{"trivia": []}`, data.MovieTrivia{}, services.ErrInvalidTrivia, http.StatusBadRequest, "invalid trivia"},
+		{"missing movie", `{"trivia": [{"question": "Who?", "answer": "Him"}]}`, data.MovieTrivia{}, services.ErrMovieNotFound, http.StatusNotFound, "movie not found"},
+		{"write fails", `{"trivia": [{"question": "Who?", "answer": "Him"}]}`, data.MovieTrivia{}, errors.New("failed to save trivia for casablanca_1942"), http.StatusInternalServerError, "failed to save"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.Default()
+			mockService := new(services.MockMoviesService)
+			h := handlers.NewMoviesHandlerWithService(mockService)
+			r.PUT("/movies/:movieID/trivia", h.SetTrivia)
+			mockService.On("SetTrivia", mock.Anything, "casablanca_1942", mock.Anything).Return(tt.trivia, tt.err)
+
+			req, _ := http.NewRequest(http.MethodPut, "/movies/casablanca_1942/trivia", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.code, resp.Code)
+			assert.Contains(t, resp.Body.String(), tt.contains)
+		})
+	}
+}
+
+func TestGetAlsoRented_CapsK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	mockService := new(services.MockMoviesService)
+	h := handlers.NewMoviesHandlerWithService(mockService)
+	r.GET("/movies/:movieID/also-rented", h.GetAlsoRented)
+
+	expected := []data.CoRental{{Movie: data.Movie{ID: "aliens"}, Count: 3, Score: 0.75}}
+	mockService.On("GetAlsoRented", mock.Anything, "alien", constants.MAX_ALSO_RENTED).Return(expected, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/movies/alien/also-rented?k=100", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var coRentals []data.CoRental
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &coRentals))
+	assert.Equal(t, expected, coRentals)
+}
+
+func TestGetAlsoRented_InvalidK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	h := handlers.NewMoviesHandlerWithService(new(services.MockMoviesService))
+	r.GET("/movies/:movieID/also-rented", h.GetAlsoRented)
+
+	req, _ := http.NewRequest(http.MethodGet, "/movies/alien/also-rented?k=zero", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestGetSimilarMovies_DefaultK(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	mockService := new(services.MockMoviesService)
+	h := handlers.NewMoviesHandlerWithService(mockService)
+	r.GET("/movies/:movieID/similar", h.GetSimilarMovies)
+
+	expected := []data.SimilarMovie{{Movie: data.Movie{ID: "aliens"}, Distance: 12, Centroid: 3}}
+	mockService.On("GetSimilarMovies", mock.Anything, "alien", constants.DEFAULT_SIMILAR).Return(expected, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, "/movies/alien/similar", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var similar []data.SimilarMovie
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &similar))
+	assert.Equal(t, expected, similar)
+}
+
+func TestGetSimilarMovies_Error(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	mockService := new(services.MockMoviesService)
+	h := handlers.NewMoviesHandlerWithService(mockService)
+	r.GET("/movies/:movieID/similar", h.GetSimilarMovies)
+	mockService.On("GetSimilarMovies", mock.Anything, "bad", 2).Return([]data.SimilarMovie{}, errors.New("fail"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/movies/bad/similar?k=2", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
+
+func TestGetSimilarMovies_UnknownMovie(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	mockService := new(services.MockMoviesService)
+	h := handlers.NewMoviesHandlerWithService(mockService)
+	r.GET("/movies/:movieID/similar", h.GetSimilarMovies)
+	mockService.On("GetSimilarMovies", mock.Anything, "missing", constants.DEFAULT_SIMILAR).
+		Return([]data.SimilarMovie{}, fmt.Errorf("%w: missing", services.ErrMovieNotFound))
+	mockService.On("GetSimilarMovies", mock.Anything, "unrated", constants.DEFAULT_SIMILAR).
+		Return([]data.SimilarMovie{}, fmt.Errorf("%w: unrated", services.ErrNoMetrics))
+
+	for _, id := range []string{"missing", "unrated"} {
+		req, _ := http.NewRequest(http.MethodGet, "/movies/"+id+"/similar", nil)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusNotFound, resp.Code, id)
+	}
+}
+
+func TestGetMetricDimensions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	handlers.NewMoviesHandlerWithService(new(services.MockMoviesService)).RegisterRoutes(r.Group(""))
+
+	req, _ := http.NewRequest(http.MethodGet, "/metrics/dimensions", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var body map[string][]string
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Len(t, body[constants.DIMENSIONS], data.NumMetricDimensions)
+	assert.Equal(t, "acting", body[constants.DIMENSIONS][0])
+	assert.Contains(t, body[constants.DIMENSIONS], "story_telling")
+}
+
 func TestGetIniitialVotingSlateHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tests := []struct {
 		name           string
+		query          string
 		mockMovies     []string
 		mockError      error
 		expectedStatus int
@@ -166,7 +327,20 @@ func TestGetIniitialVotingSlateHandler(t *testing.T) {
 			mockMovies:     []string{"m1", "m2", "m3", "m4", "m5", "m6", "m7"},
 			mockError:      nil,
 			expectedStatus: http.StatusOK,
-			expectedBody:   `"movies":["m1","m2","m3","m4","m5","m6","m7"]`,
+			expectedBody:   `"movies":["m1","m2","m3","m4","m5","m6","m7"],"seed":42`,
+		},
+		{
+			name:           "given seed",
+			query:          "?seed=42",
+			mockMovies:     []string{"m1"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"seed":42`,
+		},
+		{
+			name:           "invalid seed",
+			query:          "?seed=abc",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"msg":"seed must be an integer"`,
 		},
 		{
 			name:           "partial error",
@@ -189,14 +363,15 @@ func TestGetIniitialVotingSlateHandler(t *testing.T) {
 			mockSvc := new(services.MockMembersService)
 			handler := handlers.NewMembersHandlerWithService(mockSvc)
 
-			mockSvc.On("GetIniitialVotingSlate", mock.Anything).
-				Return(tt.mockMovies, tt.mockError)
+			seed := mock.MatchedBy(func(seed *int64) bool { return (tt.query == "") == (seed == nil) })
+			mockSvc.On("GetIniitialVotingSlate", mock.Anything, "", seed).
+				Return(tt.mockMovies, int64(42), tt.mockError)
 
 			w := httptest.NewRecorder()
 			c, r := gin.CreateTestContext(w)
 			r.GET("/members/mood/initial_voting", handler.GetIniitialVotingSlate)
 
-			req, _ := http.NewRequest(http.MethodGet, "/members/mood/initial_voting", nil)
+			req, _ := http.NewRequest(http.MethodGet, "/members/mood/initial_voting"+tt.query, nil)
 			c.Request = req
 
 			r.ServeHTTP(w, req)
@@ -206,3 +381,38 @@ func TestGetIniitialVotingSlateHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestSetMovieMetricsHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		movie    data.Movie
+		err      error
+		code     int
+		contains string
+	}{
+		{"assigned", `{"horror": 80, "suspense": 60}`, data.Movie{ID: "the_thing", Centroid: 4}, nil, http.StatusOK, `"centroid":4`},
+		{"invalid json", `{"horror":`, data.Movie{}, nil, http.StatusBadRequest, "Invalid request body"},
+		{"invalid metrics", `{}`, data.Movie{}, services.ErrInvalidMetrics, http.StatusBadRequest, "not all zero"},
+		{"missing movie", `{"horror": 80}`, data.Movie{}, services.ErrMovieNotFound, http.StatusNotFound, "movie not found"},
+		{"no centroids", `{"horror": 80}`, data.Movie{}, errors.New("failed to assign a centroid to the_thing"), http.StatusInternalServerError, "failed to assign"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.Default()
+			mockService := new(services.MockMoviesService)
+			h := handlers.NewMoviesHandlerWithService(mockService)
+			r.PUT("/movies/:movieID/metrics", h.SetMovieMetrics)
+			mockService.On("SetMovieMetrics", mock.Anything, "the_thing", mock.Anything).Return(tt.movie, tt.err)
+
+			req, _ := http.NewRequest(http.MethodPut, "/movies/the_thing/metrics", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.code, resp.Code)
+			assert.Contains(t, resp.Body.String(), tt.contains)
+		})
+	}
+}
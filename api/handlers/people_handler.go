@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"blockbuster/api/constants"
+	graphsearch "blockbuster/api/graph_search"
+	"blockbuster/api/services"
+)
+
+type PeopleHandler struct {
+	service services.PeopleServiceInterface
+}
+
+func NewPeopleHandler() *PeopleHandler {
+	return &PeopleHandler{
+		service: services.GetPeopleService(),
+	}
+}
+
+func NewPeopleHandlerWithService(service services.PeopleServiceInterface) *PeopleHandler {
+	return &PeopleHandler{
+		service: service,
+	}
+}
+
+func (h *PeopleHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/people/:name", h.GetPerson)
+}
+
+func (h *PeopleHandler) GetPerson(c *gin.Context) {
+	name := c.Param(constants.NAME)
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Missing name parameter"})
+		return
+	}
+	person, err := h.service.GetPerson(c.Request.Context(), name)
+	if err != nil {
+		var notFound *graphsearch.NameNotFoundError
+		if errors.As(err, &notFound) {
+			c.JSON(http.StatusNotFound, gin.H{"msg": err.Error(), constants.SUGGESTIONS: notFound.Suggestions})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, person)
+}
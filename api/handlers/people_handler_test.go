@@ -0,0 +1,67 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/data"
+	graphsearch "blockbuster/api/graph_search"
+	"blockbuster/api/handlers"
+	"blockbuster/api/services"
+)
+
+func setupPeopleRouter(service services.PeopleServiceInterface) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.Default()
+	h := handlers.NewPeopleHandlerWithService(service)
+	r.GET("/people/:name", h.GetPerson)
+	return r
+}
+
+func TestGetPerson_Success(t *testing.T) {
+	mockService := new(services.MockPeopleService)
+	mockService.On("GetPerson", mock.Anything, "Tom Hanks").Return(data.Person{Name: "Tom Hanks", Roles: []string{"star"}}, nil)
+	r := setupPeopleRouter(mockService)
+
+	req, _ := http.NewRequest(http.MethodGet, "/people/Tom%20Hanks", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	var person data.Person
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &person))
+	assert.Equal(t, "Tom Hanks", person.Name)
+}
+
+func TestGetPerson_NotFound(t *testing.T) {
+	mockService := new(services.MockPeopleService)
+	notFound := &graphsearch.NameNotFoundError{Kind: "person", Name: "Tom Hankz", Suggestions: []string{"Tom Hanks"}}
+	mockService.On("GetPerson", mock.Anything, "Tom Hankz").Return(data.Person{}, notFound)
+	r := setupPeopleRouter(mockService)
+
+	req, _ := http.NewRequest(http.MethodGet, "/people/Tom%20Hankz", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"suggestions":["Tom Hanks"]`)
+}
+
+func TestGetPerson_Error(t *testing.T) {
+	mockService := new(services.MockPeopleService)
+	mockService.On("GetPerson", mock.Anything, "Tom Hanks").Return(data.Person{}, errors.New("failed to fetch available titles"))
+	r := setupPeopleRouter(mockService)
+
+	req, _ := http.NewRequest(http.MethodGet, "/people/Tom%20Hanks", nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/services"
+	"blockbuster/api/utils"
+)
+
+type QuizHandler struct {
+	service services.QuizServiceInterface
+}
+
+func NewQuizHandler() *QuizHandler {
+	return &QuizHandler{
+		service: services.GetQuizService(),
+	}
+}
+
+func NewQuizHandlerWithService(service services.QuizServiceInterface) *QuizHandler {
+	return &QuizHandler{
+		service: service,
+	}
+}
+
+func (h *QuizHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/quizzes", h.StartQuiz)
+	rg.GET("/quizzes/leaderboard", h.GetQuizLeaderboard)
+	rg.GET("/quizzes/:quizID", h.GetQuiz)
+	rg.POST("/quizzes/:quizID/answers", h.SubmitQuiz)
+	rg.GET("/members/:username/quiz-stats", h.GetQuizStats)
+}
+
+// StartQuiz deals a member a new trivia quiz, from their rentals or from one centroid.
+func (h *QuizHandler) StartQuiz(c *gin.Context) {
+	var opts data.QuizOptions
+	if err := c.ShouldBindJSON(&opts); err != nil || opts.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body. Requires 'username'"})
+		return
+	}
+	quiz, err := h.service.StartQuiz(c.Request.Context(), opts)
+	if err != nil {
+		c.JSON(quizErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, quiz)
+}
+
+func (h *QuizHandler) GetQuiz(c *gin.Context) {
+	quizID, err := utils.GetStringArg(c.Params, constants.QUIZ_ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	quiz, err := h.service.GetQuiz(c.Request.Context(), quizID)
+	if err != nil {
+		c.JSON(quizErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, quiz)
+}
+
+// SubmitQuiz grades a quiz. answers holds the index of the chosen choice for each question
+// in order; -1 or a missing answer skips the question.
+func (h *QuizHandler) SubmitQuiz(c *gin.Context) {
+	quizID, err := utils.GetStringArg(c.Params, constants.QUIZ_ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	var req struct {
+		Answers []int `json:"answers"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid request body. Requires 'answers'"})
+		return
+	}
+	result, err := h.service.SubmitQuiz(c.Request.Context(), quizID, req.Answers)
+	if err != nil {
+		c.JSON(quizErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *QuizHandler) GetQuizLeaderboard(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery(constants.LIMIT, strconv.Itoa(constants.DEFAULT_QUIZ_LEADERBOARD)))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": "Invalid limit; must be a positive integer"})
+		return
+	}
+	leaderboard, err := h.service.GetQuizLeaderboard(c.Request.Context(), min(limit, constants.MAX_QUIZ_LEADERBOARD))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, leaderboard)
+}
+
+func (h *QuizHandler) GetQuizStats(c *gin.Context) {
+	username, err := utils.GetStringArg(c.Params, constants.USERNAME)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"msg": err.Error()})
+		return
+	}
+	stats, err := h.service.GetQuizStats(c.Request.Context(), username)
+	if err != nil {
+		c.JSON(quizErrorStatus(err), gin.H{"msg": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+func quizErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, api_cache.ErrQuizNotFound), errors.Is(err, services.ErrUnknownMember):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidQuiz), errors.Is(err, services.ErrInvalidAnswers):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrNotEnoughTrivia):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
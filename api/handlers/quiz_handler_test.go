@@ -0,0 +1,196 @@
+package handlers_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/handlers"
+	"blockbuster/api/services"
+)
+
+func TestQuizHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	centroid := 3
+	quiz := data.Quiz{
+		ID:       "q1",
+		Username: "john",
+		Source:   constants.QUIZ_SOURCE_RENTED,
+		Questions: []data.QuizQuestion{
+			{MovieID: "m1", Title: "Heat", Question: "Who plays Neil?", Choices: []string{"Al Pacino", "Robert De Niro", "Val Kilmer", "Jon Voight"}},
+		},
+		Answers: []int{1},
+	}
+	result := data.QuizResult{QuizID: "q1", Username: "john", Correct: 1, Total: 1, Stats: data.QuizStats{Username: "john", Points: 4, Asked: 5, Played: 1}}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setup          func(*services.MockQuizService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "start quiz",
+			method: http.MethodPost, path: "/quizzes", body: `{"username":"john","numQuestions":1}`,
+			setup: func(m *services.MockQuizService) {
+				m.On("StartQuiz", mock.Anything, data.QuizOptions{Username: "john", NumQuestions: 1}).Return(quiz, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"quizID":"q1"`,
+		},
+		{
+			name:   "start centroid quiz",
+			method: http.MethodPost, path: "/quizzes", body: `{"username":"john","source":"centroid","centroid":3}`,
+			setup: func(m *services.MockQuizService) {
+				m.On("StartQuiz", mock.Anything, data.QuizOptions{Username: "john", Source: constants.QUIZ_SOURCE_CENTROID, Centroid: &centroid}).Return(quiz, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "start quiz without username",
+			method: http.MethodPost, path: "/quizzes", body: `{}`,
+			setup:          func(m *services.MockQuizService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "start quiz without trivia",
+			method: http.MethodPost, path: "/quizzes", body: `{"username":"john"}`,
+			setup: func(m *services.MockQuizService) {
+				m.On("StartQuiz", mock.Anything, data.QuizOptions{Username: "john"}).Return(data.Quiz{}, services.ErrNotEnoughTrivia)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "start quiz for unknown member",
+			method: http.MethodPost, path: "/quizzes", body: `{"username":"ghost"}`,
+			setup: func(m *services.MockQuizService) {
+				m.On("StartQuiz", mock.Anything, data.QuizOptions{Username: "ghost"}).Return(data.Quiz{}, services.ErrUnknownMember)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "get quiz hides answers",
+			method: http.MethodGet, path: "/quizzes/q1",
+			setup: func(m *services.MockQuizService) {
+				m.On("GetQuiz", mock.Anything, "q1").Return(quiz, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"choices":["Al Pacino","Robert De Niro","Val Kilmer","Jon Voight"]}]`,
+		},
+		{
+			name:   "submit answers",
+			method: http.MethodPost, path: "/quizzes/q1/answers", body: `{"answers":[1]}`,
+			setup: func(m *services.MockQuizService) {
+				m.On("SubmitQuiz", mock.Anything, "q1", []int{1}).Return(result, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"correct":1,"total":1`,
+		},
+		{
+			name:   "submit invalid answers",
+			method: http.MethodPost, path: "/quizzes/q1/answers", body: `{"answers":[7]}`,
+			setup: func(m *services.MockQuizService) {
+				m.On("SubmitQuiz", mock.Anything, "q1", []int{7}).Return(data.QuizResult{}, services.ErrInvalidAnswers)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "submit twice",
+			method: http.MethodPost, path: "/quizzes/q1/answers", body: `{"answers":[1]}`,
+			setup: func(m *services.MockQuizService) {
+				m.On("SubmitQuiz", mock.Anything, "q1", []int{1}).Return(data.QuizResult{}, api_cache.ErrQuizNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "submit malformed body",
+			method: http.MethodPost, path: "/quizzes/q1/answers", body: `{"answers":"b"}`,
+			setup:          func(m *services.MockQuizService) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "quiz stats",
+			method: http.MethodGet, path: "/members/john/quiz-stats",
+			setup: func(m *services.MockQuizService) {
+				m.On("GetQuizStats", mock.Anything, "john").Return(data.QuizStats{Username: "john", Points: 4, Asked: 5, Played: 1, Rank: 2}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"rank":2`,
+		},
+		{
+			name:   "quiz stats of unknown member",
+			method: http.MethodGet, path: "/members/ghost/quiz-stats",
+			setup: func(m *services.MockQuizService) {
+				m.On("GetQuizStats", mock.Anything, "ghost").Return(data.QuizStats{}, services.ErrUnknownMember)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(services.MockQuizService)
+			tt.setup(mockSvc)
+			r := gin.New()
+			handlers.NewQuizHandlerWithService(mockSvc).RegisterRoutes(r.Group(""))
+
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+			assert.NotContains(t, w.Body.String(), `"answers"`)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetQuizLeaderboardHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		query          string
+		limit          int
+		mockErr        error
+		expectedStatus int
+	}{
+		{name: "default limit", query: "", limit: constants.DEFAULT_QUIZ_LEADERBOARD, expectedStatus: http.StatusOK},
+		{name: "capped limit", query: "?limit=1000", limit: constants.MAX_QUIZ_LEADERBOARD, expectedStatus: http.StatusOK},
+		{name: "invalid limit", query: "?limit=0", expectedStatus: http.StatusBadRequest},
+		{name: "service error", query: "", limit: constants.DEFAULT_QUIZ_LEADERBOARD, mockErr: errors.New("boom"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSvc := new(services.MockQuizService)
+			leaderboard := []data.QuizStats{{Username: "john", Points: 4, Asked: 5, Played: 1, Rank: 1}}
+			mockSvc.On("GetQuizLeaderboard", mock.Anything, tt.limit).Return(leaderboard, tt.mockErr)
+			r := gin.New()
+			handlers.NewQuizHandlerWithService(mockSvc).RegisterRoutes(r.Group(""))
+
+			req, _ := http.NewRequest(http.MethodGet, "/quizzes/leaderboard"+tt.query, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"rank":1`)
+			}
+		})
+	}
+}
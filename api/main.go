@@ -35,11 +35,21 @@ func main() {
 	// === Create and register handlers ===
 	membersHandler := handlers.NewMembersHandler()
 	moviesHandler := handlers.NewMoviesHandler()
+	graphHandler := handlers.NewGraphHandler()
+	peopleHandler := handlers.NewPeopleHandler()
+	centroidsHandler := handlers.NewCentroidsHandler()
+	adminHandler := handlers.NewAdminHandler()
+	quizHandler := handlers.NewQuizHandler()
 
 	// === register routes ===
 	api := router.Group(constants.REST_ROUTER_GROUP)
 	membersHandler.RegisterRoutes(api)
 	moviesHandler.RegisterRoutes(api)
+	graphHandler.RegisterRoutes(api)
+	peopleHandler.RegisterRoutes(api)
+	centroidsHandler.RegisterRoutes(api)
+	adminHandler.RegisterRoutes(api)
+	quizHandler.RegisterRoutes(api)
 
 	// === GraphQL endpoint ===
 	router.POST(constants.GRAPHQL_ENDPOINT, gqlHandler)
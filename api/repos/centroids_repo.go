@@ -0,0 +1,181 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/utils"
+)
+
+const centroidsTableName = "centroids"
+
+// ErrCentroidNotFound is returned by writes to a centroid that does not exist.
+var ErrCentroidNotFound = errors.New("centroid not found")
+
+// DynamoCentroidsRepo reads and writes the centroids table. Each item is a centroid's id
+// alongside its metric dimensions as top-level attributes.
+type DynamoCentroidsRepo struct {
+	client    DynamoClientInterface
+	tableName string
+}
+
+func NewDynamoCentroidsRepo(client DynamoClientInterface) *DynamoCentroidsRepo {
+	return &DynamoCentroidsRepo{
+		client:    client,
+		tableName: centroidsTableName,
+	}
+}
+
+// GetCentroids scans every centroid, keyed by id.
+func (r *DynamoCentroidsRepo) GetCentroids(ctx context.Context) (map[int]data.MovieMetrics, error) {
+	input := &dynamodb.ScanInput{TableName: &r.tableName}
+	centroids := make(map[int]data.MovieMetrics)
+	for {
+		output, err := r.client.Scan(ctx, input)
+		if err != nil {
+			return nil, utils.LogError("scanning centroids", err)
+		}
+		for _, item := range output.Items {
+			var centroid data.MovieMetrics
+			if err := attributevalue.Unmarshal(&types.AttributeValueMemberM{Value: item}, &centroid); err != nil {
+				return nil, utils.LogError("unmarshalling centroid", err)
+			}
+			centroids[centroid.ID] = centroid
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return centroids, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// ReplaceCentroids writes centroids, keyed by their ID, and deletes any stored centroid not
+// among them so a smaller k leaves no stale clusters behind.
+func (r *DynamoCentroidsRepo) ReplaceCentroids(ctx context.Context, centroids []data.MovieMetrics) error {
+	existing, err := r.GetCentroids(ctx)
+	if err != nil {
+		return err
+	}
+
+	var requests []types.WriteRequest
+	for _, centroid := range centroids {
+		av, err := attributevalue.Marshal(centroid)
+		if err != nil {
+			return utils.LogError(fmt.Sprintf("marshalling centroid %d", centroid.ID), err)
+		}
+		item := av.(*types.AttributeValueMemberM).Value
+		// the metrics marshaller omits a zero id, but every centroid needs its key
+		item[constants.ID] = centroidKey(centroid.ID)
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		delete(existing, centroid.ID)
+	}
+	for id := range existing {
+		requests = append(requests, types.WriteRequest{DeleteRequest: &types.DeleteRequest{
+			Key: map[string]types.AttributeValue{constants.ID: centroidKey(id)},
+		}})
+	}
+	return batchWrite(ctx, r.client, r.tableName, requests)
+}
+
+// GetCentroidLabels scans the labels staff have set, keyed by centroid id. Centroids
+// without one are left out.
+func (r *DynamoCentroidsRepo) GetCentroidLabels(ctx context.Context) (map[int]data.CentroidLabel, error) {
+	input := &dynamodb.ScanInput{
+		TableName:            &r.tableName,
+		ProjectionExpression: aws.String("#i, #l, #d"),
+		FilterExpression:     aws.String("attribute_exists(#l)"),
+		ExpressionAttributeNames: map[string]string{
+			"#i": constants.ID,
+			"#l": constants.LABEL,
+			"#d": constants.DESCRIPTION,
+		},
+	}
+	labels := make(map[int]data.CentroidLabel)
+	for {
+		output, err := r.client.Scan(ctx, input)
+		if err != nil {
+			return nil, utils.LogError("scanning centroid labels", err)
+		}
+		for _, item := range output.Items {
+			var labelled struct {
+				ID int `dynamodbav:"id"`
+				data.CentroidLabel
+			}
+			if err := attributevalue.UnmarshalMap(item, &labelled); err != nil {
+				return nil, utils.LogError("unmarshalling centroid label", err)
+			}
+			labelled.Custom = true
+			labels[labelled.ID] = labelled.CentroidLabel
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return labels, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// SetCentroidLabel stores a staff label for a centroid, or removes it when the label is
+// empty so the generated one is used again. Labels are dropped by ReplaceCentroids, as the
+// clusters they describe are gone.
+func (r *DynamoCentroidsRepo) SetCentroidLabel(ctx context.Context, id int, label data.CentroidLabel) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName:           &r.tableName,
+		Key:                 map[string]types.AttributeValue{constants.ID: centroidKey(id)},
+		UpdateExpression:    aws.String("REMOVE #l, #d"),
+		ConditionExpression: aws.String("attribute_exists(#i)"),
+		ExpressionAttributeNames: map[string]string{
+			"#i": constants.ID,
+			"#l": constants.LABEL,
+			"#d": constants.DESCRIPTION,
+		},
+	}
+	if label.Label != "" {
+		input.UpdateExpression = aws.String("SET #l = :l, #d = :d")
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":l": &types.AttributeValueMemberS{Value: label.Label},
+			":d": &types.AttributeValueMemberS{Value: label.Description},
+		}
+	}
+	if _, err := r.client.UpdateItem(ctx, input); err != nil {
+		var missing *types.ConditionalCheckFailedException
+		if errors.As(err, &missing) {
+			return fmt.Errorf("%w: %d", ErrCentroidNotFound, id)
+		}
+		return utils.LogError(fmt.Sprintf("setting label of centroid %d", id), err)
+	}
+	return nil
+}
+
+func centroidKey(id int) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.Itoa(id)}
+}
+
+// batchWrite sends requests BATCH_WRITE_LIMIT at a time, resending unprocessed items up to
+// BATCH_WRITE_RETRIES times per batch.
+func batchWrite(ctx context.Context, client DynamoClientInterface, tableName string, requests []types.WriteRequest) error {
+	for start := 0; start < len(requests); start += constants.BATCH_WRITE_LIMIT {
+		pending := map[string][]types.WriteRequest{
+			tableName: requests[start:min(start+constants.BATCH_WRITE_LIMIT, len(requests))],
+		}
+		for attempt := 0; len(pending[tableName]) > 0; attempt++ {
+			if attempt > constants.BATCH_WRITE_RETRIES {
+				return utils.LogError(fmt.Sprintf("%d writes to %s left unprocessed", len(pending[tableName]), tableName), nil)
+			}
+			output, err := client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: pending})
+			if err != nil {
+				return utils.LogError(fmt.Sprintf("batch writing to %s", tableName), err)
+			}
+			pending = output.UnprocessedItems
+		}
+	}
+	return nil
+}
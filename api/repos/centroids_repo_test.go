@@ -0,0 +1,156 @@
+package repos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+)
+
+func centroidItem(id, horror string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		constants.ID: &types.AttributeValueMemberN{Value: id},
+		"horror":     &types.AttributeValueMemberN{Value: horror},
+	}
+}
+
+func TestGetCentroids_Paginates(t *testing.T) {
+	dynamo := new(MockDynamoClient)
+	lastKey := map[string]types.AttributeValue{constants.ID: &types.AttributeValueMemberN{Value: "1"}}
+	dynamo.On("Scan", mock.Anything, mock.MatchedBy(func(in *dynamodb.ScanInput) bool {
+		return in.ExclusiveStartKey == nil
+	})).Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{centroidItem("0", "10"), centroidItem("1", "20")}, LastEvaluatedKey: lastKey}, nil).Once()
+	dynamo.On("Scan", mock.Anything, mock.MatchedBy(func(in *dynamodb.ScanInput) bool {
+		return in.ExclusiveStartKey != nil
+	})).Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{centroidItem("2", "30")}}, nil).Once()
+
+	centroids, err := repos.NewDynamoCentroidsRepo(dynamo).GetCentroids(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, centroids, 3)
+	assert.Equal(t, 10.0, centroids[0].Horror)
+	assert.Equal(t, 30.0, centroids[2].Horror)
+}
+
+func TestReplaceCentroids_WritesAndDeletesStale(t *testing.T) {
+	dynamo := new(MockDynamoClient)
+	dynamo.On("Scan", mock.Anything, mock.Anything).
+		Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{centroidItem("0", "1"), centroidItem("5", "1")}}, nil)
+	var written []types.WriteRequest
+	dynamo.On("BatchWriteItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.BatchWriteItemInput) bool {
+		written = append(written, in.RequestItems["centroids"]...)
+		return true
+	})).Return(&dynamodb.BatchWriteItemOutput{}, nil)
+
+	err := repos.NewDynamoCentroidsRepo(dynamo).ReplaceCentroids(context.Background(), []data.MovieMetrics{
+		{ID: 0, Horror: 90},
+		{ID: 1, Comedy: 80},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, written, 3)
+	assert.Equal(t, "0", written[0].PutRequest.Item[constants.ID].(*types.AttributeValueMemberN).Value, "centroid 0 keeps its key")
+	assert.Equal(t, "90", written[0].PutRequest.Item["horror"].(*types.AttributeValueMemberN).Value)
+	assert.Equal(t, "1", written[1].PutRequest.Item[constants.ID].(*types.AttributeValueMemberN).Value)
+	assert.Equal(t, "5", written[2].DeleteRequest.Key[constants.ID].(*types.AttributeValueMemberN).Value)
+}
+
+func TestReplaceCentroids_BatchesAndRetriesUnprocessed(t *testing.T) {
+	dynamo := new(MockDynamoClient)
+	dynamo.On("Scan", mock.Anything, mock.Anything).Return(&dynamodb.ScanOutput{}, nil)
+	centroids := make([]data.MovieMetrics, constants.BATCH_WRITE_LIMIT+5)
+	for i := range centroids {
+		centroids[i] = data.MovieMetrics{ID: i, Drama: float64(i)}
+	}
+	unprocessed := map[string][]types.WriteRequest{"centroids": {{PutRequest: &types.PutRequest{}}}}
+	dynamo.On("BatchWriteItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.BatchWriteItemInput) bool {
+		return len(in.RequestItems["centroids"]) == constants.BATCH_WRITE_LIMIT
+	})).Return(&dynamodb.BatchWriteItemOutput{UnprocessedItems: unprocessed}, nil).Once()
+	dynamo.On("BatchWriteItem", mock.Anything, mock.Anything).Return(&dynamodb.BatchWriteItemOutput{}, nil)
+
+	err := repos.NewDynamoCentroidsRepo(dynamo).ReplaceCentroids(context.Background(), centroids)
+	assert.NoError(t, err)
+	dynamo.AssertNumberOfCalls(t, "BatchWriteItem", 3)
+}
+
+func TestReplaceCentroids_GivesUpOnUnprocessed(t *testing.T) {
+	dynamo := new(MockDynamoClient)
+	dynamo.On("Scan", mock.Anything, mock.Anything).Return(&dynamodb.ScanOutput{}, nil)
+	unprocessed := map[string][]types.WriteRequest{"centroids": {{PutRequest: &types.PutRequest{}}}}
+	dynamo.On("BatchWriteItem", mock.Anything, mock.Anything).Return(&dynamodb.BatchWriteItemOutput{UnprocessedItems: unprocessed}, nil)
+
+	err := repos.NewDynamoCentroidsRepo(dynamo).ReplaceCentroids(context.Background(), []data.MovieMetrics{{ID: 0}})
+	assert.ErrorContains(t, err, "left unprocessed")
+	dynamo.AssertNumberOfCalls(t, "BatchWriteItem", constants.BATCH_WRITE_RETRIES+1)
+}
+
+func TestReplaceCentroids_ScanError(t *testing.T) {
+	dynamo := new(MockDynamoClient)
+	dynamo.On("Scan", mock.Anything, mock.Anything).Return(&dynamodb.ScanOutput{}, errors.New("throttled"))
+
+	err := repos.NewDynamoCentroidsRepo(dynamo).ReplaceCentroids(context.Background(), []data.MovieMetrics{{ID: 0}})
+	assert.Error(t, err)
+	dynamo.AssertNotCalled(t, "BatchWriteItem", mock.Anything, mock.Anything)
+}
+
+func TestGetCentroidLabels_Success(t *testing.T) {
+	dynamo := new(MockDynamoClient)
+	dynamo.On("Scan", mock.Anything, mock.MatchedBy(func(in *dynamodb.ScanInput) bool {
+		return *in.FilterExpression == "attribute_exists(#l)"
+	})).Return(&dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{{
+		constants.ID:          &types.AttributeValueMemberN{Value: "3"},
+		constants.LABEL:       &types.AttributeValueMemberS{Value: "Date night"},
+		constants.DESCRIPTION: &types.AttributeValueMemberS{Value: "Romcoms for two."},
+	}}}, nil)
+
+	labels, err := repos.NewDynamoCentroidsRepo(dynamo).GetCentroidLabels(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]data.CentroidLabel{3: {Label: "Date night", Description: "Romcoms for two.", Custom: true}}, labels)
+}
+
+func TestGetCentroidLabels_ScanError(t *testing.T) {
+	dynamo := new(MockDynamoClient)
+	dynamo.On("Scan", mock.Anything, mock.Anything).Return(&dynamodb.ScanOutput{}, errors.New("throttled"))
+
+	_, err := repos.NewDynamoCentroidsRepo(dynamo).GetCentroidLabels(context.Background())
+	assert.Error(t, err)
+}
+
+func TestSetCentroidLabel_Sets(t *testing.T) {
+	dynamo := new(MockDynamoClient)
+	dynamo.On("UpdateItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.UpdateItemInput) bool {
+		return *in.UpdateExpression == "SET #l = :l, #d = :d" &&
+			in.Key[constants.ID].(*types.AttributeValueMemberN).Value == "0" &&
+			in.ExpressionAttributeValues[":l"].(*types.AttributeValueMemberS).Value == "Date night"
+	})).Return(&dynamodb.UpdateItemOutput{}, nil)
+
+	err := repos.NewDynamoCentroidsRepo(dynamo).SetCentroidLabel(context.Background(), 0, data.CentroidLabel{Label: "Date night"})
+	assert.NoError(t, err)
+	dynamo.AssertExpectations(t)
+}
+
+func TestSetCentroidLabel_EmptyRemoves(t *testing.T) {
+	dynamo := new(MockDynamoClient)
+	dynamo.On("UpdateItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.UpdateItemInput) bool {
+		return *in.UpdateExpression == "REMOVE #l, #d" && in.ExpressionAttributeValues == nil
+	})).Return(&dynamodb.UpdateItemOutput{}, nil)
+
+	err := repos.NewDynamoCentroidsRepo(dynamo).SetCentroidLabel(context.Background(), 2, data.CentroidLabel{})
+	assert.NoError(t, err)
+	dynamo.AssertExpectations(t)
+}
+
+func TestSetCentroidLabel_NotFound(t *testing.T) {
+	dynamo := new(MockDynamoClient)
+	dynamo.On("UpdateItem", mock.Anything, mock.Anything).
+		Return(&dynamodb.UpdateItemOutput{}, &types.ConditionalCheckFailedException{})
+
+	err := repos.NewDynamoCentroidsRepo(dynamo).SetCentroidLabel(context.Background(), 9, data.CentroidLabel{Label: "x"})
+	assert.ErrorIs(t, err, repos.ErrCentroidNotFound)
+}
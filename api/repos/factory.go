@@ -8,11 +8,15 @@ import (
 )
 
 var (
-	movieRepoOnce  sync.Once
-	memberRepoOnce sync.Once
+	movieRepoOnce     sync.Once
+	memberRepoOnce    sync.Once
+	centroidsRepoOnce sync.Once
+	quizScoreRepoOnce sync.Once
 
-	movieRepoInstance  ReadWriteMovieRepo
-	memberRepoInstance MemberRepoInterface
+	movieRepoInstance     ReadWriteMovieRepo
+	memberRepoInstance    MemberRepoInterface
+	centroidsRepoInstance CentroidsRepo
+	quizScoreRepoInstance QuizScoreRepo
 )
 
 // NewMovieRepoWithDynamo returns a singleton MovieRepo using a shared DynamoDB client.
@@ -24,17 +28,57 @@ func NewMovieRepoWithDynamo() ReadWriteMovieRepo {
 	return movieRepoInstance
 }
 
-// NewMemberRepoWithDynamo returns a singleton MemberRepo using a shared MovieRepo.
+// NewMemberRepoWithDynamo returns a singleton MemberRepo using a shared MovieRepo, with the
+// shared movie metrics index and co-rental counts available to the rec engine.
+// Co-rentals are only blended into the voting final picks when a request asks for them.
 func NewMemberRepoWithDynamo() MemberRepoInterface {
 	memberRepoOnce.Do(func() {
 		client := utils.GetDynamoClient()
 		movieRepo := NewMovieRepoWithDynamo()
-		memberRepoInstance = NewMembersRepo(
-			client,
-			movieRepo,
-			api_cache.GetDynamoClientCentroidCache(),
-			api_cache.InitCentroidsToMoviesCache(movieRepo.GetMoviesByPage),
-		)
+		centroids, centroidsToMovies := NewCentroidCachesWithDynamo()
+		memberRepo := NewMembersRepo(client, movieRepo, centroids, centroidsToMovies).(*MemberRepo)
+		memberRepo.SetMovieIndex(api_cache.InitMovieIndex(movieRepo.GetMoviesByPage))
+		memberRepo.SetCoRentalCache(api_cache.GetCoRentalCache(memberRepo.GetRentalHistories))
+		memberRepoInstance = memberRepo
 	})
 	return memberRepoInstance
 }
+
+// NewCentroidsRepoWithDynamo returns a singleton CentroidsRepo using the shared DynamoDB client.
+func NewCentroidsRepoWithDynamo() CentroidsRepo {
+	centroidsRepoOnce.Do(func() {
+		centroidsRepoInstance = NewDynamoCentroidsRepo(utils.GetDynamoClient())
+	})
+	return centroidsRepoInstance
+}
+
+// NewQuizScoreRepoWithDynamo returns a singleton QuizScoreRepo using the shared DynamoDB client.
+func NewQuizScoreRepoWithDynamo() QuizScoreRepo {
+	quizScoreRepoOnce.Do(func() {
+		quizScoreRepoInstance = NewDynamoQuizScoreRepo(utils.GetDynamoClient())
+	})
+	return quizScoreRepoInstance
+}
+
+// NewCentroidCachesWithDynamo returns the shared centroid cache and centroid to movies cache.
+// Movies found without a centroid are assigned one and saved through the shared MovieRepo.
+func NewCentroidCachesWithDynamo() (*api_cache.CentroidCache, *api_cache.CentroidsToMoviesCache) {
+	movieRepo := NewMovieRepoWithDynamo()
+	centroidsRepo := NewCentroidsRepoWithDynamo()
+	centroids := api_cache.GetCentroidCache(centroidsRepo.GetCentroids, centroidsRepo.GetCentroidLabels)
+	return centroids, api_cache.InitCentroidsToMoviesCache(movieRepo.GetMoviesByPage, centroids, movieRepo.SetCentroid)
+}
+
+// NewQuizLeaderboardWithDynamo returns the shared quiz leaderboard, loaded from the shared
+// QuizScoreRepo.
+func NewQuizLeaderboardWithDynamo() *api_cache.QuizLeaderboardCache {
+	return api_cache.GetQuizLeaderboardCache(NewQuizScoreRepoWithDynamo().GetQuizScores)
+}
+
+// NewReloadableCachesWithDynamo returns the shared centroid caches and movie metrics index
+// behind the rec engine, and the quiz leaderboard, so they can be inspected and reloaded.
+func NewReloadableCachesWithDynamo() []api_cache.ReloadableCache {
+	centroids, centroidsToMovies := NewCentroidCachesWithDynamo()
+	movieIndex := api_cache.InitMovieIndex(NewMovieRepoWithDynamo().GetMoviesByPage)
+	return []api_cache.ReloadableCache{centroids, centroidsToMovies, movieIndex, NewQuizLeaderboardWithDynamo()}
+}
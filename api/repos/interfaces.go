@@ -2,6 +2,7 @@ package repos
 
 import (
 	"blockbuster/api/data"
+	"blockbuster/api/utils"
 	"context"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -12,6 +13,8 @@ type DynamoClientInterface interface {
 	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
 	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
 	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
 }
 
 type MovieReadRepo interface {
@@ -27,9 +30,53 @@ type MovieInventoryRepo interface {
 	Return(ctx context.Context, movie data.Movie) (bool, error)
 }
 
+// MovieCentroidRepo records which centroid a movie is clustered into, alone or along with
+// new metrics for the movie.
+type MovieCentroidRepo interface {
+	SetCentroid(ctx context.Context, movieID string, centroid int) error
+	SetMetrics(ctx context.Context, movieID string, metrics data.MovieMetrics, centroid int) error
+}
+
+// MovieTriviaRepo writes a movie's trivia and reads the trivia of several movies at once.
+type MovieTriviaRepo interface {
+	SetTrivia(ctx context.Context, movieID string, trivia data.Trivia) error
+	GetMoviesTrivia(ctx context.Context, movieIDs []string) ([]data.Movie, error)
+}
+
 type ReadWriteMovieRepo interface {
 	MovieReadRepo
 	MovieInventoryRepo
+	MovieCentroidRepo
+	MovieTriviaRepo
+}
+
+// CentroidsRepo reads and replaces the stored k-means centroids and the labels staff have
+// given them.
+type CentroidsRepo interface {
+	GetCentroids(ctx context.Context) (map[int]data.MovieMetrics, error)
+	ReplaceCentroids(ctx context.Context, centroids []data.MovieMetrics) error
+	GetCentroidLabels(ctx context.Context) (map[int]data.CentroidLabel, error)
+	SetCentroidLabel(ctx context.Context, id int, label data.CentroidLabel) error
+}
+
+// QuizScoreRepo keeps members' running trivia quiz totals.
+type QuizScoreRepo interface {
+	RecordQuizScore(ctx context.Context, username string, correct, asked int) (data.QuizStats, error)
+	GetQuizScores(ctx context.Context) ([]data.QuizStats, error)
+}
+
+// FinalPicksQuery configures GetVotingFinalPicks. Diversity runs from 0 (closest to the mood
+// only) to 1 (as varied as possible); Username, when set, excludes the member's rentals, as
+// does every member of Group, and Voted lists the movies voted for so explanations can point
+// back to them. Co-rentals of the best pick are only blended in when BlendCoRentals is set.
+type FinalPicksQuery struct {
+	NumPicks       int
+	Diversity      float64
+	Username       string
+	Group          []string
+	Voted          []string
+	Distance       utils.DistanceFunc
+	BlendCoRentals bool
 }
 
 type MemberRepoInterface interface {
@@ -40,8 +87,12 @@ type MemberRepoInterface interface {
 	Checkout(ctx context.Context, username string, movieIDs []string) ([]string, int, error)
 	Return(ctx context.Context, username string, movieIDs []string) ([]string, int, error)
 	SetMemberAPIChoice(ctx context.Context, username, apiChoice string) error
-	GetIniitialVotingSlate(ctx context.Context) ([]string, error)
-	IterateRecommendationVoting(ctx context.Context, currentMood data.MovieMetrics, iteration, numPrevSelected int, movieIDs []string) (data.MovieMetrics, []string, error)
-	GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics) ([]string, error)
+	GetIniitialVotingSlate(ctx context.Context, username string, seed int64) ([]string, error)
+	IterateRecommendationVoting(ctx context.Context, currentMood data.MovieMetrics, iteration, numPrevSelected int, movieIDs []string, seed int64) (data.MovieMetrics, []string, error)
+	GetVotingSlate(ctx context.Context, mood data.MovieMetrics, iteration int, exclude []string, seed int64) ([]string, error)
+	GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics, query FinalPicksQuery) ([]data.FinalPick, error)
 	UpdateMood(ctx context.Context, currentMood data.MovieMetrics, iteration int, movieIDs []string) (data.MovieMetrics, error)
+	UpdateTaste(ctx context.Context, username string, signal data.MovieMetrics, weight float64) (data.TasteProfile, error)
+	GetRecommendations(ctx context.Context, username string, limit int) ([]data.Recommendation, error)
+	GetRentalHistories(ctx context.Context) ([][]string, error)
 }
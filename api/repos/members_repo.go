@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -20,29 +22,42 @@ import (
 
 const membersTableName = "BluckBoster_members"
 
+var ErrMemberNotFound = errors.New("member not found")
+
 type MemberRepo struct {
 	client            DynamoClientInterface
 	tableName         string
 	movieRepo         ReadWriteMovieRepo
 	centroids         api_cache.CentroidCacheInterface
 	centroidsToMovies api_cache.CentroidsToMoviesCacheInterface
-	movieMetricCache  map[string]data.MovieMetrics
-	randGen           rand.Rand
+	movieIndex        api_cache.MovieIndexInterface
+	coRentals         api_cache.CoRentalCacheInterface
 }
 
 func NewMembersRepo(client DynamoClientInterface, movieRepo ReadWriteMovieRepo, centroidsCache api_cache.CentroidCacheInterface, centroidsToMovies api_cache.CentroidsToMoviesCacheInterface) MemberRepoInterface {
-	source := rand.NewSource(time.Now().UnixNano())
 	return &MemberRepo{
 		client:            client,
 		tableName:         membersTableName,
 		movieRepo:         movieRepo,
 		centroids:         centroidsCache,
 		centroidsToMovies: centroidsToMovies,
-		movieMetricCache:  make(map[string]data.MovieMetrics),
-		randGen:           *rand.New(source),
+		movieIndex:        api_cache.NewMovieIndex(nil),
 	}
 }
 
+// SetCoRentalCache sets the co-rental counts used to weight the initial voting slate by
+// popularity and to blend "also rented" movies into final picks that ask for it. A nil cache
+// leaves both purely metric based.
+func (r *MemberRepo) SetCoRentalCache(coRentals api_cache.CoRentalCacheInterface) {
+	r.coRentals = coRentals
+}
+
+// SetMovieIndex replaces the movie metrics index used for nearest-neighbour candidates. The
+// repo starts with an empty index, which finds no candidates.
+func (r *MemberRepo) SetMovieIndex(index api_cache.MovieIndexInterface) {
+	r.movieIndex = index
+}
+
 func (r *MemberRepo) GetMemberByUsername(ctx context.Context, username string, cartOnly bool) (data.Member, error) {
 	member := data.Member{}
 
@@ -64,7 +79,7 @@ func (r *MemberRepo) GetMemberByUsername(ctx context.Context, username string, c
 		return member, utils.LogError("fetching user from cloud", err)
 	}
 	if result.Item == nil {
-		return member, utils.LogError(fmt.Sprintf("user %s not found", username), errors.New("item not found"))
+		return member, utils.LogError(fmt.Sprintf("user %s not found", username), ErrMemberNotFound)
 	}
 
 	err = attributevalue.UnmarshalMap(result.Item, &member)
@@ -136,7 +151,7 @@ func (r *MemberRepo) Checkout(ctx context.Context, username string, movieIDs []s
 }
 
 func (r *MemberRepo) Return(ctx context.Context, username string, movieIDs []string) ([]string, int, error) {
-	var messages []string
+	var messages, returnedIDs []string
 	var returned int
 
 	movies, err := r.movieRepo.GetMoviesByID(ctx, movieIDs, constants.NOT_CART)
@@ -167,8 +182,10 @@ func (r *MemberRepo) Return(ctx context.Context, username string, movieIDs []str
 			messages = append(messages, utils.LogError(fmt.Sprintf("updating inventory for %s", movie.Title), err).Error())
 			continue
 		}
+		returnedIDs = append(returnedIDs, movie.ID)
 		returned++
 	}
+	r.recordTaste(ctx, username, returnedIDs, constants.TASTE_RETURN_WEIGHT)
 	return messages, returned, nil
 }
 
@@ -201,7 +218,7 @@ func (r *MemberRepo) SetMemberAPIChoice(ctx context.Context, username, apiChoice
 
 func (r *MemberRepo) performCheckout(ctx context.Context, user data.Member, movies []data.Movie) ([]string, int, error) {
 	var rented int
-	var messages []string
+	var messages, rentedIDs []string
 
 	for _, movie := range movies {
 		if movie.Inventory < 0 {
@@ -220,8 +237,10 @@ func (r *MemberRepo) performCheckout(ctx context.Context, user data.Member, movi
 			messages = append(messages, err.Error())
 			continue
 		}
+		rentedIDs = append(rentedIDs, movie.ID)
 		rented++
 	}
+	r.recordTaste(ctx, user.Username, rentedIDs, constants.TASTE_CHECKOUT_WEIGHT)
 	return messages, rented, nil
 }
 
@@ -274,57 +293,312 @@ func buildCartUpdateExpr(movieID, updateKey string, checkingOut bool) (string, m
 	return expr, attrs
 }
 
-func (r *MemberRepo) GetIniitialVotingSlate(ctx context.Context) ([]string, error) {
+// GetIniitialVotingSlate draws the first voting slate, spread across the taste space: the
+// centroids are visited in farthest-point order and take turns adding a movie, so no region
+// gets a second movie before every region has one. Members with a taste profile have the
+// centroids nearest their taste visited first. Within a centroid, popular movies are more
+// likely to be drawn and in-stock movies are preferred. The slate always has
+// MAX_MOVIE_SUGGESTIONS movies when the centroids hold that many, and the same seed draws the
+// same slate while the caches, stock and the member's taste are unchanged.
+func (r *MemberRepo) GetIniitialVotingSlate(ctx context.Context, username string, seed int64) ([]string, error) {
 	if r.centroids.Size() == 0 {
 		return nil, utils.LogError("centroid cache failed to initialize; cannot support rec engine", nil)
 	}
+	rng := utils.NewSlateRand(seed, 0)
+
+	var drafted []string
+	var errs []error
+	want := constants.MAX_MOVIE_SUGGESTIONS * constants.SLATE_STOCK_OVERSAMPLE
+	for _, group := range r.slateCentroidGroups(ctx, username) {
+		movies, err := r.draftFromCentroids(stratifyCentroids(group, rng), want-len(drafted), rng)
+		errs = append(errs, err)
+		drafted = append(drafted, movies...)
+		if len(drafted) >= want {
+			break
+		}
+	}
+
+	inStock, err := r.inStock(ctx, drafted)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	slate := make([]string, 0, constants.MAX_MOVIE_SUGGESTIONS)
+	for _, wantStocked := range []bool{true, false} {
+		for _, mid := range drafted {
+			if len(slate) < constants.MAX_MOVIE_SUGGESTIONS && inStock[mid] == wantStocked {
+				slate = append(slate, mid)
+			}
+		}
+	}
+	return slate, errors.Join(errs...)
+}
+
+// slateCentroidGroups returns the centroids the initial slate is drawn from, in the order
+// they should be used: the centroids nearest the member's taste and then the rest, or every
+// centroid for anonymous members and members with no taste yet.
+func (r *MemberRepo) slateCentroidGroups(ctx context.Context, username string) [][]data.Centroid {
+	centroids := r.centroids.GetCentroids()
+	if username == "" {
+		return [][]data.Centroid{centroids}
+	}
+	member, err := r.GetMemberByUsername(ctx, username, constants.NOT_CART)
+	if err != nil || member.Taste == nil || member.Taste.Weight <= 0 {
+		return [][]data.Centroid{centroids}
+	}
+	nearest, err := r.centroids.GetKNearestCentroidsFromMood(member.Taste.Metrics, constants.MAX_CENTROIDS_COUNT)
+	if err != nil || len(nearest) == 0 {
+		utils.LogError(fmt.Sprintf("falling back to every centroid for %s", username), err)
+		return [][]data.Centroid{centroids}
+	}
+	var near, rest []data.Centroid
+	for _, centroid := range centroids {
+		if slices.Contains(nearest, centroid.ID) {
+			near = append(near, centroid)
+		} else {
+			rest = append(rest, centroid)
+		}
+	}
+	return [][]data.Centroid{near, rest}
+}
 
-	usedIDs, attempts, errs := make(map[string]bool), 0, make([]error, 0)
-	i := 0
-	for i < constants.MAX_MOVIE_SUGGESTIONS && attempts < constants.MAX_MOVIE_SUGGESTIONS*3 {
-		attempts++
-		mid, err := r.centroidsToMovies.GetRandomMovieFromCentroid(r.randGen.Intn(r.centroids.Size()))
+// stratifyCentroids orders centroids by farthest-point sampling: after a random first
+// centroid, each next one is the centroid farthest from all those already chosen. Ties go
+// to the lowest ID.
+func stratifyCentroids(centroids []data.Centroid, rng *rand.Rand) []int {
+	if len(centroids) == 0 {
+		return nil
+	}
+	remaining := slices.Clone(centroids)
+	gaps := make([]float64, len(remaining))
+	for i := range gaps {
+		gaps[i] = math.Inf(1)
+	}
+	order := make([]int, 0, len(centroids))
+	next := rng.Intn(len(remaining))
+	for len(remaining) > 0 {
+		chosen := remaining[next]
+		order = append(order, chosen.ID)
+		remaining = slices.Delete(remaining, next, next+1)
+		gaps = slices.Delete(gaps, next, next+1)
+		next = 0
+		for i, centroid := range remaining {
+			gaps[i] = min(gaps[i], utils.MetricDistance(chosen.Metrics, centroid.Metrics))
+			if gaps[i] > gaps[next] {
+				next = i
+			}
+		}
+	}
+	return order
+}
+
+// draftFromCentroids takes up to n movies from the centroids in order, one per centroid per
+// round, until n are drafted or every centroid is exhausted.
+func (r *MemberRepo) draftFromCentroids(order []int, n int, rng *rand.Rand) ([]string, error) {
+	queues := make([][]string, 0, len(order))
+	var errs []error
+	for _, centroid := range order {
+		movieIDs, err := r.centroidsToMovies.GetMovieIDsByCentroid(centroid)
 		if err != nil {
 			errs = append(errs, err)
-			utils.LogError("failed to attain random movie from centroid", err)
 			continue
 		}
-		if usedIDs[mid] {
+		queues = append(queues, r.rankByPopularity(movieIDs, rng))
+	}
+
+	var drafted []string
+	for len(drafted) < n {
+		took := false
+		for i := range queues {
+			if len(drafted) == n || len(queues[i]) == 0 {
+				continue
+			}
+			drafted = append(drafted, queues[i][0])
+			queues[i] = queues[i][1:]
+			took = true
+		}
+		if !took {
+			break
+		}
+	}
+	return drafted, errors.Join(errs...)
+}
+
+// rankByPopularity shuffles movieIDs weighting each movie by one more than its number of
+// renters, so popular movies tend to come first without unrented ones being left out.
+func (r *MemberRepo) rankByPopularity(movieIDs []string, rng *rand.Rand) []string {
+	type ranked struct {
+		movieID string
+		key     float64
+	}
+	ranks := make([]ranked, len(movieIDs))
+	for i, mid := range movieIDs {
+		weight := 1.0
+		if r.coRentals != nil {
+			weight += float64(r.coRentals.RentalCount(mid))
+		}
+		// Weighted sampling without replacement: sort by u^(1/weight), kept in log space.
+		ranks[i] = ranked{movieID: mid, key: math.Log(1-rng.Float64()) / weight}
+	}
+	sort.SliceStable(ranks, func(i, j int) bool {
+		return ranks[i].key > ranks[j].key
+	})
+	order := make([]string, len(ranks))
+	for i, rank := range ranks {
+		order[i] = rank.movieID
+	}
+	return order
+}
+
+// inStock reports which of movieIDs have copies on the shelf. On failure every movie is
+// reported as in stock so the slate is still drawn.
+func (r *MemberRepo) inStock(ctx context.Context, movieIDs []string) (map[string]bool, error) {
+	stocked := make(map[string]bool, len(movieIDs))
+	for start := 0; start < len(movieIDs); start += constants.BATCH_GET_LIMIT {
+		batch := movieIDs[start:min(start+constants.BATCH_GET_LIMIT, len(movieIDs))]
+		movies, err := r.movieRepo.GetMoviesByID(ctx, batch, constants.CART)
+		if err != nil {
+			for _, mid := range movieIDs {
+				stocked[mid] = true
+			}
+			return stocked, utils.LogError("checking stock for the initial voting slate", err)
+		}
+		for _, movie := range movies {
+			stocked[movie.ID] = movie.Inventory > 0
+		}
+	}
+	return stocked, nil
+}
+
+// ErrTasteConflict is returned when a member's taste keeps changing underneath an update.
+var ErrTasteConflict = errors.New("taste profile changed concurrently")
+
+// UpdateTaste folds signal into the member's stored taste profile with the given weight.
+// The write is conditional on the profile being unchanged since it was read, so signals that
+// arrive together, such as a checkout and a return, are applied one after the other; a lost
+// race is retried from a fresh read.
+func (r *MemberRepo) UpdateTaste(ctx context.Context, username string, signal data.MovieMetrics, weight float64) (data.TasteProfile, error) {
+	for range constants.TASTE_UPDATE_ATTEMPTS {
+		member, err := r.GetMemberByUsername(ctx, username, constants.NOT_CART)
+		if err != nil {
+			return data.TasteProfile{}, utils.LogError(fmt.Sprintf("fetching %s to update taste", username), err)
+		}
+		profile := utils.UpdateTaste(member.Taste, signal, weight, time.Now())
+		input, err := r.getTasteInput(username, member.Taste, profile)
+		if err != nil {
+			return data.TasteProfile{}, err
+		}
+		_, err = r.client.UpdateItem(ctx, input)
+		var conflict *types.ConditionalCheckFailedException
+		if errors.As(err, &conflict) {
+			continue
+		}
+		if err != nil {
+			return data.TasteProfile{}, utils.LogError(fmt.Sprintf("updating taste for %s", username), err)
+		}
+		return profile, nil
+	}
+	return data.TasteProfile{}, utils.LogError(fmt.Sprintf("updating taste for %s", username), ErrTasteConflict)
+}
+
+// getTasteInput writes profile over previous, the taste it was derived from. previous is
+// identified by its updated_at, or by there being no taste yet.
+func (r *MemberRepo) getTasteInput(username string, previous *data.TasteProfile, profile data.TasteProfile) (*dynamodb.UpdateItemInput, error) {
+	name, err := attributevalue.Marshal(username)
+	if err != nil {
+		return nil, utils.LogError("marshalling username", err)
+	}
+	taste, err := attributevalue.Marshal(profile)
+	if err != nil {
+		return nil, utils.LogError("marshalling taste profile", err)
+	}
+	expr := "SET taste = :taste"
+	cond := "attribute_exists(username) AND attribute_not_exists(taste)"
+	attrs := map[string]types.AttributeValue{":taste": taste}
+	if previous != nil {
+		updatedAt, err := attributevalue.Marshal(previous.UpdatedAt)
+		if err != nil {
+			return nil, utils.LogError("marshalling taste timestamp", err)
+		}
+		cond = "taste.updated_at = :previous"
+		attrs[":previous"] = updatedAt
+	}
+	return &dynamodb.UpdateItemInput{
+		TableName:                 &r.tableName,
+		Key:                       map[string]types.AttributeValue{constants.USERNAME: name},
+		ExpressionAttributeValues: attrs,
+		UpdateExpression:          &expr,
+		ConditionExpression:       &cond,
+		ReturnValues:              types.ReturnValueUpdatedNew,
+	}, nil
+}
+
+// recordTaste nudges the member's taste toward the movies they rented or returned. It is
+// best effort: failures are logged and never fail the rental itself.
+func (r *MemberRepo) recordTaste(ctx context.Context, username string, movieIDs []string, weightPerMovie float64) {
+	if len(movieIDs) == 0 {
+		return
+	}
+	var sum data.MovieMetrics
+	count := 0
+	for _, mid := range movieIDs {
+		metrics, err := r.movieRepo.GetMovieMetrics(ctx, mid)
+		if err != nil {
+			utils.LogError(fmt.Sprintf("skipping %s in taste update", mid), err)
 			continue
 		}
-		usedIDs[mid] = true
-		i++
+		sum = utils.AccumulateMovieMetricsWithWeight(sum, metrics, 1)
+		count++
+	}
+	if count == 0 {
+		return
+	}
+	if _, err := r.UpdateTaste(ctx, username, utils.AverageMetrics(sum, count), weightPerMovie*float64(count)); err != nil {
+		utils.LogError(fmt.Sprintf("failed to update taste for %s", username), err)
 	}
-	return utils.GetSliceFromMapKeys(usedIDs), errors.Join(errs...)
 }
 
 func (r *MemberRepo) IterateRecommendationVoting(
 	ctx context.Context,
 	currentMood data.MovieMetrics,
 	iteration, numPrevSelected int,
-	movieIDs []string) (data.MovieMetrics, []string, error) {
+	movieIDs []string,
+	seed int64) (data.MovieMetrics, []string, error) {
 
 	updatedMood, err := r.UpdateMood(ctx, currentMood, numPrevSelected, movieIDs)
 	if err != nil {
 		return data.MovieMetrics{}, nil, utils.LogError("updating mood", err)
 	}
-	newCentroids, err := r.centroids.GetKNearestCentroidsFromMood(updatedMood, constants.MAX_CENTROIDS_COUNT-iteration)
+	slate, err := r.GetVotingSlate(ctx, updatedMood, iteration, movieIDs, seed)
+	if slate == nil && err != nil {
+		return data.MovieMetrics{}, nil, err
+	}
+	return updatedMood, slate, err
+}
+
+// GetVotingSlate draws the next voting slate from the centroids nearest mood. Later
+// iterations draw from fewer centroids and suggest fewer movies; movies in exclude are
+// never suggested. The slate follows the iteration's round of seed, so replaying a session's
+// votes with its seed suggests the same slates.
+func (r *MemberRepo) GetVotingSlate(ctx context.Context, mood data.MovieMetrics, iteration int, exclude []string, seed int64) ([]string, error) {
+	newCentroids, err := r.centroids.GetKNearestCentroidsFromMood(mood, constants.MAX_CENTROIDS_COUNT-iteration)
 	if err != nil {
-		return data.MovieMetrics{}, nil, utils.LogError("getting new centroids", err)
+		return nil, utils.LogError("getting new centroids", err)
 	}
 
+	rng := utils.NewSlateRand(seed, iteration+1)
+	var slate []string
 	movieRecs, originalMovies := make(map[string]bool), make(map[string]bool)
 	var errs []error
 	if len(newCentroids) > 0 {
-		for _, mid := range movieIDs {
+		for _, mid := range exclude {
 			originalMovies[mid] = true
 		}
 		for i := 0; i < constants.MAX_MOVIE_SUGGESTIONS-(iteration*2); i++ {
-			centroid := newCentroids[rand.Intn(len(newCentroids))]
+			centroid := newCentroids[rng.Intn(len(newCentroids))]
 			movieID, attempts := "", 0
 			for attempts < 10 {
 				attempts++
-				movieID, err = r.centroidsToMovies.GetRandomMovieFromCentroid(centroid)
+				movieID, err = r.centroidsToMovies.GetRandomMovieFromCentroid(centroid, rng)
 				if err != nil {
 					errs = append(errs, utils.LogError("error getting random movie from centroid", err))
 					continue
@@ -337,10 +611,13 @@ func (r *MemberRepo) IterateRecommendationVoting(
 				errs = append(errs, utils.LogError(fmt.Sprintf("failed to find random movie for centroid %v", centroid), nil))
 				continue
 			}
-			movieRecs[movieID] = true
+			if !movieRecs[movieID] {
+				movieRecs[movieID] = true
+				slate = append(slate, movieID)
+			}
 		}
 	}
-	return updatedMood, utils.GetSliceFromMapKeys(movieRecs), errors.Join(errs...)
+	return slate, errors.Join(errs...)
 }
 
 func (r *MemberRepo) UpdateMood(ctx context.Context, currentMood data.MovieMetrics, numPrevSelected int, movieIDs []string) (data.MovieMetrics, error) {
@@ -357,63 +634,324 @@ func (r *MemberRepo) UpdateMood(ctx context.Context, currentMood data.MovieMetri
 	return utils.AverageMetrics(accMood, numPrevSelected+updateCount), errors.Join(errs...)
 }
 
-func (r *MemberRepo) GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics) ([]string, error) {
-	centroidIDs, err := r.centroids.GetKNearestCentroidsFromMood(mood, constants.NUMBER_FINAL_PICKS)
-	if err != nil {
-		return nil, utils.LogError("failed to get centroid neighbors", err)
+// GetVotingFinalPicks re-ranks the movies nearest mood in the movie index with maximal marginal
+// relevance: each pick maximises (1-diversity)*closeness to mood minus diversity*similarity
+// to the picks already made. Out-of-stock movies and movies the query's member or any member
+// of its group has rented or checked out are never picked. Every pick is explained against the mood.
+func (r *MemberRepo) GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics, query FinalPicksQuery) ([]data.FinalPick, error) {
+	if query.Distance == nil {
+		query.Distance = utils.MetricDistance
 	}
-
-	suggestions := make([]string, 0, len(centroidIDs))
-	for _, id_ := range centroidIDs {
-		mid, err := r.getNearestNeighborInCentroid(ctx, id_, mood)
+	if query.NumPicks <= 0 {
+		query.NumPicks = constants.NUMBER_FINAL_PICKS
+	}
+	excluded := make(map[string]bool)
+	for _, username := range append([]string{query.Username}, query.Group...) {
+		if username == "" {
+			continue
+		}
+		member, err := r.GetMemberByUsername(ctx, username, constants.NOT_CART)
 		if err != nil {
-			utils.LogError(fmt.Sprintf("failed to come up with neareset movie in centroid %v", id_), nil)
+			return nil, utils.LogError(fmt.Sprintf("fetching %s to exclude rented movies from final picks", username), err)
 		}
-		suggestions = append(suggestions, mid)
+		for _, mid := range append(member.Rented, member.Checkedout...) {
+			excluded[mid] = true
+		}
+	}
+
+	neighbors := r.movieIndex.KNearest(mood, constants.ANN_CANDIDATES, query.Distance, func(movieID string) bool {
+		return !excluded[movieID]
+	})
+	metrics := make(map[string]data.MovieMetrics, len(neighbors))
+	ranked := make([]data.Recommendation, len(neighbors))
+	for i, neighbor := range neighbors {
+		metrics[neighbor.MovieID], _ = r.movieIndex.GetMetrics(neighbor.MovieID)
+		ranked[i] = data.Recommendation{Movie: data.Movie{ID: neighbor.MovieID}, Score: -neighbor.Distance, Centroid: neighbor.Centroid}
+	}
+	pool, err := r.inStockRecommendations(ctx, ranked, constants.MMR_CANDIDATE_POOL)
+	if len(pool) == 0 {
+		return nil, utils.LogError("no in-stock movies to pick from", err)
+	}
+
+	candidates := make([]string, len(pool))
+	centroids := make(map[string]int, len(pool))
+	for i, rec := range pool {
+		candidates[i] = rec.Movie.ID
+		centroids[rec.Movie.ID] = rec.Centroid
+	}
+	var coRentals map[string]coRentalCandidate
+	if query.BlendCoRentals {
+		coRentals = r.coRentalCandidates(ctx, candidates[0], centroids, excluded)
+		for mid := range coRentals {
+			mets, err := r.getMovieMetrics(ctx, mid)
+			if err != nil {
+				delete(coRentals, mid)
+				continue
+			}
+			metrics[mid] = mets
+			candidates = append(candidates, mid)
+		}
+		slices.Sort(candidates[len(pool):])
+	}
+	picks := maximalMarginalRelevance(mood, candidates, metrics, coRentals, query.NumPicks, query.Diversity, query.Distance)
+
+	voted := make(map[string]data.MovieMetrics, len(query.Voted))
+	for _, mid := range query.Voted {
+		if mets, err := r.getMovieMetrics(ctx, mid); err == nil {
+			voted[mid] = mets
+		}
+	}
+	finalPicks := make([]data.FinalPick, len(picks))
+	for i, mid := range picks {
+		finalPicks[i] = r.explainPick(ctx, mood, mid, centroids, voted, query.Distance)
+		finalPicks[i].Explanation.AlsoRentedWith = coRentals[mid].anchor
 	}
-	return suggestions, nil
+	return finalPicks, nil
 }
 
-func (r *MemberRepo) getNearestNeighborInCentroid(ctx context.Context, centroidID int, mood data.MovieMetrics) (string, error) {
-	movieMetrics, err := r.getMovieMetricsForCentroid(ctx, centroidID)
+// explainPick describes why movieID suits mood. Co-rentals aren't in the nearest-neighbour
+// pool, so their metrics and centroid are looked up.
+func (r *MemberRepo) explainPick(ctx context.Context, mood data.MovieMetrics, movieID string, centroids map[string]int,
+	voted map[string]data.MovieMetrics, distance utils.DistanceFunc) data.FinalPick {
+	pick := data.FinalPick{MovieID: movieID, Explanation: data.PickExplanation{InfluencedBy: []string{}}}
+	metrics, err := r.getMovieMetrics(ctx, movieID)
 	if err != nil {
-		return "", err
+		utils.LogError(fmt.Sprintf("explaining final pick %s", movieID), err)
+		return pick
+	}
+	centroid, ok := centroids[movieID]
+	if !ok {
+		centroid, _ = r.centroidsToMovies.GetCentroidByMovieID(movieID)
 	}
 
-	minDistance := math.MaxFloat64
-	nearestNeighbor := ""
-	for mid, mets := range movieMetrics {
-		d := utils.MetricDistance(mood, mets)
-		if d < minDistance {
-			nearestNeighbor = mid
-			minDistance = d
+	influences := make([]string, 0, len(voted))
+	for mid := range voted {
+		influences = append(influences, mid)
+	}
+	sort.Slice(influences, func(i, j int) bool {
+		di, dj := distance(voted[influences[i]], metrics), distance(voted[influences[j]], metrics)
+		if di != dj {
+			return di < dj
 		}
+		return influences[i] < influences[j]
+	})
+
+	pick.Explanation = data.PickExplanation{
+		Centroid:      centroid,
+		Distance:      distance(mood, metrics),
+		TopDimensions: utils.TopContributingDimensions(mood, metrics, constants.EXPLANATION_DIMENSIONS),
+		InfluencedBy:  influences[:min(constants.EXPLANATION_INFLUENCES, len(influences))],
 	}
-	return nearestNeighbor, nil
+	return pick
 }
 
-func (r *MemberRepo) getMovieMetricsForCentroid(ctx context.Context, centroidID int) (map[string]data.MovieMetrics, error) {
-	movies, err := r.centroidsToMovies.GetMovieIDsByCentroid(centroidID)
-	if err != nil || len(movies) == 0 {
-		return nil, utils.LogError(fmt.Sprintf("no movies found for centroid id %v", centroidID), nil)
+// maximalMarginalRelevance greedily picks up to n candidates. Distances are normalised by the
+// furthest candidate from mood so relevance and similarity share a 0 to 1 scale. A co-rental
+// is as relevant as the closer of itself and its anchor, discounted by its co-rental score.
+func maximalMarginalRelevance(mood data.MovieMetrics, candidates []string, metrics map[string]data.MovieMetrics,
+	coRentals map[string]coRentalCandidate, n int, diversity float64, distance utils.DistanceFunc) []string {
+	scale := 0.0
+	for _, mid := range candidates {
+		scale = max(scale, distance(mood, metrics[mid]))
+	}
+	if scale == 0 {
+		scale = 1
+	}
+	closeness := func(mid string) float64 { return 1 - distance(mood, metrics[mid])/scale }
+	relevance := func(mid string) float64 {
+		if coRental, ok := coRentals[mid]; ok {
+			return max(closeness(mid), coRental.score*closeness(coRental.anchor))
+		}
+		return closeness(mid)
+	}
+	similarity := func(a, b string) float64 { return max(0, 1-distance(metrics[a], metrics[b])/scale) }
+
+	picks := make([]string, 0, n)
+	picked := make(map[string]bool, n)
+	for len(picks) < n && len(picks) < len(candidates) {
+		best, bestScore := "", math.Inf(-1)
+		for _, mid := range candidates {
+			if picked[mid] {
+				continue
+			}
+			redundancy := 0.0
+			for _, pick := range picks {
+				redundancy = max(redundancy, similarity(mid, pick))
+			}
+			if score := (1-diversity)*relevance(mid) - diversity*redundancy; score > bestScore {
+				best, bestScore = mid, score
+			}
+		}
+		picks = append(picks, best)
+		picked[best] = true
 	}
+	return picks
+}
 
-	mets := make(map[string]data.MovieMetrics)
-	for _, mid := range movies {
-		// check cache
-		if metrics, ok := r.movieMetricCache[mid]; ok {
-			mets[mid] = metrics
+// coRentalCandidate is a movie often rented with anchor; score is their co-rental score.
+type coRentalCandidate struct {
+	anchor string
+	score  float64
+}
+
+// coRentalCandidates returns up to CO_RENTAL_CANDIDATES in-stock movies most often rented
+// alongside anchor, so the final picks aren't all drawn from the mood alone. Movies already
+// in the pool, excluded for the group or excluded for having no metrics are left out.
+func (r *MemberRepo) coRentalCandidates(ctx context.Context, anchor string, pool map[string]int, excluded map[string]bool) map[string]coRentalCandidate {
+	candidates := make(map[string]coRentalCandidate)
+	if r.coRentals == nil {
+		return candidates
+	}
+	var ids []string
+	for _, coRental := range r.coRentals.GetAlsoRented(anchor, constants.CO_RENTAL_CANDIDATES) {
+		mid := coRental.Movie.ID
+		if _, pooled := pool[mid]; pooled || excluded[mid] || r.centroidsToMovies.IsExcluded(mid) {
 			continue
 		}
+		ids = append(ids, mid)
+		candidates[mid] = coRentalCandidate{anchor: anchor, score: coRental.Score}
+	}
+	if len(ids) == 0 {
+		return candidates
+	}
+	movies, err := r.movieRepo.GetMoviesByID(ctx, ids, constants.CART)
+	if err != nil {
+		utils.LogError("checking inventory of co-rentals for final picks", err)
+		return map[string]coRentalCandidate{}
+	}
+	inStock := make(map[string]bool, len(movies))
+	for _, movie := range movies {
+		inStock[movie.ID] = movie.Inventory > 0
+	}
+	for mid := range candidates {
+		if !inStock[mid] {
+			delete(candidates, mid)
+		}
+	}
+	return candidates
+}
 
-		// populate cache
-		metrics, err := r.movieRepo.GetMovieMetrics(ctx, mid)
+// getMovieMetrics reads metrics from the movie index, falling back to the movies table for
+// movies added since the index was built.
+func (r *MemberRepo) getMovieMetrics(ctx context.Context, movieID string) (data.MovieMetrics, error) {
+	if metrics, ok := r.movieIndex.GetMetrics(movieID); ok {
+		return metrics, nil
+	}
+	return r.movieRepo.GetMovieMetrics(ctx, movieID)
+}
+
+// GetRecommendations ranks movies the member has not rented by closeness to the average
+// metrics of their rental history, falling back to their taste profile when they have no
+// history. Candidates are the ANN_CANDIDATES movies nearest that profile in the movie index
+// and only in-stock movies are returned.
+func (r *MemberRepo) GetRecommendations(ctx context.Context, username string, limit int) ([]data.Recommendation, error) {
+	member, err := r.GetMemberByUsername(ctx, username, constants.NOT_CART)
+	if err != nil {
+		return nil, utils.LogError(fmt.Sprintf("fetching %s for recommendations", username), err)
+	}
+	profile, ok := r.historyProfile(ctx, member)
+	if !ok {
+		// nothing rented and no taste yet: there is nothing to recommend from
+		return []data.Recommendation{}, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, mid := range append(member.Rented, member.Checkedout...) {
+		seen[mid] = true
+	}
+
+	neighbors := r.movieIndex.KNearest(profile, max(limit, constants.ANN_CANDIDATES), utils.MetricDistance, func(movieID string) bool {
+		return !seen[movieID]
+	})
+	candidates := make([]data.Recommendation, len(neighbors))
+	for i, neighbor := range neighbors {
+		score := 1 / (1 + math.Sqrt(neighbor.Distance))
+		candidates[i] = data.Recommendation{Movie: data.Movie{ID: neighbor.MovieID}, Score: score, Centroid: neighbor.Centroid}
+	}
+	return r.inStockRecommendations(ctx, candidates, limit)
+}
+
+func (r *MemberRepo) historyProfile(ctx context.Context, member data.Member) (data.MovieMetrics, bool) {
+	var sum data.MovieMetrics
+	count := 0
+	for _, mid := range append(member.Rented, member.Checkedout...) {
+		metrics, err := r.getMovieMetrics(ctx, mid)
 		if err != nil {
-			utils.LogError(fmt.Sprintf("failed to get movie id for movie id %s", mid), nil)
+			utils.LogError(fmt.Sprintf("skipping %s in recommendation profile", mid), err)
 			continue
 		}
-		r.movieMetricCache[mid] = metrics
-		mets[mid] = metrics
+		sum = utils.AccumulateMovieMetricsWithWeight(sum, metrics, 1)
+		count++
+	}
+	if count > 0 {
+		return utils.AverageMetrics(sum, count), true
+	}
+	if member.Taste != nil && member.Taste.Weight > 0 {
+		return member.Taste.Metrics, true
+	}
+	return data.MovieMetrics{}, false
+}
+
+// inStockRecommendations walks the ranked candidates in batches, keeping those with
+// inventory until limit is reached.
+func (r *MemberRepo) inStockRecommendations(ctx context.Context, ranked []data.Recommendation, limit int) ([]data.Recommendation, error) {
+	recs := make([]data.Recommendation, 0, limit)
+	for start := 0; start < len(ranked) && len(recs) < limit; start += constants.BATCH_GET_LIMIT {
+		batch := ranked[start:min(start+constants.BATCH_GET_LIMIT, len(ranked))]
+		ids := make([]string, len(batch))
+		for i, rec := range batch {
+			ids[i] = rec.Movie.ID
+		}
+		movies, err := r.movieRepo.GetMoviesByID(ctx, ids, constants.CART)
+		if err != nil {
+			return recs, utils.LogError("fetching inventory for recommendations", err)
+		}
+		byID := make(map[string]data.Movie, len(movies))
+		for _, movie := range movies {
+			byID[movie.ID] = movie
+		}
+		for _, rec := range batch {
+			movie, ok := byID[rec.Movie.ID]
+			if !ok || movie.Inventory <= 0 {
+				continue
+			}
+			rec.Movie = movie
+			recs = append(recs, rec)
+			if len(recs) == limit {
+				break
+			}
+		}
+	}
+	return recs, nil
+}
+
+// GetRentalHistories scans the members table and returns, for every member, the movies they
+// have rented or currently have checked out. Members with no history are skipped.
+func (r *MemberRepo) GetRentalHistories(ctx context.Context) ([][]string, error) {
+	projection := "rented, checked_out"
+	input := &dynamodb.ScanInput{
+		TableName:            &r.tableName,
+		ProjectionExpression: &projection,
+	}
+
+	var histories [][]string
+	for {
+		output, err := r.client.Scan(ctx, input)
+		if err != nil {
+			return nil, utils.LogError("scanning member rental histories", err)
+		}
+		var members []data.Member
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &members); err != nil {
+			return nil, utils.LogError("unmarshalling member rental histories", err)
+		}
+		for _, member := range members {
+			if history := append(member.Rented, member.Checkedout...); len(history) > 0 {
+				histories = append(histories, history)
+			}
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return histories, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
 	}
-	return mets, nil
 }
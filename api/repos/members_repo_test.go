@@ -3,13 +3,21 @@ package repos_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"slices"
 	"testing"
+	"time"
 
+	"blockbuster/api/api_cache"
 	"blockbuster/api/constants"
 	"blockbuster/api/data"
 	"blockbuster/api/repos"
+	"blockbuster/api/utils"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
@@ -21,6 +29,8 @@ import (
 type MockCentroidCache struct {
 	KNearest    []int
 	KNearestErr error
+	// Centroids, when set, are every centroid; otherwise the KNearest IDs are.
+	Centroids []data.Centroid
 }
 
 // GetMetricsByCentroid implements api_cache.CentroidCacheInterface.
@@ -28,9 +38,29 @@ func (m *MockCentroidCache) GetMetricsByCentroid(centroidID int) (data.MovieMetr
 	panic("unimplemented")
 }
 
+func (m *MockCentroidCache) GetCentroids() []data.Centroid {
+	if m.Centroids != nil {
+		return m.Centroids
+	}
+	centroids := make([]data.Centroid, len(m.KNearest))
+	for i, id := range m.KNearest {
+		centroids[i] = data.Centroid{ID: id}
+	}
+	return centroids
+}
+
+// SetLabel implements api_cache.CentroidCacheInterface.
+func (m *MockCentroidCache) SetLabel(id int, label data.CentroidLabel) {
+	panic("unimplemented")
+}
+
 // Size implements api_cache.CentroidCacheInterface.
 func (m *MockCentroidCache) Size() int {
-	return len(m.KNearest)
+	return len(m.GetCentroids())
+}
+
+func (m *MockCentroidCache) GetKNearestCentroids(mood data.MovieMetrics, k int, distance utils.DistanceFunc) ([]int, error) {
+	return m.GetKNearestCentroidsFromMood(mood, k)
 }
 
 func (m *MockCentroidCache) GetKNearestCentroidsFromMood(mood data.MovieMetrics, k int) ([]int, error) {
@@ -44,6 +74,7 @@ func (m *MockCentroidCache) GetKNearestCentroidsFromMood(mood data.MovieMetrics,
 
 type MockCentroidsToMoviesCache struct {
 	MoviesByCentroid map[int][]string
+	Excluded         map[string]bool
 	Err              error
 }
 
@@ -54,7 +85,18 @@ func (m *MockCentroidsToMoviesCache) GetMovieIDsByCentroid(centroidID int) ([]st
 	return m.MoviesByCentroid[centroidID], nil
 }
 
-func (m *MockCentroidsToMoviesCache) GetRandomMovieFromCentroid(centroidID int) (string, error) {
+func (m *MockCentroidsToMoviesCache) GetCentroidByMovieID(movieID string) (int, error) {
+	for centroid, movies := range m.MoviesByCentroid {
+		for _, mid := range movies {
+			if mid == movieID {
+				return centroid, nil
+			}
+		}
+	}
+	return 0, errors.New("no centroid")
+}
+
+func (m *MockCentroidsToMoviesCache) GetRandomMovieFromCentroid(centroidID int, rng *rand.Rand) (string, error) {
 	if m.Err != nil {
 		return "", m.Err
 	}
@@ -62,7 +104,38 @@ func (m *MockCentroidsToMoviesCache) GetRandomMovieFromCentroid(centroidID int)
 	if len(movies) == 0 {
 		return "", errors.New("no movies")
 	}
-	return movies[0], nil
+	return movies[rng.Intn(len(movies))], nil
+}
+
+func (m *MockCentroidsToMoviesCache) NearestCentroid(metrics data.MovieMetrics) (int, error) {
+	return 0, m.Err
+}
+
+func (m *MockCentroidsToMoviesCache) Assign(movieID string, centroid int) {
+	m.MoviesByCentroid[centroid] = append(m.MoviesByCentroid[centroid], movieID)
+}
+
+func (m *MockCentroidsToMoviesCache) IsExcluded(movieID string) bool {
+	return m.Excluded[movieID]
+}
+
+// --- Mock for co-rental cache ---
+
+type MockCoRentalCache struct {
+	AlsoRented map[string][]string
+	Rentals    map[string]int
+}
+
+func (m *MockCoRentalCache) RentalCount(movieID string) int {
+	return m.Rentals[movieID]
+}
+
+func (m *MockCoRentalCache) GetAlsoRented(movieID string, k int) []data.CoRental {
+	var results []data.CoRental
+	for _, mid := range m.AlsoRented[movieID] {
+		results = append(results, data.CoRental{Movie: data.Movie{ID: mid}, Count: 1, Score: 1})
+	}
+	return results[:min(k, len(results))]
 }
 
 // --- Mocks for MovieReadRepo ---
@@ -110,6 +183,30 @@ func (m *MockReadWriteMovieRepo) Return(ctx context.Context, movie data.Movie) (
 	return args.Bool(0), args.Error(1)
 }
 
+// --- MovieCentroidRepo methods ---
+
+func (m *MockReadWriteMovieRepo) SetCentroid(ctx context.Context, movieID string, centroid int) error {
+	args := m.Called(ctx, movieID, centroid)
+	return args.Error(0)
+}
+
+func (m *MockReadWriteMovieRepo) SetMetrics(ctx context.Context, movieID string, metrics data.MovieMetrics, centroid int) error {
+	args := m.Called(ctx, movieID, metrics, centroid)
+	return args.Error(0)
+}
+
+// --- MovieTriviaRepo methods ---
+
+func (m *MockReadWriteMovieRepo) GetMoviesTrivia(ctx context.Context, movieIDs []string) ([]data.Movie, error) {
+	args := m.Called(ctx, movieIDs)
+	return args.Get(0).([]data.Movie), args.Error(1)
+}
+
+func (m *MockReadWriteMovieRepo) SetTrivia(ctx context.Context, movieID string, trivia data.Trivia) error {
+	args := m.Called(ctx, movieID, trivia)
+	return args.Error(0)
+}
+
 func setupMemberRepo() (repos.MemberRepoInterface, *MockDynamoClient, *MockReadWriteMovieRepo, *MockCentroidCache, *MockCentroidsToMoviesCache) {
 	dynamo := new(MockDynamoClient)
 	movieRepo := new(MockReadWriteMovieRepo)
@@ -119,6 +216,15 @@ func setupMemberRepo() (repos.MemberRepoInterface, *MockDynamoClient, *MockReadW
 	return repo, dynamo, movieRepo, &centroidCache, &centroidsToMoviesCache
 }
 
+// withIndex gives repo a movie index built from movies.
+func withIndex(repo repos.MemberRepoInterface, movies ...data.Movie) {
+	repo.(*repos.MemberRepo).SetMovieIndex(api_cache.NewMovieIndex(movies))
+}
+
+func indexed(movieID string, centroid int, metrics data.MovieMetrics) data.Movie {
+	return data.Movie{ID: movieID, Centroid: centroid, Metrics: metrics}
+}
+
 const membersTableName = "BluckBoster_members"
 
 func TestGetMemberByUsername_NotFound(t *testing.T) {
@@ -166,48 +272,159 @@ func TestReturn_MovieError(t *testing.T) {
 	assert.Equal(t, 0, count)
 }
 
-func TestGetInitialVotingSlate_Success(t *testing.T) {
-	repoIface, _, _, centroidCache, centroidsToMoviesCache := setupMemberRepo()
-	repo := repoIface.(*repos.MemberRepo)
+// stockMovies answers every stock check with the movies of moviesByCentroid, each with one
+// copy on the shelf unless listed in outOfStock.
+func stockMovies(movieRepo *MockReadWriteMovieRepo, moviesByCentroid map[int][]string, outOfStock ...string) {
+	var movies []data.Movie
+	for _, movieIDs := range moviesByCentroid {
+		for _, mid := range movieIDs {
+			movies = append(movies, data.Movie{ID: mid, Inventory: 1})
+			if slices.Contains(outOfStock, mid) {
+				movies[len(movies)-1].Inventory = 0
+			}
+		}
+	}
+	movieRepo.On("GetMoviesByID", mock.Anything, mock.Anything, constants.CART).Return(movies, nil)
+}
 
-	// Setup: centroid size > 0, and movies available
-	centroidCache.KNearest = []int{1, 2}
-	centroidsToMoviesCache.MoviesByCentroid = map[int][]string{
-		0: {"a1", "a2"},
-		1: {"b1", "b2"},
-		2: {"c1", "c2"},
+// moviesPerCentroid names n movies in each of the centroids.
+func moviesPerCentroid(n int, centroids ...int) map[int][]string {
+	movies := make(map[int][]string)
+	for _, c := range centroids {
+		for i := range n {
+			movies[c] = append(movies[c], fmt.Sprintf("c%d_%02d", c, i))
+		}
 	}
+	return movies
+}
 
-	ctx := context.Background()
-	results, err := repo.GetIniitialVotingSlate(ctx)
+func TestGetInitialVotingSlate_Success(t *testing.T) {
+	repo, _, movieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
+	centroidCache.KNearest = []int{0, 1, 2}
+	centroidsToMoviesCache.MoviesByCentroid = moviesPerCentroid(10, 0, 1, 2)
+	stockMovies(movieRepo, centroidsToMoviesCache.MoviesByCentroid)
+
+	results, err := repo.GetIniitialVotingSlate(context.Background(), "", 1)
 
 	assert.NoError(t, err)
 	assert.Len(t, results, constants.MAX_MOVIE_SUGGESTIONS)
-	// All entries must be non-empty (since we provided movies everywhere)
+	perCentroid := make(map[int]int)
 	for _, mid := range results {
-		assert.NotEmpty(t, mid)
+		centroid, _ := centroidsToMoviesCache.GetCentroidByMovieID(mid)
+		perCentroid[centroid]++
 	}
+	assert.Equal(t, map[int]int{0: 5, 1: 5, 2: 5}, perCentroid, "centroids take turns")
+	assert.Len(t, slices.Compact(slices.Sorted(slices.Values(results))), len(results), "no movie is repeated")
 }
 
-func TestGetInitialVotingSlate_ErrorOnFetch(t *testing.T) {
-	repoIface, _, _, centroidCache, centroidsToMoviesCache := setupMemberRepo()
-	repo := repoIface.(*repos.MemberRepo)
+func TestGetInitialVotingSlate_ExactCountFromOneCentroid(t *testing.T) {
+	repo, _, movieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
+	centroidCache.KNearest = []int{0}
+	centroidsToMoviesCache.MoviesByCentroid = moviesPerCentroid(constants.MAX_MOVIE_SUGGESTIONS+1, 0)
+	stockMovies(movieRepo, centroidsToMoviesCache.MoviesByCentroid)
+
+	results, err := repo.GetIniitialVotingSlate(context.Background(), "", 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, results, constants.MAX_MOVIE_SUGGESTIONS)
+	assert.Len(t, slices.Compact(slices.Sorted(slices.Values(results))), constants.MAX_MOVIE_SUGGESTIONS)
+}
+
+func TestGetInitialVotingSlate_FewerMoviesThanSlate(t *testing.T) {
+	repo, _, movieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
+	centroidCache.KNearest = []int{0, 1}
+	centroidsToMoviesCache.MoviesByCentroid = moviesPerCentroid(2, 0, 1)
+	stockMovies(movieRepo, centroidsToMoviesCache.MoviesByCentroid)
+
+	results, err := repo.GetIniitialVotingSlate(context.Background(), "", 1)
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"c0_00", "c0_01", "c1_00", "c1_01"}, results)
+}
+
+func TestGetInitialVotingSlate_StratifiesCentroids(t *testing.T) {
+	repo, _, movieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
+	far := []data.MovieMetrics{{Comedy: 90}, {Action: 90}, {Romance: 90}, {Drama: 90}}
+	centroidsToMoviesCache.MoviesByCentroid = map[int][]string{}
+	// Sixteen centroids crowd one corner of the taste space and four sit far apart.
+	for id := range 20 {
+		metrics := data.MovieMetrics{Horror: 90 - float64(id)/100}
+		if id >= 16 {
+			metrics = far[id-16]
+		}
+		centroidCache.Centroids = append(centroidCache.Centroids, data.Centroid{ID: id, Metrics: metrics})
+		centroidsToMoviesCache.MoviesByCentroid[id] = []string{fmt.Sprintf("m%d", id)}
+	}
+	stockMovies(movieRepo, centroidsToMoviesCache.MoviesByCentroid)
+
+	for seed := range int64(5) {
+		results, err := repo.GetIniitialVotingSlate(context.Background(), "", seed)
+		assert.NoError(t, err)
+		assert.Len(t, results, constants.MAX_MOVIE_SUGGESTIONS)
+		crowded := 0
+		for _, mid := range results[:5] {
+			if !slices.Contains([]string{"m16", "m17", "m18", "m19"}, mid) {
+				crowded++
+			}
+		}
+		assert.Equal(t, 1, crowded, "every far centroid is visited before a second crowded one (seed %d)", seed)
+	}
+}
+
+func TestGetInitialVotingSlate_PrefersInStockAndPopular(t *testing.T) {
+	repo, _, movieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
+	centroidCache.KNearest = []int{0}
+	centroidsToMoviesCache.MoviesByCentroid = moviesPerCentroid(40, 0)
+	outOfStock := []string{"c0_00", "c0_01", "c0_02"}
+	stockMovies(movieRepo, centroidsToMoviesCache.MoviesByCentroid, outOfStock...)
+	repo.(*repos.MemberRepo).SetCoRentalCache(&MockCoRentalCache{Rentals: map[string]int{"c0_39": 100000}})
+
+	for seed := range int64(5) {
+		results, err := repo.GetIniitialVotingSlate(context.Background(), "", seed)
+		assert.NoError(t, err)
+		assert.Len(t, results, constants.MAX_MOVIE_SUGGESTIONS)
+		assert.Equal(t, "c0_39", results[0], "the most rented movie is drawn first")
+		for _, mid := range outOfStock {
+			assert.NotContains(t, results, mid)
+		}
+	}
+}
+
+func TestGetInitialVotingSlate_FillsWithOutOfStock(t *testing.T) {
+	repo, _, movieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
+	centroidCache.KNearest = []int{0}
+	centroidsToMoviesCache.MoviesByCentroid = moviesPerCentroid(constants.MAX_MOVIE_SUGGESTIONS, 0)
+	stockMovies(movieRepo, centroidsToMoviesCache.MoviesByCentroid, "c0_00")
+
+	results, err := repo.GetIniitialVotingSlate(context.Background(), "", 1)
+
+	assert.NoError(t, err)
+	assert.Len(t, results, constants.MAX_MOVIE_SUGGESTIONS)
+	assert.Equal(t, "c0_00", results[len(results)-1], "out of stock movies only fill the slate")
+}
 
-	// Setup: one centroid exists, but always returns error
+func TestGetInitialVotingSlate_ErrorOnFetch(t *testing.T) {
+	repo, _, movieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
 	centroidCache.KNearest = []int{1}
 	centroidsToMoviesCache.Err = errors.New("fetch failed")
+	movieRepo.On("GetMoviesByID", mock.Anything, mock.Anything, constants.CART).Return([]data.Movie{}, nil)
 
-	ctx := context.Background()
-	results, err := repo.GetIniitialVotingSlate(ctx)
+	results, err := repo.GetIniitialVotingSlate(context.Background(), "", 1)
 
-	// Expect slice length = MAX_MOVIE_SUGGESTIONS
-	assert.Len(t, results, constants.MAX_MOVIE_SUGGESTIONS)
+	assert.Empty(t, results)
+	assert.Error(t, err)
+}
+
+func TestGetInitialVotingSlate_StockCheckFails(t *testing.T) {
+	repo, _, movieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
+	centroidCache.KNearest = []int{0, 1}
+	centroidsToMoviesCache.MoviesByCentroid = moviesPerCentroid(10, 0, 1)
+	movieRepo.On("GetMoviesByID", mock.Anything, mock.Anything, constants.CART).Return([]data.Movie{}, errors.New("throttled"))
+
+	results, err := repo.GetIniitialVotingSlate(context.Background(), "", 1)
 
-	// All slots should be empty since errors prevented movie assignment
-	for _, mid := range results {
-		assert.Empty(t, mid)
-	}
 	assert.Error(t, err)
+	assert.Len(t, results, constants.MAX_MOVIE_SUGGESTIONS, "the slate is still drawn")
 }
 
 func TestGetInitialVotingSlate_NoCentroids(t *testing.T) {
@@ -218,7 +435,7 @@ func TestGetInitialVotingSlate_NoCentroids(t *testing.T) {
 	centroidCache.KNearest = []int{}
 
 	ctx := context.Background()
-	results, err := repo.GetIniitialVotingSlate(ctx)
+	results, err := repo.GetIniitialVotingSlate(ctx, "", 1)
 
 	// Expect nil slice and error
 	assert.Nil(t, results)
@@ -304,51 +521,431 @@ func TestUpdateMood_NoMovies(t *testing.T) {
 }
 
 func TestGetVotingFinalPicks_Success(t *testing.T) {
-	repoIface, _, mockMovieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
+	repo, _, mockMovieRepo, _, _ := setupMemberRepo()
+	withIndex(repo,
+		indexed("m1", 1, data.MovieMetrics{Acting: 1, Action: 1}),
+		indexed("n1", 1, data.MovieMetrics{Acting: 100, Action: 100}),
+		indexed("m2", 2, data.MovieMetrics{Acting: 5, Action: 5}),
+		indexed("n2", 2, data.MovieMetrics{Acting: 100, Action: 100}),
+	)
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, []string{"m1", "m2", "n1", "n2"}, constants.CART).Return(inStock("m1", "m2", "n1", "n2"), nil)
+
+	ctx := context.Background()
+	mood := data.MovieMetrics{Acting: 2, Action: 2}
+
+	results, err := repo.GetVotingFinalPicks(ctx, mood, repos.FinalPicksQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, results, constants.NUMBER_FINAL_PICKS)
+	assert.Equal(t, "m1", results[0].MovieID) // the nearest neighbor leads
+	mockMovieRepo.AssertExpectations(t)
+	mockMovieRepo.AssertNotCalled(t, "GetMovieMetrics", mock.Anything, mock.Anything)
+}
+
+func TestGetVotingFinalPicks_EmptyIndex(t *testing.T) {
+	repo, _, _, _, _ := setupMemberRepo()
+
+	ctx := context.Background()
+	mood := data.MovieMetrics{Acting: 1, Action: 2}
+
+	results, err := repo.GetVotingFinalPicks(ctx, mood, repos.FinalPicksQuery{})
+	assert.Error(t, err)
+	assert.Nil(t, results)
+}
+
+func TestGetVotingFinalPicks_DiversityAvoidsNearDuplicates(t *testing.T) {
+	repo, _, mockMovieRepo, _, _ := setupMemberRepo()
+	withIndex(repo,
+		indexed("saw", 1, data.MovieMetrics{Horror: 90, Suspense: 80}),
+		indexed("saw_ii", 1, data.MovieMetrics{Horror: 89, Suspense: 80}),
+		indexed("se7en", 1, data.MovieMetrics{Horror: 60, Suspense: 95}),
+	)
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, mock.Anything, constants.CART).Return(inStock("saw", "saw_ii", "se7en"), nil)
+	mood := data.MovieMetrics{Horror: 90, Suspense: 80}
+
+	relevant, err := repo.GetVotingFinalPicks(context.Background(), mood, repos.FinalPicksQuery{NumPicks: 2, Diversity: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"saw", "saw_ii"}, pickIDs(relevant))
+
+	diverse, err := repo.GetVotingFinalPicks(context.Background(), mood, repos.FinalPicksQuery{NumPicks: 2, Diversity: 0.7})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"saw", "se7en"}, pickIDs(diverse))
+}
+
+func TestGetVotingFinalPicks_SkipsRentedAndOutOfStock(t *testing.T) {
+	repo, dynamo, mockMovieRepo, _, _ := setupMemberRepo()
+	item, _ := attributevalue.MarshalMap(data.Member{Username: "john", Rented: []string{"m1"}})
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil)
+	withIndex(repo,
+		indexed("m1", 1, data.MovieMetrics{Drama: 5}),
+		indexed("m2", 1, data.MovieMetrics{Drama: 5}),
+		indexed("m3", 1, data.MovieMetrics{Drama: 5}),
+		indexed("m4", 1, data.MovieMetrics{Drama: 5}),
+	)
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, []string{"m2", "m3", "m4"}, constants.CART).
+		Return([]data.Movie{{ID: "m2", Inventory: 0}, {ID: "m3", Inventory: 1}, {ID: "m4", Inventory: 2}}, nil)
+
+	results, err := repo.GetVotingFinalPicks(context.Background(), data.MovieMetrics{Drama: 5}, repos.FinalPicksQuery{Username: "john"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m3", "m4"}, pickIDs(results))
+}
+
+func TestGetVotingFinalPicks_BlendsCoRentals(t *testing.T) {
+	repoIface, _, mockMovieRepo, _, centroidsToMoviesCache := setupMemberRepo()
 	repo := repoIface.(*repos.MemberRepo)
+	repo.SetCoRentalCache(&MockCoRentalCache{AlsoRented: map[string][]string{"m1": {"m2", "m5", "m4"}}})
+
+	withIndex(repo,
+		indexed("m1", 1, data.MovieMetrics{Acting: 1}),
+		indexed("m2", 2, data.MovieMetrics{Acting: 2}),
+		indexed("m3", 3, data.MovieMetrics{Acting: 3}),
+	)
+	// m4 was added after the index was built, so its metrics and centroid are looked up
+	centroidsToMoviesCache.MoviesByCentroid = map[int][]string{4: {"m4"}}
+	mockMovieRepo.On("GetMovieMetrics", mock.Anything, "m4").Return(data.MovieMetrics{Acting: 4}, nil)
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, []string{"m1", "m2", "m3"}, constants.CART).Return(inStock("m1", "m2", "m3"), nil)
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, []string{"m5", "m4"}, constants.CART).
+		Return([]data.Movie{{ID: "m5", Inventory: 0}, {ID: "m4", Inventory: 1}}, nil)
+
+	results, err := repo.GetVotingFinalPicks(context.Background(), data.MovieMetrics{Acting: 1}, repos.FinalPicksQuery{BlendCoRentals: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m1", "m4", "m2"}, pickIDs(results), "m2 is already a candidate and m5 is out of stock; m4 ranks with m1, which it is rented with")
+	assert.Equal(t, "m1", results[1].Explanation.AlsoRentedWith)
+	assert.Equal(t, 4, results[1].Explanation.Centroid)
+	assert.Empty(t, results[2].Explanation.AlsoRentedWith)
 
-	// Mock centroid cache returning centroid IDs
-	centroidCache.KNearest = []int{1, 2}
+	results, err = repo.GetVotingFinalPicks(context.Background(), data.MovieMetrics{Acting: 1}, repos.FinalPicksQuery{NumPicks: 1, BlendCoRentals: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m1"}, pickIDs(results), "co-rentals compete for the picks asked for rather than replacing them")
+}
+
+func TestGetVotingFinalPicks_CoRentalsRespectDiversity(t *testing.T) {
+	repoIface, _, mockMovieRepo, _, _ := setupMemberRepo()
+	repo := repoIface.(*repos.MemberRepo)
+	repo.SetCoRentalCache(&MockCoRentalCache{AlsoRented: map[string][]string{"m1": {"twin"}}})
+	withIndex(repo,
+		indexed("m1", 1, data.MovieMetrics{Acting: 10}),
+		indexed("twin", 1, data.MovieMetrics{Acting: 10}),
+		indexed("m2", 2, data.MovieMetrics{Acting: 10, Comedy: 30}),
+	)
+	// twin is a co-rental of m1 only because the index returns it too; exclude it from the pool
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, []string{"m1", "twin", "m2"}, constants.CART).
+		Return([]data.Movie{{ID: "m1", Inventory: 1}, {ID: "twin", Inventory: 0}, {ID: "m2", Inventory: 1}}, nil)
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, []string{"twin"}, constants.CART).
+		Return([]data.Movie{{ID: "twin", Inventory: 1}}, nil)
+
+	results, err := repo.GetVotingFinalPicks(context.Background(), data.MovieMetrics{Acting: 10}, repos.FinalPicksQuery{NumPicks: 2, Diversity: 1, BlendCoRentals: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m1", "m2"}, pickIDs(results), "at full diversity a co-rental identical to the first pick loses to a different movie")
+}
+
+func TestGetVotingFinalPicks_SkipsExcludedCoRentals(t *testing.T) {
+	repoIface, _, mockMovieRepo, _, centroidsToMoviesCache := setupMemberRepo()
+	repo := repoIface.(*repos.MemberRepo)
+	repo.SetCoRentalCache(&MockCoRentalCache{AlsoRented: map[string][]string{"m1": {"untitled"}}})
+	withIndex(repo,
+		indexed("m1", 1, data.MovieMetrics{Acting: 1}),
+		indexed("m2", 2, data.MovieMetrics{Acting: 2}),
+	)
+	centroidsToMoviesCache.Excluded = map[string]bool{"untitled": true}
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, []string{"m1", "m2"}, constants.CART).Return(inStock("m1", "m2"), nil)
+
+	results, err := repo.GetVotingFinalPicks(context.Background(), data.MovieMetrics{Acting: 1}, repos.FinalPicksQuery{NumPicks: 2, BlendCoRentals: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m1", "m2"}, pickIDs(results), "movies without metrics are never blended in")
+}
 
-	// Mock centroid-to-movies mapping
-	centroidsToMoviesCache.MoviesByCentroid = map[int][]string{
-		1: {"m1", "n1"},
-		2: {"m2", "n2"},
+func TestGetVotingFinalPicks_CoRentalsAreOptIn(t *testing.T) {
+	repoIface, _, mockMovieRepo, _, _ := setupMemberRepo()
+	repo := repoIface.(*repos.MemberRepo)
+	repo.SetCoRentalCache(&MockCoRentalCache{AlsoRented: map[string][]string{"m1": {"m4"}}})
+	withIndex(repo,
+		indexed("m1", 1, data.MovieMetrics{Acting: 1}),
+		indexed("m2", 2, data.MovieMetrics{Acting: 2}),
+		indexed("m3", 3, data.MovieMetrics{Acting: 3}),
+	)
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, []string{"m1", "m2", "m3"}, constants.CART).Return(inStock("m1", "m2", "m3"), nil)
+
+	results, err := repo.GetVotingFinalPicks(context.Background(), data.MovieMetrics{Acting: 1}, repos.FinalPicksQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m1", "m2", "m3"}, pickIDs(results))
+	for _, pick := range results {
+		assert.Empty(t, pick.Explanation.AlsoRentedWith)
 	}
+	mockMovieRepo.AssertNotCalled(t, "GetMoviesByID", mock.Anything, []string{"m4"}, constants.CART)
+}
 
-	// Mock metrics for the movies
-	m1 := data.MovieMetrics{Acting: 1, Action: 1}
-	m2 := data.MovieMetrics{Acting: 5, Action: 5}
+func TestGetVotingFinalPicks_ExplainsPicks(t *testing.T) {
+	repo, _, mockMovieRepo, _, _ := setupMemberRepo()
+	withIndex(repo, indexed("airplane", 4, data.MovieMetrics{Comedy: 90, Action: 20, Drama: 5}))
+	mockMovieRepo.On("GetMovieMetrics", mock.Anything, "naked_gun").Return(data.MovieMetrics{Comedy: 85, Action: 30}, nil)
+	mockMovieRepo.On("GetMovieMetrics", mock.Anything, "top_secret").Return(data.MovieMetrics{Comedy: 80, Action: 25}, nil)
+	mockMovieRepo.On("GetMovieMetrics", mock.Anything, "heat").Return(data.MovieMetrics{Action: 90, Suspense: 80}, nil)
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, []string{"airplane"}, constants.CART).Return(inStock("airplane"), nil)
+	mood := data.MovieMetrics{Comedy: 80, Action: 30}
+	query := repos.FinalPicksQuery{NumPicks: 1, Voted: []string{"heat", "naked_gun", "top_secret"}, Distance: utils.MetricDistance}
+
+	results, err := repo.GetVotingFinalPicks(context.Background(), mood, query)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	explanation := results[0].Explanation
+	assert.Equal(t, 4, explanation.Centroid)
+	assert.InDelta(t, utils.MetricDistance(mood, data.MovieMetrics{Comedy: 90, Action: 20, Drama: 5}), explanation.Distance, 1e-9)
+	assert.Equal(t, []string{"naked_gun", "top_secret"}, explanation.InfluencedBy, "the nearest voted movies influenced the pick")
+	assert.NotEmpty(t, explanation.TopDimensions)
+	assert.Equal(t, "comedy", explanation.TopDimensions[0].Dimension)
+}
 
-	n1 := data.MovieMetrics{Acting: 100, Action: 100}
-	n2 := data.MovieMetrics{Acting: 100, Action: 100}
+func pickIDs(picks []data.FinalPick) []string {
+	movieIDs := make([]string, len(picks))
+	for i, pick := range picks {
+		movieIDs[i] = pick.MovieID
+	}
+	return movieIDs
+}
 
-	mockMovieRepo.On("GetMovieMetrics", mock.Anything, "m1").Return(m1, nil)
-	mockMovieRepo.On("GetMovieMetrics", mock.Anything, "m2").Return(m2, nil)
+func inStock(movieIDs ...string) []data.Movie {
+	movies := make([]data.Movie, len(movieIDs))
+	for i, mid := range movieIDs {
+		movies[i] = data.Movie{ID: mid, Inventory: 1}
+	}
+	return movies
+}
 
-	mockMovieRepo.On("GetMovieMetrics", mock.Anything, "n1").Return(n1, nil)
-	mockMovieRepo.On("GetMovieMetrics", mock.Anything, "n2").Return(n2, nil)
+func TestGetRentalHistories_Paginates(t *testing.T) {
+	repo, dynamo, _, _, _ := setupMemberRepo()
+	lastKey := map[string]types.AttributeValue{"username": &types.AttributeValueMemberS{Value: "jane"}}
+	dynamo.On("Scan", mock.Anything, mock.MatchedBy(func(in *dynamodb.ScanInput) bool {
+		return in.ExclusiveStartKey == nil
+	})).Return(&dynamodb.ScanOutput{
+		Items: []map[string]types.AttributeValue{{
+			"rented":      &types.AttributeValueMemberSS{Value: []string{"m1"}},
+			"checked_out": &types.AttributeValueMemberSS{Value: []string{"m2"}},
+		}, {}},
+		LastEvaluatedKey: lastKey,
+	}, nil).Once()
+	dynamo.On("Scan", mock.Anything, mock.MatchedBy(func(in *dynamodb.ScanInput) bool {
+		return in.ExclusiveStartKey != nil
+	})).Return(&dynamodb.ScanOutput{
+		Items: []map[string]types.AttributeValue{{
+			"rented": &types.AttributeValueMemberSS{Value: []string{"m3"}},
+		}},
+	}, nil).Once()
+
+	histories, err := repo.GetRentalHistories(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"m1", "m2"}, {"m3"}}, histories)
+	dynamo.AssertExpectations(t)
+}
 
-	ctx := context.Background()
-	mood := data.MovieMetrics{Acting: 2, Action: 2}
+func TestUpdateTaste_DecaysExistingProfile(t *testing.T) {
+	repo, dynamo, _, _, _ := setupMemberRepo()
+	existing := data.Member{
+		Username: "john",
+		Taste: &data.TasteProfile{
+			Metrics:   data.MovieMetrics{Drama: 10},
+			Weight:    2,
+			UpdatedAt: time.Now().AddDate(0, 0, -constants.TASTE_HALF_LIFE_DAYS),
+		},
+	}
+	item, _ := attributevalue.MarshalMap(existing)
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil)
+	dynamo.On("UpdateItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.UpdateItemInput) bool {
+		_, ok := in.ExpressionAttributeValues[":taste"]
+		return ok && *in.UpdateExpression == "SET taste = :taste" &&
+			*in.ConditionExpression == "taste.updated_at = :previous" &&
+			reflect.DeepEqual(in.ExpressionAttributeValues[":previous"], item["taste"].(*types.AttributeValueMemberM).Value["updated_at"])
+	})).Return(&dynamodb.UpdateItemOutput{}, nil)
+
+	profile, err := repo.UpdateTaste(context.Background(), "john", data.MovieMetrics{Drama: 4}, 1)
 
-	results, err := repo.GetVotingFinalPicks(ctx, mood)
 	assert.NoError(t, err)
-	assert.NotEmpty(t, results)
-	assert.Contains(t, []string{"m1", "m2"}, results[0]) // should pick a valid nearest neighbor
-	mockMovieRepo.AssertExpectations(t)
+	// the old weight of 2 halves to 1 after one half-life, so the blend is even
+	assert.InDelta(t, 2.0, profile.Weight, 0.01)
+	assert.InDelta(t, 7.0, profile.Metrics.Drama, 0.01)
+	dynamo.AssertExpectations(t)
 }
 
-func TestGetVotingFinalPicks_CentroidError(t *testing.T) {
-	repoIface, _, _, centroidCache, _ := setupMemberRepo()
-	repo := repoIface.(*repos.MemberRepo)
+func TestUpdateTaste_FirstSignal(t *testing.T) {
+	repo, dynamo, _, _, _ := setupMemberRepo()
+	item, _ := attributevalue.MarshalMap(data.Member{Username: "john"})
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil)
+	dynamo.On("UpdateItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.UpdateItemInput) bool {
+		return *in.ConditionExpression == "attribute_exists(username) AND attribute_not_exists(taste)"
+	})).Return(&dynamodb.UpdateItemOutput{}, nil)
 
-	centroidCache.KNearestErr = errors.New("centroid fail")
+	profile, err := repo.UpdateTaste(context.Background(), "john", data.MovieMetrics{Drama: 4}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, profile.Metrics.Drama)
+	dynamo.AssertExpectations(t)
+}
 
-	ctx := context.Background()
-	mood := data.MovieMetrics{Acting: 1, Action: 2}
+func TestUpdateTaste_RetriesConcurrentChange(t *testing.T) {
+	repo, dynamo, _, _, _ := setupMemberRepo()
+	first, _ := attributevalue.MarshalMap(data.Member{Username: "john"})
+	// A checkout signal lands between the read and the write of this one.
+	changed, _ := attributevalue.MarshalMap(data.Member{
+		Username: "john",
+		Taste:    &data.TasteProfile{Metrics: data.MovieMetrics{Drama: 10}, Weight: 1, UpdatedAt: time.Now()},
+	})
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: first}, nil).Once()
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: changed}, nil).Once()
+	dynamo.On("UpdateItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.UpdateItemInput) bool {
+		return *in.ConditionExpression != "taste.updated_at = :previous"
+	})).Return((*dynamodb.UpdateItemOutput)(nil), &types.ConditionalCheckFailedException{}).Once()
+	dynamo.On("UpdateItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.UpdateItemInput) bool {
+		return *in.ConditionExpression == "taste.updated_at = :previous"
+	})).Return(&dynamodb.UpdateItemOutput{}, nil).Once()
+
+	profile, err := repo.UpdateTaste(context.Background(), "john", data.MovieMetrics{Drama: 4}, 1)
+	assert.NoError(t, err)
+	assert.InDelta(t, 7.0, profile.Metrics.Drama, 0.01, "both signals count")
+	assert.InDelta(t, 2.0, profile.Weight, 0.01)
+	dynamo.AssertExpectations(t)
+}
+
+func TestUpdateTaste_GivesUpAfterRepeatedConflicts(t *testing.T) {
+	repo, dynamo, _, _, _ := setupMemberRepo()
+	item, _ := attributevalue.MarshalMap(data.Member{Username: "john"})
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil)
+	dynamo.On("UpdateItem", mock.Anything, mock.Anything).Return((*dynamodb.UpdateItemOutput)(nil), &types.ConditionalCheckFailedException{})
+
+	_, err := repo.UpdateTaste(context.Background(), "john", data.MovieMetrics{Drama: 4}, 1)
+	assert.ErrorIs(t, err, repos.ErrTasteConflict)
+	dynamo.AssertNumberOfCalls(t, "UpdateItem", constants.TASTE_UPDATE_ATTEMPTS)
+}
+
+func TestUpdateTaste_MemberNotFound(t *testing.T) {
+	repo, dynamo, _, _, _ := setupMemberRepo()
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: nil}, nil)
 
-	results, err := repo.GetVotingFinalPicks(ctx, mood)
+	_, err := repo.UpdateTaste(context.Background(), "ghost", data.MovieMetrics{}, 1)
 	assert.Error(t, err)
-	assert.Nil(t, results)
+	dynamo.AssertNotCalled(t, "UpdateItem", mock.Anything, mock.Anything)
+}
+
+func TestGetInitialVotingSlate_SeededByTaste(t *testing.T) {
+	repo, dynamo, movieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
+	centroidCache.KNearest = []int{2}
+	centroidCache.Centroids = []data.Centroid{{ID: 1}, {ID: 2}}
+	centroidsToMoviesCache.MoviesByCentroid = map[int][]string{1: {"a1"}, 2: {"c1"}}
+	stockMovies(movieRepo, centroidsToMoviesCache.MoviesByCentroid)
+	item, _ := attributevalue.MarshalMap(data.Member{
+		Username: "john",
+		Taste:    &data.TasteProfile{Metrics: data.MovieMetrics{Horror: 9}, Weight: 1, UpdatedAt: time.Now()},
+	})
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil)
+
+	results, _ := repo.GetIniitialVotingSlate(context.Background(), "john", 1)
+
+	assert.Equal(t, []string{"c1", "a1"}, results, "centroids nearest the member's taste come first")
+}
+
+func TestGetRecommendations_RanksUnseenInStockMovies(t *testing.T) {
+	repo, dynamo, movieRepo, _, _ := setupMemberRepo()
+	item, _ := attributevalue.MarshalMap(data.Member{Username: "john", Rented: []string{"seen"}})
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil)
+	withIndex(repo,
+		indexed("seen", 1, data.MovieMetrics{Drama: 10}),
+		indexed("near", 1, data.MovieMetrics{Drama: 9}),
+		indexed("far", 1, data.MovieMetrics{Drama: 1}),
+		indexed("gone", 1, data.MovieMetrics{Drama: 10}),
+	)
+	movieRepo.On("GetMoviesByID", mock.Anything, []string{"gone", "near", "far"}, constants.CART).Return([]data.Movie{
+		{ID: "gone", Inventory: 0}, {ID: "near", Title: "Near", Inventory: 1}, {ID: "far", Title: "Far", Inventory: 3},
+	}, nil)
+
+	recs, err := repo.GetRecommendations(context.Background(), "john", 5)
+
+	assert.NoError(t, err)
+	assert.Len(t, recs, 2)
+	assert.Equal(t, "Near", recs[0].Movie.Title)
+	assert.Equal(t, "far", recs[1].Movie.ID)
+	assert.InDelta(t, 0.5, recs[0].Score, 0.001)
+	assert.Greater(t, recs[0].Score, recs[1].Score)
+	assert.Equal(t, 1, recs[0].Centroid)
+}
+
+func TestGetRecommendations_NoHistory(t *testing.T) {
+	repo, dynamo, _, _, _ := setupMemberRepo()
+	item, _ := attributevalue.MarshalMap(data.Member{Username: "new"})
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil)
+
+	recs, err := repo.GetRecommendations(context.Background(), "new", 5)
+	assert.NoError(t, err)
+	assert.NotNil(t, recs)
+	assert.Empty(t, recs)
+}
+
+func TestGetRecommendations_UnknownMember(t *testing.T) {
+	repo, dynamo, _, _, _ := setupMemberRepo()
+	dynamo.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: nil}, nil)
+
+	_, err := repo.GetRecommendations(context.Background(), "ghost", 5)
+	assert.ErrorIs(t, err, repos.ErrMemberNotFound)
+}
+
+func TestGetVotingFinalPicks_SkipsGroupRentals(t *testing.T) {
+	repo, dynamo, mockMovieRepo, _, _ := setupMemberRepo()
+	for _, member := range []data.Member{
+		{Username: "john", Rented: []string{"m1"}},
+		{Username: "jane", Checkedout: []string{"m2"}},
+	} {
+		item, _ := attributevalue.MarshalMap(member)
+		username := member.Username
+		dynamo.On("GetItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.GetItemInput) bool {
+			return in.Key["username"].(*types.AttributeValueMemberS).Value == username
+		})).Return(&dynamodb.GetItemOutput{Item: item}, nil)
+	}
+	withIndex(repo,
+		indexed("m1", 1, data.MovieMetrics{Drama: 5}),
+		indexed("m2", 1, data.MovieMetrics{Drama: 5}),
+		indexed("m3", 1, data.MovieMetrics{Drama: 5}),
+	)
+	mockMovieRepo.On("GetMoviesByID", mock.Anything, []string{"m3"}, constants.CART).Return(inStock("m3"), nil)
+
+	results, err := repo.GetVotingFinalPicks(context.Background(), data.MovieMetrics{Drama: 5}, repos.FinalPicksQuery{Group: []string{"jane", "john"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m3"}, pickIDs(results))
+}
+
+func TestGetVotingSlate_CentroidError(t *testing.T) {
+	repo, _, _, centroidCache, _ := setupMemberRepo()
+	centroidCache.KNearestErr = errors.New("cache down")
+
+	slate, err := repo.GetVotingSlate(context.Background(), data.MovieMetrics{Drama: 5}, 0, nil, 1)
+	assert.Error(t, err)
+	assert.Nil(t, slate)
+}
+
+func TestVotingSlates_SameSeedSameSlates(t *testing.T) {
+	repo, _, movieRepo, centroidCache, centroidsToMoviesCache := setupMemberRepo()
+	centroidCache.KNearest = []int{0, 1, 2}
+	centroidsToMoviesCache.MoviesByCentroid = map[int][]string{}
+	for c := range 3 {
+		for i := range 50 {
+			centroidsToMoviesCache.MoviesByCentroid[c] = append(centroidsToMoviesCache.MoviesByCentroid[c], fmt.Sprintf("c%d_%d", c, i))
+		}
+	}
+	stockMovies(movieRepo, centroidsToMoviesCache.MoviesByCentroid)
+	ctx := context.Background()
+
+	initial, err := repo.GetIniitialVotingSlate(ctx, "", 42)
+	assert.NoError(t, err)
+	again, _ := repo.GetIniitialVotingSlate(ctx, "", 42)
+	assert.Equal(t, initial, again, "the same seed draws the same slate in the same order")
+
+	next, err := repo.GetVotingSlate(ctx, data.MovieMetrics{Drama: 5}, 0, initial, 42)
+	assert.NoError(t, err)
+	nextAgain, _ := repo.GetVotingSlate(ctx, data.MovieMetrics{Drama: 5}, 0, initial, 42)
+	assert.Equal(t, next, nextAgain)
+	for _, mid := range next {
+		assert.NotContains(t, initial, mid)
+	}
+
+	var differs bool
+	for seed := int64(43); seed < 53 && !differs; seed++ {
+		other, _ := repo.GetIniitialVotingSlate(ctx, "", seed)
+		differs = !slices.Equal(initial, other)
+	}
+	assert.True(t, differs, "other seeds draw other slates")
 }
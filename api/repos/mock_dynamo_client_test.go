@@ -30,3 +30,13 @@ func (m *MockDynamoClient) BatchGetItem(ctx context.Context, params *dynamodb.Ba
 	args := m.Called(ctx, params)
 	return args.Get(0).(*dynamodb.BatchGetItemOutput), args.Error(1)
 }
+
+func (m *MockDynamoClient) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*dynamodb.ScanOutput), args.Error(1)
+}
+
+func (m *MockDynamoClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(*dynamodb.BatchWriteItemOutput), args.Error(1)
+}
@@ -18,6 +18,14 @@ import (
 
 const movieTableName = "BluckBoster_movies"
 
+var (
+	// ErrMovieNotFound is returned when reading metrics of, or writing to, a movie that does
+	// not exist.
+	ErrMovieNotFound = errors.New("movie not found")
+	// ErrMetricsNotFound is returned when reading the metrics of a movie that has none.
+	ErrMetricsNotFound = errors.New("movie metrics not found")
+)
+
 type DynamoMovieRepo struct {
 	client    DynamoClientInterface
 	tableName string
@@ -35,10 +43,11 @@ func (r *DynamoMovieRepo) GetMoviesByPage(ctx context.Context, page string, purp
 	var exprAttrNames map[string]string
 	switch purpose {
 	case constants.FOR_GRAPH:
-		expr = "#i, title, #c, director"
+		expr = "#i, title, #c, director, #y"
 		exprAttrNames = map[string]string{
 			"#i": constants.ID,
 			"#c": constants.CAST,
+			"#y": constants.YEAR,
 		}
 	case constants.FOR_REST_CALL:
 		expr = "#i, title, #c, director, inventory, rented, rating, #y"
@@ -47,8 +56,8 @@ func (r *DynamoMovieRepo) GetMoviesByPage(ctx context.Context, page string, purp
 			"#c": constants.CAST,
 			"#y": constants.YEAR,
 		}
-	case constants.FOR_CENTROID_CACHE:
-		expr = "#i, centroid"
+	case constants.FOR_CENTROID_CACHE, constants.FOR_METRICS_INDEX:
+		expr = "#i, centroid, " + constants.METRICS
 		exprAttrNames = map[string]string{
 			"#i": constants.ID,
 		}
@@ -81,10 +90,42 @@ func (r *DynamoMovieRepo) GetMoviesByPage(ctx context.Context, page string, purp
 	if err != nil {
 		return nil, utils.LogError("unmarshalling movies from query response", err)
 	}
+	if purpose == constants.FOR_CENTROID_CACHE || purpose == constants.FOR_METRICS_INDEX {
+		// metrics are stored under "mets", not the "metrics" attribute Movie maps, and movies
+		// added outside the clustering pipeline have no centroid rather than centroid 0
+		for i, item := range result.Items {
+			if attr, ok := item[constants.METRICS]; ok {
+				if err := attributevalue.Unmarshal(attr, &movies[i].Metrics); err != nil {
+					utils.LogError(fmt.Sprintf("unmarshalling metrics of %s", movies[i].ID), err)
+				}
+			}
+			if _, ok := item[constants.CENTROID]; !ok {
+				movies[i].Centroid = constants.NO_CENTROID
+			}
+		}
+	}
 
 	return movies, nil
 }
 
+// GetMoviesWithMetrics returns every movie that has metrics, with its current centroid, by
+// reading each page in turn. It feeds the offline clustering and evaluation commands.
+func (r *DynamoMovieRepo) GetMoviesWithMetrics(ctx context.Context) ([]data.Movie, error) {
+	var movies []data.Movie
+	for _, page := range constants.PAGES {
+		page, err := r.GetMoviesByPage(ctx, string(page), constants.FOR_METRICS_INDEX)
+		if err != nil {
+			return nil, err
+		}
+		for _, movie := range page {
+			if movie.Metrics != (data.MovieMetrics{}) {
+				movies = append(movies, movie)
+			}
+		}
+	}
+	return movies, nil
+}
+
 func (r *DynamoMovieRepo) GetMovieByID(ctx context.Context, movieID string, forCart bool) (data.Movie, error) {
 	input, err := r.getMovieByIDInput(movieID, forCart)
 	if err != nil {
@@ -138,15 +179,26 @@ func (r *DynamoMovieRepo) GetMoviesByID(ctx context.Context, movieIDs []string,
 	if len(movieIDs) > 10 {
 		return nil, utils.LogError("batch size exceeds DynamoDB 10-item limit", nil)
 	}
+	return r.batchGetMovies(ctx, movieIDs, "#i, title, inventory", map[string]string{"#i": constants.ID})
+}
 
+// GetMoviesTrivia returns the ID, title and trivia of up to BATCH_GET_LIMIT movies.
+func (r *DynamoMovieRepo) GetMoviesTrivia(ctx context.Context, movieIDs []string) ([]data.Movie, error) {
+	if len(movieIDs) == 0 {
+		return []data.Movie{}, nil
+	}
+	if len(movieIDs) > constants.BATCH_GET_LIMIT {
+		return nil, utils.LogError("batch size exceeds DynamoDB 10-item limit", nil)
+	}
+	return r.batchGetMovies(ctx, movieIDs, "#i, title, #t", map[string]string{"#i": constants.ID, "#t": constants.TRIVIA})
+}
+
+func (r *DynamoMovieRepo) batchGetMovies(ctx context.Context, movieIDs []string, expr string, exprAttrNames map[string]string) ([]data.Movie, error) {
 	keys := make([]map[string]types.AttributeValue, 0, len(movieIDs))
 	for _, id := range movieIDs {
 		keys = append(keys, map[string]types.AttributeValue{constants.ID: &types.AttributeValueMemberS{Value: id}})
 	}
 
-	expr := "#i, title, inventory"
-	exprAttrNames := map[string]string{"#i": constants.ID}
-
 	input := &dynamodb.BatchGetItemInput{
 		RequestItems: map[string]types.KeysAndAttributes{
 			r.tableName: {
@@ -178,20 +230,25 @@ func (r *DynamoMovieRepo) GetMovieMetrics(ctx context.Context, movieID string) (
 		return data.MovieMetrics{}, utils.LogError("movieID cannot be empty", nil)
 	}
 
+	// project the id too, so a movie without metrics can be told apart from a missing movie
 	input := &dynamodb.GetItemInput{
-		Key:                  map[string]types.AttributeValue{constants.ID: &types.AttributeValueMemberS{Value: movieID}},
-		TableName:            aws.String(r.tableName),
-		ProjectionExpression: aws.String(constants.METRICS),
+		Key:                      map[string]types.AttributeValue{constants.ID: &types.AttributeValueMemberS{Value: movieID}},
+		TableName:                aws.String(r.tableName),
+		ProjectionExpression:     aws.String("#i, " + constants.METRICS),
+		ExpressionAttributeNames: map[string]string{"#i": constants.ID},
 	}
 	result, err := r.client.GetItem(ctx, input)
 	if err != nil {
 		return data.MovieMetrics{}, utils.LogError("fetching movie metrics from DynamoDB", err)
 	}
 
+	if len(result.Item) == 0 {
+		return data.MovieMetrics{}, fmt.Errorf("%w: %s", ErrMovieNotFound, movieID)
+	}
 	// look only at the "mets" map
 	attr, ok := result.Item[constants.METRICS]
 	if !ok {
-		return data.MovieMetrics{}, utils.LogError("mets attribute not found", nil)
+		return data.MovieMetrics{}, fmt.Errorf("%w: %s", ErrMetricsNotFound, movieID)
 	}
 
 	var metrics data.MovieMetrics
@@ -234,6 +291,57 @@ func (r *DynamoMovieRepo) updateInventory(ctx context.Context, movie data.Movie,
 	return true, nil
 }
 
+// SetCentroid assigns movieID to centroid.
+func (r *DynamoMovieRepo) SetCentroid(ctx context.Context, movieID string, centroid int) error {
+	input := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(r.tableName),
+		Key:              map[string]types.AttributeValue{constants.ID: &types.AttributeValueMemberS{Value: movieID}},
+		UpdateExpression: aws.String("SET centroid = :c"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":c": &types.AttributeValueMemberN{Value: strconv.Itoa(centroid)},
+		},
+		ConditionExpression: aws.String("attribute_exists(#i)"),
+		ExpressionAttributeNames: map[string]string{
+			"#i": constants.ID,
+		},
+	}
+	if _, err := r.client.UpdateItem(ctx, input); err != nil {
+		return utils.LogError(fmt.Sprintf("setting centroid of %s to %d", movieID, centroid), err)
+	}
+	return nil
+}
+
+// SetMetrics writes a movie's metrics together with the centroid they were assigned to.
+func (r *DynamoMovieRepo) SetMetrics(ctx context.Context, movieID string, metrics data.MovieMetrics, centroid int) error {
+	metrics.ID = 0
+	mets, err := attributevalue.Marshal(metrics)
+	if err != nil {
+		return utils.LogError(fmt.Sprintf("marshalling metrics of %s", movieID), err)
+	}
+	input := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(r.tableName),
+		Key:              map[string]types.AttributeValue{constants.ID: &types.AttributeValueMemberS{Value: movieID}},
+		UpdateExpression: aws.String("SET #m = :m, centroid = :c"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":m": mets,
+			":c": &types.AttributeValueMemberN{Value: strconv.Itoa(centroid)},
+		},
+		ConditionExpression: aws.String("attribute_exists(#i)"),
+		ExpressionAttributeNames: map[string]string{
+			"#i": constants.ID,
+			"#m": constants.METRICS,
+		},
+	}
+	if _, err := r.client.UpdateItem(ctx, input); err != nil {
+		var missing *types.ConditionalCheckFailedException
+		if errors.As(err, &missing) {
+			return fmt.Errorf("%w: %s", ErrMovieNotFound, movieID)
+		}
+		return utils.LogError(fmt.Sprintf("setting metrics of %s", movieID), err)
+	}
+	return nil
+}
+
 func (r *DynamoMovieRepo) GetTrivia(ctx context.Context, movieID string) (data.MovieTrivia, error) {
 	input := &dynamodb.GetItemInput{
 		Key:             map[string]types.AttributeValue{constants.ID: &types.AttributeValueMemberS{Value: movieID}},
@@ -252,3 +360,68 @@ func (r *DynamoMovieRepo) GetTrivia(ctx context.Context, movieID string) (data.M
 	}
 	return trivia, nil
 }
+
+// SetTrivia replaces a movie's trivia with the structured list.
+func (r *DynamoMovieRepo) SetTrivia(ctx context.Context, movieID string, trivia data.Trivia) error {
+	items, err := attributevalue.Marshal([]data.TriviaItem(trivia))
+	if err != nil {
+		return utils.LogError(fmt.Sprintf("marshalling trivia of %s", movieID), err)
+	}
+	input := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(r.tableName),
+		Key:              map[string]types.AttributeValue{constants.ID: &types.AttributeValueMemberS{Value: movieID}},
+		UpdateExpression: aws.String("SET #t = :t"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":t": items,
+		},
+		ConditionExpression: aws.String("attribute_exists(#i)"),
+		ExpressionAttributeNames: map[string]string{
+			"#i": constants.ID,
+			"#t": constants.TRIVIA,
+		},
+	}
+	if _, err := r.client.UpdateItem(ctx, input); err != nil {
+		var missing *types.ConditionalCheckFailedException
+		if errors.As(err, &missing) {
+			return fmt.Errorf("%w: %s", ErrMovieNotFound, movieID)
+		}
+		return utils.LogError(fmt.Sprintf("setting trivia of %s", movieID), err)
+	}
+	return nil
+}
+
+// GetLegacyTrivia returns the raw delimited trivia of every movie still storing it as a
+// string, keyed by movie ID.
+func (r *DynamoMovieRepo) GetLegacyTrivia(ctx context.Context) (map[string]string, error) {
+	input := &dynamodb.ScanInput{
+		TableName:            aws.String(r.tableName),
+		ProjectionExpression: aws.String("#i, #t"),
+		FilterExpression:     aws.String("attribute_type(#t, :s)"),
+		ExpressionAttributeNames: map[string]string{
+			"#i": constants.ID,
+			"#t": constants.TRIVIA,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":s": &types.AttributeValueMemberS{Value: "S"},
+		},
+	}
+
+	legacy := make(map[string]string)
+	for {
+		output, err := r.client.Scan(ctx, input)
+		if err != nil {
+			return nil, utils.LogError("scanning legacy movie trivia", err)
+		}
+		for _, item := range output.Items {
+			id, idOK := item[constants.ID].(*types.AttributeValueMemberS)
+			trivia, triviaOK := item[constants.TRIVIA].(*types.AttributeValueMemberS)
+			if idOK && triviaOK {
+				legacy[id.Value] = trivia.Value
+			}
+		}
+		if len(output.LastEvaluatedKey) == 0 {
+			return legacy, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
@@ -47,6 +48,71 @@ func TestGetMoviesByPage_Success(t *testing.T) {
 	assert.Equal(t, "Movie 1", movies[0].Title)
 }
 
+func TestGetMoviesByPage_ForMetricsIndex(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	repo := reposTestWrapper(mockClient)
+	mets, _ := attributevalue.Marshal(data.MovieMetrics{Horror: 90, Suspense: 70})
+	mockClient.On("Query", mock.Anything, mock.MatchedBy(func(in *dynamodb.QueryInput) bool {
+		return *in.ProjectionExpression == "#i, centroid, mets"
+	})).Return(&dynamodb.QueryOutput{
+		Items: []map[string]types.AttributeValue{{
+			constants.ID:      &types.AttributeValueMemberS{Value: "alien"},
+			"centroid":        &types.AttributeValueMemberN{Value: "3"},
+			constants.METRICS: mets,
+		}, {
+			constants.ID: &types.AttributeValueMemberS{Value: "unrated"},
+		}},
+	}, nil)
+
+	movies, err := repo.GetMoviesByPage(context.Background(), "A", constants.FOR_METRICS_INDEX)
+	assert.NoError(t, err)
+	assert.Len(t, movies, 2)
+	assert.Equal(t, 3, movies[0].Centroid)
+	assert.Equal(t, 90.0, movies[0].Metrics.Horror)
+	assert.Equal(t, data.MovieMetrics{}, movies[1].Metrics)
+	assert.Equal(t, constants.NO_CENTROID, movies[1].Centroid)
+}
+
+func TestGetMoviesWithMetrics_ReadsEveryPage(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	mets, _ := attributevalue.Marshal(data.MovieMetrics{Horror: 90})
+	mockClient.On("Query", mock.Anything, mock.MatchedBy(func(in *dynamodb.QueryInput) bool {
+		return in.KeyConditions[constants.PAGINATE_KEY].AttributeValueList[0].(*types.AttributeValueMemberS).Value == "A"
+	})).Return(&dynamodb.QueryOutput{
+		Items: []map[string]types.AttributeValue{{
+			constants.ID:      &types.AttributeValueMemberS{Value: "alien"},
+			"centroid":        &types.AttributeValueMemberN{Value: "3"},
+			constants.METRICS: mets,
+		}, {
+			constants.ID: &types.AttributeValueMemberS{Value: "unrated"},
+		}},
+	}, nil).Once()
+	mockClient.On("Query", mock.Anything, mock.Anything).Return(&dynamodb.QueryOutput{}, nil)
+
+	movies, err := reposTestWrapper(mockClient).GetMoviesWithMetrics(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []data.Movie{{ID: "alien", Centroid: 3, Metrics: data.MovieMetrics{Horror: 90}}}, movies)
+	mockClient.AssertNumberOfCalls(t, "Query", len(constants.PAGES))
+}
+
+func TestGetMoviesByPage_ForCentroidCacheKeepsCentroidZero(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	repo := reposTestWrapper(mockClient)
+	mockClient.On("Query", mock.Anything, mock.Anything).Return(&dynamodb.QueryOutput{
+		Items: []map[string]types.AttributeValue{{
+			constants.ID: &types.AttributeValueMemberS{Value: "airplane"},
+			"centroid":   &types.AttributeValueMemberN{Value: "0"},
+		}, {
+			constants.ID: &types.AttributeValueMemberS{Value: "new_release"},
+		}},
+	}, nil)
+
+	movies, err := repo.GetMoviesByPage(context.Background(), "A", constants.FOR_CENTROID_CACHE)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, movies[0].Centroid)
+	assert.Equal(t, constants.NO_CENTROID, movies[1].Centroid)
+}
+
 func TestGetMovieByID_EmptyID(t *testing.T) {
 	repo := reposTestWrapper(new(MockDynamoClient))
 	ctx := context.Background()
@@ -92,6 +158,60 @@ func TestReturnMovie_Error(t *testing.T) {
 	assert.Contains(t, err.Error(), "updating inventory")
 }
 
+func TestSetCentroid_Success(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	repo := reposTestWrapper(mockClient)
+
+	mockClient.On("UpdateItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.UpdateItemInput) bool {
+		c, ok := in.ExpressionAttributeValues[":c"].(*types.AttributeValueMemberN)
+		return *in.UpdateExpression == "SET centroid = :c" && ok && c.Value == "7"
+	})).Return(&dynamodb.UpdateItemOutput{}, nil)
+
+	err := repo.SetCentroid(context.Background(), "123", 7)
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSetMetrics_WritesMetricsAndCentroid(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	repo := reposTestWrapper(mockClient)
+
+	mockClient.On("UpdateItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.UpdateItemInput) bool {
+		mets, ok := in.ExpressionAttributeValues[":m"].(*types.AttributeValueMemberM)
+		if !ok || in.ExpressionAttributeNames["#m"] != constants.METRICS {
+			return false
+		}
+		_, hasID := mets.Value["id"]
+		horror := mets.Value["horror"].(*types.AttributeValueMemberN)
+		c := in.ExpressionAttributeValues[":c"].(*types.AttributeValueMemberN)
+		return !hasID && horror.Value == "90" && c.Value == "4"
+	})).Return(&dynamodb.UpdateItemOutput{}, nil)
+
+	err := repo.SetMetrics(context.Background(), "the_thing", data.MovieMetrics{ID: 7, Horror: 90}, 4)
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSetMetrics_MissingMovie(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	repo := reposTestWrapper(mockClient)
+	mockClient.On("UpdateItem", mock.Anything, mock.Anything).
+		Return(&dynamodb.UpdateItemOutput{}, &types.ConditionalCheckFailedException{})
+
+	err := repo.SetMetrics(context.Background(), "missing", data.MovieMetrics{Horror: 90}, 4)
+	assert.ErrorIs(t, err, repos.ErrMovieNotFound)
+}
+
+func TestSetCentroid_Error(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	repo := reposTestWrapper(mockClient)
+
+	mockClient.On("UpdateItem", mock.Anything, mock.Anything).Return(&dynamodb.UpdateItemOutput{}, errors.New("conditional check failed"))
+
+	err := repo.SetCentroid(context.Background(), "missing", 7)
+	assert.ErrorContains(t, err, "setting centroid of missing to 7")
+}
+
 func reposTestWrapper(client repos.DynamoClientInterface) *repos.DynamoMovieRepo {
 	return repos.NewDynamoMovieRepo(client)
 }
@@ -136,6 +256,125 @@ func TestGetMovieMetrics_NotFound(t *testing.T) {
 	repo := repos.NewDynamoMovieRepo(mockClient)
 	metrics, err := repo.GetMovieMetrics(context.Background(), "missing_id")
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, repos.ErrMovieNotFound)
 	assert.Equal(t, data.MovieMetrics{}, metrics)
 }
+
+func TestGetMovieMetrics_NoMetrics(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	mockClient.On("GetItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.GetItemInput) bool {
+		return *in.ProjectionExpression == "#i, mets" && in.ExpressionAttributeNames["#i"] == constants.ID
+	})).Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{constants.ID: &types.AttributeValueMemberS{Value: "unrated_2024"}},
+	}, nil)
+
+	_, err := repos.NewDynamoMovieRepo(mockClient).GetMovieMetrics(context.Background(), "unrated_2024")
+	assert.ErrorIs(t, err, repos.ErrMetricsNotFound)
+}
+
+func TestGetTrivia_ParsesLegacyString(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	mockClient.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{
+		Item: map[string]types.AttributeValue{
+			constants.TRIVIA: &types.AttributeValueMemberS{Value: ":Who plays Rick?: Humphrey Bogart&:&:Where is Rick's?:Casablanca&:&:no answer here"},
+		},
+	}, nil)
+
+	trivia, err := repos.NewDynamoMovieRepo(mockClient).GetTrivia(context.Background(), "casablanca_1942")
+	assert.NoError(t, err)
+	assert.Equal(t, data.Trivia{
+		{Question: "Who plays Rick?", Answer: "Humphrey Bogart"},
+		{Question: "Where is Rick's?", Answer: "Casablanca"},
+	}, trivia.Trivia, "malformed entries are dropped")
+}
+
+func TestGetTrivia_ReadsList(t *testing.T) {
+	want := data.Trivia{{Question: "Who plays Rick?", Answer: "Humphrey Bogart"}}
+	item, err := attributevalue.MarshalMap(data.MovieTrivia{Trivia: want})
+	assert.NoError(t, err)
+	mockClient := new(MockDynamoClient)
+	mockClient.On("GetItem", mock.Anything, mock.Anything).Return(&dynamodb.GetItemOutput{Item: item}, nil)
+
+	trivia, err := repos.NewDynamoMovieRepo(mockClient).GetTrivia(context.Background(), "casablanca_1942")
+	assert.NoError(t, err)
+	assert.Equal(t, want, trivia.Trivia)
+}
+
+func TestSetTrivia_WritesList(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	mockClient.On("UpdateItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.UpdateItemInput) bool {
+		list, ok := in.ExpressionAttributeValues[":t"].(*types.AttributeValueMemberL)
+		if !ok || len(list.Value) != 1 || in.ExpressionAttributeNames["#t"] != constants.TRIVIA {
+			return false
+		}
+		item := list.Value[0].(*types.AttributeValueMemberM)
+		question := item.Value["question"].(*types.AttributeValueMemberS)
+		return question.Value == "Who plays Rick?"
+	})).Return(&dynamodb.UpdateItemOutput{}, nil)
+
+	err := reposTestWrapper(mockClient).SetTrivia(context.Background(), "casablanca_1942",
+		data.Trivia{{Question: "Who plays Rick?", Answer: "Humphrey Bogart"}})
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSetTrivia_MissingMovie(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	mockClient.On("UpdateItem", mock.Anything, mock.Anything).
+		Return(&dynamodb.UpdateItemOutput{}, &types.ConditionalCheckFailedException{})
+
+	err := reposTestWrapper(mockClient).SetTrivia(context.Background(), "missing",
+		data.Trivia{{Question: "Who?", Answer: "Him"}})
+	assert.ErrorIs(t, err, repos.ErrMovieNotFound)
+}
+
+func TestGetLegacyTrivia_Paginates(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	lastKey := map[string]types.AttributeValue{constants.ID: &types.AttributeValueMemberS{Value: "a"}}
+	mockClient.On("Scan", mock.Anything, mock.MatchedBy(func(in *dynamodb.ScanInput) bool {
+		return in.ExclusiveStartKey == nil
+	})).Return(&dynamodb.ScanOutput{
+		Items: []map[string]types.AttributeValue{{
+			constants.ID:     &types.AttributeValueMemberS{Value: "a"},
+			constants.TRIVIA: &types.AttributeValueMemberS{Value: ":Who?:Him"},
+		}},
+		LastEvaluatedKey: lastKey,
+	}, nil).Once()
+	mockClient.On("Scan", mock.Anything, mock.MatchedBy(func(in *dynamodb.ScanInput) bool {
+		return in.ExclusiveStartKey != nil
+	})).Return(&dynamodb.ScanOutput{
+		Items: []map[string]types.AttributeValue{{
+			constants.ID:     &types.AttributeValueMemberS{Value: "b"},
+			constants.TRIVIA: &types.AttributeValueMemberS{Value: ":What?:That"},
+		}},
+	}, nil).Once()
+
+	legacy, err := reposTestWrapper(mockClient).GetLegacyTrivia(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": ":Who?:Him", "b": ":What?:That"}, legacy)
+}
+
+func TestGetMoviesTrivia_ProjectsTitleAndTrivia(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	mockClient.On("BatchGetItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.BatchGetItemInput) bool {
+		request := in.RequestItems["BluckBoster_movies"]
+		return *request.ProjectionExpression == "#i, title, #t" && request.ExpressionAttributeNames["#t"] == constants.TRIVIA
+	})).Return(&dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{
+		"BluckBoster_movies": {{
+			constants.ID:     &types.AttributeValueMemberS{Value: "casablanca_1942"},
+			constants.TITLE:  &types.AttributeValueMemberS{Value: "Casablanca"},
+			constants.TRIVIA: &types.AttributeValueMemberS{Value: ":Who plays Rick?:Humphrey Bogart"},
+		}},
+	}}, nil)
+
+	movies, err := reposTestWrapper(mockClient).GetMoviesTrivia(context.Background(), []string{"casablanca_1942"})
+	assert.NoError(t, err)
+	assert.Len(t, movies, 1)
+	assert.Equal(t, "Casablanca", movies[0].Title)
+	assert.Equal(t, data.Trivia{{Question: "Who plays Rick?", Answer: "Humphrey Bogart"}}, movies[0].Trivia)
+}
+
+func TestGetMoviesTrivia_BatchLimitExceeded(t *testing.T) {
+	_, err := reposTestWrapper(new(MockDynamoClient)).GetMoviesTrivia(context.Background(), make([]string, constants.BATCH_GET_LIMIT+1))
+	assert.ErrorContains(t, err, "batch size")
+}
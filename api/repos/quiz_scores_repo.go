@@ -0,0 +1,92 @@
+package repos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/utils"
+)
+
+// DynamoQuizScoreRepo keeps members' trivia quiz totals as counters on their items in the
+// members table, so a score is recorded with a single atomic update.
+type DynamoQuizScoreRepo struct {
+	client    DynamoClientInterface
+	tableName string
+}
+
+func NewDynamoQuizScoreRepo(client DynamoClientInterface) *DynamoQuizScoreRepo {
+	return &DynamoQuizScoreRepo{
+		client:    client,
+		tableName: membersTableName,
+	}
+}
+
+// RecordQuizScore adds a played quiz with correct right answers out of asked questions to
+// the member's totals and returns the new totals.
+func (r *DynamoQuizScoreRepo) RecordQuizScore(ctx context.Context, username string, correct, asked int) (data.QuizStats, error) {
+	input := &dynamodb.UpdateItemInput{
+		TableName:        aws.String(r.tableName),
+		Key:              map[string]types.AttributeValue{constants.USERNAME: &types.AttributeValueMemberS{Value: username}},
+		UpdateExpression: aws.String("ADD quiz_points :p, quiz_asked :a, quizzes_played :one"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":p":   &types.AttributeValueMemberN{Value: strconv.Itoa(correct)},
+			":a":   &types.AttributeValueMemberN{Value: strconv.Itoa(asked)},
+			":one": &types.AttributeValueMemberN{Value: "1"},
+		},
+		ConditionExpression: aws.String("attribute_exists(#u)"),
+		ExpressionAttributeNames: map[string]string{
+			"#u": constants.USERNAME,
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	}
+	output, err := r.client.UpdateItem(ctx, input)
+	if err != nil {
+		var missing *types.ConditionalCheckFailedException
+		if errors.As(err, &missing) {
+			return data.QuizStats{}, fmt.Errorf("%w: %s", ErrMemberNotFound, username)
+		}
+		return data.QuizStats{}, utils.LogError(fmt.Sprintf("recording quiz score of %s", username), err)
+	}
+
+	var stats data.QuizStats
+	if err := attributevalue.UnmarshalMap(output.Attributes, &stats); err != nil {
+		return data.QuizStats{}, utils.LogError(fmt.Sprintf("unmarshalling quiz score of %s", username), err)
+	}
+	return stats, nil
+}
+
+// GetQuizScores scans the totals of every member who has played a quiz, in no particular
+// order. It loads the quiz leaderboard cache rather than serving requests.
+func (r *DynamoQuizScoreRepo) GetQuizScores(ctx context.Context) ([]data.QuizStats, error) {
+	input := &dynamodb.ScanInput{
+		TableName:            aws.String(r.tableName),
+		ProjectionExpression: aws.String("username, quiz_points, quiz_asked, quizzes_played"),
+		FilterExpression:     aws.String("attribute_exists(quizzes_played)"),
+	}
+
+	var scores []data.QuizStats
+	for {
+		output, err := r.client.Scan(ctx, input)
+		if err != nil {
+			return nil, utils.LogError("scanning quiz scores", err)
+		}
+		var page []data.QuizStats
+		if err := attributevalue.UnmarshalListOfMaps(output.Items, &page); err != nil {
+			return nil, utils.LogError("unmarshalling quiz scores", err)
+		}
+		scores = append(scores, page...)
+		if len(output.LastEvaluatedKey) == 0 {
+			return scores, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,75 @@
+package repos_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+)
+
+func TestRecordQuizScore_AddsToTotals(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	mockClient.On("UpdateItem", mock.Anything, mock.MatchedBy(func(in *dynamodb.UpdateItemInput) bool {
+		points := in.ExpressionAttributeValues[":p"].(*types.AttributeValueMemberN)
+		asked := in.ExpressionAttributeValues[":a"].(*types.AttributeValueMemberN)
+		return *in.UpdateExpression == "ADD quiz_points :p, quiz_asked :a, quizzes_played :one" &&
+			points.Value == "3" && asked.Value == "5" && in.ReturnValues == types.ReturnValueAllNew
+	})).Return(&dynamodb.UpdateItemOutput{Attributes: map[string]types.AttributeValue{
+		constants.USERNAME: &types.AttributeValueMemberS{Value: "alice"},
+		"first_name":       &types.AttributeValueMemberS{Value: "Alice"},
+		"quiz_points":      &types.AttributeValueMemberN{Value: "13"},
+		"quiz_asked":       &types.AttributeValueMemberN{Value: "20"},
+		"quizzes_played":   &types.AttributeValueMemberN{Value: "4"},
+	}}, nil)
+
+	stats, err := repos.NewDynamoQuizScoreRepo(mockClient).RecordQuizScore(context.Background(), "alice", 3, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, data.QuizStats{Username: "alice", Points: 13, Asked: 20, Played: 4}, stats)
+	mockClient.AssertExpectations(t)
+}
+
+func TestRecordQuizScore_MissingMember(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	mockClient.On("UpdateItem", mock.Anything, mock.Anything).
+		Return(&dynamodb.UpdateItemOutput{}, &types.ConditionalCheckFailedException{})
+
+	_, err := repos.NewDynamoQuizScoreRepo(mockClient).RecordQuizScore(context.Background(), "nobody", 1, 1)
+	assert.ErrorIs(t, err, repos.ErrMemberNotFound)
+}
+
+func TestGetQuizScores_Paginates(t *testing.T) {
+	mockClient := new(MockDynamoClient)
+	score := func(username, points string) map[string]types.AttributeValue {
+		return map[string]types.AttributeValue{
+			constants.USERNAME: &types.AttributeValueMemberS{Value: username},
+			"quiz_points":      &types.AttributeValueMemberN{Value: points},
+			"quiz_asked":       &types.AttributeValueMemberN{Value: "10"},
+			"quizzes_played":   &types.AttributeValueMemberN{Value: "2"},
+		}
+	}
+	mockClient.On("Scan", mock.Anything, mock.MatchedBy(func(in *dynamodb.ScanInput) bool {
+		return in.ExclusiveStartKey == nil
+	})).Return(&dynamodb.ScanOutput{
+		Items:            []map[string]types.AttributeValue{score("alice", "7")},
+		LastEvaluatedKey: map[string]types.AttributeValue{constants.USERNAME: &types.AttributeValueMemberS{Value: "alice"}},
+	}, nil).Once()
+	mockClient.On("Scan", mock.Anything, mock.MatchedBy(func(in *dynamodb.ScanInput) bool {
+		return in.ExclusiveStartKey != nil
+	})).Return(&dynamodb.ScanOutput{
+		Items: []map[string]types.AttributeValue{score("bob", "9")},
+	}, nil).Once()
+
+	scores, err := repos.NewDynamoQuizScoreRepo(mockClient).GetQuizScores(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []data.QuizStats{
+		{Username: "alice", Points: 7, Asked: 10, Played: 2},
+		{Username: "bob", Points: 9, Asked: 10, Played: 2},
+	}, scores)
+}
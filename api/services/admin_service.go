@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+)
+
+var ErrUnknownCache = errors.New("unknown cache")
+
+type AdminService struct {
+	caches []api_cache.ReloadableCache
+}
+
+var (
+	instantiateAdminServiceOnce sync.Once
+	adminService                *AdminService
+)
+
+func GetAdminService() *AdminService {
+	instantiateAdminServiceOnce.Do(func() {
+		adminService = &AdminService{caches: repos.NewReloadableCachesWithDynamo()}
+	})
+	return adminService
+}
+
+func NewAdminServiceWithCaches(caches ...api_cache.ReloadableCache) *AdminService {
+	return &AdminService{caches: caches}
+}
+
+func (s *AdminService) GetCacheStatuses(ctx context.Context) []data.CacheStatus {
+	statuses := make([]data.CacheStatus, 0, len(s.caches))
+	for _, cache := range s.caches {
+		statuses = append(statuses, cache.Status())
+	}
+	return statuses
+}
+
+// ReloadCaches reloads the named cache, or every cache when name is empty, and returns the
+// statuses of the caches it reloaded. Every requested cache is attempted even if an earlier
+// one fails; the errors are joined.
+func (s *AdminService) ReloadCaches(ctx context.Context, name string) ([]data.CacheStatus, error) {
+	var statuses []data.CacheStatus
+	var errs []error
+	for _, cache := range s.caches {
+		if name != "" && cache.Status().Name != name {
+			continue
+		}
+		if err := cache.Reload(ctx); err != nil {
+			errs = append(errs, err)
+		}
+		statuses = append(statuses, cache.Status())
+	}
+	if statuses == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCache, name)
+	}
+	return statuses, errors.Join(errs...)
+}
@@ -0,0 +1,72 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"blockbuster/api/data"
+	"blockbuster/api/services"
+)
+
+type fakeCache struct {
+	name    string
+	err     error
+	reloads int
+}
+
+func (f *fakeCache) Reload(ctx context.Context) error {
+	f.reloads++
+	return f.err
+}
+
+func (f *fakeCache) Status() data.CacheStatus {
+	return data.CacheStatus{Name: f.name, Size: f.reloads, LastError: errorText(f.err)}
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func TestGetCacheStatuses(t *testing.T) {
+	service := services.NewAdminServiceWithCaches(&fakeCache{name: "centroids"}, &fakeCache{name: "centroids_to_movies"})
+
+	statuses := service.GetCacheStatuses(context.Background())
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "centroids", statuses[0].Name)
+	assert.Equal(t, "centroids_to_movies", statuses[1].Name)
+}
+
+func TestReloadCaches_Named(t *testing.T) {
+	centroids, toMovies := &fakeCache{name: "centroids"}, &fakeCache{name: "centroids_to_movies"}
+	service := services.NewAdminServiceWithCaches(centroids, toMovies)
+
+	statuses, err := service.ReloadCaches(context.Background(), "centroids")
+	assert.NoError(t, err)
+	assert.Equal(t, []data.CacheStatus{{Name: "centroids", Size: 1}}, statuses)
+	assert.Equal(t, 0, toMovies.reloads)
+}
+
+func TestReloadCaches_AllAttemptedOnFailure(t *testing.T) {
+	centroids := &fakeCache{name: "centroids", err: errors.New("scan failed")}
+	toMovies := &fakeCache{name: "centroids_to_movies"}
+	service := services.NewAdminServiceWithCaches(centroids, toMovies)
+
+	statuses, err := service.ReloadCaches(context.Background(), "")
+	assert.ErrorContains(t, err, "scan failed")
+	assert.Len(t, statuses, 2)
+	assert.Equal(t, "scan failed", statuses[0].LastError)
+	assert.Equal(t, 1, toMovies.reloads)
+}
+
+func TestReloadCaches_Unknown(t *testing.T) {
+	service := services.NewAdminServiceWithCaches(&fakeCache{name: "centroids"})
+
+	_, err := service.ReloadCaches(context.Background(), "trivia")
+	assert.ErrorIs(t, err, services.ErrUnknownCache)
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+	"blockbuster/api/utils"
+)
+
+var (
+	ErrCentroidNotFound = repos.ErrCentroidNotFound
+	ErrInvalidLabel     = errors.New("a description needs a label")
+)
+
+type CentroidsService struct {
+	centroids         api_cache.CentroidCacheInterface
+	centroidsToMovies api_cache.CentroidsToMoviesCacheInterface
+	index             api_cache.MovieIndexInterface
+	labels            repos.CentroidsRepo
+	movies            repos.MovieReadRepo
+}
+
+var (
+	instantiateCentroidsServiceOnce sync.Once
+	centroidsService                *CentroidsService
+)
+
+func GetCentroidsService() *CentroidsService {
+	instantiateCentroidsServiceOnce.Do(func() {
+		movieRepo := repos.NewMovieRepoWithDynamo()
+		centroids, centroidsToMovies := repos.NewCentroidCachesWithDynamo()
+		centroidsService = &CentroidsService{
+			centroids:         centroids,
+			centroidsToMovies: centroidsToMovies,
+			index:             api_cache.InitMovieIndex(movieRepo.GetMoviesByPage),
+			labels:            repos.NewCentroidsRepoWithDynamo(),
+			movies:            movieRepo,
+		}
+	})
+	return centroidsService
+}
+
+func NewCentroidsServiceWithDeps(
+	centroids api_cache.CentroidCacheInterface,
+	centroidsToMovies api_cache.CentroidsToMoviesCacheInterface,
+	index api_cache.MovieIndexInterface,
+	labels repos.CentroidsRepo,
+	movies repos.MovieReadRepo,
+) *CentroidsService {
+	return &CentroidsService{centroids: centroids, centroidsToMovies: centroidsToMovies, index: index, labels: labels, movies: movies}
+}
+
+// GetCentroids lists every centroid as a shelf of its k most representative movies, those
+// nearest the centroid. Staff labels, cached with the centroids, replace the generated ones.
+func (s *CentroidsService) GetCentroids(c context.Context, k int) ([]data.Centroid, error) {
+	return s.shelves(c, s.centroids.GetCentroids(), k)
+}
+
+// GetCentroid returns a single shelf like GetCentroids.
+func (s *CentroidsService) GetCentroid(c context.Context, id int, k int) (data.Centroid, error) {
+	for _, centroid := range s.centroids.GetCentroids() {
+		if centroid.ID == id {
+			shelves, err := s.shelves(c, []data.Centroid{centroid}, k)
+			if err != nil {
+				return data.Centroid{}, err
+			}
+			return shelves[0], nil
+		}
+	}
+	return data.Centroid{}, fmt.Errorf("%w: %d", ErrCentroidNotFound, id)
+}
+
+// SetCentroidLabel stores a staff label for a centroid and caches it with the centroids. An
+// empty label reverts to the generated one.
+func (s *CentroidsService) SetCentroidLabel(c context.Context, id int, label data.CentroidLabel) (data.Centroid, error) {
+	label.Label, label.Description = strings.TrimSpace(label.Label), strings.TrimSpace(label.Description)
+	if label.Label == "" && label.Description != "" {
+		return data.Centroid{}, ErrInvalidLabel
+	}
+	if err := s.labels.SetCentroidLabel(c, id, label); err != nil {
+		if errors.Is(err, ErrCentroidNotFound) {
+			return data.Centroid{}, err
+		}
+		utils.LogError("err setting centroid label", err)
+		return data.Centroid{}, fmt.Errorf("failed to label centroid %d", id)
+	}
+	s.centroids.SetLabel(id, label)
+	return s.GetCentroid(c, id, 0)
+}
+
+func (s *CentroidsService) shelves(c context.Context, centroids []data.Centroid, k int) ([]data.Centroid, error) {
+	var representative []string
+	for i := range centroids {
+		centroid := &centroids[i]
+		members, _ := s.centroidsToMovies.GetMovieIDsByCentroid(centroid.ID)
+		centroid.Size = len(members)
+		centroid.Movies = []data.Movie{}
+		for _, movieID := range s.closestMembers(centroid.Metrics, members, k) {
+			centroid.Movies = append(centroid.Movies, data.Movie{ID: movieID})
+			representative = append(representative, movieID)
+		}
+	}
+
+	byID := make(map[string]data.Movie, len(representative))
+	for start := 0; start < len(representative); start += constants.BATCH_GET_LIMIT {
+		movies, err := s.movies.GetMoviesByID(c, representative[start:min(start+constants.BATCH_GET_LIMIT, len(representative))], constants.CART)
+		if err != nil {
+			utils.LogError("err fetching representative movies", err)
+			return nil, errors.New("failed to fetch centroid movies")
+		}
+		for _, movie := range movies {
+			byID[movie.ID] = movie
+		}
+	}
+	for i := range centroids {
+		for j, movie := range centroids[i].Movies {
+			if full, ok := byID[movie.ID]; ok {
+				centroids[i].Movies[j] = full
+			}
+		}
+	}
+	return centroids, nil
+}
+
+// closestMembers returns up to k of a centroid's movies, closest to its metrics first.
+// Movies missing from the movie index, such as those added since it was loaded, come last.
+func (s *CentroidsService) closestMembers(metrics data.MovieMetrics, members []string, k int) []string {
+	if k <= 0 || len(members) == 0 {
+		return nil
+	}
+	ranked := make([]data.Neighbor, len(members))
+	for i, movieID := range members {
+		ranked[i] = data.Neighbor{MovieID: movieID, Distance: math.Inf(1)}
+		if movieMetrics, ok := s.index.GetMetrics(movieID); ok {
+			ranked[i].Distance = utils.MetricDistance(metrics, movieMetrics)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Distance != ranked[j].Distance {
+			return ranked[i].Distance < ranked[j].Distance
+		}
+		return ranked[i].MovieID < ranked[j].MovieID
+	})
+	closest := make([]string, min(k, len(ranked)))
+	for i := range closest {
+		closest[i] = ranked[i].MovieID
+	}
+	return closest
+}
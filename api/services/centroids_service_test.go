@@ -0,0 +1,161 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+	"blockbuster/api/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCentroidsRepo struct {
+	mock.Mock
+}
+
+func (m *MockCentroidsRepo) GetCentroids(ctx context.Context) (map[int]data.MovieMetrics, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[int]data.MovieMetrics), args.Error(1)
+}
+
+func (m *MockCentroidsRepo) ReplaceCentroids(ctx context.Context, centroids []data.MovieMetrics) error {
+	args := m.Called(ctx, centroids)
+	return args.Error(0)
+}
+
+func (m *MockCentroidsRepo) GetCentroidLabels(ctx context.Context) (map[int]data.CentroidLabel, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(map[int]data.CentroidLabel), args.Error(1)
+}
+
+func (m *MockCentroidsRepo) SetCentroidLabel(ctx context.Context, id int, label data.CentroidLabel) error {
+	args := m.Called(ctx, id, label)
+	return args.Error(0)
+}
+
+func setupCentroidsService(t *testing.T) (*services.CentroidsService, *MockCentroidsRepo, *MockMovieRepo) {
+	centroids := api_cache.NewLabelledCentroidCache(func(ctx context.Context) (map[int]data.MovieMetrics, error) {
+		return map[int]data.MovieMetrics{0: {Comedy: 90, Romance: 80}, 1: {Horror: 90, Suspense: 80}, 2: {Action: 90}}, nil
+	}, func(ctx context.Context) (map[int]data.CentroidLabel, error) {
+		return map[int]data.CentroidLabel{1: {Label: "Midnight movies", Custom: true}}, nil
+	})
+	assert.NoError(t, centroids.Reload(context.Background()))
+	movies := []data.Movie{
+		{ID: "airplane", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 95, Romance: 10}},
+		{ID: "notting_hill", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 85, Romance: 80}},
+		{ID: "the_thing", Centroid: 1, Metrics: data.MovieMetrics{Horror: 90, Suspense: 85}},
+	}
+	centroidsToMovies := api_cache.NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		return movies, nil
+	}, centroids, nil)
+	assert.NoError(t, centroidsToMovies.Reload(context.Background()))
+
+	labels := new(MockCentroidsRepo)
+	movieRepo := new(MockMovieRepo)
+	service := services.NewCentroidsServiceWithDeps(centroids, centroidsToMovies, api_cache.NewMovieIndex(movies), labels, movieRepo)
+	return service, labels, movieRepo
+}
+
+func TestGetCentroids_Shelves(t *testing.T) {
+	service, labels, movieRepo := setupCentroidsService(t)
+	movieRepo.On("GetMoviesByID", mock.Anything, []string{"notting_hill", "the_thing"}, constants.CART).
+		Return([]data.Movie{{ID: "notting_hill", Title: "Notting Hill"}, {ID: "the_thing", Title: "The Thing"}}, nil)
+
+	centroids, err := service.GetCentroids(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, centroids, 3)
+
+	assert.Equal(t, "romantic comedies", centroids[0].Label)
+	assert.False(t, centroids[0].Custom)
+	assert.Equal(t, 2, centroids[0].Size)
+	assert.Equal(t, []data.Movie{{ID: "notting_hill", Title: "Notting Hill"}}, centroids[0].Movies)
+
+	assert.Equal(t, "Midnight movies", centroids[1].Label)
+	assert.True(t, centroids[1].Custom)
+	assert.Equal(t, "The Thing", centroids[1].Movies[0].Title)
+
+	assert.Equal(t, 0, centroids[2].Size)
+	assert.Empty(t, centroids[2].Movies)
+	assert.NotNil(t, centroids[2].Movies)
+	movieRepo.AssertExpectations(t)
+	labels.AssertNotCalled(t, "GetCentroidLabels", mock.Anything)
+}
+
+func TestGetCentroid_RanksMembersMissingFromIndexLast(t *testing.T) {
+	centroids := api_cache.NewCentroidCache(func(ctx context.Context) (map[int]data.MovieMetrics, error) {
+		return map[int]data.MovieMetrics{0: {Comedy: 90, Romance: 80}}, nil
+	})
+	assert.NoError(t, centroids.Reload(context.Background()))
+	movies := []data.Movie{
+		{ID: "airplane", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 95, Romance: 10}},
+		{ID: "notting_hill", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 85, Romance: 80}},
+		{ID: "amelie", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 80, Romance: 85}},
+	}
+	centroidsToMovies := api_cache.NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		return movies, nil
+	}, centroids, nil)
+	assert.NoError(t, centroidsToMovies.Reload(context.Background()))
+	labels := new(MockCentroidsRepo)
+	movieRepo := new(MockMovieRepo)
+	movieRepo.On("GetMoviesByID", mock.Anything, []string{"notting_hill", "airplane", "amelie"}, constants.CART).
+		Return([]data.Movie{{ID: "airplane"}, {ID: "amelie"}, {ID: "notting_hill"}}, nil)
+	// amelie was added after the index was loaded
+	service := services.NewCentroidsServiceWithDeps(centroids, centroidsToMovies, api_cache.NewMovieIndex(movies[:2]), labels, movieRepo)
+
+	centroid, err := service.GetCentroid(context.Background(), 0, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, centroid.Size)
+	assert.Equal(t, []data.Movie{{ID: "notting_hill"}, {ID: "airplane"}, {ID: "amelie"}}, centroid.Movies)
+}
+
+func TestGetCentroids_Errors(t *testing.T) {
+	service, _, movieRepo := setupCentroidsService(t)
+	movieRepo.On("GetMoviesByID", mock.Anything, mock.Anything, constants.CART).Return([]data.Movie{}, errors.New("throttled"))
+	_, err := service.GetCentroids(context.Background(), 1)
+	assert.ErrorContains(t, err, "failed to fetch centroid movies")
+}
+
+func TestGetCentroid_NotFound(t *testing.T) {
+	service, _, _ := setupCentroidsService(t)
+	_, err := service.GetCentroid(context.Background(), 7, 1)
+	assert.ErrorIs(t, err, services.ErrCentroidNotFound)
+}
+
+func TestSetCentroidLabel_Success(t *testing.T) {
+	service, labels, _ := setupCentroidsService(t)
+	label := data.CentroidLabel{Label: "Date night", Description: "Romcoms for two."}
+	labels.On("SetCentroidLabel", mock.Anything, 0, label).Return(nil)
+	labels.On("SetCentroidLabel", mock.Anything, 1, data.CentroidLabel{}).Return(nil)
+
+	centroid, err := service.SetCentroidLabel(context.Background(), 0, data.CentroidLabel{Label: "  Date night ", Description: "Romcoms for two. "})
+	assert.NoError(t, err)
+	assert.Equal(t, "Date night", centroid.Label)
+	assert.True(t, centroid.Custom)
+	assert.Empty(t, centroid.Movies)
+
+	centroid, err = service.SetCentroidLabel(context.Background(), 1, data.CentroidLabel{})
+	assert.NoError(t, err)
+	assert.Equal(t, "suspenseful horror films", centroid.Label, "clearing a staff label reverts to the generated one")
+	assert.False(t, centroid.Custom)
+	labels.AssertExpectations(t)
+}
+
+func TestSetCentroidLabel_Errors(t *testing.T) {
+	service, labels, _ := setupCentroidsService(t)
+	_, err := service.SetCentroidLabel(context.Background(), 0, data.CentroidLabel{Description: "no label"})
+	assert.ErrorIs(t, err, services.ErrInvalidLabel)
+
+	labels.On("SetCentroidLabel", mock.Anything, 9, mock.Anything).Return(repos.ErrCentroidNotFound)
+	_, err = service.SetCentroidLabel(context.Background(), 9, data.CentroidLabel{Label: "x"})
+	assert.ErrorIs(t, err, services.ErrCentroidNotFound)
+
+	labels.On("SetCentroidLabel", mock.Anything, 1, mock.Anything).Return(errors.New("throttled"))
+	_, err = service.SetCentroidLabel(context.Background(), 1, data.CentroidLabel{Label: "x"})
+	assert.ErrorContains(t, err, "failed to label centroid 1")
+}
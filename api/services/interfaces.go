@@ -17,10 +17,19 @@ type MembersServiceInterface interface {
 	Return(ctx context.Context, username string, movieIDs []string) ([]string, int, error)
 	GetCheckedOutMovies(ctx context.Context, username string) ([]data.Movie, error)
 	SetAPIChoice(ctx context.Context, username, apiChoice string) error
-	GetIniitialVotingSlate(ctx context.Context) ([]string, error)
-	IterateRecommendationVoting(ctx context.Context, currentMood data.MovieMetrics, iteration, numPrevSelected int, movieIDs []string) (data.MovieMetrics, []string, error)
-	GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics) ([]string, error)
+	GetIniitialVotingSlate(ctx context.Context, username string, seed *int64) ([]string, int64, error)
+	IterateRecommendationVoting(ctx context.Context, currentMood data.MovieMetrics, iteration, numPrevSelected int, movieIDs []string, seed *int64) (data.MovieMetrics, []string, int64, error)
+	GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics, opts data.FinalPicksOptions) ([]data.FinalPick, error)
 	UpdateMood(ctx context.Context, currentMood data.MovieMetrics, iteration int, movieIDs []string) (data.MovieMetrics, error)
+	StartVotingSession(ctx context.Context, username string, seed *int64) (data.VotingSession, error)
+	VoteInSession(ctx context.Context, sessionID string, movieIDs []string) (data.VotingSession, error)
+	FinishVotingSession(ctx context.Context, sessionID string, opts data.FinalPicksOptions) ([]data.FinalPick, error)
+	CreateVotingRoom(ctx context.Context, host, strategy string, seed *int64) (data.VotingRoom, error)
+	JoinVotingRoom(ctx context.Context, code, username string) (data.VotingRoom, error)
+	GetVotingRoom(ctx context.Context, code string) (data.VotingRoom, error)
+	VoteInRoom(ctx context.Context, code, username string, movieIDs []string) (data.VotingRoom, error)
+	FinishVotingRoom(ctx context.Context, code, username string, opts data.FinalPicksOptions) ([]data.FinalPick, error)
+	GetRecommendations(ctx context.Context, username string, limit int) ([]data.Recommendation, error)
 }
 
 type MoviesServiceInterface interface {
@@ -29,4 +38,31 @@ type MoviesServiceInterface interface {
 	GetMovies(ctx context.Context, movieIDs []string) ([]data.Movie, error)
 	GetMovieMetrics(ctx context.Context, movieID string) (data.MovieMetrics, error)
 	GetTrivia(ctx context.Context, movieID string) (data.MovieTrivia, error)
+	SetTrivia(ctx context.Context, movieID string, trivia data.Trivia) (data.MovieTrivia, error)
+	GetAlsoRented(ctx context.Context, movieID string, k int) ([]data.CoRental, error)
+	GetSimilarMovies(ctx context.Context, movieID string, k int) ([]data.SimilarMovie, error)
+	SetMovieMetrics(ctx context.Context, movieID string, metrics data.MovieMetrics) (data.Movie, error)
+}
+
+type QuizServiceInterface interface {
+	StartQuiz(ctx context.Context, opts data.QuizOptions) (data.Quiz, error)
+	GetQuiz(ctx context.Context, quizID string) (data.Quiz, error)
+	SubmitQuiz(ctx context.Context, quizID string, answers []int) (data.QuizResult, error)
+	GetQuizLeaderboard(ctx context.Context, limit int) ([]data.QuizStats, error)
+	GetQuizStats(ctx context.Context, username string) (data.QuizStats, error)
+}
+
+type PeopleServiceInterface interface {
+	GetPerson(ctx context.Context, name string) (data.Person, error)
+}
+
+type AdminServiceInterface interface {
+	GetCacheStatuses(ctx context.Context) []data.CacheStatus
+	ReloadCaches(ctx context.Context, name string) ([]data.CacheStatus, error)
+}
+
+type CentroidsServiceInterface interface {
+	GetCentroids(ctx context.Context, k int) ([]data.Centroid, error)
+	GetCentroid(ctx context.Context, id int, k int) (data.Centroid, error)
+	SetCentroidLabel(ctx context.Context, id int, label data.CentroidLabel) (data.Centroid, error)
 }
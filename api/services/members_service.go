@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 
+	"blockbuster/api/api_cache"
 	"blockbuster/api/constants"
 	"blockbuster/api/data"
 	"blockbuster/api/repos"
@@ -13,9 +16,21 @@ import (
 )
 
 type MembersService struct {
-	repo repos.MemberRepoInterface
+	repo     repos.MemberRepoInterface
+	sessions api_cache.VotingSessionCacheInterface
+	rooms    api_cache.VotingRoomCacheInterface
+	newSeed  func() int64
 }
 
+var (
+	ErrInvalidVote     = errors.New("voted movies must come from the session's current slate")
+	ErrVotingComplete  = errors.New("voting session has no iterations left; request final picks")
+	ErrSlateChanged    = errors.New("voting session moved on to a new slate while voting; vote on the new slate")
+	ErrNoVotesRecorded = errors.New("voting session has no votes yet")
+	ErrInvalidDistance = errors.New("invalid distance options")
+	ErrInvalidPicks    = errors.New("invalid final picks options")
+)
+
 var (
 	instantiateServiceOnce sync.Once
 	membersService         *MembersService
@@ -23,13 +38,45 @@ var (
 
 func GetMemberService() *MembersService {
 	instantiateServiceOnce.Do(func() {
-		membersService = &MembersService{repo: repos.NewMemberRepoWithDynamo()}
+		membersService = &MembersService{
+			repo:     repos.NewMemberRepoWithDynamo(),
+			sessions: api_cache.GetVotingSessionCache(),
+			rooms:    api_cache.GetVotingRoomCache(),
+			newSeed:  utils.NewSeed,
+		}
 	})
 	return membersService
 }
 
 func NewMemberServiceWithRepo(repo repos.MemberRepoInterface) *MembersService {
-	return &MembersService{repo: repo}
+	return &MembersService{
+		repo:     repo,
+		sessions: api_cache.NewVotingSessionCache(constants.VOTING_SESSION_TTL_MINUTES*time.Minute, time.Now),
+		rooms:    api_cache.NewVotingRoomCache(constants.VOTING_SESSION_TTL_MINUTES*time.Minute, time.Now),
+		newSeed:  utils.NewSeed,
+	}
+}
+
+func NewMemberServiceWithDeps(repo repos.MemberRepoInterface, sessions api_cache.VotingSessionCacheInterface) *MembersService {
+	return &MembersService{
+		repo:     repo,
+		sessions: sessions,
+		rooms:    api_cache.NewVotingRoomCache(constants.VOTING_SESSION_TTL_MINUTES*time.Minute, time.Now),
+		newSeed:  utils.NewSeed,
+	}
+}
+
+// SetSeedSource replaces where sessions started without a seed get theirs.
+func (s *MembersService) SetSeedSource(newSeed func() int64) {
+	s.newSeed = newSeed
+}
+
+// seedOrNew returns the seed the client sent, or a fresh one when it sent none.
+func (s *MembersService) seedOrNew(seed *int64) int64 {
+	if seed != nil {
+		return *seed
+	}
+	return s.newSeed()
 }
 
 func (s *MembersService) GetMember(c context.Context, username string, forCart bool) (data.Member, error) {
@@ -122,35 +169,79 @@ func (s *MembersService) SetAPIChoice(c context.Context, username, apiChoice str
 	return nil
 }
 
-func (s *MembersService) GetIniitialVotingSlate(c context.Context) ([]string, error) {
-	movieIDs, err := s.repo.GetIniitialVotingSlate(c)
+// GetIniitialVotingSlate draws the first slate from seed, or from a fresh seed when seed is
+// nil, and returns the seed used so the rest of the voting can be replayed with it.
+func (s *MembersService) GetIniitialVotingSlate(c context.Context, username string, seed *int64) ([]string, int64, error) {
+	used := s.seedOrNew(seed)
+	movieIDs, err := s.repo.GetIniitialVotingSlate(c, username, used)
 	if err != nil {
-		return movieIDs, utils.LogError("errors occured in getting initial voting slate", nil)
+		return movieIDs, used, utils.LogError("errors occured in getting initial voting slate", nil)
 	}
-	return movieIDs, nil
+	return movieIDs, used, nil
 }
 
+// IterateRecommendationVoting draws the next slate from seed like GetIniitialVotingSlate.
 func (s *MembersService) IterateRecommendationVoting(
 	ctx context.Context,
 	currentMood data.MovieMetrics,
 	iteration, numPrevSelected int,
-	movieIDs []string) (data.MovieMetrics, []string, error) {
-	mood, newMovieIDS, err := s.repo.IterateRecommendationVoting(ctx, currentMood, iteration, numPrevSelected, movieIDs)
+	movieIDs []string,
+	seed *int64) (data.MovieMetrics, []string, int64, error) {
+	used := s.seedOrNew(seed)
+	mood, newMovieIDS, err := s.repo.IterateRecommendationVoting(ctx, currentMood, iteration, numPrevSelected, movieIDs, used)
 	if err != nil {
-		return mood, nil, utils.LogError("failed to iterate voting", nil)
+		return mood, nil, used, utils.LogError("failed to iterate voting", nil)
 	}
-	return mood, newMovieIDS, nil
+	return mood, newMovieIDS, used, nil
 }
 
-func (s *MembersService) GetVotingFinalPicks(c context.Context, mood data.MovieMetrics) ([]string, error) {
-	movieIDs, err := s.repo.GetVotingFinalPicks(c, mood)
-	if len(movieIDs) == 0 {
+// GetVotingFinalPicks returns the final picks for mood, tuned by opts.
+func (s *MembersService) GetVotingFinalPicks(c context.Context, mood data.MovieMetrics, opts data.FinalPicksOptions) ([]data.FinalPick, error) {
+	query, err := finalPicksQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.finalPicks(c, mood, query)
+}
+
+func (s *MembersService) finalPicks(c context.Context, mood data.MovieMetrics, query repos.FinalPicksQuery) ([]data.FinalPick, error) {
+	picks, err := s.repo.GetVotingFinalPicks(c, mood, query)
+	if len(picks) == 0 {
 		return nil, utils.LogError("failed to make final voting selections", nil)
 	}
 	if err != nil {
-		return movieIDs, utils.LogError("errs occured making final movie selections", nil)
+		return picks, utils.LogError("errs occured making final movie selections", nil)
 	}
-	return movieIDs, nil
+	return picks, nil
+}
+
+// finalPicksQuery validates opts and fills in the defaults.
+func finalPicksQuery(opts data.FinalPicksOptions) (repos.FinalPicksQuery, error) {
+	distance, err := utils.NewDistanceFunc(opts.Distance)
+	if err != nil {
+		return repos.FinalPicksQuery{}, fmt.Errorf("%w: %v", ErrInvalidDistance, err)
+	}
+	query := repos.FinalPicksQuery{
+		NumPicks:       opts.NumPicks,
+		Diversity:      constants.DEFAULT_PICK_DIVERSITY,
+		Username:       opts.Username,
+		Voted:          opts.Voted,
+		Distance:       distance,
+		BlendCoRentals: opts.BlendCoRentals,
+	}
+	if query.NumPicks == 0 {
+		query.NumPicks = constants.NUMBER_FINAL_PICKS
+	}
+	if query.NumPicks < 0 || query.NumPicks > constants.MAX_FINAL_PICKS {
+		return repos.FinalPicksQuery{}, fmt.Errorf("%w: numPicks must be between 1 and %d", ErrInvalidPicks, constants.MAX_FINAL_PICKS)
+	}
+	if opts.Diversity != nil {
+		if *opts.Diversity < 0 || *opts.Diversity > 1 {
+			return repos.FinalPicksQuery{}, fmt.Errorf("%w: diversity must be between 0 and 1", ErrInvalidPicks)
+		}
+		query.Diversity = *opts.Diversity
+	}
+	return query, nil
 }
 
 func (s *MembersService) UpdateMood(c context.Context, currentMood data.MovieMetrics, iteration int, movieIDs []string) (data.MovieMetrics, error) {
@@ -160,3 +251,120 @@ func (s *MembersService) UpdateMood(c context.Context, currentMood data.MovieMet
 	}
 	return mood, nil
 }
+
+// StartVotingSession opens a server-held voting session seeded with the initial slate.
+// username is optional; when set the slate follows the member's taste and the finished
+// session's mood is folded back into it. Every slate is drawn from seed, or from a fresh seed
+// when seed is nil.
+func (s *MembersService) StartVotingSession(c context.Context, username string, seed *int64) (data.VotingSession, error) {
+	used := s.seedOrNew(seed)
+	movieIDs, err := s.repo.GetIniitialVotingSlate(c, username, used)
+	if len(movieIDs) == 0 {
+		return data.VotingSession{}, utils.LogError("unable to get initial voting slate for session", err)
+	}
+	if err != nil {
+		utils.LogError("initial voting slate gathered with errors", err)
+	}
+	session, err := s.sessions.Create(data.VotingSession{Username: username, Slate: movieIDs, Shown: slices.Clone(movieIDs), Seed: used})
+	if err != nil {
+		return data.VotingSession{}, utils.LogError("failed to create voting session", err)
+	}
+	return session, nil
+}
+
+// VoteInSession records the movies picked from the session's current slate, updates the
+// server-held mood and replaces the slate with the next round of suggestions.
+func (s *MembersService) VoteInSession(c context.Context, sessionID string, movieIDs []string) (data.VotingSession, error) {
+	session, err := s.sessions.Get(sessionID)
+	if err != nil {
+		return data.VotingSession{}, err
+	}
+	if session.Iteration >= constants.MAX_VOTING_ITERATIONS {
+		return data.VotingSession{}, ErrVotingComplete
+	}
+	for _, mid := range movieIDs {
+		if !utils.Contains(session.Slate, mid) {
+			return data.VotingSession{}, ErrInvalidVote
+		}
+	}
+
+	mood, newMovieIDs, err := s.repo.IterateRecommendationVoting(c, session.Mood, session.Iteration, session.NumSelected, movieIDs, session.Seed)
+	if len(newMovieIDs) == 0 {
+		return data.VotingSession{}, utils.LogError(fmt.Sprintf("failed to iterate voting session %s", sessionID), err)
+	}
+	if err != nil {
+		utils.LogError(fmt.Sprintf("voting session %s iterated with errors", sessionID), err)
+	}
+
+	// The next slate was drawn outside the cache's lock; a vote that landed on the session in
+	// the meantime has already replaced the slate this vote was cast on.
+	iteration := session.Iteration
+	return s.sessions.Update(sessionID, func(session *data.VotingSession) error {
+		if session.Iteration != iteration {
+			return ErrSlateChanged
+		}
+		slate := make([]string, 0, len(newMovieIDs))
+		for _, mid := range newMovieIDs {
+			if !utils.Contains(session.Shown, mid) {
+				slate = append(slate, mid)
+			}
+		}
+		if len(slate) == 0 {
+			slate = slices.Clone(newMovieIDs)
+		}
+
+		session.Mood = mood
+		session.Iteration++
+		session.NumSelected += len(movieIDs)
+		session.Selected = append(session.Selected, movieIDs...)
+		session.Slate = slate
+		session.Shown = append(session.Shown, slate...)
+		return nil
+	})
+}
+
+// FinishVotingSession returns the final picks for the session's mood and closes it. The
+// session's member and votes are used when opts names none. The session is taken from the
+// cache before the picks are drawn, so concurrent finishes update the member's taste once;
+// it is put back when no picks could be drawn.
+func (s *MembersService) FinishVotingSession(c context.Context, sessionID string, opts data.FinalPicksOptions) ([]data.FinalPick, error) {
+	session, err := s.sessions.Take(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.NumSelected == 0 {
+		s.sessions.Restore(session)
+		return nil, ErrNoVotesRecorded
+	}
+	if opts.Username == "" {
+		opts.Username = session.Username
+	}
+	if len(opts.Voted) == 0 {
+		opts.Voted = session.Selected
+	}
+	picks, err := s.GetVotingFinalPicks(c, session.Mood, opts)
+	if err != nil {
+		s.sessions.Restore(session)
+		return picks, err
+	}
+	if session.Username != "" {
+		if _, err := s.repo.UpdateTaste(c, session.Username, session.Mood, constants.TASTE_VOTE_WEIGHT); err != nil {
+			utils.LogError(fmt.Sprintf("failed to update taste for %s", session.Username), err)
+		}
+	}
+	return picks, nil
+}
+
+// GetRecommendations ranks in-stock movies near the member's rental history, or their taste
+// when they have no history. Members with neither get no recommendations.
+func (s *MembersService) GetRecommendations(c context.Context, username string, limit int) ([]data.Recommendation, error) {
+	recs, err := s.repo.GetRecommendations(c, username, limit)
+	if errors.Is(err, repos.ErrMemberNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMember, username)
+	}
+	if err != nil {
+		utils.LogError(fmt.Sprintf("failed to get recommendations for %s", username), err)
+		return nil, fmt.Errorf("failed to get recommendations for %s", username)
+	}
+	return recs, nil
+}
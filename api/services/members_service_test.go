@@ -3,10 +3,13 @@ package services_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
+	"blockbuster/api/api_cache"
 	"blockbuster/api/constants"
 	"blockbuster/api/data"
+	"blockbuster/api/repos"
 	"blockbuster/api/services"
 
 	"github.com/stretchr/testify/assert"
@@ -52,29 +55,57 @@ func (m *MockMemberRepo) SetMemberAPIChoice(ctx context.Context, username, apiCh
 	return args.Error(1)
 }
 
-func (m *MockMemberRepo) GetIniitialVotingSlate(ctx context.Context) ([]string, error) {
-	args := m.Called(ctx)
+func (m *MockMemberRepo) GetIniitialVotingSlate(ctx context.Context, username string, seed int64) ([]string, error) {
+	args := m.Called(ctx, username, seed)
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (m *MockMemberRepo) IterateRecommendationVoting(ctx context.Context, currentMood data.MovieMetrics, iteration, numPrevSelected int, movieIDs []string) (data.MovieMetrics, []string, error) {
-	args := m.Called(ctx, currentMood, iteration, numPrevSelected, movieIDs)
+func (m *MockMemberRepo) IterateRecommendationVoting(ctx context.Context, currentMood data.MovieMetrics, iteration, numPrevSelected int, movieIDs []string, seed int64) (data.MovieMetrics, []string, error) {
+	args := m.Called(ctx, currentMood, iteration, numPrevSelected, movieIDs, seed)
 	return args.Get(0).(data.MovieMetrics), args.Get(1).([]string), args.Error(2)
 }
 
-func (m *MockMemberRepo) GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics) ([]string, error) {
-	args := m.Called(ctx, mood)
+func (m *MockMemberRepo) GetVotingSlate(ctx context.Context, mood data.MovieMetrics, iteration int, exclude []string, seed int64) ([]string, error) {
+	args := m.Called(ctx, mood, iteration, exclude, seed)
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *MockMemberRepo) GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics, query repos.FinalPicksQuery) ([]data.FinalPick, error) {
+	query.Distance = nil // funcs never compare equal, so match on the rest of the query
+	args := m.Called(ctx, mood, query)
+	picks, _ := args.Get(0).([]data.FinalPick)
+	return picks, args.Error(1)
+}
+
 func (m *MockMemberRepo) UpdateMood(ctx context.Context, currentMood data.MovieMetrics, iteration int, movieIDs []string) (data.MovieMetrics, error) {
 	args := m.Called(ctx, currentMood, iteration, movieIDs)
 	return args.Get(0).(data.MovieMetrics), args.Error(1)
 }
 
+func (m *MockMemberRepo) UpdateTaste(ctx context.Context, username string, signal data.MovieMetrics, weight float64) (data.TasteProfile, error) {
+	args := m.Called(ctx, username, signal, weight)
+	return args.Get(0).(data.TasteProfile), args.Error(1)
+}
+
+func (m *MockMemberRepo) GetRecommendations(ctx context.Context, username string, limit int) ([]data.Recommendation, error) {
+	args := m.Called(ctx, username, limit)
+	recs, _ := args.Get(0).([]data.Recommendation)
+	return recs, args.Error(1)
+}
+
+func (m *MockMemberRepo) GetRentalHistories(ctx context.Context) ([][]string, error) {
+	args := m.Called(ctx)
+	histories, _ := args.Get(0).([][]string)
+	return histories, args.Error(1)
+}
+
+// testSeed is the seed every session started by setupMockService's service gets.
+const testSeed int64 = 7
+
 func setupMockService() (*services.MembersService, *MockMemberRepo) {
 	repo := new(MockMemberRepo)
 	service := services.NewMemberServiceWithRepo(repo)
+	service.SetSeedSource(func() int64 { return testSeed })
 	return service, repo
 }
 
@@ -200,20 +231,33 @@ func TestMembersService_GetInitialVotingSlate(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		expectedMovies := []string{"m1", "m2", "m3", "m4", "m5", "m6", "m7"} // adjust to MAX_MOVIE_SUGGESTIONS
-		mockRepo.On("GetIniitialVotingSlate", ctx).Return(expectedMovies, nil).Once()
+		mockRepo.On("GetIniitialVotingSlate", ctx, "", testSeed).Return(expectedMovies, nil).Once()
 
-		result, err := service.GetIniitialVotingSlate(ctx)
+		result, seed, err := service.GetIniitialVotingSlate(ctx, "", nil)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedMovies, result)
+		assert.Equal(t, testSeed, seed, "a fresh seed is drawn and returned")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("given seed", func(t *testing.T) {
+		seed := int64(12345)
+		mockRepo.On("GetIniitialVotingSlate", ctx, "", seed).Return([]string{"m1"}, nil).Once()
+
+		result, used, err := service.GetIniitialVotingSlate(ctx, "", &seed)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"m1"}, result)
+		assert.Equal(t, seed, used)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("repo returns error", func(t *testing.T) {
 		expectedMovies := []string{"m1", "m2", "m3", "m4", "m5", "m6", "m7"}
-		mockRepo.On("GetIniitialVotingSlate", ctx).Return(expectedMovies, errors.New("failed to get centroids")).Once()
+		mockRepo.On("GetIniitialVotingSlate", ctx, "", testSeed).Return(expectedMovies, errors.New("failed to get centroids")).Once()
 
-		result, err := service.GetIniitialVotingSlate(ctx)
+		result, _, err := service.GetIniitialVotingSlate(ctx, "", nil)
 
 		assert.Error(t, err)
 		assert.ErrorContains(t, err, "errors occured in getting initial voting slate")
@@ -222,6 +266,16 @@ func TestMembersService_GetInitialVotingSlate(t *testing.T) {
 	})
 }
 
+var defaultPicksQuery = repos.FinalPicksQuery{NumPicks: constants.NUMBER_FINAL_PICKS, Diversity: constants.DEFAULT_PICK_DIVERSITY}
+
+func finalPicks(movieIDs ...string) []data.FinalPick {
+	picks := make([]data.FinalPick, len(movieIDs))
+	for i, mid := range movieIDs {
+		picks[i] = data.FinalPick{MovieID: mid}
+	}
+	return picks
+}
+
 func TestGetVotingFinalPicks(t *testing.T) {
 	ctx := context.Background()
 	service, mockRepo := setupMockService()
@@ -229,10 +283,10 @@ func TestGetVotingFinalPicks(t *testing.T) {
 	mood := data.MovieMetrics{Acting: 50, Action: 40, Cinematography: 30}
 
 	t.Run("success", func(t *testing.T) {
-		finalMovies := []string{"m1", "m2", "m3"}
-		mockRepo.On("GetVotingFinalPicks", ctx, mood).Return(finalMovies, nil).Once()
+		finalMovies := finalPicks("m1", "m2", "m3")
+		mockRepo.On("GetVotingFinalPicks", ctx, mood, defaultPicksQuery).Return(finalMovies, nil).Once()
 
-		result, err := service.GetVotingFinalPicks(ctx, mood)
+		result, err := service.GetVotingFinalPicks(ctx, mood, data.FinalPicksOptions{})
 
 		assert.NoError(t, err)
 		assert.Equal(t, finalMovies, result)
@@ -240,9 +294,9 @@ func TestGetVotingFinalPicks(t *testing.T) {
 	})
 
 	t.Run("repo error", func(t *testing.T) {
-		mockRepo.On("GetVotingFinalPicks", ctx, mood).Return([]string{"m1"}, errors.New("db error")).Once()
+		mockRepo.On("GetVotingFinalPicks", ctx, mood, defaultPicksQuery).Return(finalPicks("m1"), errors.New("db error")).Once()
 
-		result, err := service.GetVotingFinalPicks(ctx, mood)
+		result, err := service.GetVotingFinalPicks(ctx, mood, data.FinalPicksOptions{})
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -250,9 +304,9 @@ func TestGetVotingFinalPicks(t *testing.T) {
 	})
 
 	t.Run("empty movie list", func(t *testing.T) {
-		mockRepo.On("GetVotingFinalPicks", ctx, mood).Return([]string{}, nil).Once()
+		mockRepo.On("GetVotingFinalPicks", ctx, mood, defaultPicksQuery).Return([]data.FinalPick{}, nil).Once()
 
-		result, err := service.GetVotingFinalPicks(ctx, mood)
+		result, err := service.GetVotingFinalPicks(ctx, mood, data.FinalPicksOptions{})
 
 		assert.Error(t, err)
 		assert.Nil(t, result)
@@ -260,6 +314,40 @@ func TestGetVotingFinalPicks(t *testing.T) {
 	})
 }
 
+func TestGetVotingFinalPicks_Options(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo := setupMockService()
+	mood := data.MovieMetrics{Suspense: 80, Writing: 70}
+
+	diversity := 0.8
+	query := repos.FinalPicksQuery{NumPicks: 5, Diversity: diversity, Username: "john", Voted: []string{"m9"}}
+	mockRepo.On("GetVotingFinalPicks", ctx, mood, query).Return(finalPicks("m1"), nil).Once()
+	opts := data.FinalPicksOptions{
+		NumPicks:  5,
+		Diversity: &diversity,
+		Username:  "john",
+		Voted:     []string{"m9"},
+		Distance:  data.DistanceOptions{Metric: constants.WEIGHTED_EUCLIDEAN, Weights: map[string]float64{"suspense": 3, "writing": 3}},
+	}
+	result, err := service.GetVotingFinalPicks(ctx, mood, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, finalPicks("m1"), result)
+
+	_, err = service.GetVotingFinalPicks(ctx, mood, data.FinalPicksOptions{Distance: data.DistanceOptions{Metric: "chebyshev"}})
+	assert.ErrorIs(t, err, services.ErrInvalidDistance)
+
+	_, err = service.GetVotingFinalPicks(ctx, mood, data.FinalPicksOptions{Distance: data.DistanceOptions{Weights: map[string]float64{"gore": 2}}})
+	assert.ErrorIs(t, err, services.ErrInvalidDistance)
+
+	_, err = service.GetVotingFinalPicks(ctx, mood, data.FinalPicksOptions{NumPicks: constants.MAX_FINAL_PICKS + 1})
+	assert.ErrorIs(t, err, services.ErrInvalidPicks)
+
+	tooDiverse := 1.5
+	_, err = service.GetVotingFinalPicks(ctx, mood, data.FinalPicksOptions{Diversity: &tooDiverse})
+	assert.ErrorIs(t, err, services.ErrInvalidPicks)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestMembersService_UpdateMood(t *testing.T) {
 	ctx := context.Background()
 	service, mockRepo := setupMockService()
@@ -292,3 +380,181 @@ func TestMembersService_UpdateMood(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestVotingSession_Flow(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo := setupMockService()
+
+	mockRepo.On("GetIniitialVotingSlate", ctx, "", testSeed).Return([]string{"m1", "m2", "m3"}, nil).Once()
+	session, err := service.StartVotingSession(ctx, "", nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, session.ID)
+	assert.Equal(t, []string{"m1", "m2", "m3"}, session.Slate)
+	assert.Equal(t, testSeed, session.Seed)
+
+	mood := data.MovieMetrics{Drama: 7}
+	mockRepo.On("IterateRecommendationVoting", ctx, data.MovieMetrics{}, 0, 0, []string{"m1", "m2"}, testSeed).
+		Return(mood, []string{"m3", "m4", "m5"}, nil).Once()
+	session, err = service.VoteInSession(ctx, session.ID, []string{"m1", "m2"})
+	assert.NoError(t, err)
+	assert.Equal(t, mood, session.Mood)
+	assert.Equal(t, 1, session.Iteration)
+	assert.Equal(t, 2, session.NumSelected)
+	assert.Equal(t, []string{"m4", "m5"}, session.Slate, "previously shown movies are not repeated")
+
+	_, err = service.VoteInSession(ctx, session.ID, []string{"m1"})
+	assert.ErrorIs(t, err, services.ErrInvalidVote)
+
+	sessionQuery := defaultPicksQuery
+	sessionQuery.Voted = []string{"m1", "m2"}
+	mockRepo.On("GetVotingFinalPicks", ctx, mood, sessionQuery).Return(finalPicks("m4", "m5", "m6"), nil).Once()
+	picks, err := service.FinishVotingSession(ctx, session.ID, data.FinalPicksOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, finalPicks("m4", "m5", "m6"), picks)
+
+	_, err = service.VoteInSession(ctx, session.ID, []string{"m4"})
+	assert.ErrorIs(t, err, api_cache.ErrVotingSessionNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVotingSession_ConcurrentVoteOnSameSlate(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo := setupMockService()
+	mockRepo.On("GetIniitialVotingSlate", ctx, "", testSeed).Return([]string{"m1", "m2"}, nil).Once()
+	session, err := service.StartVotingSession(ctx, "", nil)
+	assert.NoError(t, err)
+
+	// The second vote lands while the first is still drawing its next slate.
+	var second data.VotingSession
+	mockRepo.On("IterateRecommendationVoting", ctx, data.MovieMetrics{}, 0, 0, []string{"m1"}, testSeed).
+		Run(func(mock.Arguments) {
+			second, err = service.VoteInSession(ctx, session.ID, []string{"m2"})
+		}).
+		Return(data.MovieMetrics{Drama: 7}, []string{"m3"}, nil).Once()
+	mockRepo.On("IterateRecommendationVoting", ctx, data.MovieMetrics{}, 0, 0, []string{"m2"}, testSeed).
+		Return(data.MovieMetrics{Comedy: 7}, []string{"m4", "m1"}, nil).Once()
+
+	_, firstErr := service.VoteInSession(ctx, session.ID, []string{"m1"})
+	assert.ErrorIs(t, firstErr, services.ErrSlateChanged)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"m4"}, second.Slate)
+
+	stored, err := service.VoteInSession(ctx, session.ID, []string{"m1"})
+	assert.ErrorIs(t, err, services.ErrInvalidVote, "the losing vote's slate is gone")
+	assert.Empty(t, stored.Slate)
+	assert.Equal(t, []string{"m1", "m2", "m4"}, second.Shown)
+	assert.Equal(t, 1, second.NumSelected, "the losing vote is not counted")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVotingSession_GivenSeedDrawsEverySlate(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo := setupMockService()
+	seed := int64(99)
+
+	mockRepo.On("GetIniitialVotingSlate", ctx, "", seed).Return([]string{"m1", "m2"}, nil).Once()
+	mockRepo.On("IterateRecommendationVoting", ctx, data.MovieMetrics{}, 0, 0, []string{"m1"}, seed).
+		Return(data.MovieMetrics{Drama: 7}, []string{"m3"}, nil).Once()
+	session, err := service.StartVotingSession(ctx, "", &seed)
+	assert.NoError(t, err)
+	assert.Equal(t, seed, session.Seed)
+	_, err = service.VoteInSession(ctx, session.ID, []string{"m1"})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVotingSession_Errors(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo := setupMockService()
+
+	_, err := service.VoteInSession(ctx, "missing", []string{"m1"})
+	assert.ErrorIs(t, err, api_cache.ErrVotingSessionNotFound)
+
+	mockRepo.On("GetIniitialVotingSlate", ctx, "", testSeed).Return([]string{"m1"}, nil).Once()
+	session, _ := service.StartVotingSession(ctx, "", nil)
+	_, err = service.FinishVotingSession(ctx, session.ID, data.FinalPicksOptions{})
+	assert.ErrorIs(t, err, services.ErrNoVotesRecorded)
+
+	mockRepo.On("IterateRecommendationVoting", ctx, data.MovieMetrics{}, 0, 0, []string{"m1"}, testSeed).
+		Return(data.MovieMetrics{}, []string{}, errors.New("no centroids")).Once()
+	_, err = service.VoteInSession(ctx, session.ID, []string{"m1"})
+	assert.ErrorContains(t, err, "failed to iterate voting session")
+
+	mockRepo.On("GetIniitialVotingSlate", ctx, "", testSeed).Return([]string{}, errors.New("cache down")).Once()
+	_, err = service.StartVotingSession(ctx, "", nil)
+	assert.Error(t, err)
+}
+
+func TestVotingSession_UpdatesMemberTaste(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo := setupMockService()
+	mood := data.MovieMetrics{Comedy: 8}
+
+	mockRepo.On("GetIniitialVotingSlate", ctx, "john", testSeed).Return([]string{"m1"}, nil).Once()
+	mockRepo.On("IterateRecommendationVoting", ctx, data.MovieMetrics{}, 0, 0, []string{"m1"}, testSeed).
+		Return(mood, []string{"m2"}, nil).Once()
+	johnsQuery := defaultPicksQuery
+	johnsQuery.Username = "john"
+	johnsQuery.Voted = []string{"m1"}
+	mockRepo.On("GetVotingFinalPicks", ctx, mood, johnsQuery).Return(finalPicks("m2"), nil).Once()
+	mockRepo.On("UpdateTaste", ctx, "john", mood, constants.TASTE_VOTE_WEIGHT).Return(data.TasteProfile{}, nil).Once()
+
+	session, err := service.StartVotingSession(ctx, "john", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "john", session.Username)
+	_, err = service.VoteInSession(ctx, session.ID, []string{"m1"})
+	assert.NoError(t, err)
+	_, err = service.FinishVotingSession(ctx, session.ID, data.FinalPicksOptions{})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVotingSession_FinishesOnce(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo := setupMockService()
+	mood := data.MovieMetrics{Comedy: 8}
+
+	mockRepo.On("GetIniitialVotingSlate", ctx, "john", testSeed).Return([]string{"m1"}, nil).Once()
+	mockRepo.On("IterateRecommendationVoting", ctx, data.MovieMetrics{}, 0, 0, []string{"m1"}, testSeed).
+		Return(mood, []string{"m2"}, nil).Once()
+	session, _ := service.StartVotingSession(ctx, "john", nil)
+	_, err := service.VoteInSession(ctx, session.ID, []string{"m1"})
+	assert.NoError(t, err)
+
+	johnsQuery := defaultPicksQuery
+	johnsQuery.Username = "john"
+	johnsQuery.Voted = []string{"m1"}
+	mockRepo.On("GetVotingFinalPicks", ctx, mood, johnsQuery).Return(nil, errors.New("throttled")).Once()
+	_, err = service.FinishVotingSession(ctx, session.ID, data.FinalPicksOptions{})
+	assert.Error(t, err)
+
+	// The second finish lands while the first is still drawing its picks.
+	var secondErr error
+	mockRepo.On("GetVotingFinalPicks", ctx, mood, johnsQuery).
+		Run(func(mock.Arguments) {
+			_, secondErr = service.FinishVotingSession(ctx, session.ID, data.FinalPicksOptions{})
+		}).
+		Return(finalPicks("m2"), nil).Once()
+	mockRepo.On("UpdateTaste", ctx, "john", mood, constants.TASTE_VOTE_WEIGHT).Return(data.TasteProfile{}, nil).Once()
+
+	picks, err := service.FinishVotingSession(ctx, session.ID, data.FinalPicksOptions{})
+	assert.NoError(t, err, "a session whose picks failed can be finished again")
+	assert.Equal(t, finalPicks("m2"), picks)
+	assert.ErrorIs(t, secondErr, api_cache.ErrVotingSessionNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRecommendations_UnknownMember(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo := setupMockService()
+	mockRepo.On("GetRecommendations", ctx, "ghost", 5).
+		Return(nil, fmt.Errorf("fetching ghost for recommendations: %w", repos.ErrMemberNotFound)).Once()
+	mockRepo.On("GetRecommendations", ctx, "john", 5).Return(nil, errors.New("throttled")).Once()
+
+	_, err := service.GetRecommendations(ctx, "ghost", 5)
+	assert.ErrorIs(t, err, services.ErrUnknownMember)
+
+	_, err = service.GetRecommendations(ctx, "john", 5)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, services.ErrUnknownMember)
+}
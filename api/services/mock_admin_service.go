@@ -0,0 +1,25 @@
+package services
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/data"
+)
+
+type MockAdminService struct {
+	mock.Mock
+}
+
+func (m *MockAdminService) GetCacheStatuses(ctx context.Context) []data.CacheStatus {
+	args := m.Called(ctx)
+	statuses, _ := args.Get(0).([]data.CacheStatus)
+	return statuses
+}
+
+func (m *MockAdminService) ReloadCaches(ctx context.Context, name string) ([]data.CacheStatus, error) {
+	args := m.Called(ctx, name)
+	statuses, _ := args.Get(0).([]data.CacheStatus)
+	return statuses, args.Error(1)
+}
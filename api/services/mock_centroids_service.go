@@ -0,0 +1,29 @@
+package services
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/data"
+)
+
+type MockCentroidsService struct {
+	mock.Mock
+}
+
+func (m *MockCentroidsService) GetCentroids(ctx context.Context, k int) ([]data.Centroid, error) {
+	args := m.Called(ctx, k)
+	centroids, _ := args.Get(0).([]data.Centroid)
+	return centroids, args.Error(1)
+}
+
+func (m *MockCentroidsService) GetCentroid(ctx context.Context, id int, k int) (data.Centroid, error) {
+	args := m.Called(ctx, id, k)
+	return args.Get(0).(data.Centroid), args.Error(1)
+}
+
+func (m *MockCentroidsService) SetCentroidLabel(ctx context.Context, id int, label data.CentroidLabel) (data.Centroid, error) {
+	args := m.Called(ctx, id, label)
+	return args.Get(0).(data.Centroid), args.Error(1)
+}
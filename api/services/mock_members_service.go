@@ -61,22 +61,71 @@ func (m *MockMembersService) SetAPIChoice(ctx context.Context, username, choice
 	return args.Error(1)
 }
 
-func (m *MockMembersService) GetIniitialVotingSlate(ctx context.Context) ([]string, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]string), args.Error(1)
+func (m *MockMembersService) GetIniitialVotingSlate(ctx context.Context, username string, seed *int64) ([]string, int64, error) {
+	args := m.Called(ctx, username, seed)
+	return args.Get(0).([]string), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockMembersService) IterateRecommendationVoting(ctx context.Context, currentMood data.MovieMetrics, iteration, numPrevSelected int, movieIDs []string) (data.MovieMetrics, []string, error) {
-	args := m.Called(ctx, currentMood, iteration, numPrevSelected, movieIDs)
-	return args.Get(0).(data.MovieMetrics), args.Get(1).([]string), args.Error(2)
+func (m *MockMembersService) IterateRecommendationVoting(ctx context.Context, currentMood data.MovieMetrics, iteration, numPrevSelected int, movieIDs []string, seed *int64) (data.MovieMetrics, []string, int64, error) {
+	args := m.Called(ctx, currentMood, iteration, numPrevSelected, movieIDs, seed)
+	return args.Get(0).(data.MovieMetrics), args.Get(1).([]string), args.Get(2).(int64), args.Error(3)
 }
 
-func (m *MockMembersService) GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics) ([]string, error) {
-	args := m.Called(ctx, mood)
-	return args.Get(0).([]string), args.Error(1)
+func (m *MockMembersService) GetVotingFinalPicks(ctx context.Context, mood data.MovieMetrics, opts data.FinalPicksOptions) ([]data.FinalPick, error) {
+	args := m.Called(ctx, mood, opts)
+	picks, _ := args.Get(0).([]data.FinalPick)
+	return picks, args.Error(1)
 }
 
 func (m *MockMembersService) UpdateMood(ctx context.Context, currentMood data.MovieMetrics, iteration int, movieIDs []string) (data.MovieMetrics, error) {
 	args := m.Called(ctx, currentMood, iteration, movieIDs)
 	return args.Get(0).(data.MovieMetrics), args.Error(1)
 }
+
+func (m *MockMembersService) StartVotingSession(ctx context.Context, username string, seed *int64) (data.VotingSession, error) {
+	args := m.Called(ctx, username, seed)
+	return args.Get(0).(data.VotingSession), args.Error(1)
+}
+
+func (m *MockMembersService) VoteInSession(ctx context.Context, sessionID string, movieIDs []string) (data.VotingSession, error) {
+	args := m.Called(ctx, sessionID, movieIDs)
+	return args.Get(0).(data.VotingSession), args.Error(1)
+}
+
+func (m *MockMembersService) FinishVotingSession(ctx context.Context, sessionID string, opts data.FinalPicksOptions) ([]data.FinalPick, error) {
+	args := m.Called(ctx, sessionID, opts)
+	picks, _ := args.Get(0).([]data.FinalPick)
+	return picks, args.Error(1)
+}
+
+func (m *MockMembersService) CreateVotingRoom(ctx context.Context, host, strategy string, seed *int64) (data.VotingRoom, error) {
+	args := m.Called(ctx, host, strategy, seed)
+	return args.Get(0).(data.VotingRoom), args.Error(1)
+}
+
+func (m *MockMembersService) JoinVotingRoom(ctx context.Context, code, username string) (data.VotingRoom, error) {
+	args := m.Called(ctx, code, username)
+	return args.Get(0).(data.VotingRoom), args.Error(1)
+}
+
+func (m *MockMembersService) GetVotingRoom(ctx context.Context, code string) (data.VotingRoom, error) {
+	args := m.Called(ctx, code)
+	return args.Get(0).(data.VotingRoom), args.Error(1)
+}
+
+func (m *MockMembersService) VoteInRoom(ctx context.Context, code, username string, movieIDs []string) (data.VotingRoom, error) {
+	args := m.Called(ctx, code, username, movieIDs)
+	return args.Get(0).(data.VotingRoom), args.Error(1)
+}
+
+func (m *MockMembersService) FinishVotingRoom(ctx context.Context, code, username string, opts data.FinalPicksOptions) ([]data.FinalPick, error) {
+	args := m.Called(ctx, code, username, opts)
+	picks, _ := args.Get(0).([]data.FinalPick)
+	return picks, args.Error(1)
+}
+
+func (m *MockMembersService) GetRecommendations(ctx context.Context, username string, limit int) ([]data.Recommendation, error) {
+	args := m.Called(ctx, username, limit)
+	recs, _ := args.Get(0).([]data.Recommendation)
+	return recs, args.Error(1)
+}
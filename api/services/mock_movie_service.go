@@ -36,3 +36,23 @@ func (m *MockMoviesService) GetTrivia(ctx context.Context, id string) (data.Movi
 	args := m.Called(ctx, id)
 	return args.Get(0).(data.MovieTrivia), args.Error(1)
 }
+
+func (m *MockMoviesService) SetTrivia(ctx context.Context, id string, trivia data.Trivia) (data.MovieTrivia, error) {
+	args := m.Called(ctx, id, trivia)
+	return args.Get(0).(data.MovieTrivia), args.Error(1)
+}
+
+func (m *MockMoviesService) GetAlsoRented(ctx context.Context, id string, k int) ([]data.CoRental, error) {
+	args := m.Called(ctx, id, k)
+	return args.Get(0).([]data.CoRental), args.Error(1)
+}
+
+func (m *MockMoviesService) GetSimilarMovies(ctx context.Context, id string, k int) ([]data.SimilarMovie, error) {
+	args := m.Called(ctx, id, k)
+	return args.Get(0).([]data.SimilarMovie), args.Error(1)
+}
+
+func (m *MockMoviesService) SetMovieMetrics(ctx context.Context, movieID string, metrics data.MovieMetrics) (data.Movie, error) {
+	args := m.Called(ctx, movieID, metrics)
+	return args.Get(0).(data.Movie), args.Error(1)
+}
@@ -0,0 +1,18 @@
+package services
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/data"
+)
+
+type MockPeopleService struct {
+	mock.Mock
+}
+
+func (m *MockPeopleService) GetPerson(ctx context.Context, name string) (data.Person, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(data.Person), args.Error(1)
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/data"
+)
+
+type MockQuizService struct {
+	mock.Mock
+}
+
+func (m *MockQuizService) StartQuiz(ctx context.Context, opts data.QuizOptions) (data.Quiz, error) {
+	args := m.Called(ctx, opts)
+	return args.Get(0).(data.Quiz), args.Error(1)
+}
+
+func (m *MockQuizService) GetQuiz(ctx context.Context, quizID string) (data.Quiz, error) {
+	args := m.Called(ctx, quizID)
+	return args.Get(0).(data.Quiz), args.Error(1)
+}
+
+func (m *MockQuizService) SubmitQuiz(ctx context.Context, quizID string, answers []int) (data.QuizResult, error) {
+	args := m.Called(ctx, quizID, answers)
+	return args.Get(0).(data.QuizResult), args.Error(1)
+}
+
+func (m *MockQuizService) GetQuizLeaderboard(ctx context.Context, limit int) ([]data.QuizStats, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]data.QuizStats), args.Error(1)
+}
+
+func (m *MockQuizService) GetQuizStats(ctx context.Context, username string) (data.QuizStats, error) {
+	args := m.Called(ctx, username)
+	return args.Get(0).(data.QuizStats), args.Error(1)
+}
@@ -6,14 +6,27 @@ import (
 	"fmt"
 	"sync"
 
+	"blockbuster/api/api_cache"
 	"blockbuster/api/constants"
 	"blockbuster/api/data"
 	"blockbuster/api/repos"
 	"blockbuster/api/utils"
 )
 
+var (
+	ErrInvalidMetrics = errors.New("metrics must be non-negative and not all zero")
+	ErrInvalidTrivia  = errors.New("invalid trivia")
+	ErrMovieNotFound  = repos.ErrMovieNotFound
+	ErrNoMetrics      = repos.ErrMetricsNotFound
+)
+
 type MoviesService struct {
-	repo repos.MovieReadRepo
+	repo              repos.MovieReadRepo
+	coRentals         api_cache.CoRentalCacheInterface
+	movieIndex        api_cache.MovieIndexInterface
+	centroidWriter    repos.MovieCentroidRepo
+	centroidsToMovies api_cache.CentroidsToMoviesCacheInterface
+	triviaWriter      repos.MovieTriviaRepo
 }
 
 var (
@@ -23,7 +36,17 @@ var (
 
 func GetMovieService() *MoviesService {
 	instantiateMovieServiceOnce.Do(func() {
-		moviesService = &MoviesService{repo: repos.NewMovieRepoWithDynamo()}
+		memberRepo := repos.NewMemberRepoWithDynamo()
+		movieRepo := repos.NewMovieRepoWithDynamo()
+		_, centroidsToMovies := repos.NewCentroidCachesWithDynamo()
+		moviesService = &MoviesService{
+			repo:              movieRepo,
+			coRentals:         api_cache.GetCoRentalCache(memberRepo.GetRentalHistories),
+			movieIndex:        api_cache.InitMovieIndex(movieRepo.GetMoviesByPage),
+			centroidWriter:    movieRepo,
+			centroidsToMovies: centroidsToMovies,
+			triviaWriter:      movieRepo,
+		}
 	})
 	return moviesService
 }
@@ -32,6 +55,27 @@ func NewMovieserviceWithRepo(repo repos.MovieReadRepo) *MoviesService {
 	return &MoviesService{repo: repo}
 }
 
+func NewMovieServiceWithDeps(repo repos.MovieReadRepo, coRentals api_cache.CoRentalCacheInterface, movieIndex api_cache.MovieIndexInterface) *MoviesService {
+	return &MoviesService{repo: repo, coRentals: coRentals, movieIndex: movieIndex}
+}
+
+// SetCentroidAssignment lets SetMovieMetrics write metrics and keep centroid assignments
+// current.
+func (s *MoviesService) SetCentroidAssignment(writer repos.MovieCentroidRepo, centroidsToMovies api_cache.CentroidsToMoviesCacheInterface) {
+	s.centroidWriter, s.centroidsToMovies = writer, centroidsToMovies
+}
+
+// SetMovieIndex sets the movie metrics index GetSimilarMovies searches and SetMovieMetrics
+// keeps current.
+func (s *MoviesService) SetMovieIndex(index api_cache.MovieIndexInterface) {
+	s.movieIndex = index
+}
+
+// SetTriviaWriter lets SetTrivia write trivia.
+func (s *MoviesService) SetTriviaWriter(writer repos.MovieTriviaRepo) {
+	s.triviaWriter = writer
+}
+
 func (s *MoviesService) GetMoviesByPage(c context.Context, page string) ([]data.Movie, error) {
 	movies, err := s.repo.GetMoviesByPage(c, page, constants.FOR_REST_CALL)
 	if err != nil {
@@ -76,3 +120,141 @@ func (s *MoviesService) GetTrivia(c context.Context, movieID string) (data.Movie
 	}
 	return trivia, nil
 }
+
+// SetTrivia validates and replaces a movie's trivia, returning it as written with the
+// whitespace around questions and answers trimmed.
+func (s *MoviesService) SetTrivia(c context.Context, movieID string, trivia data.Trivia) (data.MovieTrivia, error) {
+	trivia = trivia.Normalize()
+	if err := trivia.Validate(); err != nil {
+		return data.MovieTrivia{}, fmt.Errorf("%w: %v", ErrInvalidTrivia, err)
+	}
+	if s.triviaWriter == nil {
+		return data.MovieTrivia{}, utils.LogError("trivia writes are unavailable", nil)
+	}
+	if err := s.triviaWriter.SetTrivia(c, movieID, trivia); err != nil {
+		if errors.Is(err, ErrMovieNotFound) {
+			return data.MovieTrivia{}, err
+		}
+		utils.LogError("err setting trivia", err)
+		return data.MovieTrivia{}, fmt.Errorf("failed to save trivia for %s", movieID)
+	}
+	return data.MovieTrivia{Trivia: trivia}, nil
+}
+
+// GetAlsoRented returns up to k movies most often rented by members who also rented movieID,
+// filled in with their title and inventory.
+func (s *MoviesService) GetAlsoRented(c context.Context, movieID string, k int) ([]data.CoRental, error) {
+	if s.coRentals == nil {
+		return nil, utils.LogError("co-rental model is unavailable", nil)
+	}
+	coRentals := s.coRentals.GetAlsoRented(movieID, k)
+	if len(coRentals) == 0 {
+		return []data.CoRental{}, nil
+	}
+
+	ids := make([]string, len(coRentals))
+	for i, coRental := range coRentals {
+		ids[i] = coRental.Movie.ID
+	}
+	movies, err := s.repo.GetMoviesByID(c, ids, constants.CART)
+	if err != nil {
+		utils.LogError("err fetching also rented movies", err)
+		return nil, fmt.Errorf("failed to fetch movies also rented with %s", movieID)
+	}
+	byID := make(map[string]data.Movie, len(movies))
+	for _, movie := range movies {
+		byID[movie.ID] = movie
+	}
+	results := make([]data.CoRental, 0, len(coRentals))
+	for _, coRental := range coRentals {
+		if movie, ok := byID[coRental.Movie.ID]; ok {
+			coRental.Movie = movie
+			results = append(results, coRental)
+		}
+	}
+	return results, nil
+}
+
+// GetSimilarMovies returns up to k movies closest to movieID by MetricDistance in the movie
+// index, filled in with their title and inventory. The movie's metrics are read from the
+// movies table when it was added since the index was built; ErrMovieNotFound or ErrNoMetrics
+// is returned when it has none.
+func (s *MoviesService) GetSimilarMovies(c context.Context, movieID string, k int) ([]data.SimilarMovie, error) {
+	if s.movieIndex == nil {
+		return nil, utils.LogError("similar movies are unavailable", nil)
+	}
+	target, ok := s.movieIndex.GetMetrics(movieID)
+	if !ok {
+		var err error
+		if target, err = s.repo.GetMovieMetrics(c, movieID); err != nil {
+			if errors.Is(err, ErrMovieNotFound) || errors.Is(err, ErrNoMetrics) {
+				return nil, err
+			}
+			utils.LogError("err fetching metrics for similar movies", err)
+			return nil, fmt.Errorf("failed to find movies similar to %s", movieID)
+		}
+	}
+	neighbors := s.movieIndex.KNearest(target, k, utils.MetricDistance, func(mid string) bool {
+		return mid != movieID
+	})
+
+	similar := make([]data.SimilarMovie, 0, len(neighbors))
+	for start := 0; start < len(neighbors); start += constants.BATCH_GET_LIMIT {
+		batch := neighbors[start:min(start+constants.BATCH_GET_LIMIT, len(neighbors))]
+		ids := make([]string, len(batch))
+		for i, neighbor := range batch {
+			ids[i] = neighbor.MovieID
+		}
+		movies, err := s.repo.GetMoviesByID(c, ids, constants.CART)
+		if err != nil {
+			utils.LogError("err fetching similar movies", err)
+			return nil, fmt.Errorf("failed to find movies similar to %s", movieID)
+		}
+		byID := make(map[string]data.Movie, len(movies))
+		for _, movie := range movies {
+			byID[movie.ID] = movie
+		}
+		for _, neighbor := range batch {
+			if movie, ok := byID[neighbor.MovieID]; ok {
+				similar = append(similar, data.SimilarMovie{Movie: movie, Distance: neighbor.Distance, Centroid: neighbor.Centroid})
+			}
+		}
+	}
+	return similar, nil
+}
+
+// SetMovieMetrics writes new metrics for a movie along with the centroid nearest them, and
+// moves the movie to that centroid in the cache and the movie index without waiting for a
+// reload. Only the ID,
+// metrics and centroid of the returned movie are set.
+func (s *MoviesService) SetMovieMetrics(c context.Context, movieID string, metrics data.MovieMetrics) (data.Movie, error) {
+	metrics.ID = 0
+	if metrics == (data.MovieMetrics{}) {
+		return data.Movie{}, ErrInvalidMetrics
+	}
+	for _, value := range metrics.Vector() {
+		if value < 0 {
+			return data.Movie{}, ErrInvalidMetrics
+		}
+	}
+	if s.centroidWriter == nil || s.centroidsToMovies == nil {
+		return data.Movie{}, utils.LogError("centroid assignment is unavailable", nil)
+	}
+	centroid, err := s.centroidsToMovies.NearestCentroid(metrics)
+	if err != nil {
+		utils.LogError("err assigning centroid", err)
+		return data.Movie{}, fmt.Errorf("failed to assign a centroid to %s", movieID)
+	}
+	if err := s.centroidWriter.SetMetrics(c, movieID, metrics, centroid); err != nil {
+		if errors.Is(err, ErrMovieNotFound) {
+			return data.Movie{}, err
+		}
+		utils.LogError("err setting movie metrics", err)
+		return data.Movie{}, fmt.Errorf("failed to save metrics for %s", movieID)
+	}
+	s.centroidsToMovies.Assign(movieID, centroid)
+	if s.movieIndex != nil {
+		s.movieIndex.Set(movieID, metrics, centroid)
+	}
+	return data.Movie{ID: movieID, Metrics: metrics, Centroid: centroid}, nil
+}
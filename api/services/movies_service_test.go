@@ -3,10 +3,14 @@ package services_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 
+	"blockbuster/api/api_cache"
 	"blockbuster/api/constants"
 	"blockbuster/api/data"
+	"blockbuster/api/repos"
 	"blockbuster/api/services"
 
 	"github.com/stretchr/testify/assert"
@@ -42,6 +46,26 @@ func (m *MockMovieRepo) GetTrivia(ctx context.Context, movieID string) (data.Mov
 	return args.Get(0).(data.MovieTrivia), args.Error(1)
 }
 
+func (m *MockMovieRepo) GetMoviesTrivia(ctx context.Context, movieIDs []string) ([]data.Movie, error) {
+	args := m.Called(ctx, movieIDs)
+	return args.Get(0).([]data.Movie), args.Error(1)
+}
+
+func (m *MockMovieRepo) SetTrivia(ctx context.Context, movieID string, trivia data.Trivia) error {
+	args := m.Called(ctx, movieID, trivia)
+	return args.Error(0)
+}
+
+func (m *MockMovieRepo) SetCentroid(ctx context.Context, movieID string, centroid int) error {
+	args := m.Called(ctx, movieID, centroid)
+	return args.Error(0)
+}
+
+func (m *MockMovieRepo) SetMetrics(ctx context.Context, movieID string, metrics data.MovieMetrics, centroid int) error {
+	args := m.Called(ctx, movieID, metrics, centroid)
+	return args.Error(0)
+}
+
 func setupMockMovieService() (*services.MoviesService, *MockMovieRepo) {
 	repo := new(MockMovieRepo)
 	service := services.NewMovieserviceWithRepo(repo)
@@ -121,11 +145,11 @@ func TestMovieService_GetMovieMetrics(t *testing.T) {
 func TestGetTrivia_Success(t *testing.T) {
 	service, repo := setupMockMovieService()
 	repo.On("GetTrivia", mock.Anything, "m1").
-		Return(data.MovieTrivia{Trivia: "fact"}, nil)
+		Return(data.MovieTrivia{Trivia: data.Trivia{{Question: "Who?", Answer: "Them"}}}, nil)
 
 	trivia, err := service.GetTrivia(context.Background(), "m1")
 	assert.NoError(t, err)
-	assert.Equal(t, trivia.Trivia, "fact")
+	assert.Equal(t, data.Trivia{{Question: "Who?", Answer: "Them"}}, trivia.Trivia)
 }
 
 func TestGetTrivia_Error(t *testing.T) {
@@ -136,3 +160,218 @@ func TestGetTrivia_Error(t *testing.T) {
 	_, err := service.GetTrivia(context.Background(), "bad")
 	assert.Error(t, err)
 }
+
+func TestSetTrivia_WritesTrimmedTrivia(t *testing.T) {
+	service, repo := setupMockMovieService()
+	service.SetTriviaWriter(repo)
+	want := data.Trivia{{Question: "Who plays Rick?", Answer: "Humphrey Bogart"}}
+	repo.On("SetTrivia", mock.Anything, "casablanca_1942", want).Return(nil)
+
+	trivia, err := service.SetTrivia(context.Background(), "casablanca_1942",
+		data.Trivia{{Question: "  Who plays Rick? ", Answer: " Humphrey Bogart\n"}})
+	assert.NoError(t, err)
+	assert.Equal(t, want, trivia.Trivia)
+	repo.AssertExpectations(t)
+}
+
+func TestSetTrivia_Errors(t *testing.T) {
+	valid := data.Trivia{{Question: "Who plays Rick?", Answer: "Humphrey Bogart"}}
+	tests := []struct {
+		name    string
+		trivia  data.Trivia
+		repoErr error
+		wantErr error
+	}{
+		{"empty", data.Trivia{}, nil, services.ErrInvalidTrivia},
+		{"not a question", data.Trivia{{Question: "Rick's name", Answer: "Rick Blaine"}}, nil, services.ErrInvalidTrivia},
+		{"blank answer", data.Trivia{{Question: "Who plays Rick?", Answer: "  "}}, nil, services.ErrInvalidTrivia},
+		{"duplicate", append(valid, data.TriviaItem{Question: "who plays rick?", Answer: "Bogart"}), nil, services.ErrInvalidTrivia},
+		{"answer too long", data.Trivia{{Question: "Who plays Rick?", Answer: strings.Repeat("é", constants.MAX_TRIVIA_ANSWER_LENGTH+1)}}, nil, services.ErrInvalidTrivia},
+		{"accented answer at the limit", data.Trivia{{Question: "Who plays Rick?", Answer: strings.Repeat("é", constants.MAX_TRIVIA_ANSWER_LENGTH)}}, nil, nil},
+		{"accented question at the limit", data.Trivia{{Question: strings.Repeat("é", constants.MAX_TRIVIA_QUESTION_LENGTH-1) + "?", Answer: "Rick"}}, nil, nil},
+		{"missing movie", valid, repos.ErrMovieNotFound, services.ErrMovieNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := setupMockMovieService()
+			service.SetTriviaWriter(repo)
+			repo.On("SetTrivia", mock.Anything, "casablanca_1942", mock.Anything).Return(tt.repoErr)
+
+			_, err := service.SetTrivia(context.Background(), "casablanca_1942", tt.trivia)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if errors.Is(tt.wantErr, services.ErrInvalidTrivia) {
+				repo.AssertNotCalled(t, "SetTrivia", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+type stubCoRentalCache struct {
+	coRentals []data.CoRental
+}
+
+func (s *stubCoRentalCache) GetAlsoRented(movieID string, k int) []data.CoRental {
+	return s.coRentals[:min(k, len(s.coRentals))]
+}
+
+func (s *stubCoRentalCache) RentalCount(movieID string) int {
+	return 0
+}
+
+func TestGetAlsoRented_Success(t *testing.T) {
+	repo := new(MockMovieRepo)
+	coRentals := &stubCoRentalCache{coRentals: []data.CoRental{
+		{Movie: data.Movie{ID: "aliens"}, Count: 4, Score: 0.8},
+		{Movie: data.Movie{ID: "the_thing"}, Count: 2, Score: 0.5},
+	}}
+	service := services.NewMovieServiceWithDeps(repo, coRentals, nil)
+	repo.On("GetMoviesByID", mock.Anything, []string{"aliens", "the_thing"}, constants.CART).
+		Return([]data.Movie{{ID: "the_thing", Title: "The Thing"}, {ID: "aliens", Title: "Aliens"}}, nil)
+
+	results, err := service.GetAlsoRented(context.Background(), "alien", 5)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "Aliens", results[0].Movie.Title)
+	assert.Equal(t, 4, results[0].Count)
+	assert.Equal(t, "The Thing", results[1].Movie.Title)
+}
+
+func TestGetAlsoRented_Unavailable(t *testing.T) {
+	service, _ := setupMockMovieService()
+	_, err := service.GetAlsoRented(context.Background(), "alien", 5)
+	assert.Error(t, err)
+}
+
+func setupSimilarMovies() (*services.MoviesService, *MockMovieRepo) {
+	repo := new(MockMovieRepo)
+	index := api_cache.NewMovieIndex([]data.Movie{
+		{ID: "alien", Centroid: 1, Metrics: data.MovieMetrics{Horror: 90, Action: 60}},
+		{ID: "aliens", Centroid: 1, Metrics: data.MovieMetrics{Horror: 70, Action: 90}},
+		{ID: "the_thing", Centroid: 2, Metrics: data.MovieMetrics{Horror: 95, Action: 50}},
+		{ID: "annie_hall", Centroid: 3, Metrics: data.MovieMetrics{Comedy: 90, Romance: 80}},
+	})
+	return services.NewMovieServiceWithDeps(repo, nil, index), repo
+}
+
+func TestGetSimilarMovies_NearestInIndex(t *testing.T) {
+	service, repo := setupSimilarMovies()
+	repo.On("GetMoviesByID", mock.Anything, []string{"the_thing", "aliens"}, constants.CART).
+		Return([]data.Movie{{ID: "aliens", Title: "Aliens"}, {ID: "the_thing", Title: "The Thing"}}, nil)
+
+	similar, err := service.GetSimilarMovies(context.Background(), "alien", 2)
+	assert.NoError(t, err)
+	assert.Len(t, similar, 2)
+	assert.Equal(t, "The Thing", similar[0].Movie.Title)
+	assert.Equal(t, 125.0, similar[0].Distance)
+	assert.Equal(t, 2, similar[0].Centroid)
+	assert.Equal(t, "Aliens", similar[1].Movie.Title)
+	assert.Equal(t, 1, similar[1].Centroid)
+	repo.AssertNotCalled(t, "GetMovieMetrics", mock.Anything, mock.Anything)
+}
+
+func TestGetSimilarMovies_NewMovieReadsMetrics(t *testing.T) {
+	service, repo := setupSimilarMovies()
+	repo.On("GetMovieMetrics", mock.Anything, "the_fly").Return(data.MovieMetrics{Horror: 95, Action: 50}, nil)
+	repo.On("GetMoviesByID", mock.Anything, []string{"the_thing"}, constants.CART).
+		Return([]data.Movie{{ID: "the_thing", Title: "The Thing"}}, nil)
+
+	similar, err := service.GetSimilarMovies(context.Background(), "the_fly", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []data.SimilarMovie{{Movie: data.Movie{ID: "the_thing", Title: "The Thing"}, Centroid: 2}}, similar)
+}
+
+func TestGetSimilarMovies_NotFound(t *testing.T) {
+	service, repo := setupSimilarMovies()
+	repo.On("GetMovieMetrics", mock.Anything, "missing").Return(data.MovieMetrics{}, fmt.Errorf("%w: missing", repos.ErrMovieNotFound))
+	repo.On("GetMovieMetrics", mock.Anything, "unrated").Return(data.MovieMetrics{}, fmt.Errorf("%w: unrated", repos.ErrMetricsNotFound))
+
+	_, err := service.GetSimilarMovies(context.Background(), "missing", 5)
+	assert.ErrorIs(t, err, services.ErrMovieNotFound)
+	_, err = service.GetSimilarMovies(context.Background(), "unrated", 5)
+	assert.ErrorIs(t, err, services.ErrNoMetrics)
+	repo.AssertNotCalled(t, "GetMoviesByID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetSimilarMovies_Error(t *testing.T) {
+	service, repo := setupSimilarMovies()
+	repo.On("GetMovieMetrics", mock.Anything, "bad").Return(data.MovieMetrics{}, errors.New("throttled"))
+
+	_, err := service.GetSimilarMovies(context.Background(), "bad", 5)
+	assert.ErrorContains(t, err, "failed to find movies similar to bad")
+}
+
+func setupCentroidAssignment(t *testing.T) (*services.MoviesService, *MockMovieRepo, *api_cache.CentroidsToMoviesCache) {
+	centroids := api_cache.NewCentroidCache(func(ctx context.Context) (map[int]data.MovieMetrics, error) {
+		return map[int]data.MovieMetrics{0: {Comedy: 90}, 1: {ID: 1, Horror: 90}}, nil
+	})
+	assert.NoError(t, centroids.Reload(context.Background()))
+	centroidsToMovies := api_cache.NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		return []data.Movie{{ID: "airplane", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 95}}}, nil
+	}, centroids, nil)
+	assert.NoError(t, centroidsToMovies.Reload(context.Background()))
+
+	repo := new(MockMovieRepo)
+	service := services.NewMovieserviceWithRepo(repo)
+	service.SetCentroidAssignment(repo, centroidsToMovies)
+	return service, repo, centroidsToMovies
+}
+
+func TestSetMovieMetrics_AssignsNearestCentroid(t *testing.T) {
+	service, repo, centroidsToMovies := setupCentroidAssignment(t)
+	metrics := data.MovieMetrics{Horror: 80, Suspense: 60}
+	repo.On("SetMetrics", mock.Anything, "the_thing", metrics, 1).Return(nil)
+
+	movie, err := service.SetMovieMetrics(context.Background(), "the_thing", metrics)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, movie.Centroid)
+	centroid, err := centroidsToMovies.GetCentroidByMovieID("the_thing")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, centroid)
+	repo.AssertExpectations(t)
+}
+
+func TestSetMovieMetrics_UpdatesMovieIndex(t *testing.T) {
+	service, repo, _ := setupCentroidAssignment(t)
+	index := api_cache.NewMovieIndex([]data.Movie{
+		{ID: "the_thing", Centroid: 0, Metrics: data.MovieMetrics{Comedy: 90}},
+		{ID: "alien", Centroid: 1, Metrics: data.MovieMetrics{Horror: 85, Suspense: 60}},
+	})
+	service.SetMovieIndex(index)
+	metrics := data.MovieMetrics{Horror: 80, Suspense: 60}
+	repo.On("SetMetrics", mock.Anything, "the_thing", metrics, 1).Return(nil)
+	repo.On("GetMoviesByID", mock.Anything, []string{"the_thing"}, constants.CART).
+		Return([]data.Movie{{ID: "the_thing", Title: "The Thing"}}, nil)
+
+	_, err := service.SetMovieMetrics(context.Background(), "the_thing", metrics)
+	assert.NoError(t, err)
+	indexed, _ := index.GetMetrics("the_thing")
+	assert.Equal(t, metrics, indexed)
+
+	similar, err := service.GetSimilarMovies(context.Background(), "alien", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, similar[0].Centroid, "neighbours report the movie's new centroid")
+}
+
+func TestSetMovieMetrics_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics data.MovieMetrics
+		repoErr error
+		wantErr error
+	}{
+		{"all zero", data.MovieMetrics{ID: 3}, nil, services.ErrInvalidMetrics},
+		{"negative", data.MovieMetrics{Horror: 80, Comedy: -1}, nil, services.ErrInvalidMetrics},
+		{"missing movie", data.MovieMetrics{Horror: 80}, repos.ErrMovieNotFound, services.ErrMovieNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo, centroidsToMovies := setupCentroidAssignment(t)
+			repo.On("SetMetrics", mock.Anything, "the_thing", mock.Anything, mock.Anything).Return(tt.repoErr)
+
+			_, err := service.SetMovieMetrics(context.Background(), "the_thing", tt.metrics)
+			assert.ErrorIs(t, err, tt.wantErr)
+			_, err = centroidsToMovies.GetCentroidByMovieID("the_thing")
+			assert.Error(t, err, "a failed write leaves the cache alone")
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	graphsearch "blockbuster/api/graph_search"
+	"blockbuster/api/repos"
+	"blockbuster/api/utils"
+)
+
+type PeopleService struct {
+	graph graphsearch.MovieGraphInterface
+	repo  repos.MovieReadRepo
+	index api_cache.MovieIndexInterface
+}
+
+var (
+	instantiatePeopleServiceOnce sync.Once
+	peopleService                *PeopleService
+)
+
+func GetPeopleService() *PeopleService {
+	instantiatePeopleServiceOnce.Do(func() {
+		movieRepo := repos.NewMovieRepoWithDynamo()
+		peopleService = &PeopleService{repo: movieRepo, index: api_cache.InitMovieIndex(movieRepo.GetMoviesByPage)}
+		graph, err := graphsearch.GetMovieGraph()
+		if err != nil {
+			utils.LogError("people service started without a MovieGraph", err)
+			return
+		}
+		peopleService.graph = graph
+	})
+	return peopleService
+}
+
+func NewPeopleServiceWithDeps(graph graphsearch.MovieGraphInterface, repo repos.MovieReadRepo, index api_cache.MovieIndexInterface) *PeopleService {
+	return &PeopleService{graph: graph, repo: repo, index: index}
+}
+
+// GetPerson builds the profile of a star and/or director. The name is resolved against
+// both indexes of the MovieGraph so someone who acts and directs gets a single profile.
+// A *graphsearch.NameNotFoundError is returned when the name matches neither.
+func (s *PeopleService) GetPerson(c context.Context, name string) (data.Person, error) {
+	if s.graph == nil {
+		return data.Person{}, errors.New("movie graph is unavailable")
+	}
+	star, starErr := s.graph.ResolveStar(name)
+	director, directorErr := s.graph.ResolveDirector(name)
+	if starErr != nil && directorErr != nil {
+		return data.Person{}, &graphsearch.NameNotFoundError{
+			Kind:        constants.PERSON,
+			Name:        name,
+			Suggestions: mergeSuggestions(starErr, directorErr),
+		}
+	}
+
+	person := data.Person{Name: star}
+	self := map[string]bool{}
+	credits := make(map[string]*data.Credit)
+	movies := make(map[string]data.Movie)
+	addCredits := func(role string, films []data.Movie) {
+		person.Roles = append(person.Roles, role)
+		for _, movie := range films {
+			if _, ok := credits[movie.ID]; !ok {
+				credits[movie.ID] = &data.Credit{ID: movie.ID, Title: movie.Title, Year: movie.Year}
+				movies[movie.ID] = movie
+			}
+			credits[movie.ID].Roles = append(credits[movie.ID].Roles, role)
+		}
+	}
+	if starErr == nil {
+		self[star] = true
+		addCredits(constants.STAR, s.graph.GetStarredIn(star))
+	}
+	if directorErr == nil {
+		if person.Name == "" {
+			person.Name = director
+		}
+		self[director] = true
+		addCredits(constants.DIRECTOR, s.graph.GetDirectedMovies(director))
+	}
+
+	person.Filmography = sortedCredits(credits)
+	person.Collaborators = topCollaborators(movies, self)
+	person.CareerStart, person.CareerEnd = careerSpan(person.Filmography)
+
+	ids := make([]string, 0, len(person.Filmography))
+	for _, credit := range person.Filmography {
+		ids = append(ids, credit.ID)
+	}
+	person.AverageMetrics = s.averageMetrics(ids)
+	available, err := s.availableTitles(c, ids)
+	if err != nil {
+		utils.LogError(fmt.Sprintf("failed to fetch inventory for %s", person.Name), err)
+		return data.Person{}, fmt.Errorf("failed to fetch available titles for %s", person.Name)
+	}
+	person.AvailableTitles = available
+	return person, nil
+}
+
+// averageMetrics averages the metrics the movie index holds for the given movies. Movies
+// the index does not hold have no metrics and are skipped.
+func (s *PeopleService) averageMetrics(movieIDs []string) data.MovieMetrics {
+	var sum data.MovieMetrics
+	count := 0
+	if s.index == nil {
+		return sum
+	}
+	for _, id := range movieIDs {
+		metrics, ok := s.index.GetMetrics(id)
+		if !ok {
+			continue
+		}
+		sum = utils.AccumulateMovieMetricsWithWeight(sum, metrics, 1)
+		count++
+	}
+	return utils.AverageMetrics(sum, count)
+}
+
+// availableTitles returns the movies with copies in stock, fetched in batches the size
+// DynamoDB allows.
+func (s *PeopleService) availableTitles(c context.Context, movieIDs []string) ([]data.Movie, error) {
+	available := []data.Movie{}
+	for start := 0; start < len(movieIDs); start += constants.BATCH_GET_LIMIT {
+		end := min(start+constants.BATCH_GET_LIMIT, len(movieIDs))
+		movies, err := s.repo.GetMoviesByID(c, movieIDs[start:end], constants.CART)
+		if err != nil {
+			return nil, err
+		}
+		for _, movie := range movies {
+			if movie.Inventory > 0 {
+				available = append(available, movie)
+			}
+		}
+	}
+	sort.Slice(available, func(i, j int) bool { return available[i].Title < available[j].Title })
+	return available, nil
+}
+
+func sortedCredits(credits map[string]*data.Credit) []data.Credit {
+	filmography := make([]data.Credit, 0, len(credits))
+	for _, credit := range credits {
+		filmography = append(filmography, *credit)
+	}
+	sort.Slice(filmography, func(i, j int) bool {
+		if filmography[i].Year != filmography[j].Year {
+			return filmography[i].Year < filmography[j].Year
+		}
+		return filmography[i].Title < filmography[j].Title
+	})
+	return filmography
+}
+
+// topCollaborators counts the movies shared with each co-star and director, once per movie.
+func topCollaborators(movies map[string]data.Movie, self map[string]bool) []data.Collaborator {
+	counts := make(map[data.Collaborator]int)
+	for _, movie := range movies {
+		for _, star := range movie.Cast {
+			if !self[star] {
+				counts[data.Collaborator{Name: star, Role: constants.STAR}]++
+			}
+		}
+		if movie.Director != "" && !self[movie.Director] {
+			counts[data.Collaborator{Name: movie.Director, Role: constants.DIRECTOR}]++
+		}
+	}
+
+	collaborators := make([]data.Collaborator, 0, len(counts))
+	for collaborator, count := range counts {
+		collaborator.Count = count
+		collaborators = append(collaborators, collaborator)
+	}
+	sort.Slice(collaborators, func(i, j int) bool {
+		if collaborators[i].Count != collaborators[j].Count {
+			return collaborators[i].Count > collaborators[j].Count
+		}
+		if collaborators[i].Name != collaborators[j].Name {
+			return collaborators[i].Name < collaborators[j].Name
+		}
+		return collaborators[i].Role < collaborators[j].Role
+	})
+	return collaborators[:min(len(collaborators), constants.MAX_COLLABORATORS)]
+}
+
+func careerSpan(filmography []data.Credit) (int, int) {
+	start, end := 0, 0
+	for _, credit := range filmography {
+		year, err := strconv.Atoi(credit.Year)
+		if err != nil {
+			continue
+		}
+		if start == 0 || year < start {
+			start = year
+		}
+		end = max(end, year)
+	}
+	return start, end
+}
+
+func mergeSuggestions(errs ...error) []string {
+	seen := make(map[string]bool)
+	var suggestions []string
+	for _, err := range errs {
+		var notFound *graphsearch.NameNotFoundError
+		if !errors.As(err, &notFound) {
+			continue
+		}
+		for _, suggestion := range notFound.Suggestions {
+			if !seen[suggestion] {
+				seen[suggestion] = true
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+	}
+	return suggestions[:min(len(suggestions), constants.MAX_NAME_SUGGESTIONS)]
+}
@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	graphsearch "blockbuster/api/graph_search"
+	"blockbuster/api/services"
+)
+
+func setupMockPeopleService() (*services.PeopleService, *graphsearch.MockMovieGraph, *MockMovieRepo) {
+	graph := new(graphsearch.MockMovieGraph)
+	repo := new(MockMovieRepo)
+	index := api_cache.NewMovieIndex([]data.Movie{
+		{ID: "apollo_13_1995", Metrics: data.MovieMetrics{Drama: 8}},
+		{ID: "that_thing_you_do_1996", Metrics: data.MovieMetrics{Drama: 4}},
+	})
+	return services.NewPeopleServiceWithDeps(graph, repo, index), graph, repo
+}
+
+func TestGetPerson_StarAndDirector(t *testing.T) {
+	service, graph, repo := setupMockPeopleService()
+	acted := []data.Movie{
+		{ID: "apollo_13_1995", Title: "Apollo 13", Year: "1995", Director: "Ron Howard", Cast: []string{"Tom Hanks", "Kevin Bacon"}},
+		{ID: "that_thing_you_do_1996", Title: "That Thing You Do!", Year: "1996", Director: "Tom Hanks", Cast: []string{"Tom Hanks", "Liv Tyler"}},
+	}
+	directed := acted[1:]
+	graph.On("ResolveStar", "tom hanks").Return("Tom Hanks", nil)
+	graph.On("ResolveDirector", "tom hanks").Return("Tom Hanks", nil)
+	graph.On("GetStarredIn", "Tom Hanks").Return(acted)
+	graph.On("GetDirectedMovies", "Tom Hanks").Return(directed)
+	repo.On("GetMoviesByID", mock.Anything, []string{"apollo_13_1995", "that_thing_you_do_1996"}, constants.CART).
+		Return([]data.Movie{{ID: "apollo_13_1995", Title: "Apollo 13", Inventory: 2}, {ID: "that_thing_you_do_1996", Inventory: 0}}, nil)
+
+	person, err := service.GetPerson(context.Background(), "tom hanks")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Tom Hanks", person.Name)
+	assert.Equal(t, []string{constants.STAR, constants.DIRECTOR}, person.Roles)
+	assert.Len(t, person.Filmography, 2)
+	assert.Equal(t, "apollo_13_1995", person.Filmography[0].ID)
+	assert.Equal(t, []string{constants.STAR, constants.DIRECTOR}, person.Filmography[1].Roles)
+	assert.Equal(t, 1995, person.CareerStart)
+	assert.Equal(t, 1996, person.CareerEnd)
+	assert.Equal(t, 6.0, person.AverageMetrics.Drama)
+	repo.AssertNotCalled(t, "GetMovieMetrics", mock.Anything, mock.Anything)
+	assert.Len(t, person.AvailableTitles, 1)
+	assert.ElementsMatch(t, []data.Collaborator{
+		{Name: "Kevin Bacon", Role: constants.STAR, Count: 1},
+		{Name: "Liv Tyler", Role: constants.STAR, Count: 1},
+		{Name: "Ron Howard", Role: constants.DIRECTOR, Count: 1},
+	}, person.Collaborators)
+}
+
+func TestGetPerson_NotFound(t *testing.T) {
+	service, graph, _ := setupMockPeopleService()
+	graph.On("ResolveStar", "Tom Hankz").Return("", &graphsearch.NameNotFoundError{Suggestions: []string{"Tom Hanks"}})
+	graph.On("ResolveDirector", "Tom Hankz").Return("", &graphsearch.NameNotFoundError{Suggestions: []string{"Tom Hanks", "Tom Hooper"}})
+
+	_, err := service.GetPerson(context.Background(), "Tom Hankz")
+
+	var notFound *graphsearch.NameNotFoundError
+	assert.True(t, errors.As(err, &notFound))
+	assert.Equal(t, []string{"Tom Hanks", "Tom Hooper"}, notFound.Suggestions)
+}
+
+func TestGetPerson_InventoryError(t *testing.T) {
+	service, graph, repo := setupMockPeopleService()
+	graph.On("ResolveStar", "Ron Howard").Return("", errors.New("not found"))
+	graph.On("ResolveDirector", "Ron Howard").Return("Ron Howard", nil)
+	graph.On("GetDirectedMovies", "Ron Howard").Return([]data.Movie{{ID: "apollo_13_1995", Year: "1995"}})
+	repo.On("GetMoviesByID", mock.Anything, []string{"apollo_13_1995"}, constants.CART).Return([]data.Movie{}, errors.New("db error"))
+
+	_, err := service.GetPerson(context.Background(), "Ron Howard")
+	assert.ErrorContains(t, err, "failed to fetch available titles")
+}
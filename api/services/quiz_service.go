@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+	"blockbuster/api/utils"
+)
+
+var (
+	ErrInvalidQuiz     = errors.New("invalid quiz options")
+	ErrNotEnoughTrivia = errors.New("not enough trivia to draw a quiz")
+	ErrInvalidAnswers  = errors.New("answers must pick one of each question's choices, or -1 to skip it")
+)
+
+// QuizService runs the in-store trivia game. Questions come from the trivia of the movies a
+// member rented or of a centroid's movies, and the wrong choices of each are answers to other
+// movies' trivia, drawn from the same centroids so they stay plausible.
+type QuizService struct {
+	members           repos.MemberRepoInterface
+	movies            repos.MovieTriviaRepo
+	centroidsToMovies api_cache.CentroidsToMoviesCacheInterface
+	quizzes           api_cache.QuizCacheInterface
+	scores            repos.QuizScoreRepo
+	leaderboard       api_cache.QuizLeaderboardInterface
+	newSeed           func() int64
+}
+
+var (
+	instantiateQuizServiceOnce sync.Once
+	quizService                *QuizService
+)
+
+func GetQuizService() *QuizService {
+	instantiateQuizServiceOnce.Do(func() {
+		_, centroidsToMovies := repos.NewCentroidCachesWithDynamo()
+		quizService = &QuizService{
+			members:           repos.NewMemberRepoWithDynamo(),
+			movies:            repos.NewMovieRepoWithDynamo(),
+			centroidsToMovies: centroidsToMovies,
+			quizzes:           api_cache.GetQuizCache(),
+			scores:            repos.NewQuizScoreRepoWithDynamo(),
+			leaderboard:       repos.NewQuizLeaderboardWithDynamo(),
+			newSeed:           utils.NewSeed,
+		}
+	})
+	return quizService
+}
+
+func NewQuizServiceWithDeps(members repos.MemberRepoInterface, movies repos.MovieTriviaRepo, centroidsToMovies api_cache.CentroidsToMoviesCacheInterface,
+	quizzes api_cache.QuizCacheInterface, scores repos.QuizScoreRepo, leaderboard api_cache.QuizLeaderboardInterface) *QuizService {
+	return &QuizService{
+		members:           members,
+		movies:            movies,
+		centroidsToMovies: centroidsToMovies,
+		quizzes:           quizzes,
+		scores:            scores,
+		leaderboard:       leaderboard,
+		newSeed:           utils.NewSeed,
+	}
+}
+
+// SetSeedSource replaces where quizzes get the seed they are drawn from.
+func (s *QuizService) SetSeedSource(newSeed func() int64) {
+	s.newSeed = newSeed
+}
+
+// StartQuiz draws up to opts.NumQuestions questions for the member, spread across as many
+// movies as possible. A quiz may come back shorter when there isn't enough trivia, or
+// enough other answers to choose from, for every question asked for.
+func (s *QuizService) StartQuiz(c context.Context, opts data.QuizOptions) (data.Quiz, error) {
+	numQuestions := opts.NumQuestions
+	if numQuestions == 0 {
+		numQuestions = constants.DEFAULT_QUIZ_QUESTIONS
+	}
+	if numQuestions < 0 || numQuestions > constants.MAX_QUIZ_QUESTIONS {
+		return data.Quiz{}, fmt.Errorf("%w: numQuestions must be between 1 and %d", ErrInvalidQuiz, constants.MAX_QUIZ_QUESTIONS)
+	}
+	source := opts.Source
+	if source == "" {
+		source = constants.QUIZ_SOURCE_RENTED
+	}
+	if strings.TrimSpace(opts.Username) == "" {
+		return data.Quiz{}, fmt.Errorf("%w: a username is required", ErrUnknownMember)
+	}
+	member, err := s.members.GetMemberByUsername(c, opts.Username, constants.NOT_CART)
+	if errors.Is(err, repos.ErrMemberNotFound) {
+		return data.Quiz{}, fmt.Errorf("%w: %s", ErrUnknownMember, opts.Username)
+	}
+	if err != nil {
+		utils.LogError(fmt.Sprintf("failed to retrieve user %s for quiz", opts.Username), err)
+		return data.Quiz{}, fmt.Errorf("failed to start a quiz for %s", opts.Username)
+	}
+
+	rng := rand.New(rand.NewSource(s.newSeed()))
+	var askable, others []string
+	switch source {
+	case constants.QUIZ_SOURCE_RENTED:
+		askable, others = s.rentedQuizMovies(member, rng)
+	case constants.QUIZ_SOURCE_CENTROID:
+		if opts.Centroid == nil {
+			return data.Quiz{}, fmt.Errorf("%w: a centroid is required for centroid quizzes", ErrInvalidQuiz)
+		}
+		movieIDs, err := s.centroidsToMovies.GetMovieIDsByCentroid(*opts.Centroid)
+		if err != nil {
+			return data.Quiz{}, fmt.Errorf("%w: unknown centroid %d", ErrInvalidQuiz, *opts.Centroid)
+		}
+		movieIDs = shuffled(movieIDs, rng)
+		split := min(len(movieIDs), constants.QUIZ_TRIVIA_MOVIES/2)
+		askable, others = movieIDs[:split], movieIDs[split:]
+	default:
+		return data.Quiz{}, fmt.Errorf("%w: source must be %s or %s", ErrInvalidQuiz, constants.QUIZ_SOURCE_RENTED, constants.QUIZ_SOURCE_CENTROID)
+	}
+	if len(askable) == 0 {
+		return data.Quiz{}, ErrNotEnoughTrivia
+	}
+
+	movies := s.loadTrivia(c, slices.Concat(askable, others))
+	questions, answers := drawQuestions(movies, askable, numQuestions, rng)
+	if len(questions) == 0 {
+		return data.Quiz{}, ErrNotEnoughTrivia
+	}
+	quiz := data.Quiz{Username: opts.Username, Source: source, Questions: questions, Answers: answers}
+	if source == constants.QUIZ_SOURCE_CENTROID {
+		quiz.Centroid = opts.Centroid
+	}
+	quiz, err = s.quizzes.Create(quiz)
+	if err != nil {
+		return data.Quiz{}, utils.LogError("failed to create quiz", err)
+	}
+	return quiz, nil
+}
+
+// rentedQuizMovies returns the member's rentals to ask about, and the other movies of their
+// centroids to draw wrong answers from.
+func (s *QuizService) rentedQuizMovies(member data.Member, rng *rand.Rand) (askable, others []string) {
+	rented := slices.Compact(slices.Sorted(slices.Values(append(slices.Clone(member.Rented), member.Checkedout...))))
+	rented = shuffled(rented, rng)
+	split := min(len(rented), constants.QUIZ_TRIVIA_MOVIES/2)
+	askable = rented[:split]
+
+	var neighbours []string
+	seen := make(map[int]bool)
+	for _, movieID := range askable {
+		centroid, err := s.centroidsToMovies.GetCentroidByMovieID(movieID)
+		if err != nil || seen[centroid] {
+			continue
+		}
+		seen[centroid] = true
+		movieIDs, err := s.centroidsToMovies.GetMovieIDsByCentroid(centroid)
+		if err != nil {
+			continue
+		}
+		for _, mid := range movieIDs {
+			if !slices.Contains(rented, mid) {
+				neighbours = append(neighbours, mid)
+			}
+		}
+	}
+	return askable, slices.Concat(rented[split:], shuffled(neighbours, rng))
+}
+
+// loadTrivia fetches the title and trivia of up to QUIZ_TRIVIA_MOVIES of movieIDs, in order.
+// It is best effort: a batch that fails is logged and left out.
+func (s *QuizService) loadTrivia(c context.Context, movieIDs []string) []data.Movie {
+	movieIDs = movieIDs[:min(len(movieIDs), constants.QUIZ_TRIVIA_MOVIES)]
+	byID := make(map[string]data.Movie, len(movieIDs))
+	for start := 0; start < len(movieIDs); start += constants.BATCH_GET_LIMIT {
+		batch, err := s.movies.GetMoviesTrivia(c, movieIDs[start:min(start+constants.BATCH_GET_LIMIT, len(movieIDs))])
+		if err != nil {
+			utils.LogError("err fetching trivia for quiz", err)
+			continue
+		}
+		for _, movie := range batch {
+			byID[movie.ID] = movie
+		}
+	}
+	movies := make([]data.Movie, 0, len(byID))
+	for _, movieID := range movieIDs {
+		if movie, ok := byID[movieID]; ok && len(movie.Trivia) > 0 {
+			movies = append(movies, movie)
+		}
+	}
+	return movies
+}
+
+type triviaAnswer struct {
+	movieID string
+	answer  string
+}
+
+// drawQuestions asks up to n questions about the askable movies, taking one question from
+// each in turn so the quiz covers as many movies as it can. Every question gets
+// QUIZ_CHOICES-1 wrong choices from other movies' answers and is skipped when there aren't
+// enough. answers holds the index of the right choice of each question.
+func drawQuestions(movies []data.Movie, askable []string, n int, rng *rand.Rand) (questions []data.QuizQuestion, answers []int) {
+	var pool []triviaAnswer
+	var asked []data.Movie
+	for _, movie := range movies {
+		for _, item := range movie.Trivia {
+			pool = append(pool, triviaAnswer{movieID: movie.ID, answer: item.Answer})
+		}
+		if slices.Contains(askable, movie.ID) {
+			movie.Trivia = shuffled(movie.Trivia, rng)
+			asked = append(asked, movie)
+		}
+	}
+
+	for round := 0; len(questions) < n; round++ {
+		drawn := false
+		for _, movie := range asked {
+			if round >= len(movie.Trivia) || len(questions) == n {
+				continue
+			}
+			drawn = true
+			item := movie.Trivia[round]
+			choices := pickDistractors(pool, movie.ID, item.Answer, constants.QUIZ_CHOICES-1, rng)
+			if len(choices) < constants.QUIZ_CHOICES-1 {
+				continue
+			}
+			choices = shuffled(append(choices, item.Answer), rng)
+			questions = append(questions, data.QuizQuestion{
+				MovieID:  movie.ID,
+				Title:    movie.Title,
+				Question: item.Question,
+				Choices:  choices,
+			})
+			answers = append(answers, slices.Index(choices, item.Answer))
+		}
+		if !drawn {
+			break
+		}
+	}
+	return questions, answers
+}
+
+// pickDistractors draws up to k distinct answers from pool that belong to other movies and
+// can't be mistaken for answer.
+func pickDistractors(pool []triviaAnswer, movieID, answer string, k int, rng *rand.Rand) []string {
+	distractors := make([]string, 0, k+1)
+	for _, i := range rng.Perm(len(pool)) {
+		if len(distractors) == k {
+			break
+		}
+		candidate := pool[i]
+		if candidate.movieID == movieID || strings.EqualFold(strings.TrimSpace(candidate.answer), strings.TrimSpace(answer)) {
+			continue
+		}
+		if slices.ContainsFunc(distractors, func(d string) bool { return strings.EqualFold(d, candidate.answer) }) {
+			continue
+		}
+		distractors = append(distractors, candidate.answer)
+	}
+	return distractors
+}
+
+func shuffled[T any](items []T, rng *rand.Rand) []T {
+	out := slices.Clone(items)
+	rng.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	return out
+}
+
+// GetQuiz returns a quiz still waiting for its answers, without them.
+func (s *QuizService) GetQuiz(c context.Context, quizID string) (data.Quiz, error) {
+	return s.quizzes.Get(quizID)
+}
+
+// SubmitQuiz grades answers, the index of the choice picked for each question in order or
+// -1 to skip one, and adds the quiz to the member's totals. A quiz is graded only once.
+// Saving the score is best effort: when it fails the graded quiz is still returned, with
+// only the username of Stats set.
+func (s *QuizService) SubmitQuiz(c context.Context, quizID string, answers []int) (data.QuizResult, error) {
+	quiz, err := s.quizzes.Get(quizID)
+	if err != nil {
+		return data.QuizResult{}, err
+	}
+	if len(answers) > len(quiz.Questions) {
+		return data.QuizResult{}, fmt.Errorf("%w: the quiz has %d questions", ErrInvalidAnswers, len(quiz.Questions))
+	}
+	for i, chosen := range answers {
+		if chosen < -1 || chosen >= len(quiz.Questions[i].Choices) {
+			return data.QuizResult{}, fmt.Errorf("%w: question %d has no choice %d", ErrInvalidAnswers, i+1, chosen)
+		}
+	}
+	quiz, err = s.quizzes.Take(quizID)
+	if err != nil {
+		return data.QuizResult{}, err
+	}
+
+	result := gradeQuiz(quiz, answers)
+	stats, err := s.scores.RecordQuizScore(c, quiz.Username, result.Correct, result.Total)
+	if err != nil {
+		utils.LogError(fmt.Sprintf("failed to record quiz score of %s", quiz.Username), err)
+		result.Stats = data.QuizStats{Username: quiz.Username}
+		return result, nil
+	}
+	result.Stats = s.leaderboard.Record(stats)
+	return result, nil
+}
+
+// gradeQuiz scores answers against the quiz. Questions without an answer count as skipped.
+func gradeQuiz(quiz data.Quiz, answers []int) data.QuizResult {
+	result := data.QuizResult{
+		QuizID:   quiz.ID,
+		Username: quiz.Username,
+		Total:    len(quiz.Questions),
+		Graded:   make([]data.GradedAnswer, len(quiz.Questions)),
+	}
+	for i, question := range quiz.Questions {
+		chosen := -1
+		if i < len(answers) {
+			chosen = answers[i]
+		}
+		correct := chosen == quiz.Answers[i]
+		if correct {
+			result.Correct++
+		}
+		result.Graded[i] = data.GradedAnswer{Question: question.Question, Chosen: chosen, Answer: quiz.Answers[i], Correct: correct}
+	}
+	return result
+}
+
+// GetQuizLeaderboard returns the top limit members by quiz points.
+func (s *QuizService) GetQuizLeaderboard(c context.Context, limit int) ([]data.QuizStats, error) {
+	if limit <= 0 {
+		limit = constants.DEFAULT_QUIZ_LEADERBOARD
+	}
+	leaders, err := s.leaderboard.Top(c, limit)
+	if err != nil {
+		utils.LogError("err fetching quiz leaderboard", err)
+		return nil, errors.New("failed to load the quiz leaderboard")
+	}
+	return leaders, nil
+}
+
+// GetQuizStats returns a member's quiz totals and leaderboard rank. Members who have never
+// played have zero totals and no rank.
+func (s *QuizService) GetQuizStats(c context.Context, username string) (data.QuizStats, error) {
+	_, err := s.members.GetMemberByUsername(c, username, constants.NOT_CART)
+	if errors.Is(err, repos.ErrMemberNotFound) {
+		return data.QuizStats{}, fmt.Errorf("%w: %s", ErrUnknownMember, username)
+	}
+	if err != nil {
+		utils.LogError(fmt.Sprintf("failed to retrieve user %s for quiz stats", username), err)
+		return data.QuizStats{}, fmt.Errorf("failed to load quiz stats for %s", username)
+	}
+	stats, err := s.leaderboard.Stats(c, username)
+	if err != nil {
+		utils.LogError("err fetching quiz leaderboard", err)
+		return data.QuizStats{}, fmt.Errorf("failed to load quiz stats for %s", username)
+	}
+	return stats, nil
+}
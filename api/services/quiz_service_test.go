@@ -0,0 +1,330 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+	"blockbuster/api/services"
+)
+
+type MockQuizScoreRepo struct {
+	mock.Mock
+}
+
+func (m *MockQuizScoreRepo) RecordQuizScore(ctx context.Context, username string, correct, asked int) (data.QuizStats, error) {
+	args := m.Called(ctx, username, correct, asked)
+	return args.Get(0).(data.QuizStats), args.Error(1)
+}
+
+func (m *MockQuizScoreRepo) GetQuizScores(ctx context.Context) ([]data.QuizStats, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]data.QuizStats), args.Error(1)
+}
+
+// stubTriviaRepo serves trivia from memory and records how many movies each batch asked for.
+type stubTriviaRepo struct {
+	movies  map[string]data.Movie
+	batches []int
+}
+
+func (s *stubTriviaRepo) GetMoviesTrivia(ctx context.Context, movieIDs []string) ([]data.Movie, error) {
+	s.batches = append(s.batches, len(movieIDs))
+	movies := make([]data.Movie, 0, len(movieIDs))
+	for _, mid := range movieIDs {
+		if movie, ok := s.movies[mid]; ok {
+			movies = append(movies, movie)
+		}
+	}
+	return movies, nil
+}
+
+func (s *stubTriviaRepo) SetTrivia(ctx context.Context, movieID string, trivia data.Trivia) error {
+	return errors.New("read only")
+}
+
+// quizCatalog has perCentroid movies in each of centroids 0 and 1, each with two trivia
+// questions whose answers name the movie.
+func quizCatalog(t *testing.T, perCentroid int) (*stubTriviaRepo, *api_cache.CentroidsToMoviesCache) {
+	repo := &stubTriviaRepo{movies: make(map[string]data.Movie)}
+	var movies []data.Movie
+	for centroid := range 2 {
+		for i := range perCentroid {
+			id := fmt.Sprintf("c%d_m%d", centroid, i)
+			movie := data.Movie{ID: id, Title: strings.ToUpper(id), Centroid: centroid, Metrics: data.MovieMetrics{Drama: float64(centroid*100 + i + 1)}}
+			movie.Trivia = data.Trivia{
+				{Question: "Who directed " + id + "?", Answer: "Director of " + id},
+				{Question: "When was " + id + " made?", Answer: "Year of " + id},
+			}
+			repo.movies[id] = movie
+			movies = append(movies, movie)
+		}
+	}
+	centroids := api_cache.NewCentroidCache(func(ctx context.Context) (map[int]data.MovieMetrics, error) {
+		return map[int]data.MovieMetrics{0: {Drama: 1}, 1: {ID: 1, Drama: 100}}, nil
+	})
+	assert.NoError(t, centroids.Reload(context.Background()))
+	centroidsToMovies := api_cache.NewCentroidsToMoviesCache(func(ctx context.Context) ([]data.Movie, error) {
+		return movies, nil
+	}, centroids, nil)
+	assert.NoError(t, centroidsToMovies.Reload(context.Background()))
+	return repo, centroidsToMovies
+}
+
+func setupQuizService(t *testing.T, perCentroid int) (*services.QuizService, *MockMemberRepo, *MockQuizScoreRepo, *stubTriviaRepo) {
+	trivia, centroidsToMovies := quizCatalog(t, perCentroid)
+	members := new(MockMemberRepo)
+	scores := new(MockQuizScoreRepo)
+	quizzes := api_cache.NewQuizCache(constants.QUIZ_TTL_MINUTES*time.Minute, time.Now)
+	leaderboard := api_cache.NewQuizLeaderboardCache(scores.GetQuizScores)
+	service := services.NewQuizServiceWithDeps(members, trivia, centroidsToMovies, quizzes, scores, leaderboard)
+	service.SetSeedSource(func() int64 { return testSeed })
+	return service, members, scores, trivia
+}
+
+func rightAnswer(t *testing.T, trivia *stubTriviaRepo, question data.QuizQuestion) string {
+	for _, item := range trivia.movies[question.MovieID].Trivia {
+		if item.Question == question.Question {
+			return item.Answer
+		}
+	}
+	t.Fatalf("%q is not trivia of %s", question.Question, question.MovieID)
+	return ""
+}
+
+func TestStartQuiz_FromRentals(t *testing.T) {
+	service, members, _, trivia := setupQuizService(t, 6)
+	members.On("GetMemberByUsername", mock.Anything, "alice", constants.NOT_CART).
+		Return(data.Member{Username: "alice", Rented: []string{"c0_m0"}, Checkedout: []string{"c0_m1", "c0_m0"}}, nil)
+
+	quiz, err := service.StartQuiz(context.Background(), data.QuizOptions{Username: "alice", NumQuestions: 4})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, quiz.ID)
+	assert.Equal(t, constants.QUIZ_SOURCE_RENTED, quiz.Source)
+	assert.Len(t, quiz.Questions, 4)
+
+	var asked []string
+	for _, question := range quiz.Questions {
+		asked = append(asked, question.MovieID)
+		assert.Equal(t, strings.ToUpper(question.MovieID), question.Title)
+		assert.Len(t, question.Choices, constants.QUIZ_CHOICES)
+		assert.Len(t, slices.Compact(slices.Sorted(slices.Values(question.Choices))), constants.QUIZ_CHOICES, "choices are distinct")
+		assert.Contains(t, question.Choices, rightAnswer(t, trivia, question))
+		for _, choice := range question.Choices {
+			if choice != rightAnswer(t, trivia, question) {
+				assert.NotContains(t, choice, question.MovieID, "wrong choices come from other movies")
+				assert.Contains(t, choice, "c0_", "wrong choices come from the rentals' centroid")
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"c0_m0", "c0_m0", "c0_m1", "c0_m1"}, asked)
+	assert.NotEqual(t, asked[0], asked[1], "questions take turns between movies")
+}
+
+func TestStartQuiz_FromCentroid(t *testing.T) {
+	service, members, _, trivia := setupQuizService(t, 40)
+	members.On("GetMemberByUsername", mock.Anything, "alice", constants.NOT_CART).Return(data.Member{Username: "alice"}, nil)
+	centroid := 1
+
+	quiz, err := service.StartQuiz(context.Background(), data.QuizOptions{Username: "alice", Source: constants.QUIZ_SOURCE_CENTROID, Centroid: &centroid})
+	assert.NoError(t, err)
+	assert.Equal(t, &centroid, quiz.Centroid)
+	assert.Len(t, quiz.Questions, constants.DEFAULT_QUIZ_QUESTIONS)
+	for _, question := range quiz.Questions {
+		assert.True(t, strings.HasPrefix(question.MovieID, "c1_"))
+	}
+	assert.Equal(t, []int{10, 10, 10}, trivia.batches, "trivia is loaded in batches up to QUIZ_TRIVIA_MOVIES")
+}
+
+func TestStartQuiz_SameSeedSameQuiz(t *testing.T) {
+	service, members, _, _ := setupQuizService(t, 20)
+	members.On("GetMemberByUsername", mock.Anything, "alice", constants.NOT_CART).Return(data.Member{Username: "alice"}, nil)
+	centroid := 0
+	opts := data.QuizOptions{Username: "alice", Source: constants.QUIZ_SOURCE_CENTROID, Centroid: &centroid}
+
+	first, err := service.StartQuiz(context.Background(), opts)
+	assert.NoError(t, err)
+	second, err := service.StartQuiz(context.Background(), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, first.Questions, second.Questions)
+	assert.NotEqual(t, first.ID, second.ID)
+}
+
+func TestStartQuiz_Errors(t *testing.T) {
+	centroid, unknownCentroid := 0, 9
+	tests := []struct {
+		name        string
+		opts        data.QuizOptions
+		perCentroid int
+		member      data.Member
+		memberErr   error
+		wantErr     error
+	}{
+		{"no username", data.QuizOptions{}, 6, data.Member{}, nil, services.ErrUnknownMember},
+		{"unknown member", data.QuizOptions{Username: "alice"}, 6, data.Member{}, fmt.Errorf("user alice not found: %w", repos.ErrMemberNotFound), services.ErrUnknownMember},
+		{"member lookup fails", data.QuizOptions{Username: "alice"}, 6, data.Member{}, errors.New("dynamo unavailable"), nil},
+		{"too many questions", data.QuizOptions{Username: "alice", NumQuestions: constants.MAX_QUIZ_QUESTIONS + 1}, 6, data.Member{}, nil, services.ErrInvalidQuiz},
+		{"unknown source", data.QuizOptions{Username: "alice", Source: "random"}, 6, data.Member{}, nil, services.ErrInvalidQuiz},
+		{"no centroid", data.QuizOptions{Username: "alice", Source: constants.QUIZ_SOURCE_CENTROID}, 6, data.Member{}, nil, services.ErrInvalidQuiz},
+		{"unknown centroid", data.QuizOptions{Username: "alice", Source: constants.QUIZ_SOURCE_CENTROID, Centroid: &unknownCentroid}, 6, data.Member{}, nil, services.ErrInvalidQuiz},
+		{"no rentals", data.QuizOptions{Username: "alice"}, 6, data.Member{Username: "alice"}, nil, services.ErrNotEnoughTrivia},
+		{"rentals without trivia", data.QuizOptions{Username: "alice"}, 6, data.Member{Username: "alice", Rented: []string{"unknown"}}, nil, services.ErrNotEnoughTrivia},
+		{"too few other answers", data.QuizOptions{Username: "alice", Source: constants.QUIZ_SOURCE_CENTROID, Centroid: &centroid}, 1, data.Member{}, nil, services.ErrNotEnoughTrivia},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, members, _, _ := setupQuizService(t, tt.perCentroid)
+			members.On("GetMemberByUsername", mock.Anything, "alice", constants.NOT_CART).Return(tt.member, tt.memberErr)
+
+			_, err := service.StartQuiz(context.Background(), tt.opts)
+			if tt.wantErr == nil {
+				assert.Error(t, err)
+				assert.NotErrorIs(t, err, services.ErrUnknownMember)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestSubmitQuiz_GradesAndRecordsScore(t *testing.T) {
+	service, members, scores, trivia := setupQuizService(t, 6)
+	members.On("GetMemberByUsername", mock.Anything, "alice", constants.NOT_CART).
+		Return(data.Member{Username: "alice", Rented: []string{"c0_m0", "c0_m1"}}, nil)
+	quiz, err := service.StartQuiz(context.Background(), data.QuizOptions{Username: "alice", NumQuestions: 3})
+	assert.NoError(t, err)
+
+	right := slices.Index(quiz.Questions[0].Choices, rightAnswer(t, trivia, quiz.Questions[0]))
+	wrong := (slices.Index(quiz.Questions[1].Choices, rightAnswer(t, trivia, quiz.Questions[1])) + 1) % constants.QUIZ_CHOICES
+	stats := data.QuizStats{Username: "alice", Points: 11, Asked: 30, Played: 10}
+	scores.On("RecordQuizScore", mock.Anything, "alice", 1, 3).Return(stats, nil)
+
+	result, err := service.SubmitQuiz(context.Background(), quiz.ID, []int{right, wrong})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Correct)
+	assert.Equal(t, 3, result.Total)
+	assert.Equal(t, stats, result.Stats)
+	assert.True(t, result.Graded[0].Correct)
+	assert.Equal(t, wrong, result.Graded[1].Chosen)
+	assert.False(t, result.Graded[1].Correct)
+	assert.Equal(t, -1, result.Graded[2].Chosen, "missing answers are skipped")
+	scores.AssertExpectations(t)
+
+	_, err = service.SubmitQuiz(context.Background(), quiz.ID, []int{right})
+	assert.ErrorIs(t, err, api_cache.ErrQuizNotFound, "a quiz is graded once")
+}
+
+func TestSubmitQuiz_InvalidAnswers(t *testing.T) {
+	service, members, scores, _ := setupQuizService(t, 6)
+	members.On("GetMemberByUsername", mock.Anything, "alice", constants.NOT_CART).
+		Return(data.Member{Username: "alice", Rented: []string{"c0_m0"}}, nil)
+	quiz, err := service.StartQuiz(context.Background(), data.QuizOptions{Username: "alice", NumQuestions: 2})
+	assert.NoError(t, err)
+
+	for _, answers := range [][]int{{0, 0, 0}, {constants.QUIZ_CHOICES}, {-2}} {
+		_, err := service.SubmitQuiz(context.Background(), quiz.ID, answers)
+		assert.ErrorIs(t, err, services.ErrInvalidAnswers, "%v", answers)
+	}
+	scores.AssertNotCalled(t, "RecordQuizScore", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	scores.On("RecordQuizScore", mock.Anything, "alice", mock.Anything, 2).Return(data.QuizStats{}, errors.New("throttled"))
+	result, err := service.SubmitQuiz(context.Background(), quiz.ID, nil)
+	assert.NoError(t, err, "an invalid submission leaves the quiz open, and a lost score still grades")
+	assert.Equal(t, data.QuizStats{Username: "alice"}, result.Stats)
+}
+
+func TestGetQuizLeaderboard_RanksByPoints(t *testing.T) {
+	service, _, scores, _ := setupQuizService(t, 1)
+	scores.On("GetQuizScores", mock.Anything).Return([]data.QuizStats{
+		{Username: "dave", Points: 5, Asked: 10},
+		{Username: "bob", Points: 9, Asked: 20},
+		{Username: "carol", Points: 9, Asked: 10},
+		{Username: "alice", Points: 12, Asked: 15},
+	}, nil)
+
+	leaderboard, err := service.GetQuizLeaderboard(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []data.QuizStats{
+		{Username: "alice", Points: 12, Asked: 15, Rank: 1},
+		{Username: "carol", Points: 9, Asked: 10, Rank: 2},
+		{Username: "bob", Points: 9, Asked: 20, Rank: 2},
+	}, leaderboard)
+
+	_, err = service.GetQuizLeaderboard(context.Background(), 3)
+	assert.NoError(t, err)
+	scores.AssertNumberOfCalls(t, "GetQuizScores", 1)
+}
+
+func TestGetQuizLeaderboard_LoadFails(t *testing.T) {
+	service, _, scores, _ := setupQuizService(t, 1)
+	scores.On("GetQuizScores", mock.Anything).Return([]data.QuizStats{}, errors.New("throttled")).Once()
+	_, err := service.GetQuizLeaderboard(context.Background(), 3)
+	assert.ErrorContains(t, err, "failed to load the quiz leaderboard")
+
+	scores.On("GetQuizScores", mock.Anything).Return([]data.QuizStats{{Username: "alice", Points: 1, Asked: 1, Played: 1}}, nil)
+	leaderboard, err := service.GetQuizLeaderboard(context.Background(), 3)
+	assert.NoError(t, err, "a failed load is retried on the next read")
+	assert.Len(t, leaderboard, 1)
+}
+
+func TestSubmitQuiz_UpdatesLeaderboard(t *testing.T) {
+	service, members, scores, _ := setupQuizService(t, 6)
+	members.On("GetMemberByUsername", mock.Anything, "bob", constants.NOT_CART).
+		Return(data.Member{Username: "bob", Rented: []string{"c0_m0"}}, nil)
+	scores.On("GetQuizScores", mock.Anything).Return([]data.QuizStats{
+		{Username: "alice", Points: 12, Asked: 15, Played: 3},
+		{Username: "bob", Points: 9, Asked: 20, Played: 4},
+	}, nil)
+	_, err := service.GetQuizLeaderboard(context.Background(), 3)
+	assert.NoError(t, err)
+
+	quiz, err := service.StartQuiz(context.Background(), data.QuizOptions{Username: "bob", NumQuestions: 2})
+	assert.NoError(t, err)
+	scores.On("RecordQuizScore", mock.Anything, "bob", mock.Anything, 2).
+		Return(data.QuizStats{Username: "bob", Points: 14, Asked: 22, Played: 5}, nil)
+	result, err := service.SubmitQuiz(context.Background(), quiz.ID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Stats.Rank)
+
+	stats, err := service.GetQuizStats(context.Background(), "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, data.QuizStats{Username: "bob", Points: 14, Asked: 22, Played: 5, Rank: 1}, stats)
+	scores.AssertNumberOfCalls(t, "GetQuizScores", 1)
+}
+
+func TestGetQuizStats(t *testing.T) {
+	service, members, scores, _ := setupQuizService(t, 1)
+	members.On("GetMemberByUsername", mock.Anything, "bob", constants.NOT_CART).Return(data.Member{Username: "bob"}, nil)
+	members.On("GetMemberByUsername", mock.Anything, "erin", constants.NOT_CART).Return(data.Member{Username: "erin"}, nil)
+	members.On("GetMemberByUsername", mock.Anything, "nobody", constants.NOT_CART).Return(data.Member{}, fmt.Errorf("user nobody not found: %w", repos.ErrMemberNotFound))
+	members.On("GetMemberByUsername", mock.Anything, "flaky", constants.NOT_CART).Return(data.Member{}, errors.New("dynamo unavailable"))
+	scores.On("GetQuizScores", mock.Anything).Return([]data.QuizStats{
+		{Username: "alice", Points: 12, Asked: 15, Played: 3},
+		{Username: "bob", Points: 9, Asked: 20, Played: 4},
+	}, nil)
+
+	stats, err := service.GetQuizStats(context.Background(), "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, data.QuizStats{Username: "bob", Points: 9, Asked: 20, Played: 4, Rank: 2}, stats)
+
+	stats, err = service.GetQuizStats(context.Background(), "erin")
+	assert.NoError(t, err)
+	assert.Equal(t, data.QuizStats{Username: "erin"}, stats, "members who never played have no rank")
+
+	_, err = service.GetQuizStats(context.Background(), "nobody")
+	assert.ErrorIs(t, err, services.ErrUnknownMember)
+	_, err = service.GetQuizStats(context.Background(), "flaky")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, services.ErrUnknownMember)
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+	"blockbuster/api/utils"
+)
+
+var (
+	ErrInvalidStrategy = fmt.Errorf("strategy must be %s, %s or %s",
+		constants.AVERAGE_STRATEGY, constants.LEAST_MISERY_STRATEGY, constants.APPROVAL_STRATEGY)
+	ErrUnknownMember = errors.New("member not found")
+	ErrNotRoomMember = errors.New("member has not joined this voting room")
+	ErrNotRoomHost   = errors.New("only the host can finish this voting room")
+	ErrRoomFull      = fmt.Errorf("voting rooms hold at most %d members", constants.MAX_ROOM_MEMBERS)
+	ErrAlreadyVoted  = errors.New("member has already voted this round")
+)
+
+// CreateVotingRoom opens a group voting room hosted by username, who is its first member.
+// The members' moods are combined by strategy, average by default, and every slate is drawn
+// from seed, or from a fresh seed when seed is nil.
+func (s *MembersService) CreateVotingRoom(c context.Context, host, strategy string, seed *int64) (data.VotingRoom, error) {
+	if strategy == "" {
+		strategy = constants.AVERAGE_STRATEGY
+	}
+	if !slices.Contains([]string{constants.AVERAGE_STRATEGY, constants.LEAST_MISERY_STRATEGY, constants.APPROVAL_STRATEGY}, strategy) {
+		return data.VotingRoom{}, ErrInvalidStrategy
+	}
+	if err := s.checkMember(c, host); err != nil {
+		return data.VotingRoom{}, err
+	}
+	used := s.seedOrNew(seed)
+	movieIDs, err := s.repo.GetIniitialVotingSlate(c, "", used)
+	if len(movieIDs) == 0 {
+		return data.VotingRoom{}, utils.LogError("unable to get initial voting slate for room", err)
+	}
+	if err != nil {
+		utils.LogError("initial voting slate gathered with errors", err)
+	}
+	room, err := s.rooms.Create(data.VotingRoom{
+		Host:     host,
+		Strategy: strategy,
+		Members:  map[string]data.RoomMember{host: {}},
+		Slate:    movieIDs,
+		Shown:    movieIDs,
+		Seed:     used,
+	})
+	if err != nil {
+		return data.VotingRoom{}, utils.LogError("failed to create voting room", err)
+	}
+	return room, nil
+}
+
+// JoinVotingRoom adds username to the room. Members joining mid-round vote on the current
+// slate before the round can end; joining twice is harmless.
+func (s *MembersService) JoinVotingRoom(c context.Context, code, username string) (data.VotingRoom, error) {
+	if err := s.checkMember(c, username); err != nil {
+		return data.VotingRoom{}, err
+	}
+	return s.rooms.Update(code, func(room *data.VotingRoom) error {
+		if _, ok := room.Members[username]; ok {
+			return nil
+		}
+		if len(room.Members) >= constants.MAX_ROOM_MEMBERS {
+			return ErrRoomFull
+		}
+		room.Members[username] = data.RoomMember{}
+		return nil
+	})
+}
+
+// GetVotingRoom returns the room, first moving it on to its next slate if every member has
+// voted but the slate couldn't be drawn at the time.
+func (s *MembersService) GetVotingRoom(c context.Context, code string) (data.VotingRoom, error) {
+	room, err := s.rooms.Get(code)
+	if err != nil {
+		return data.VotingRoom{}, err
+	}
+	return s.advanceRoom(c, room)
+}
+
+// VoteInRoom records the movies username picked from the room's current slate and updates
+// their own mood. The last vote of a round aggregates everyone's moods into the group mood
+// and draws the next slate from it. An empty vote passes on the whole slate.
+func (s *MembersService) VoteInRoom(c context.Context, code, username string, movieIDs []string) (data.VotingRoom, error) {
+	room, err := s.rooms.Get(code)
+	if err != nil {
+		return data.VotingRoom{}, err
+	}
+	member, err := checkRoomVote(room, username, movieIDs)
+	if err != nil {
+		return data.VotingRoom{}, err
+	}
+
+	mood := member.Mood
+	if len(movieIDs) > 0 {
+		mood, err = s.repo.UpdateMood(c, member.Mood, member.NumSelected, movieIDs)
+		if err != nil {
+			utils.LogError(fmt.Sprintf("mood of %s in voting room %s updated with errors", username, room.Code), err)
+		}
+	}
+
+	room, err = s.rooms.Update(code, func(room *data.VotingRoom) error {
+		member, err := checkRoomVote(*room, username, movieIDs)
+		if err != nil {
+			return err
+		}
+		member.Mood = mood
+		member.NumSelected += len(movieIDs)
+		member.Selected = append(member.Selected, movieIDs...)
+		member.Voted = true
+		room.Members[username] = member
+		return nil
+	})
+	if err != nil {
+		return data.VotingRoom{}, err
+	}
+	return s.advanceRoom(c, room)
+}
+
+// FinishVotingRoom returns the final picks for the group's mood and closes the room. Only
+// the host, username, may finish it. Movies any member has rented are left out, every
+// member's votes are used when opts names none, and each member's taste is nudged by their
+// own mood. The room is taken from the cache before the picks are drawn, so concurrent
+// finishes update tastes once; it is put back when no picks could be drawn.
+func (s *MembersService) FinishVotingRoom(c context.Context, code, username string, opts data.FinalPicksOptions) ([]data.FinalPick, error) {
+	room, err := s.rooms.Get(code)
+	if err != nil {
+		return nil, err
+	}
+	if room.Host != username {
+		return nil, ErrNotRoomHost
+	}
+	if room, err = s.rooms.Take(code); err != nil {
+		return nil, err
+	}
+	picks, err := s.finishRoom(c, room, opts)
+	if err != nil {
+		s.rooms.Restore(room)
+		return picks, err
+	}
+	for username, member := range room.Members {
+		if member.NumSelected == 0 {
+			continue
+		}
+		if _, err := s.repo.UpdateTaste(c, username, member.Mood, constants.TASTE_VOTE_WEIGHT); err != nil {
+			utils.LogError(fmt.Sprintf("failed to update taste for %s", username), err)
+		}
+	}
+	return picks, nil
+}
+
+// finishRoom draws the final picks for a room taken from the cache.
+func (s *MembersService) finishRoom(c context.Context, room data.VotingRoom, opts data.FinalPicksOptions) ([]data.FinalPick, error) {
+	mood, ok := groupMood(room)
+	if !ok {
+		return nil, ErrNoVotesRecorded
+	}
+	usernames := make([]string, 0, len(room.Members))
+	for username := range room.Members {
+		usernames = append(usernames, username)
+	}
+	slices.Sort(usernames)
+	if len(opts.Voted) == 0 {
+		for _, username := range usernames {
+			opts.Voted = append(opts.Voted, room.Members[username].Selected...)
+		}
+	}
+
+	query, err := finalPicksQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+	query.Group = usernames
+	return s.finalPicks(c, mood, query)
+}
+
+func (s *MembersService) checkMember(c context.Context, username string) error {
+	if strings.TrimSpace(username) == "" {
+		return fmt.Errorf("%w: a username is required", ErrUnknownMember)
+	}
+	_, err := s.repo.GetMemberByUsername(c, username, constants.NOT_CART)
+	if errors.Is(err, repos.ErrMemberNotFound) {
+		return fmt.Errorf("%w: %s", ErrUnknownMember, username)
+	}
+	if err != nil {
+		utils.LogError(fmt.Sprintf("failed to retrieve user %s for voting room", username), err)
+		return fmt.Errorf("failed to look up %s", username)
+	}
+	return nil
+}
+
+// checkRoomVote returns the voting member if they may vote movieIDs in the room's current
+// round.
+func checkRoomVote(room data.VotingRoom, username string, movieIDs []string) (data.RoomMember, error) {
+	member, ok := room.Members[username]
+	if !ok {
+		return data.RoomMember{}, ErrNotRoomMember
+	}
+	if room.Iteration >= constants.MAX_VOTING_ITERATIONS {
+		return data.RoomMember{}, ErrVotingComplete
+	}
+	if member.Voted {
+		return data.RoomMember{}, ErrAlreadyVoted
+	}
+	for _, mid := range movieIDs {
+		if !utils.Contains(room.Slate, mid) {
+			return data.RoomMember{}, ErrInvalidVote
+		}
+	}
+	return member, nil
+}
+
+// advanceRoom draws the next slate once every member has voted in the current round. The
+// slate is drawn outside the cache's lock, so concurrent callers may both draw one; only the
+// first is kept.
+func (s *MembersService) advanceRoom(c context.Context, room data.VotingRoom) (data.VotingRoom, error) {
+	if !roundComplete(room) {
+		return room, nil
+	}
+	mood, ok := groupMood(room)
+	if !ok {
+		mood = room.Mood
+	}
+	slate, err := s.repo.GetVotingSlate(c, mood, room.Iteration, room.Shown, room.Seed)
+	if len(slate) == 0 {
+		return data.VotingRoom{}, utils.LogError(fmt.Sprintf("failed to draw the next slate for voting room %s", room.Code), err)
+	}
+	if err != nil {
+		utils.LogError(fmt.Sprintf("voting room %s slate drawn with errors", room.Code), err)
+	}
+
+	iteration := room.Iteration
+	return s.rooms.Update(room.Code, func(room *data.VotingRoom) error {
+		if room.Iteration != iteration || !roundComplete(*room) {
+			return nil
+		}
+		room.Mood = mood
+		room.Iteration++
+		room.Slate = slate
+		room.Shown = append(room.Shown, slate...)
+		for username, member := range room.Members {
+			member.Voted = false
+			room.Members[username] = member
+		}
+		return nil
+	})
+}
+
+func roundComplete(room data.VotingRoom) bool {
+	if room.Iteration >= constants.MAX_VOTING_ITERATIONS {
+		return false
+	}
+	for _, member := range room.Members {
+		if !member.Voted {
+			return false
+		}
+	}
+	return true
+}
+
+// groupMood aggregates the moods of the members who have picked anything so far; members
+// who have only passed have no mood to count. It reports false when nobody has picked.
+func groupMood(room data.VotingRoom) (data.MovieMetrics, bool) {
+	var moods []data.MetricVector
+	for _, member := range room.Members {
+		if member.NumSelected > 0 {
+			moods = append(moods, member.Mood.Vector())
+		}
+	}
+	if len(moods) == 0 {
+		return data.MovieMetrics{}, false
+	}
+	return aggregateMoods(moods, room.Strategy).Metrics(), true
+}
+
+// aggregateMoods combines member moods into one group mood:
+//   - average takes the mean of every dimension;
+//   - least_misery takes the lowest, so no member is left with a mood they dislike;
+//   - approval counts each member as approving the dimensions at or above their own mean
+//     and scales the mean of every dimension by the share of members approving it.
+func aggregateMoods(moods []data.MetricVector, strategy string) data.MetricVector {
+	var mean data.MetricVector
+	for _, mood := range moods {
+		mean = mean.Add(mood)
+	}
+	mean = mean.Scale(1 / float64(len(moods)))
+
+	switch strategy {
+	case constants.LEAST_MISERY_STRATEGY:
+		least := moods[0]
+		for _, mood := range moods[1:] {
+			for i := range least {
+				least[i] = math.Min(least[i], mood[i])
+			}
+		}
+		return least
+	case constants.APPROVAL_STRATEGY:
+		var approvals data.MetricVector
+		for _, mood := range moods {
+			var own float64
+			for _, value := range mood {
+				own += value
+			}
+			own /= data.NumMetricDimensions
+			for i, value := range mood {
+				if value >= own {
+					approvals[i]++
+				}
+			}
+		}
+		for i := range mean {
+			mean[i] *= approvals[i] / float64(len(moods))
+		}
+		return mean
+	default:
+		return mean
+	}
+}
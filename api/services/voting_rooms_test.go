@@ -0,0 +1,191 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"blockbuster/api/api_cache"
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+	"blockbuster/api/repos"
+	"blockbuster/api/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var initialRoomSlate = []string{"m1", "m2", "m3", "m4"}
+
+// setupVotingRoom opens a room hosted by john with jane as a second member.
+func setupVotingRoom(t *testing.T, strategy string) (*services.MembersService, *MockMemberRepo, data.VotingRoom) {
+	ctx := context.Background()
+	service, mockRepo := setupMockService()
+	mockRepo.On("GetMemberByUsername", ctx, mock.Anything, constants.NOT_CART).Return(data.Member{}, nil)
+	mockRepo.On("GetIniitialVotingSlate", ctx, "", testSeed).Return(initialRoomSlate, nil).Once()
+
+	room, err := service.CreateVotingRoom(ctx, "john", strategy, nil)
+	assert.NoError(t, err)
+	room, err = service.JoinVotingRoom(ctx, room.Code, "jane")
+	assert.NoError(t, err)
+	assert.Len(t, room.Members, 2)
+	return service, mockRepo, room
+}
+
+func TestVotingRoom_Flow(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo, room := setupVotingRoom(t, "")
+	assert.Equal(t, constants.AVERAGE_STRATEGY, room.Strategy)
+	assert.Equal(t, "john", room.Host)
+	assert.Equal(t, initialRoomSlate, room.Slate)
+	assert.Equal(t, testSeed, room.Seed)
+
+	johnsMood, janesMood := data.MovieMetrics{Drama: 8, Comedy: 2}, data.MovieMetrics{Drama: 4, Comedy: 6}
+	mockRepo.On("UpdateMood", ctx, data.MovieMetrics{}, 0, []string{"m1"}).Return(johnsMood, nil).Once()
+	room, err := service.VoteInRoom(ctx, room.Code, "john", []string{"m1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, room.Iteration, "the round waits for every member")
+	assert.True(t, room.Members["john"].Voted)
+
+	_, err = service.VoteInRoom(ctx, room.Code, "john", []string{"m2"})
+	assert.ErrorIs(t, err, services.ErrAlreadyVoted)
+
+	groupMood := data.MovieMetrics{Drama: 6, Comedy: 4}
+	mockRepo.On("UpdateMood", ctx, data.MovieMetrics{}, 0, []string{"m2"}).Return(janesMood, nil).Once()
+	mockRepo.On("GetVotingSlate", ctx, groupMood, 0, initialRoomSlate, testSeed).Return([]string{"m5", "m6"}, nil).Once()
+	room, err = service.VoteInRoom(ctx, room.Code, "jane", []string{"m2"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, room.Iteration)
+	assert.Equal(t, groupMood, room.Mood)
+	assert.Equal(t, []string{"m5", "m6"}, room.Slate)
+	assert.Equal(t, []string{"m1", "m2", "m3", "m4", "m5", "m6"}, room.Shown)
+	assert.False(t, room.Members["john"].Voted || room.Members["jane"].Voted, "a new round starts")
+
+	groupQuery := defaultPicksQuery
+	groupQuery.Group = []string{"jane", "john"}
+	groupQuery.Voted = []string{"m2", "m1"}
+	mockRepo.On("UpdateTaste", ctx, "john", johnsMood, constants.TASTE_VOTE_WEIGHT).Return(data.TasteProfile{}, nil).Once()
+	mockRepo.On("UpdateTaste", ctx, "jane", janesMood, constants.TASTE_VOTE_WEIGHT).Return(data.TasteProfile{}, nil).Once()
+	_, err = service.FinishVotingRoom(ctx, room.Code, "jane", data.FinalPicksOptions{})
+	assert.ErrorIs(t, err, services.ErrNotRoomHost)
+	// a second finish lands while the first is still drawing its picks
+	var secondErr error
+	mockRepo.On("GetVotingFinalPicks", ctx, groupMood, groupQuery).
+		Run(func(mock.Arguments) {
+			_, secondErr = service.FinishVotingRoom(ctx, room.Code, "john", data.FinalPicksOptions{})
+		}).
+		Return(finalPicks("m7", "m8", "m9"), nil).Once()
+	picks, err := service.FinishVotingRoom(ctx, room.Code, "john", data.FinalPicksOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, finalPicks("m7", "m8", "m9"), picks)
+	assert.ErrorIs(t, secondErr, api_cache.ErrVotingRoomNotFound, "tastes are updated once")
+
+	_, err = service.GetVotingRoom(ctx, room.Code)
+	assert.ErrorIs(t, err, api_cache.ErrVotingRoomNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVotingRoom_Strategies(t *testing.T) {
+	johnsMood, janesMood := data.MovieMetrics{Drama: 12, Comedy: 0.5}, data.MovieMetrics{Drama: 6, Comedy: 6}
+	tests := []struct {
+		strategy string
+		want     data.MovieMetrics
+	}{
+		{constants.AVERAGE_STRATEGY, data.MovieMetrics{Drama: 9, Comedy: 3.25}},
+		{constants.LEAST_MISERY_STRATEGY, data.MovieMetrics{Drama: 6, Comedy: 0.5}},
+		// john's comedy is below his own mean, so only jane approves of comedy.
+		{constants.APPROVAL_STRATEGY, data.MovieMetrics{Drama: 9, Comedy: 1.625}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			ctx := context.Background()
+			service, mockRepo, room := setupVotingRoom(t, tt.strategy)
+			mockRepo.On("UpdateMood", ctx, data.MovieMetrics{}, 0, []string{"m1"}).Return(johnsMood, nil).Once()
+			mockRepo.On("UpdateMood", ctx, data.MovieMetrics{}, 0, []string{"m2"}).Return(janesMood, nil).Once()
+			mockRepo.On("GetVotingSlate", ctx, tt.want, 0, initialRoomSlate, testSeed).Return([]string{"m5"}, nil).Once()
+
+			_, err := service.VoteInRoom(ctx, room.Code, "john", []string{"m1"})
+			assert.NoError(t, err)
+			room, err = service.VoteInRoom(ctx, room.Code, "jane", []string{"m2"})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, room.Mood)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestVotingRoom_PassesDoNotCountTowardsTheMood(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo, room := setupVotingRoom(t, constants.LEAST_MISERY_STRATEGY)
+	johnsMood := data.MovieMetrics{Horror: 9}
+	mockRepo.On("UpdateMood", ctx, data.MovieMetrics{}, 0, []string{"m1"}).Return(johnsMood, nil).Once()
+	mockRepo.On("GetVotingSlate", ctx, johnsMood, 0, initialRoomSlate, testSeed).Return([]string{"m5"}, nil).Once()
+
+	_, err := service.VoteInRoom(ctx, room.Code, "john", []string{"m1"})
+	assert.NoError(t, err)
+	room, err = service.VoteInRoom(ctx, room.Code, "jane", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, johnsMood, room.Mood)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVotingRoom_RetriesFailedSlate(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo, room := setupVotingRoom(t, "")
+	mockRepo.On("UpdateMood", ctx, data.MovieMetrics{}, 0, mock.Anything).Return(data.MovieMetrics{Action: 5}, nil)
+	mockRepo.On("GetVotingSlate", ctx, data.MovieMetrics{Action: 5}, 0, initialRoomSlate, testSeed).
+		Return([]string{}, errors.New("no centroids")).Once()
+
+	_, err := service.VoteInRoom(ctx, room.Code, "john", []string{"m1"})
+	assert.NoError(t, err)
+	_, err = service.VoteInRoom(ctx, room.Code, "jane", []string{"m2"})
+	assert.ErrorContains(t, err, "failed to draw the next slate")
+
+	mockRepo.On("GetVotingSlate", ctx, data.MovieMetrics{Action: 5}, 0, initialRoomSlate, testSeed).Return([]string{"m5"}, nil).Once()
+	room, err = service.GetVotingRoom(ctx, room.Code)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, room.Iteration)
+	assert.Equal(t, []string{"m5"}, room.Slate)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVotingRoom_Errors(t *testing.T) {
+	ctx := context.Background()
+	service, mockRepo, room := setupVotingRoom(t, "")
+
+	_, err := service.CreateVotingRoom(ctx, "john", "dictator", nil)
+	assert.ErrorIs(t, err, services.ErrInvalidStrategy)
+	_, err = service.JoinVotingRoom(ctx, "NOROOM", "jane")
+	assert.ErrorIs(t, err, api_cache.ErrVotingRoomNotFound)
+	_, err = service.VoteInRoom(ctx, room.Code, "bob", []string{"m1"})
+	assert.ErrorIs(t, err, services.ErrNotRoomMember)
+	_, err = service.VoteInRoom(ctx, room.Code, "john", []string{"m9"})
+	assert.ErrorIs(t, err, services.ErrInvalidVote)
+	_, err = service.FinishVotingRoom(ctx, room.Code, "john", data.FinalPicksOptions{})
+	assert.ErrorIs(t, err, services.ErrNoVotesRecorded)
+	_, err = service.GetVotingRoom(ctx, room.Code)
+	assert.NoError(t, err, "a room that could not be finished is put back")
+
+	for i := len(room.Members); i < constants.MAX_ROOM_MEMBERS; i++ {
+		_, err = service.JoinVotingRoom(ctx, room.Code, fmt.Sprintf("member%d", i))
+		assert.NoError(t, err)
+	}
+	_, err = service.JoinVotingRoom(ctx, room.Code, "bob")
+	assert.ErrorIs(t, err, services.ErrRoomFull)
+	room, err = service.JoinVotingRoom(ctx, room.Code, "jane")
+	assert.NoError(t, err, "members already in a full room can rejoin")
+	assert.Len(t, room.Members, constants.MAX_ROOM_MEMBERS)
+
+	other, otherRepo := setupMockService()
+	otherRepo.On("GetMemberByUsername", ctx, "ghost", constants.NOT_CART).Return(data.Member{}, fmt.Errorf("user ghost not found: %w", repos.ErrMemberNotFound))
+	otherRepo.On("GetMemberByUsername", ctx, "flaky", constants.NOT_CART).Return(data.Member{}, errors.New("dynamo unavailable"))
+	_, err = other.CreateVotingRoom(ctx, "ghost", "", nil)
+	assert.ErrorIs(t, err, services.ErrUnknownMember)
+	_, err = other.CreateVotingRoom(ctx, "flaky", "", nil)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, services.ErrUnknownMember, "lookup failures are not reported as unknown members")
+	_, err = other.CreateVotingRoom(ctx, " ", "", nil)
+	assert.ErrorIs(t, err, services.ErrUnknownMember)
+	mockRepo.AssertNotCalled(t, "UpdateMood", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+
+	"blockbuster/api/constants"
+	"blockbuster/api/data"
+)
+
+// DistanceFunc measures how far apart two sets of movie metrics are; smaller is closer.
+type DistanceFunc func(a, b data.MovieMetrics) float64
+
+// ManhattanDistance sums the absolute difference of every metric.
+func ManhattanDistance(a, b data.MovieMetrics) float64 {
+	return weightedManhattan(a, b, nil)
+}
+
+// CosineDistance is 1 minus the cosine similarity of the metrics, so it compares the shape
+// of two profiles regardless of how strongly they score overall.
+func CosineDistance(a, b data.MovieMetrics) float64 {
+	return weightedCosine(a, b, nil)
+}
+
+// WeightedEuclidean returns a squared euclidean distance where each dimension's squared
+// difference is scaled by its weight, in data.MetricDimensions order.
+func WeightedEuclidean(weights []float64) DistanceFunc {
+	return func(a, b data.MovieMetrics) float64 {
+		d := a.Vector().Sub(b.Vector())
+		sum := 0.0
+		for i := range d {
+			sum += weightAt(weights, i) * d[i] * d[i]
+		}
+		return sum
+	}
+}
+
+// NewDistanceFunc builds the distance requested by opts. With no metric it is squared
+// euclidean (MetricDistance), or weighted euclidean when weights are given. Weights are keyed
+// by data.MetricDimensions; dimensions left out keep a weight of 1.
+func NewDistanceFunc(opts data.DistanceOptions) (DistanceFunc, error) {
+	weights, err := dimensionWeights(opts.Weights)
+	if err != nil {
+		return nil, err
+	}
+	switch opts.Metric {
+	case "", constants.EUCLIDEAN, constants.WEIGHTED_EUCLIDEAN:
+		if weights == nil {
+			return MetricDistance, nil
+		}
+		return WeightedEuclidean(weights), nil
+	case constants.MANHATTAN:
+		return func(a, b data.MovieMetrics) float64 { return weightedManhattan(a, b, weights) }, nil
+	case constants.COSINE:
+		return func(a, b data.MovieMetrics) float64 { return weightedCosine(a, b, weights) }, nil
+	default:
+		return nil, fmt.Errorf("unknown distance metric %q; must be one of %s, %s, %s or %s",
+			opts.Metric, constants.EUCLIDEAN, constants.WEIGHTED_EUCLIDEAN, constants.MANHATTAN, constants.COSINE)
+	}
+}
+
+func dimensionWeights(byName map[string]float64) ([]float64, error) {
+	if len(byName) == 0 {
+		return nil, nil
+	}
+	weights := make([]float64, data.NumMetricDimensions)
+	for i := range weights {
+		weights[i] = 1
+	}
+	for name, weight := range byName {
+		i := data.MetricDimensionIndex(name)
+		if i < 0 {
+			return nil, fmt.Errorf("unknown metric dimension %q", name)
+		}
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("weight for %s must be a non-negative number", name)
+		}
+		weights[i] = weight
+	}
+	return weights, nil
+}
+
+func weightedManhattan(a, b data.MovieMetrics, weights []float64) float64 {
+	d := a.Vector().Sub(b.Vector())
+	sum := 0.0
+	for i := range d {
+		sum += weightAt(weights, i) * math.Abs(d[i])
+	}
+	return sum
+}
+
+func weightedCosine(a, b data.MovieMetrics, weights []float64) float64 {
+	va, vb := a.Vector(), b.Vector()
+	dot, normA, normB := 0.0, 0.0, 0.0
+	for i := range va {
+		w := weightAt(weights, i)
+		dot += w * va[i] * vb[i]
+		normA += w * va[i] * va[i]
+		normB += w * vb[i] * vb[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return 1 - dot/math.Sqrt(normA*normB)
+}
+
+func weightAt(weights []float64, i int) float64 {
+	if weights == nil {
+		return 1
+	}
+	return weights[i]
+}
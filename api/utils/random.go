@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	mathrand "math/rand"
+	"time"
+)
+
+// maxSeed keeps seeds within the integers a JSON number holds exactly, so clients written in
+// JavaScript can send back the seed they were given.
+const maxSeed = 1<<53 - 1
+
+// NewSeed draws a fresh seed for a recommendation session. It is safe for concurrent use.
+func NewSeed() int64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return time.Now().UnixNano() & maxSeed
+	}
+	return int64(binary.BigEndian.Uint64(b[:]) & maxSeed)
+}
+
+// NewSlateRand returns the random source for one round of a voting session. Every round of
+// a session draws from its own source derived from the session's seed, so replaying the same
+// votes with the same seed suggests the same slates in the same order.
+func NewSlateRand(seed int64, round int) *mathrand.Rand {
+	return mathrand.New(mathrand.NewSource(seed ^ int64(round)*0x9E3779B97F4A7C))
+}
@@ -1,12 +1,16 @@
 package utils
 
 import (
+	"blockbuster/api/constants"
 	"blockbuster/api/data"
 	"context"
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -72,56 +76,62 @@ func GetSliceFromMapKeys(m map[string]bool) []string {
 }
 
 func AccumulateMovieMetricsWithWeight(a, b data.MovieMetrics, weight int) data.MovieMetrics {
-	return data.MovieMetrics{
-		Acting:         a.Acting + b.Acting*float64(weight),
-		Action:         a.Action + b.Action*float64(weight),
-		Cinematography: a.Cinematography + b.Cinematography*float64(weight),
-		Comedy:         a.Comedy + b.Comedy*float64(weight),
-		Directing:      a.Directing + b.Directing*float64(weight),
-		Drama:          a.Drama + b.Drama*float64(weight),
-		Fantasy:        a.Fantasy + b.Fantasy*float64(weight),
-		Horror:         a.Horror + b.Horror*float64(weight),
-		Romance:        a.Romance + b.Romance*float64(weight),
-		StoryTelling:   a.StoryTelling + b.StoryTelling*float64(weight),
-		Suspense:       a.Suspense + b.Suspense*float64(weight),
-		Writing:        a.Writing + b.Writing*float64(weight),
-	}
+	return a.Vector().Add(b.Vector().Scale(float64(weight))).Metrics()
 }
 
 func AverageMetrics(m data.MovieMetrics, count int) data.MovieMetrics {
 	if count <= 1 {
 		return m
 	}
-	return data.MovieMetrics{
-		Acting:         m.Acting / float64(count),
-		Action:         m.Action / float64(count),
-		Cinematography: m.Cinematography / float64(count),
-		Comedy:         m.Comedy / float64(count),
-		Directing:      m.Directing / float64(count),
-		Drama:          m.Drama / float64(count),
-		Fantasy:        m.Fantasy / float64(count),
-		Horror:         m.Horror / float64(count),
-		Romance:        m.Romance / float64(count),
-		StoryTelling:   m.StoryTelling / float64(count),
-		Suspense:       m.Suspense / float64(count),
-		Writing:        m.Writing / float64(count),
+	return m.Vector().Scale(1 / float64(count)).Metrics()
+}
+
+// BlendMetrics returns the weighted average of a and b.
+func BlendMetrics(a, b data.MovieMetrics, weightA, weightB float64) data.MovieMetrics {
+	total := weightA + weightB
+	if total <= 0 {
+		return a
+	}
+	return a.Vector().Scale(weightA / total).Add(b.Vector().Scale(weightB / total)).Metrics()
+}
+
+// UpdateTaste folds a new signal into a taste profile. The existing weight halves every
+// TASTE_HALF_LIFE_DAYS, so recent rentals and votes outweigh old ones.
+func UpdateTaste(profile *data.TasteProfile, signal data.MovieMetrics, weight float64, now time.Time) data.TasteProfile {
+	if profile == nil || profile.Weight <= 0 {
+		return data.TasteProfile{Metrics: signal, Weight: weight, UpdatedAt: now}
+	}
+	elapsedDays := max(now.Sub(profile.UpdatedAt).Hours()/24, 0)
+	decayed := profile.Weight * math.Pow(0.5, elapsedDays/constants.TASTE_HALF_LIFE_DAYS)
+	return data.TasteProfile{
+		Metrics:   BlendMetrics(profile.Metrics, signal, decayed, weight),
+		Weight:    decayed + weight,
+		UpdatedAt: now,
+	}
+}
+
+// TopContributingDimensions returns the n dimensions contributing most to the dot product of
+// mood and movie, i.e. where both score highly, with each one's share of the total.
+func TopContributingDimensions(mood, movie data.MovieMetrics, n int) []data.DimensionContribution {
+	vm, vv := mood.Vector(), movie.Vector()
+	total := vm.Dot(vv)
+	dims := data.MetricDimensions()
+	contributions := make([]data.DimensionContribution, len(dims))
+	for i, name := range dims {
+		share := 0.0
+		if total != 0 {
+			share = vm[i] * vv[i] / total
+		}
+		contributions[i] = data.DimensionContribution{Dimension: name, Mood: vm[i], Movie: vv[i], Share: share}
 	}
+	sort.SliceStable(contributions, func(i, j int) bool {
+		return contributions[i].Share > contributions[j].Share
+	})
+	return contributions[:min(n, len(contributions))]
 }
 
-// Euclidean distance between two MovieMetrics
+// Squared euclidean distance between two MovieMetrics
 func MetricDistance(a, b data.MovieMetrics) float64 {
-	sum := 0.0
-	sum += (a.Acting - b.Acting) * (a.Acting - b.Acting)
-	sum += (a.Action - b.Action) * (a.Action - b.Action)
-	sum += (a.Cinematography - b.Cinematography) * (a.Cinematography - b.Cinematography)
-	sum += (a.Comedy - b.Comedy) * (a.Comedy - b.Comedy)
-	sum += (a.Directing - b.Directing) * (a.Directing - b.Directing)
-	sum += (a.Drama - b.Drama) * (a.Drama - b.Drama)
-	sum += (a.Fantasy - b.Fantasy) * (a.Fantasy - b.Fantasy)
-	sum += (a.Horror - b.Horror) * (a.Horror - b.Horror)
-	sum += (a.Romance - b.Romance) * (a.Romance - b.Romance)
-	sum += (a.StoryTelling - b.StoryTelling) * (a.StoryTelling - b.StoryTelling)
-	sum += (a.Suspense - b.Suspense) * (a.Suspense - b.Suspense)
-	sum += (a.Writing - b.Writing) * (a.Writing - b.Writing)
-	return sum
+	d := a.Vector().Sub(b.Vector())
+	return d.Dot(d)
 }